@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/jayuuza/battlesnake/pkg/selfplay"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// runArena plays games between strategies, and strategies with weight
+// files, on the simulator, rating them on a ladder that persists across
+// runs.
+func runArena(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("arena", flag.ContinueOnError)
+	names := fs.String("strategies", "", "comma-separated strategies to play with the configured weights, or empty for every registered one")
+	weightFiles := fs.String("weights", "", "comma-separated weight files, JSON objects of term weights or TOML files with a [weights] table, each played by the -weighted strategies")
+	weighted := fs.String("weighted", "heuristic,duel", "comma-separated strategies to play with each weight file")
+	ladderFile := fs.String("ladder", "ladder.json", "file the ladder is kept in across runs")
+	games := fs.Int("games", 100, "games to play, or 0 to only print the ladder")
+	snakes := fs.Int("snakes", 2, "snakes in each game, each a different player")
+	size := fs.Int("size", selfplay.DefaultSettings.Width, "width and height of the board")
+	timeout := fs.Duration("timeout", selfplay.DefaultSettings.Timeout, "time each snake has to decide a move")
+	maxTurns := fs.Int("max-turns", selfplay.DefaultSettings.MaxTurns, "turns after which a game is a draw between the snakes left, or 0 for no limit")
+	parallel := fs.Int("parallel", runtime.GOMAXPROCS(0), "games to play at once")
+	asJSON := fs.Bool("json", false, "print the ladder as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	players, err := arenaPlayers(*names, *weightFiles, *weighted)
+	if err != nil {
+		return err
+	}
+	if *games > 0 && len(players) < *snakes {
+		return fmt.Errorf("%d players can't fill games of %d snakes", len(players), *snakes)
+	}
+	ladder, err := selfplay.LoadLadder(*ladderFile)
+	if err != nil {
+		return err
+	}
+	settings := selfplay.Settings{Width: *size, Height: *size, Timeout: *timeout, MaxTurns: *maxTurns}
+
+	// An interrupted run still saves the games played so far.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	jobs := make(chan struct{})
+	var (
+		wg     sync.WaitGroup
+		errsMu sync.Mutex
+		errs   []error
+	)
+	for range max(*parallel, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				table := make([]selfplay.Player, 0, *snakes)
+				for _, i := range rand.Perm(len(players))[:*snakes] {
+					table = append(table, players[i])
+				}
+				outcome, err := selfplay.Play(ctx, settings, table)
+				if err != nil {
+					if !errors.Is(err, context.Canceled) {
+						errsMu.Lock()
+						errs = append(errs, err)
+						errsMu.Unlock()
+					}
+					continue
+				}
+				ladder.Record(table, outcome)
+			}
+		}()
+	}
+feed:
+	for range *games {
+		select {
+		case jobs <- struct{}{}:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ladder.Save(); err != nil {
+		return err
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(ladder.Ratings())
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tplayer\trating\tgames\twins\tlosses\tdraws\tfirst played")
+	for i, r := range ladder.Ratings() {
+		fmt.Fprintf(tw, "%d\t%s\t%.0f\t%d\t%d\t%d\t%d\t%s\n", i+1, r.Name, r.Rating, r.Games, r.Wins, r.Losses, r.Draws, r.First.Format("2006-01-02 15:04"))
+	}
+	return tw.Flush()
+}
+
+// arenaPlayers returns the players of the arena: the strategies named, or
+// every registered one, with the configured weights, and each weighted
+// strategy with each weight file.
+func arenaPlayers(names, weightFiles, weighted string) ([]selfplay.Player, error) {
+	list := func(s string) []string {
+		if s == "" {
+			return nil
+		}
+		return strings.Split(s, ",")
+	}
+	strategies := list(names)
+	if strategies == nil {
+		strategies = strategy.Names()
+	}
+	var players []selfplay.Player
+	for _, name := range strategies {
+		if _, err := strategy.New(name); err != nil {
+			return nil, err
+		}
+		players = append(players, selfplay.Player{Name: name, Strategy: name})
+	}
+	for _, path := range list(weightFiles) {
+		weights, err := selfplay.LoadWeights(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range list(weighted) {
+			if _, err := strategy.New(name); err != nil {
+				return nil, err
+			}
+			players = append(players, selfplay.WeightedPlayer(name, path, weights))
+		}
+	}
+	return players, nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/jayuuza/battlesnake/pkg/appearance"
+	"github.com/jayuuza/battlesnake/pkg/config"
+	"github.com/jayuuza/battlesnake/pkg/eval"
+	"github.com/jayuuza/battlesnake/pkg/personality"
+	"github.com/jayuuza/battlesnake/pkg/server"
+)
+
+// configFlags maps the keys of each configuration table to the flags they
+// set.
+var configFlags = map[string]map[string]string{
+	"server": {
+		"strategy":         "strategy",
+		"personality":      "personality",
+		"personalities":    "personalities",
+		"appearance":       "appearance",
+		"fallback":         "fallback",
+		"breaker-trips":    "breaker-trips",
+		"shadow":           "shadow",
+		"experiment":       "experiment",
+		"experiment-ratio": "experiment-ratio",
+		"shout-replies":    "shout-replies",
+		"grpc":             "grpc",
+		"tcp":              "tcp",
+		"listen":           "listen",
+		"unix":             "unix",
+		"admin-token":      "admin-token",
+		"max-heap-mb":      "max-heap-mb",
+		"max-goroutines":   "max-goroutines",
+		"search-table-mb":  "search-table-mb",
+		"panic-health":     "panic-health",
+		"self-test":        "self-test",
+		"chaos":            "chaos",
+		"warmup":           "warmup",
+	},
+	"storage": {
+		"data-dir":           "data-dir",
+		"redis":              "redis",
+		"history":            "history",
+		"decisions":          "decisions",
+		"keep-wins":          "keep-wins",
+		"compress-after":     "compress-after",
+		"results":            "results",
+		"slow-positions":     "slow-positions",
+		"incident-threshold": "incident-threshold",
+		"capture-raw":        "capture-raw",
+		"profile-rate":       "profile-rate",
+		"spectate":           "spectate",
+		"spectate-url":       "spectate-url",
+	},
+	"log": {
+		"sink":     "log",
+		"level":    "log-level",
+		"levels":   "log-levels",
+		"format":   "log-format",
+		"max-size": "log-max-size",
+		"backups":  "log-backups",
+	},
+	"webhook": {
+		"urls": "webhooks",
+		"on":   "webhook-on",
+		"link": "webhook-link",
+	},
+	"upload": {
+		"endpoint":   "upload-endpoint",
+		"bucket":     "upload-bucket",
+		"region":     "upload-region",
+		"prefix":     "upload-prefix",
+		"keep-local": "upload-keep-local",
+	},
+	"sentry": {
+		"dsn": "sentry-dsn",
+		"env": "sentry-env",
+	},
+}
+
+// applyConfig sets the flags not given on the command line from cfg and
+// its environment overrides.
+func applyConfig(cfg *config.File) error {
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for table, keys := range configFlags {
+		for key, name := range keys {
+			value, ok := cfg.Get(table, key)
+			if !ok || set[name] {
+				continue
+			}
+			if err := flag.Set(name, value); err != nil {
+				return fmt.Errorf("%s.%s: %v", table, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// applyWeights sets the heuristic's term weights from the [weights] table.
+func applyWeights(cfg *config.File) error {
+	for _, term := range eval.Terms {
+		value, ok := cfg.Get("weights", term.Name)
+		if !ok {
+			continue
+		}
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("weights.%s: %v", term.Name, err)
+		}
+		if err := eval.SetWeight(term.Name, weight); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configSnakes registers the snakes in the [[snakes]] tables, each with a
+// personality of its own name based on its "personality" and overriding
+// its look, taunts and risk.
+func configSnakes(cfg *config.File) (map[string]server.Snake, error) {
+	snakes := map[string]server.Snake{}
+	for _, t := range cfg.Arrays["snakes"] {
+		name := t.String("name")
+		if name == "" {
+			return nil, fmt.Errorf("snakes: snake without a name")
+		}
+		base := t.String("personality")
+		if base == "" {
+			base = personality.Default
+		}
+		p, ok := personality.Get(base)
+		if !ok {
+			return nil, fmt.Errorf("snake %s: unknown personality %q", name, base)
+		}
+		p.Name = name
+		p.Appearance = appearance.Appearance{
+			Color: t.String("color"),
+			Head:  t.String("head"),
+			Tail:  t.String("tail"),
+		}.Over(p.Appearance)
+		if taunts := t.Strings("taunts"); taunts != nil {
+			p.Taunts = taunts
+		}
+		p.TauntChance = t.Float("taunt-chance", p.TauntChance)
+		p.Risk = t.Float("risk", p.Risk)
+		personality.Register(p)
+		snakes[name] = server.Snake{Strategy: t.String("strategy"), Personality: name}
+	}
+	return snakes, nil
+}
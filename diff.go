@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jayuuza/battlesnake/pkg/analysis"
+	"github.com/jayuuza/battlesnake/pkg/eval"
+	"github.com/jayuuza/battlesnake/pkg/history"
+)
+
+// runDiff replays a recorded game through two strategies, or one strategy
+// with two sets of weights, and prints every turn where they chose
+// different moves with both sides' evaluation of each move.
+func runDiff(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 0, "time each side has to decide each move, or 0 for the game's own timeout")
+	asJSON := fs.Bool("json", false, "print the disagreements as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 3 {
+		return fmt.Errorf("usage: diff [flags] <game dir or %s> <strategy[@weight-file]> <strategy[@weight-file]>", history.FileName)
+	}
+	path := fs.Arg(0)
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, history.FileName)
+	}
+	turns, err := history.Load(path)
+	if err != nil {
+		return err
+	}
+	a, err := baselinePlayer(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	b, err := baselinePlayer(fs.Arg(2))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	diffs, err := analysis.Diff(ctx, turns, a, b, *timeout)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diffs)
+	}
+	switch len(diffs) {
+	case 0:
+		fmt.Fprintf(w, "%s and %s agree on every turn\n", a.Name, b.Name)
+	case 1:
+		fmt.Fprintf(w, "%s and %s disagree on 1 turn\n", a.Name, b.Name)
+	default:
+		fmt.Fprintf(w, "%s and %s disagree on %d turns\n", a.Name, b.Name, len(diffs))
+	}
+	for _, d := range diffs {
+		fmt.Fprintf(w, "\nturn %d, played %s: %s plays %s, %s plays %s\n",
+			d.Turn, d.Played, a.Name, describeChoice(d.A), b.Name, describeChoice(d.B))
+		if err := printChoices(w, []string{a.Name, b.Name}, []analysis.Choice{d.A, d.B}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describeChoice returns the move of c, with its plan if it has one.
+func describeChoice(c analysis.Choice) string {
+	if c.Plan == "" {
+		return c.Move.String()
+	}
+	return fmt.Sprintf("%s (%s)", c.Move, c.Plan)
+}
+
+// printChoices prints a table of every side's score of each move, with its
+// terms, marking the move each side chose.
+func printChoices(w io.Writer, names []string, choices []analysis.Choice) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := []string{"player", "move", "total"}
+	for _, t := range eval.Terms {
+		header = append(header, t.Name)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for i, c := range choices {
+		for _, s := range c.Scores {
+			move := s.Move.String()
+			if s.Move == c.Move {
+				move += "*"
+			}
+			row := []string{names[i], move, fmt.Sprintf("%.3f", s.Score)}
+			for _, t := range eval.Terms {
+				row = append(row, fmt.Sprintf("%.3f", s.Terms[t.Name]))
+			}
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/jayuuza/battlesnake/pkg/analysis"
+)
+
+// runExport writes per-turn features of the recorded games as CSV.
+func runExport(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	dir := fs.String("data-dir", "games", "directory of recorded games")
+	out := fs.String("o", "", "file to write the CSV to, instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	dirs, err := analysis.GameDirs(*dir)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		return analysis.WriteFeatures(w, dirs)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := analysis.WriteFeatures(f, dirs); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
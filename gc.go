@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/jayuuza/battlesnake/pkg/config"
+	"github.com/jayuuza/battlesnake/pkg/history"
+	"github.com/jayuuza/battlesnake/pkg/results"
+)
+
+// runGC enforces the retention policy on the recorded games: histories of
+// won games outside the sample are discarded, and old ones compressed.
+func runGC(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("gc", flag.ContinueOnError)
+	cfgFile := fs.String("config", "", "TOML configuration file to read the [storage] policy from; flags given take precedence")
+	dir := fs.String("data-dir", "games", "directory of recorded games")
+	keep := fs.Float64("keep-wins", 1, "fraction (0-1) of won games whose history is kept; losses and draws always are")
+	age := fs.Duration("compress-after", 0, "age after which histories are gzipped, e.g. 168h, or 0 to never compress them")
+	dryRun := fs.Bool("n", false, "only report what would be done")
+	asJSON := fs.Bool("json", false, "print the outcome as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *cfgFile != "" {
+		cfg, err := config.Load(*cfgFile)
+		if err != nil {
+			return err
+		}
+		set := map[string]bool{}
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		for _, name := range []string{"data-dir", "keep-wins", "compress-after"} {
+			if value, ok := cfg.Get("storage", name); ok && !set[name] {
+				if err := fs.Set(name, value); err != nil {
+					return fmt.Errorf("storage.%s: %v", name, err)
+				}
+			}
+		}
+	}
+
+	rs, err := results.NewStore(*dir).All()
+	if err != nil {
+		return err
+	}
+	won := make(map[string]bool, len(rs))
+	for _, r := range rs {
+		won[r.GameID] = r.Outcome == results.Win
+	}
+	recorder := &history.Recorder{Dir: *dir}
+	collected, err := recorder.Collect(history.Retention{WinRate: *keep, CompressAfter: *age}, won, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return json.NewEncoder(w).Encode(collected)
+	}
+	verb := "freed"
+	if *dryRun {
+		verb = "would free"
+	}
+	_, err = fmt.Fprintf(w, "%d games: %d histories discarded, %d compressed, %s %.1f MiB\n",
+		collected.Games, collected.Discarded, collected.Compressed, verb, float64(collected.FreedBytes)/(1<<20))
+	return err
+}
@@ -1,3 +1,3 @@
 module github.com/jayuuza/battlesnake
 
-go 1.16
+go 1.24
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jayuuza/battlesnake/pkg/analysis"
+)
+
+// runHeatmap aggregates the recorded games into heatmaps per board size,
+// written as JSON and PNG images.
+func runHeatmap(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+	dir := fs.String("data-dir", "games", "directory of recorded games")
+	out := fs.String("out", ".", "directory to write the heatmaps to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	dirs, err := analysis.GameDirs(*dir)
+	if err != nil {
+		return err
+	}
+	maps, err := analysis.Heatmaps(dirs)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+	for size, h := range maps {
+		base := filepath.Join(*out, "heatmap-"+size)
+		b, err := json.MarshalIndent(h, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(base+".json", b, 0644); err != nil {
+			return err
+		}
+		for name, counts := range map[string][]int{"visits": h.Visits, "deaths": h.Deaths} {
+			if err := writePNG(base+"-"+name+".png", h, counts); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(w, "%s: %d games, %s.json, %s-visits.png, %s-deaths.png\n", size, h.Games, base, base, base)
+	}
+	return nil
+}
+
+func writePNG(path string, h *analysis.Heatmap, counts []int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := h.WritePNG(f, counts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
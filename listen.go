@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/logging"
+)
+
+// shutdownGrace is how long in-flight requests are given to finish when
+// the server is asked to stop.
+const shutdownGrace = 5 * time.Second
+
+// listenFDsStart is the first file descriptor passed by systemd socket
+// activation.
+const listenFDsStart = 3
+
+// listeners opens the listeners the HTTP API is served on: the sockets
+// passed by systemd socket activation if any, otherwise TCP on the -listen
+// addresses (all interfaces on port if none) unless -tcp is off, and the
+// Unix socket at -unix if set.
+func listeners(port string) ([]net.Listener, error) {
+	ls, err := activated()
+	if err != nil || len(ls) > 0 {
+		return ls, err
+	}
+	if *serveTCP {
+		addrs := []string{":" + port}
+		if *listenAddrs != "" {
+			addrs = strings.Split(*listenAddrs, ",")
+		}
+		for _, addr := range addrs {
+			l, err := listenTCP(strings.TrimSpace(addr))
+			if err != nil {
+				closeAll(ls)
+				return nil, err
+			}
+			ls = append(ls, l)
+		}
+	}
+	if *unixSocket != "" {
+		l, err := listenUnix(*unixSocket)
+		if err != nil {
+			closeAll(ls)
+			return nil, err
+		}
+		ls = append(ls, l)
+	}
+	if len(ls) == 0 {
+		return nil, errors.New("nothing to listen on: -tcp is off and no -unix socket is set")
+	}
+	return ls, nil
+}
+
+// listenTCP listens on addr, a host:port. An IPv4 or IPv6 address is bound
+// for that family only, so that 0.0.0.0:8080 and [::]:8080 can be listened
+// on side by side; a host name or no host listens on both where possible.
+func listenTCP(addr string) (net.Listener, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("-listen %q: %v", addr, err)
+	}
+	network := "tcp"
+	if ip := net.ParseIP(host); ip != nil {
+		network = "tcp6"
+		if ip.To4() != nil {
+			network = "tcp4"
+		}
+	}
+	return net.Listen(network, addr)
+}
+
+// listenUnix listens on a Unix socket at path, replacing a socket left
+// behind by an earlier run. The socket is removed when the listener is
+// closed.
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// activated returns the listening sockets systemd passed to this process
+// (LISTEN_PID and LISTEN_FDS), so that it holds them across restarts and
+// connections made meanwhile wait in the backlog instead of being refused.
+// The variables are unset so that child processes don't inherit them.
+func activated() ([]net.Listener, error) {
+	pid, fds := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS")
+	if fds == "" || pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("bad LISTEN_FDS %q", fds)
+	}
+	var ls []net.Listener
+	for fd := listenFDsStart; fd < listenFDsStart+n; fd++ {
+		syscall.CloseOnExec(fd)
+		f := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			closeAll(ls)
+			return nil, fmt.Errorf("socket activation: fd %d: %v", fd, err)
+		}
+		ls = append(ls, l)
+	}
+	return ls, nil
+}
+
+func closeAll(ls []net.Listener) {
+	for _, l := range ls {
+		l.Close()
+	}
+}
+
+// serveHTTP serves handler on every listener, returning once serving on any
+// of them fails, or nil once SIGINT or SIGTERM has stopped the server after
+// letting in-flight requests finish.
+func serveHTTP(handler http.Handler, ls []net.Listener) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Handler: handler}
+	errs := make(chan error, len(ls))
+	for _, l := range ls {
+		logging.For(logging.Server).Info("starting Battlesnake server", "addr", l.Addr().Network()+"://"+l.Addr().String())
+		go func() { errs <- srv.Serve(l) }()
+	}
+	select {
+	case err := <-errs:
+		srv.Close()
+		return err
+	case <-ctx.Done():
+	}
+	logging.For(logging.Server).Info("shutting down")
+	shutdown, cancel := context.WithTimeout(context.Background(), shutdownGrace)
+	defer cancel()
+	return srv.Shutdown(shutdown)
+}
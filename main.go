@@ -1,224 +1,384 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"log"
-	"math/rand"
-	"net/http"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/appearance"
+	"github.com/jayuuza/battlesnake/pkg/bench"
+	"github.com/jayuuza/battlesnake/pkg/config"
+	"github.com/jayuuza/battlesnake/pkg/decision"
+	"github.com/jayuuza/battlesnake/pkg/history"
+	"github.com/jayuuza/battlesnake/pkg/logging"
+	"github.com/jayuuza/battlesnake/pkg/parity"
+	"github.com/jayuuza/battlesnake/pkg/personality"
+	"github.com/jayuuza/battlesnake/pkg/results"
+	"github.com/jayuuza/battlesnake/pkg/rpc"
+	"github.com/jayuuza/battlesnake/pkg/search"
+	"github.com/jayuuza/battlesnake/pkg/sentry"
+	"github.com/jayuuza/battlesnake/pkg/server"
+	"github.com/jayuuza/battlesnake/pkg/serverless"
+	"github.com/jayuuza/battlesnake/pkg/spectate"
+	"github.com/jayuuza/battlesnake/pkg/store"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+	"github.com/jayuuza/battlesnake/pkg/timing"
+	"github.com/jayuuza/battlesnake/pkg/upload"
+	"github.com/jayuuza/battlesnake/pkg/webhook"
 )
 
-type Game struct {
-	ID      string `json:"id"`
-	Timeout int32  `json:"timeout"`
-}
-
-type Coord struct {
-	X int `json:"x"`
-	Y int `json:"y"`
-}
-
-type Battlesnake struct {
-	ID     string  `json:"id"`
-	Name   string  `json:"name"`
-	Health int32   `json:"health"`
-	Body   []Coord `json:"body"`
-	Head   Coord   `json:"head"`
-	Length int32   `json:"length"`
-	Shout  string  `json:"shout"`
-}
-
-type Board struct {
-	Height int           `json:"height"`
-	Width  int           `json:"width"`
-	Food   []Coord       `json:"food"`
-	Snakes []Battlesnake `json:"snakes"`
-}
+var (
+	configFile      = flag.String("config", "", "TOML configuration file; flags given on the command line take precedence")
+	redisURL        = flag.String("redis", "", "redis://[:password@]host[:port][/db] to keep game state in, instead of memory")
+	grpcAddr        = flag.String("grpc", "", "address to also serve the API over gRPC on, e.g. :9090")
+	serveTCP        = flag.Bool("tcp", true, "serve HTTP over TCP on PORT or the -listen addresses; turn off to serve only on the -unix socket")
+	listenAddrs     = flag.String("listen", "", "comma-separated host:port addresses to serve HTTP on instead of PORT on all interfaces, e.g. 0.0.0.0:8080,[::]:8080")
+	unixSocket      = flag.String("unix", "", "path of a Unix socket to also serve HTTP on, e.g. for a local reverse proxy")
+	appearanceFile  = flag.String("appearance", "", "JSON file scheduling skins that override the personality's appearance")
+	personalities   = flag.String("personalities", "", "JSON file of extra personality packs")
+	personalityName = flag.String("personality", personality.Default, "name of the personality used unless a game selects another")
+	dataDir         = flag.String("data-dir", "games", "directory game data is written to, one subdirectory per game ID")
+	profileRate     = flag.Float64("profile-rate", 0, "fraction of games (0-1) to capture CPU and heap profiles for")
+	recordHistory   = flag.Bool("history", false, "record every turn of every game to the data directory")
+	recordDecisions = flag.Bool("decisions", false, "record a trace of every move decided, with the candidates' scores and timing, to "+decision.FileName+" in each game's directory")
+	keepWins        = flag.Float64("keep-wins", 1, "fraction (0-1) of won games whose history is kept; losses and draws always are")
+	compressAfter   = flag.Duration("compress-after", 0, "age after which recorded histories are gzipped, or 0 to never compress them")
+	spectateGames   = flag.Bool("spectate", false, "record the engine's frames of games played on the public engine to the data directory, compared with our history in reports")
+	spectateURL     = flag.String("spectate-url", spectate.DefaultURL, "websocket streaming a game's events, with {game} replaced by the game ID")
+	writeReports    = flag.Bool("reports", true, "write a post-game analysis report to each game's directory when it ends, if recording history")
+	shoutReplies    = flag.String("shout-replies", "", "JSON file mapping opponent names to the shout we reply to them with")
+	fallbackName    = flag.String("fallback", "greedy", "cheap strategy to switch a game to after repeated soft budget overruns, or empty to never switch")
+	breakerTrips    = flag.Int("breaker-trips", 3, "consecutive soft budget overruns before switching to the fallback strategy")
+	shadowName      = flag.String("shadow", "", "strategy to evaluate in the background on every move, logging where it disagrees with the live strategy")
+	slowPositions   = flag.Bool("slow-positions", true, "save positions on which the strategy blew its budget to the slow directory of the data directory")
+	captureRaw      = flag.Bool("capture-raw", false, "write the raw body of every start, move and end request to the raw directory of the data directory, by game and turn, before it is decoded")
+	incidentAfter   = flag.Duration("incident-threshold", 0, "move latency above which the request, search statistics and a profile are saved to the incidents directory of the data directory, or 0 to never save")
+	recordResults   = flag.Bool("results", true, "record the outcome of every game to the data directory, served as win rates at /stats")
+	experiment      = flag.String("experiment", "", "A,B strategies to split games that don't select a strategy between, comparing their win rates")
+	experimentRatio = flag.Float64("experiment-ratio", 0.5, "fraction (0-1) of experiment games assigned to strategy B")
+	webhooks        = flag.String("webhooks", "", "comma-separated Discord or Slack compatible webhook URLs to post game results to")
+	webhookOn       = flag.String("webhook-on", "win,loss,draw", "comma-separated outcomes to post to webhooks")
+	webhookLink     = flag.String("webhook-link", webhook.DefaultLink, "link included in webhook posts, with {game} replaced by the game ID")
+	adminToken      = flag.String("admin-token", "", "bearer token for the admin API at /admin/, which is disabled without one")
+	maxHeapMB       = flag.Int("max-heap-mb", 0, "heap size in MiB above which new games play the fallback strategy and debug features are shed, or 0 for no limit")
+	searchTableMB   = flag.Int("search-table-mb", search.DefaultTableMB, "MiB of memory for the transposition table shared by every search, or 0 for none")
+	panicHealth     = flag.Int("panic-health", strategy.DefaultPanicHealth, "health below which heuristic strategies panic and go all out for food, or 0 to never panic")
+	maxGoroutines   = flag.Int("max-goroutines", 0, "goroutine count above which new games play the fallback strategy and debug features are shed, or 0 for no limit")
+	uploadEndpoint  = flag.String("upload-endpoint", "", "S3-compatible object storage URL to upload finished games to, e.g. https://storage.googleapis.com; credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	uploadBucket    = flag.String("upload-bucket", "", "bucket to upload finished games to")
+	uploadRegion    = flag.String("upload-region", upload.DefaultRegion, "region of the upload bucket")
+	uploadPrefix    = flag.String("upload-prefix", "games/{date}/", "prefix of uploaded objects, with {date} replaced by the day the game was played")
+	uploadKeepLocal = flag.Duration("upload-keep-local", 0, "how long a game's data directory is kept once uploaded, or negative to keep it")
+	sentryDSN       = flag.String("sentry-dsn", os.Getenv("SENTRY_DSN"), "Sentry DSN to report panics, undecodable requests and move overruns to")
+	sentryEnv       = flag.String("sentry-env", "production", "environment tag of error reports")
+	logSink         = flag.String("log", "stdout", "where to log: stdout, stderr or a file path, rotated by size")
+	logMaxSize      = flag.Int64("log-max-size", 100<<20, "bytes a log file may grow to before it is rotated, or 0 to never rotate")
+	logBackups      = flag.Int("log-backups", 5, "rotated log files to keep")
+	logFormat       = flag.String("log-format", "text", "log format: text or json")
+	logLevel        = flag.String("log-level", "info", "minimum level logged: trace, debug, info, warn or error")
+	logLevels       = flag.String("log-levels", "", "per-component levels overriding -log-level, e.g. strategy=debug,server=warn")
+	warmup          = flag.Bool("warmup", true, "decide a throwaway move when each game starts so that its first move doesn't pay cold-start costs")
+	chaosSpec       = flag.String("chaos", "", "TESTING ONLY: failures to inject into move decisions, e.g. delay=0.2,max-delay=800ms,drop=0.05,panic=0.05")
+	selfTest        = flag.Bool("self-test", true, "play a synthetic game with every configured strategy through the handlers before serving, refusing to start if one fails")
+	strategyName    = flag.String("strategy", "random", "name of the strategy played unless a game selects another")
+)
 
-type BattlesnakeInfoResponse struct {
-	APIVersion string `json:"apiversion"`
-	Author     string `json:"author"`
-	Color      string `json:"color"`
-	Head       string `json:"head"`
-	Tail       string `json:"tail"`
+// commands are run by naming them as the first argument, instead of
+// serving.
+var commands = map[string]func(w io.Writer, args []string) error{
+	"bench": func(w io.Writer, args []string) error {
+		bench.Run(w)
+		return nil
+	},
+	"parity": func(w io.Writer, args []string) error {
+		failed, err := parity.Run(w)
+		if err == nil && failed > 0 {
+			err = fmt.Errorf("%d parity cases failed", failed)
+		}
+		return err
+	},
+	"perft":      runPerft,
+	"report":     runReport,
+	"heatmap":    runHeatmap,
+	"export":     runExport,
+	"gc":         runGC,
+	"stats":      runStats,
+	"arena":      runArena,
+	"sweep":      runSweep,
+	"tournament": runTournament,
+	"traces":     runTraces,
+	"diff":       runDiff,
+	"repl":       runREPL,
 }
 
-type GameRequest struct {
-	Game  Game        `json:"game"`
-	Turn  int         `json:"turn"`
-	Board Board       `json:"board"`
-	You   Battlesnake `json:"you"`
-}
+func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Stdout, os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
 
-type MoveResponse struct {
-	Move  string `json:"move"`
-	Shout string `json:"shout,omitempty"`
-}
+	flag.Parse()
 
-// HandleIndex is called when your Battlesnake is created and refreshed
-// by play.battlesnake.com. BattlesnakeInfoResponse contains information about
-// your Battlesnake, including what it should look like on the game board.
-func HandleIndex(w http.ResponseWriter, r *http.Request) {
-	response := BattlesnakeInfoResponse{
-		APIVersion: "1",
-		Author:     "jayuuza",
-		Color:      "#ff6600",
-		Head:       "pixel",
-		Tail:       "pixel",
+	cfg := &config.File{}
+	if *configFile != "" {
+		var err error
+		if cfg, err = config.Load(*configFile); err != nil {
+			log.Fatal(err)
+		}
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	err := json.NewEncoder(w).Encode(response)
-	if err != nil {
+	if err := applyConfig(cfg); err != nil {
 		log.Fatal(err)
 	}
-}
-
-// HandleStart is called at the start of each game your Battlesnake is playing.
-// The GameRequest object contains information about the game that's about to start.
-// TODO: Use this function to decide how your Battlesnake is going to look on the board.
-func HandleStart(w http.ResponseWriter, r *http.Request) {
-	request := GameRequest{}
-	err := json.NewDecoder(r.Body).Decode(&request)
-	if err != nil {
+	if err := applyWeights(cfg); err != nil {
 		log.Fatal(err)
 	}
 
-	// Nothing to respond with here
-	fmt.Printf("START GAME %+v\n", request)
-}
-
-// HandleMove is called for each turn of each game.
-// Valid responses are "up", "down", "left", or "right".
-func HandleMove(w http.ResponseWriter, r *http.Request) {
-	request := GameRequest{}
-	err := json.NewDecoder(r.Body).Decode(&request)
+	closeLog, err := setupLogging()
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer closeLog()
 
-	move := makeMove(request)
+	port := os.Getenv("PORT")
+	if len(port) == 0 {
+		port, _ = cfg.Get("server", "port")
+	}
+	if len(port) == 0 {
+		port = "8080"
+	}
 
-	fmt.Printf("MOVE: %s\n", move.Move)
-	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(move)
+	if _, err := strategy.New(*strategyName); err != nil {
+		log.Fatal(err)
+	}
+	if *shadowName != "" {
+		if _, err := strategy.New(*shadowName); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *fallbackName != "" {
+		if _, err := strategy.New(*fallbackName); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *personalities != "" {
+		if err := personality.Load(*personalities); err != nil {
+			log.Fatalf("%s: %v", *personalities, err)
+		}
+	}
+	snakes, err := configSnakes(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
-}
-
-// validMoves returns moves that won't result in death for a given position
-func validMoves(pos Coord, board Board) []string {
-	var moves []string
-	left := Coord{
-		X: pos.X - 1,
-		Y: pos.Y,
+	for _, snake := range snakes {
+		if snake.Strategy == "" {
+			continue
+		}
+		if _, err := strategy.New(snake.Strategy); err != nil {
+			log.Fatal(err)
+		}
 	}
-	if isValid(left, board) {
-		moves = append(moves, "left")
+	if _, ok := personality.Get(*personalityName); !ok {
+		log.Fatalf("unknown personality %q", *personalityName)
 	}
 
- 	right := Coord{
-		X: pos.X + 1,
-		Y: pos.Y,
+	if *searchTableMB > 0 {
+		search.UseTable(search.NewTable(*searchTableMB))
 	}
-	if isValid(right, board) {
-		moves = append(moves, "right")
+	strategy.SetPanicHealth(*panicHealth)
+
+	var gameStore store.Store = store.NewMemory()
+	if *redisURL != "" {
+		redis, err := store.NewRedis(*redisURL)
+		if err != nil {
+			log.Fatal(err)
+		}
+		gameStore = redis
 	}
 
-	down := Coord{
-		X: pos.X,
-		Y: pos.Y - 1,
+	srv := &server.Server{
+		DefaultStrategy:    *strategyName,
+		DefaultPersonality: *personalityName,
+		Snakes:             snakes,
+		Store:              gameStore,
+		DataDir:            *dataDir,
+		ProfileRate:        *profileRate,
+		Shadow:             *shadowName,
+		Fallback:           *fallbackName,
+		BreakerTrips:       *breakerTrips,
+		Timing:             timing.DefaultManager,
+		AdminToken:         *adminToken,
 	}
-	if isValid(down, board) {
-		moves = append(moves, "down")
+	if *appearanceFile != "" {
+		schedule, err := appearance.Load(*appearanceFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		srv.Appearance = schedule
 	}
-
-	up := Coord{
-		X: pos.X,
-		Y: pos.Y + 1,
+	if *recordHistory {
+		srv.History = &history.Recorder{Dir: *dataDir}
+		srv.Reports = *writeReports
+		if *keepWins < 1 || *compressAfter > 0 {
+			srv.Retention = &history.Retention{WinRate: *keepWins, CompressAfter: *compressAfter}
+		}
 	}
-	if isValid(up, board) {
-		moves = append(moves, "up")
+	if *recordDecisions {
+		srv.Decisions = &decision.Recorder{Dir: *dataDir}
 	}
-
-	return moves
-}
-
-func isValid(pos Coord, board Board) bool {
-	return !isEdge(pos, board) && !isSnake(pos, board)
-}
-
-func isEdge(pos Coord, board Board) bool {
-	return pos.X > board.Width - 1 ||  pos.Y > board.Height - 1 || pos.X < 0 || pos.Y < 0
-}
-
-func isFood(pos Coord, board Board) bool {
-	for _, coord := range board.Food {
-		if coord.Y == pos.Y && coord.X == pos.X {
-			return true
+	if *spectateGames {
+		srv.Spectator = &spectate.Client{Dir: *dataDir, URL: *spectateURL}
+	}
+	if *sentryDSN != "" {
+		errs, err := sentry.New(*sentryDSN)
+		if err != nil {
+			log.Fatal(err)
 		}
+		errs.Environment = *sentryEnv
+		srv.Errors = errs
 	}
-	return false
-}
-
-func isSnake(pos Coord, board Board) bool {
-	for _, snake := range board.Snakes {
-		for _, coord := range snake.Body {
-			if coord.Y == pos.Y && coord.X == pos.X {
-				return true
+	if *webhooks != "" {
+		notifier := webhook.New(strings.Split(*webhooks, ","))
+		notifier.On = map[string]bool{}
+		for _, outcome := range strings.Split(*webhookOn, ",") {
+			notifier.On[outcome] = true
+		}
+		notifier.Link = *webhookLink
+		srv.Webhooks = notifier
+	}
+	if *slowPositions {
+		srv.SlowDir = filepath.Join(*dataDir, "slow")
+	}
+	if *incidentAfter > 0 {
+		srv.IncidentDir = filepath.Join(*dataDir, "incidents")
+		srv.IncidentThreshold = *incidentAfter
+	}
+	srv.Warmup = *warmup
+	if *chaosSpec != "" {
+		if srv.Chaos, err = server.ParseChaos(*chaosSpec); err != nil {
+			log.Fatal(err)
+		}
+		logging.For(logging.Server).Warn("chaos enabled: moves will be delayed, dropped or panic", "chaos", *chaosSpec)
+	}
+	if *captureRaw {
+		srv.CaptureDir = filepath.Join(*dataDir, "raw")
+	}
+	if *recordResults {
+		srv.Results = results.NewStore(*dataDir)
+	}
+	if *experiment != "" {
+		arms := strings.Split(*experiment, ",")
+		if len(arms) != 2 {
+			log.Fatalf("-experiment %q: want two strategies, A,B", *experiment)
+		}
+		for _, name := range arms {
+			if _, err := strategy.New(name); err != nil {
+				log.Fatal(err)
 			}
 		}
+		srv.Experiment = &server.Experiment{
+			Name:   arms[0] + "-vs-" + arms[1],
+			A:      arms[0],
+			B:      arms[1],
+			RatioB: *experimentRatio,
+		}
+	}
+	if *shoutReplies != "" {
+		b, err := os.ReadFile(*shoutReplies)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := json.Unmarshal(b, &srv.ShoutReplies); err != nil {
+			log.Fatalf("%s: %v", *shoutReplies, err)
+		}
 	}
-	return false
-}
 
-func makeMove(game GameRequest) MoveResponse {
-	possibleMoves := validMoves(game.You.Head, game.Board)
-	if len(possibleMoves) == 0 {
-		return randomMove()
+	if *maxHeapMB > 0 || *maxGoroutines > 0 {
+		go srv.WatchPressure(context.Background(), server.Limits{
+			HeapBytes:  uint64(*maxHeapMB) << 20,
+			Goroutines: *maxGoroutines,
+		})
 	}
 
-	return MoveResponse{
-		Move: possibleMoves[rand.Intn(len(possibleMoves))],
+	srv.PrecomputeInfo()
+	if *selfTest {
+		start := time.Now()
+		if err := srv.SelfTest(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+		logging.For(logging.Server).Info("self-test passed", "took", time.Since(start).Round(time.Millisecond))
+	}
+
+	if *uploadEndpoint != "" {
+		if *uploadBucket == "" {
+			log.Fatal("-upload-endpoint needs -upload-bucket")
+		}
+		srv.Uploads = &upload.Uploader{
+			Bucket: &upload.Bucket{
+				Endpoint:  *uploadEndpoint,
+				Name:      *uploadBucket,
+				Region:    *uploadRegion,
+				AccessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+				SecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			},
+			Prefix:    *uploadPrefix,
+			KeepLocal: *uploadKeepLocal,
+		}
+		go srv.UploadGames(context.Background())
+	}
+	if *compressAfter > 0 {
+		go srv.CompressHistory(context.Background(), min(*compressAfter, time.Hour))
 	}
-}
 
-// randomMove Chooses a random direction to move in
-func randomMove() MoveResponse {
-	possibleMoves := []string{"up", "down", "left", "right"}
-	move := possibleMoves[rand.Intn(len(possibleMoves))]
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") != "" {
+		log.Fatal(serverless.ServeLambda(srv.Handler()))
+	}
 
-	return MoveResponse{
-		Move: move,
+	if *grpcAddr != "" {
+		l, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		logging.For(logging.Server).Info("serving gRPC", "addr", l.Addr().String())
+		go func() { log.Fatal(rpc.Serve(l, srv)) }()
 	}
-}
 
-// HandleEnd is called when a game your Battlesnake was playing has ended.
-// It's purely for informational purposes, no response required.
-func HandleEnd(w http.ResponseWriter, r *http.Request) {
-	request := GameRequest{}
-	err := json.NewDecoder(r.Body).Decode(&request)
+	ls, err := listeners(port)
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	// Nothing to respond with here
-	fmt.Print("END\n")
+	if err := serveHTTP(srv.Handler(), ls); err != nil {
+		log.Fatal(err)
+	}
 }
 
-func main() {
-	port := os.Getenv("PORT")
-	if len(port) == 0 {
-		port = "8080"
+// setupLogging configures logging from the -log flags.
+func setupLogging() (func() error, error) {
+	level, err := logging.ParseLevel(*logLevel)
+	if err != nil {
+		return nil, err
 	}
-
-	http.HandleFunc("/", HandleIndex)
-	http.HandleFunc("/start", HandleStart)
-	http.HandleFunc("/move", HandleMove)
-	http.HandleFunc("/end", HandleEnd)
-
-	fmt.Printf("Starting Battlesnake Server at http://0.0.0.0:%s...\n", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
-}
\ No newline at end of file
+	levels, err := logging.ParseLevels(*logLevels)
+	if err != nil {
+		return nil, err
+	}
+	return logging.Setup(logging.Config{
+		Sink:     *logSink,
+		MaxBytes: *logMaxSize,
+		Backups:  *logBackups,
+		Format:   *logFormat,
+		Level:    level,
+		Levels:   levels,
+	})
+}
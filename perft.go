@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/bench"
+	"github.com/jayuuza/battlesnake/pkg/repl"
+	"github.com/jayuuza/battlesnake/pkg/sim"
+)
+
+// runPerft counts the positions reachable from a position at each depth,
+// and how fast the simulator reaches them.
+func runPerft(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("perft", flag.ContinueOnError)
+	depth := fs.Int("depth", 3, "turns to expand")
+	divide := fs.Bool("divide", false, "break the deepest count down by our first move")
+	turn := fs.Int("turn", 0, "turn to start from, when the position is a recorded game")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: perft [flags] [position file or game dir]")
+	}
+
+	game := bench.Position()
+	if fs.NArg() == 1 {
+		var err error
+		if game, err = repl.Load(fs.Arg(0), *turn); err != nil {
+			return err
+		}
+	}
+	s := sim.New(game)
+	fmt.Fprintf(w, "%-6s %14s %12s %14s\n", "depth", "positions", "time", "positions/s")
+	for d := 1; d <= *depth; d++ {
+		start := time.Now()
+		n := sim.Perft(s, d)
+		elapsed := time.Since(start)
+		fmt.Fprintf(w, "%-6d %14d %12v %14.0f\n", d, n, elapsed.Round(time.Microsecond), float64(n)/elapsed.Seconds())
+	}
+	if *divide {
+		counts := sim.Divide(s, *depth)
+		for _, d := range api.Directions {
+			if n, ok := counts[d]; ok {
+				fmt.Fprintf(w, "%-6s %14d\n", d, n)
+			}
+		}
+	}
+	return nil
+}
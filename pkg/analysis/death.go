@@ -0,0 +1,100 @@
+package analysis
+
+import (
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/history"
+)
+
+// Causes of death, in the order the rules eliminate snakes.
+const (
+	Starvation    = "starvation"
+	Hazard        = "hazard"
+	Wall          = "wall"
+	SelfCollision = "self-collision"
+	BodyCollision = "body-collision"
+	HeadToHead    = "head-to-head"
+	Unknown       = "unknown"
+)
+
+// Death classifies what eliminated us in the game recorded in turns, or
+// returns "" if we were never eliminated. Only the last turn we moved on
+// and the board that followed it are needed.
+func Death(turns []history.Turn) string {
+	for i := len(turns) - 1; i >= 0; i-- {
+		t := turns[i]
+		if !onBoard(t.Request) {
+			continue
+		}
+		if i == len(turns)-1 || t.Move == nil {
+			return ""
+		}
+		return DeathCause(t.Request, t.Move.Move, turns[i+1].Request)
+	}
+	return ""
+}
+
+// DeathCause classifies what eliminated us when we played move in game,
+// given next, the board after the turn.
+func DeathCause(game api.GameRequest, move api.Direction, next api.GameRequest) string {
+	grid := board.GridFor(game)
+	you := game.You
+	head := grid.Step(you.Head, move)
+	if !grid.InBounds(head) {
+		return Wall
+	}
+
+	if !grid.IsFood(head) {
+		health := int(you.Health) - 1
+		if health <= 0 {
+			return Starvation
+		}
+		if health-grid.Damage(head) <= 0 {
+			return Hazard
+		}
+	}
+
+	// Our last segment moves on; if we ate last turn it was doubled, and
+	// its twin stays behind.
+	for _, seg := range you.Body[:max(len(you.Body)-1, 0)] {
+		if seg == head {
+			return SelfCollision
+		}
+	}
+
+	for _, snake := range next.Board.Snakes {
+		if snake.ID == you.ID {
+			continue
+		}
+		if snake.Head == head {
+			return HeadToHead
+		}
+		for _, seg := range snake.Body[1:] {
+			if seg == head {
+				return BodyCollision
+			}
+		}
+	}
+	// An opponent that died in the same collision is missing from next,
+	// but could only have been next to the cell.
+	for _, snake := range game.Board.Snakes {
+		if snake.ID == you.ID || snake.Length < you.Length || onBoardID(next, snake.ID) {
+			continue
+		}
+		if grid.Distance(snake.Head, head) == 1 {
+			return HeadToHead
+		}
+	}
+	return Unknown
+}
+
+// onBoardID reports whether the snake with the given ID is on the board in
+// game.
+func onBoardID(game api.GameRequest, id string) bool {
+	for _, snake := range game.Board.Snakes {
+		if snake.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/arena"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/eval"
+	"github.com/jayuuza/battlesnake/pkg/history"
+	"github.com/jayuuza/battlesnake/pkg/personality"
+	"github.com/jayuuza/battlesnake/pkg/selfplay"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// Disagreement is a turn of a recorded game where two players replaying it
+// chose different moves.
+type Disagreement struct {
+	Turn int `json:"turn"`
+	// Played is the move recorded in the game.
+	Played api.Direction `json:"played"`
+	A      Choice        `json:"a"`
+	B      Choice        `json:"b"`
+}
+
+// Choice is the move a player chose on a turn, with its evaluation of every
+// move that doesn't immediately kill us, best first.
+type Choice struct {
+	Move   api.Direction `json:"move"`
+	Plan   string        `json:"plan,omitempty"`
+	Scores []MoveScore   `json:"scores"`
+}
+
+// MoveScore is a move's evaluation, the sum of Terms, and the weighted
+// score of each term, with the player's weights and risk tolerance.
+type MoveScore struct {
+	Move  api.Direction      `json:"move"`
+	Score float64            `json:"score"`
+	Terms map[string]float64 `json:"terms"`
+}
+
+// Diff replays the game recorded in turns through players a and b, each
+// deciding every turn we were on the board from the recorded position
+// within timeout, or the game's own timeout if 0, and returns the turns
+// where they disagreed, in order.
+//
+// Both players see the positions of the game as it was played, not the
+// ones their own moves would have led to, so that every disagreement is on
+// the same board.
+func Diff(ctx context.Context, turns []history.Turn, a, b selfplay.Player, timeout time.Duration) ([]Disagreement, error) {
+	if len(turns) == 0 {
+		return nil, fmt.Errorf("analysis: no turns recorded")
+	}
+	sides := []*replayer{{Player: a}, {Player: b}}
+	for _, r := range sides {
+		strat, err := strategy.New(r.Strategy)
+		if err != nil {
+			return nil, err
+		}
+		r.strat, r.ctx = strat, r.Context(ctx)
+		r.strat.Start(r.ctx, turns[0].Request)
+	}
+
+	diffs := []Disagreement{}
+	for _, t := range turns {
+		if !onBoard(t.Request) {
+			break
+		}
+		if t.Move == nil {
+			continue
+		}
+		limit := timeout
+		if limit <= 0 {
+			limit = time.Duration(t.Request.Game.Timeout) * time.Millisecond
+		}
+		moveA, planA := sides[0].move(t.Request, limit)
+		moveB, planB := sides[1].move(t.Request, limit)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if moveA == moveB {
+			continue
+		}
+		diffs = append(diffs, Disagreement{
+			Turn:   t.Turn,
+			Played: t.Move.Move,
+			A:      Choice{Move: moveA, Plan: planA, Scores: sides[0].scores(t.Request)},
+			B:      Choice{Move: moveB, Plan: planB, Scores: sides[1].scores(t.Request)},
+		})
+	}
+
+	if final := turns[len(turns)-1]; final.Move == nil {
+		for _, r := range sides {
+			r.strat.End(r.ctx, final.Request)
+		}
+	}
+	return diffs, nil
+}
+
+// replayer is one side of a Diff.
+type replayer struct {
+	selfplay.Player
+	strat strategy.Strategy
+	ctx   context.Context
+}
+
+// move returns the move the player decides in game within timeout, and the
+// plan it described.
+func (r *replayer) move(game api.GameRequest, timeout time.Duration) (api.Direction, string) {
+	ctx, cancel := context.WithTimeout(r.ctx, timeout)
+	defer cancel()
+	scratch := arena.Get()
+	defer arena.Put(scratch)
+	ctx, plan := strategy.WithPlan(arena.NewContext(ctx, scratch))
+	return r.strat.Move(ctx, game).Move, plan.String()
+}
+
+// scores evaluates every safe move in game with the player's weights and
+// risk tolerance, best first.
+func (r *replayer) scores(game api.GameRequest) []MoveScore {
+	grid := board.GridFor(game)
+	cache := eval.NewCache(game, grid)
+	scores := []MoveScore{}
+	for _, move := range grid.ValidMoves(game.You.Head) {
+		p := eval.NewPosition(cache, move)
+		p.Risk = personality.FromContext(r.ctx).Risk
+		p.Weights = r.Weights
+		s := MoveScore{Move: move, Terms: eval.Breakdown(p)}
+		for _, score := range s.Terms {
+			s.Score += score
+		}
+		scores = append(scores, s)
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Score > scores[j].Score })
+	return scores
+}
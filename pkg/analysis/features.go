@@ -0,0 +1,152 @@
+package analysis
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/eval"
+	"github.com/jayuuza/battlesnake/pkg/history"
+)
+
+// featureColumns are the columns every feature row starts with; the
+// evaluation terms' weighted scores for the chosen move follow, then
+// outcomeColumns.
+var (
+	featureColumns = []string{
+		"game_id", "turn", "ruleset", "map", "width", "height", "snakes",
+		"health", "length", "length_lead", "food_distance", "enemy_distance",
+		"reachable", "voronoi_share", "move", "score",
+	}
+	outcomeColumns = []string{"outcome", "death", "turns_survived"}
+)
+
+// termNames returns the names of the evaluation terms in sorted order.
+func termNames() []string {
+	var names []string
+	for name := range eval.Weights() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteFeatures writes a CSV row of features for every turn we moved on in
+// the games recorded in the game directories dirs, labelled with how each
+// game turned out.
+func WriteFeatures(w io.Writer, dirs []string) error {
+	terms := termNames()
+	out := csv.NewWriter(w)
+	header := append(append(append([]string{}, featureColumns...), prefixed("term_", terms)...), outcomeColumns...)
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		turns, err := history.Load(filepath.Join(dir, history.FileName))
+		if err != nil {
+			return fmt.Errorf("%s: %v", dir, err)
+		}
+		if err := writeGameFeatures(out, turns, terms); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func writeGameFeatures(out *csv.Writer, turns []history.Turn, terms []string) error {
+	if len(turns) == 0 {
+		return nil
+	}
+	r, err := Analyze(turns)
+	if err != nil {
+		return err
+	}
+	outcome := []string{r.Outcome, r.Death, strconv.Itoa(r.Turns)}
+	for _, t := range turns {
+		if t.Move == nil || !onBoard(t.Request) {
+			continue
+		}
+		row := append(features(t.Request, t.Move.Move, terms), outcome...)
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// features returns the feature columns and term scores of playing move in
+// game.
+func features(game api.GameRequest, move api.Direction, terms []string) []string {
+	grid := board.GridFor(game)
+	cache := eval.NewCache(game, grid)
+	you := game.You
+
+	// lead is our length less the longest opponent's.
+	lead, longest := 0, -1
+	enemyDistance := -1
+	owned, ours := 0, -1
+	for i, snake := range game.Board.Snakes {
+		if snake.ID == you.ID {
+			ours = i
+			continue
+		}
+		longest = max(longest, int(snake.Length))
+		if d := grid.Distance(you.Head, snake.Head); enemyDistance < 0 || d < enemyDistance {
+			enemyDistance = d
+		}
+	}
+	if longest >= 0 {
+		lead = int(you.Length) - longest
+	}
+	for _, owner := range cache.Voronoi() {
+		if owner == ours {
+			owned++
+		}
+	}
+
+	scores := eval.Breakdown(eval.NewPosition(cache, move))
+	total := 0.0
+	for _, s := range scores {
+		total += s
+	}
+	row := []string{
+		game.Game.ID,
+		strconv.Itoa(game.Turn),
+		game.Game.Ruleset.Name,
+		game.Game.Map,
+		strconv.Itoa(int(game.Board.Width)),
+		strconv.Itoa(int(game.Board.Height)),
+		strconv.Itoa(len(game.Board.Snakes)),
+		strconv.Itoa(int(you.Health)),
+		strconv.Itoa(int(you.Length)),
+		strconv.Itoa(lead),
+		strconv.Itoa(cache.NearestFood(you.Head)),
+		strconv.Itoa(enemyDistance),
+		strconv.Itoa(cache.Reachable(you.Head)),
+		formatFloat(float64(owned) / float64(grid.Width*grid.Height)),
+		move.String(),
+		formatFloat(total),
+	}
+	for _, name := range terms {
+		row = append(row, formatFloat(scores[name]))
+	}
+	return row
+}
+
+func prefixed(prefix string, names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = prefix + name
+	}
+	return out
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', 6, 64)
+}
@@ -0,0 +1,164 @@
+package analysis
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/history"
+)
+
+// Heatmap counts, for every cell of boards of one size, the turns our head
+// was there and the deaths there, across many games. Cells are indexed by
+// y*Width+x.
+type Heatmap struct {
+	Width  int   `json:"width"`
+	Height int   `json:"height"`
+	Games  int   `json:"games"`
+	Visits []int `json:"visits"`
+	Deaths []int `json:"deaths"`
+	// Causes counts the deaths on every cell by cause.
+	Causes []map[string]int `json:"causes"`
+}
+
+// NewHeatmap returns an empty heatmap of a width by height board.
+func NewHeatmap(width, height int) *Heatmap {
+	return &Heatmap{
+		Width:  width,
+		Height: height,
+		Visits: make([]int, width*height),
+		Deaths: make([]int, width*height),
+		Causes: make([]map[string]int, width*height),
+	}
+}
+
+// Add counts the game recorded in turns, which must be played on a board of
+// the heatmap's size.
+func (h *Heatmap) Add(turns []history.Turn) {
+	h.Games++
+	for i, t := range turns {
+		if !onBoard(t.Request) {
+			break
+		}
+		head := t.Request.You.Head
+		if h.contains(head) {
+			h.Visits[head.Y*h.Width+head.X]++
+		}
+		if t.Move == nil || i == len(turns)-1 || onBoard(turns[i+1].Request) {
+			continue
+		}
+		// We died moving from here. Deaths off the edge are put on the
+		// cell we left.
+		cause := DeathCause(t.Request, t.Move.Move, turns[i+1].Request)
+		at := board.GridFor(t.Request).Step(head, t.Move.Move)
+		if !h.contains(at) {
+			at = head
+		}
+		if h.contains(at) {
+			idx := at.Y*h.Width + at.X
+			h.Deaths[idx]++
+			if h.Causes[idx] == nil {
+				h.Causes[idx] = map[string]int{}
+			}
+			h.Causes[idx][cause]++
+		}
+		break
+	}
+}
+
+func (h *Heatmap) contains(pos api.Coord) bool {
+	return board.InBounds(pos, h.Width, h.Height)
+}
+
+// Heatmaps aggregates the games recorded in the game directories dirs into
+// a heatmap per board size, keyed "WxH".
+func Heatmaps(dirs []string) (map[string]*Heatmap, error) {
+	maps := map[string]*Heatmap{}
+	for _, dir := range dirs {
+		turns, err := history.Load(filepath.Join(dir, history.FileName))
+		if err != nil {
+			return nil, err
+		}
+		if len(turns) == 0 {
+			continue
+		}
+		b := turns[0].Request.Board
+		key := fmt.Sprintf("%dx%d", b.Width, b.Height)
+		if maps[key] == nil {
+			maps[key] = NewHeatmap(int(b.Width), int(b.Height))
+		}
+		maps[key].Add(turns)
+	}
+	return maps, nil
+}
+
+// GameDirs returns the directories below dataDir that hold a recorded
+// history, in sorted order.
+func GameDirs(dataDir string) ([]string, error) {
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(dataDir, e.Name())
+		if history.Exists(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// cellPixels is the size in pixels of a cell in heatmap images.
+const cellPixels = 24
+
+// WritePNG draws counts, one per cell of the heatmap's board, as an image
+// shading cells from black (none) through red to yellow (the most), with y
+// increasing upwards as on the game board.
+func (h *Heatmap) WritePNG(w io.Writer, counts []int) error {
+	most := 0
+	for _, n := range counts {
+		most = max(most, n)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, h.Width*cellPixels, h.Height*cellPixels))
+	for y := 0; y < h.Height; y++ {
+		for x := 0; x < h.Width; x++ {
+			c := shade(counts[y*h.Width+x], most)
+			top := (h.Height - 1 - y) * cellPixels
+			for py := top; py < top+cellPixels; py++ {
+				for px := x * cellPixels; px < (x+1)*cellPixels; px++ {
+					// Leave a grid line between cells.
+					if py == top || px == x*cellPixels {
+						img.Set(px, py, color.RGBA{40, 40, 40, 255})
+						continue
+					}
+					img.Set(px, py, c)
+				}
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// shade maps n out of most to a black-red-yellow heat colour.
+func shade(n, most int) color.RGBA {
+	if most == 0 || n == 0 {
+		return color.RGBA{0, 0, 0, 255}
+	}
+	t := float64(n) / float64(most)
+	if t < 0.5 {
+		return color.RGBA{uint8(80 + 350*t), 0, 0, 255}
+	}
+	return color.RGBA{255, uint8(510 * (t - 0.5)), 0, 255}
+}
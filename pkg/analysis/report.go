@@ -0,0 +1,170 @@
+// Package analysis reviews finished games from their recorded history.
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/eval"
+	"github.com/jayuuza/battlesnake/pkg/history"
+	"github.com/jayuuza/battlesnake/pkg/results"
+	"github.com/jayuuza/battlesnake/pkg/spectate"
+)
+
+// FileName is the name of the report within a game's directory.
+const FileName = "report.json"
+
+// Report is the review of one game.
+type Report struct {
+	GameID  string `json:"gameId"`
+	Outcome string `json:"outcome"`
+	// Turns is the last turn we were on the board.
+	Turns int `json:"turns"`
+	// Death is what eliminated us, or "" if we weren't.
+	Death string `json:"death,omitempty"`
+	// DecisionPoints are the turns whose move the evaluation only narrowly
+	// preferred, by turn.
+	DecisionPoints []DecisionPoint `json:"decisionPoints"`
+	// Health and Length are ours at every recorded turn, in order.
+	Health []int `json:"health"`
+	Length []int `json:"length"`
+	// Discrepancies are where the boards we were sent differ from the
+	// engine's frames, if they were recorded.
+	Discrepancies []spectate.Discrepancy `json:"discrepancies,omitempty"`
+}
+
+// DecisionPoint is a turn where the chosen move's evaluation barely beat
+// the runner-up's.
+type DecisionPoint struct {
+	Turn          int           `json:"turn"`
+	Move          api.Direction `json:"move"`
+	Score         float64       `json:"score"`
+	RunnerUp      api.Direction `json:"runnerUp"`
+	RunnerUpScore float64       `json:"runnerUpScore"`
+}
+
+// Margin returns how far the chosen move's score beat the runner-up's.
+func (d DecisionPoint) Margin() float64 {
+	return d.Score - d.RunnerUpScore
+}
+
+// A move is a close call when its score beats the runner-up's by less than
+// closeCall. At most maxDecisionPoints of the closest are reported.
+const (
+	closeCall         = 0.5
+	maxDecisionPoints = 10
+)
+
+// Analyze reviews the game recorded in turns, which end with the final
+// board sent to /end.
+func Analyze(turns []history.Turn) (Report, error) {
+	if len(turns) == 0 {
+		return Report{}, fmt.Errorf("analysis: no turns recorded")
+	}
+	final := turns[len(turns)-1].Request
+	r := Report{
+		GameID:         final.Game.ID,
+		Outcome:        results.New(final).Outcome,
+		DecisionPoints: []DecisionPoint{},
+	}
+
+	for i := range turns {
+		t := &turns[i]
+		if !onBoard(t.Request) {
+			break
+		}
+		r.Turns = t.Turn
+		r.Health = append(r.Health, int(t.Request.You.Health))
+		r.Length = append(r.Length, int(t.Request.You.Length))
+		if t.Move == nil {
+			continue
+		}
+		if d, ok := decisionPoint(t.Request, t.Move.Move); ok {
+			r.DecisionPoints = append(r.DecisionPoints, d)
+		}
+	}
+	r.Death = Death(turns)
+
+	sort.Slice(r.DecisionPoints, func(i, j int) bool {
+		return r.DecisionPoints[i].Margin() < r.DecisionPoints[j].Margin()
+	})
+	if len(r.DecisionPoints) > maxDecisionPoints {
+		r.DecisionPoints = r.DecisionPoints[:maxDecisionPoints]
+	}
+	sort.Slice(r.DecisionPoints, func(i, j int) bool {
+		return r.DecisionPoints[i].Turn < r.DecisionPoints[j].Turn
+	})
+	return r, nil
+}
+
+// onBoard reports whether our snake is still on the board in game.
+func onBoard(game api.GameRequest) bool {
+	return onBoardID(game, game.You.ID)
+}
+
+// decisionPoint evaluates every safe move in game as the heuristic
+// strategy does, reporting whether move was chosen by a close call.
+func decisionPoint(game api.GameRequest, move api.Direction) (DecisionPoint, bool) {
+	grid := board.GridFor(game)
+	moves := grid.ValidMoves(game.You.Head)
+	if len(moves) < 2 {
+		return DecisionPoint{}, false
+	}
+	cache := eval.NewCache(game, grid)
+	d := DecisionPoint{Turn: game.Turn, Move: move}
+	found, runnerUp := false, false
+	for _, m := range moves {
+		score := eval.Evaluate(eval.NewPosition(cache, m))
+		switch {
+		case m == move:
+			d.Score, found = score, true
+		case !runnerUp || score > d.RunnerUpScore:
+			d.RunnerUp, d.RunnerUpScore, runnerUp = m, score, true
+		}
+	}
+	if !found || !runnerUp {
+		return DecisionPoint{}, false
+	}
+	margin := d.Margin()
+	return d, margin >= 0 && margin < closeCall
+}
+
+// Load reviews the game whose history is in the game directory dir,
+// comparing it with the engine's frames if they were recorded there too.
+func Load(dir string) (Report, error) {
+	turns, err := history.Load(filepath.Join(dir, history.FileName))
+	if err != nil {
+		return Report{}, err
+	}
+	r, err := Analyze(turns)
+	if err != nil {
+		return r, err
+	}
+	frames, err := spectate.Load(filepath.Join(dir, spectate.FileName))
+	switch {
+	case err == nil:
+		r.Discrepancies = spectate.Compare(frames, turns)
+	case !os.IsNotExist(err):
+		return r, err
+	}
+	return r, nil
+}
+
+// Write reviews the game whose history is in the game directory dir and
+// saves the report there.
+func Write(dir string) (Report, error) {
+	r, err := Load(dir)
+	if err != nil {
+		return r, err
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return r, err
+	}
+	return r, os.WriteFile(filepath.Join(dir, FileName), b, 0644)
+}
@@ -0,0 +1,80 @@
+// Package api defines the Battlesnake wire types exchanged with the game
+// engine.
+package api
+
+type Game struct {
+	ID      string  `json:"id"`
+	Ruleset Ruleset `json:"ruleset"`
+	Map     string  `json:"map"`
+	Source  string  `json:"source"`
+	Timeout int32   `json:"timeout"`
+}
+
+type Ruleset struct {
+	Name     string          `json:"name"`
+	Version  string          `json:"version"`
+	Settings RulesetSettings `json:"settings"`
+}
+
+type RulesetSettings struct {
+	FoodSpawnChance     int32          `json:"foodSpawnChance"`
+	MinimumFood         int32          `json:"minimumFood"`
+	HazardDamagePerTurn int32          `json:"hazardDamagePerTurn"`
+	Royale              RoyaleSettings `json:"royale"`
+	Squad               SquadSettings  `json:"squad"`
+}
+
+type RoyaleSettings struct {
+	ShrinkEveryNTurns int32 `json:"shrinkEveryNTurns"`
+}
+
+type SquadSettings struct {
+	AllowBodyCollisions bool `json:"allowBodyCollisions"`
+	SharedElimination   bool `json:"sharedElimination"`
+	SharedHealth        bool `json:"sharedHealth"`
+	SharedLength        bool `json:"sharedLength"`
+}
+
+type Coord struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type Battlesnake struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Health  int32   `json:"health"`
+	Body    []Coord `json:"body"`
+	Head    Coord   `json:"head"`
+	Length  int32   `json:"length"`
+	Latency string  `json:"latency"`
+	Shout   string  `json:"shout"`
+}
+
+type Board struct {
+	Height  int           `json:"height"`
+	Width   int           `json:"width"`
+	Food    []Coord       `json:"food"`
+	Hazards []Coord       `json:"hazards"`
+	Snakes  []Battlesnake `json:"snakes"`
+}
+
+type BattlesnakeInfoResponse struct {
+	APIVersion string `json:"apiversion"`
+	Author     string `json:"author"`
+	Color      string `json:"color"`
+	Head       string `json:"head"`
+	Tail       string `json:"tail"`
+}
+
+type GameRequest struct {
+	Game  Game        `json:"game"`
+	Turn  int         `json:"turn"`
+	Board Board       `json:"board"`
+	You   Battlesnake `json:"you"`
+}
+
+type MoveResponse struct {
+	Move  Direction `json:"move"`
+	Shout string    `json:"shout,omitempty"`
+}
@@ -0,0 +1,471 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// UnmarshalJSON decodes a request without reflection, as it is decoded
+// on every turn and large boards made encoding/json's allocations show in
+// profiles. Strings share one copy of data, and the coordinates of the
+// food, hazards and every body share one array, so a request takes a
+// handful of allocations whatever its size. Game and snake IDs and names,
+// which outlive the request as keys of per-game and per-opponent state,
+// get copies of their own so as not to keep the whole request alive. It
+// accepts what json.Unmarshal would into the same fields, ignoring unknown
+// ones and matching keys without regard to case.
+func (r *GameRequest) UnmarshalJSON(data []byte) error {
+	d := decoder{data: data, doc: string(data)}
+	// A body's coordinates almost always follow an "x" key each, so this
+	// is enough room for them all.
+	d.coords = make([]Coord, 0, bytes.Count(data, []byte(`"x"`)))
+	err := d.object(func(key string) error {
+		switch {
+		case is(key, "game"):
+			return d.game(&r.Game)
+		case is(key, "turn"):
+			return d.int(&r.Turn)
+		case is(key, "board"):
+			return d.board(&r.Board)
+		case is(key, "you"):
+			return d.snake(&r.You)
+		}
+		return d.skip()
+	})
+	if err != nil {
+		return fmt.Errorf("api: decoding request: %v", err)
+	}
+	return nil
+}
+
+// is reports whether key names the field name, as encoding/json matches
+// them.
+func is(key, name string) bool {
+	return len(key) == len(name) && (key == name || strings.EqualFold(key, name))
+}
+
+// decoder reads JSON values from data, of which doc is a copy that decoded
+// strings are cut from.
+type decoder struct {
+	data   []byte
+	doc    string
+	pos    int
+	coords []Coord
+}
+
+func (d *decoder) errorf(format string, args ...any) error {
+	return fmt.Errorf("offset %d: %s", d.pos, fmt.Sprintf(format, args...))
+}
+
+func (d *decoder) space() {
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case ' ', '\t', '\n', '\r':
+			d.pos++
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the next byte after any space, or 0 at the end of the data.
+func (d *decoder) peek() byte {
+	d.space()
+	if d.pos >= len(d.data) {
+		return 0
+	}
+	return d.data[d.pos]
+}
+
+func (d *decoder) expect(c byte) error {
+	if d.peek() != c {
+		return d.errorf("expected %q", c)
+	}
+	d.pos++
+	return nil
+}
+
+// null consumes a null, reporting whether there was one.
+func (d *decoder) null() bool {
+	if d.peek() == 'n' && bytes.HasPrefix(d.data[d.pos:], []byte("null")) {
+		d.pos += 4
+		return true
+	}
+	return false
+}
+
+// object calls field with each key of an object, which must consume the
+// key's value. A null object has no keys.
+func (d *decoder) object(field func(key string) error) error {
+	if d.null() {
+		return nil
+	}
+	if err := d.expect('{'); err != nil {
+		return err
+	}
+	if d.peek() == '}' {
+		d.pos++
+		return nil
+	}
+	for {
+		key, err := d.string()
+		if err != nil {
+			return err
+		}
+		if err := d.expect(':'); err != nil {
+			return err
+		}
+		if err := field(key); err != nil {
+			return err
+		}
+		switch d.peek() {
+		case ',':
+			d.pos++
+		case '}':
+			d.pos++
+			return nil
+		default:
+			return d.errorf("expected ',' or '}'")
+		}
+	}
+}
+
+// array calls elem for each element of an array, which must consume it,
+// reporting false for a null array.
+func (d *decoder) array(elem func() error) (bool, error) {
+	if d.null() {
+		return false, nil
+	}
+	if err := d.expect('['); err != nil {
+		return false, err
+	}
+	if d.peek() == ']' {
+		d.pos++
+		return true, nil
+	}
+	for {
+		if err := elem(); err != nil {
+			return false, err
+		}
+		switch d.peek() {
+		case ',':
+			d.pos++
+		case ']':
+			d.pos++
+			return true, nil
+		default:
+			return false, d.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+// string decodes a string, cut from doc unless it has escapes, control
+// characters or invalid UTF-8, which json.Unmarshal rejects or replaces.
+func (d *decoder) string() (string, error) {
+	if err := d.expect('"'); err != nil {
+		return "", err
+	}
+	start := d.pos
+	escaped, ascii := false, true
+	for ; d.pos < len(d.data); d.pos++ {
+		switch c := d.data[d.pos]; {
+		case c == '\\':
+			escaped = true
+			d.pos++
+		case c < ' ':
+			escaped = true
+		case c >= utf8.RuneSelf:
+			ascii = false
+		case c == '"':
+			d.pos++
+			raw := d.doc[start : d.pos-1]
+			if !escaped && (ascii || utf8.ValidString(raw)) {
+				return raw, nil
+			}
+			var s string
+			if err := json.Unmarshal(d.data[start-1:d.pos], &s); err != nil {
+				return "", err
+			}
+			return s, nil
+		}
+	}
+	return "", d.errorf("unterminated string")
+}
+
+// text decodes a string into *s, leaving it alone if null.
+func (d *decoder) text(s *string) error {
+	if d.null() {
+		return nil
+	}
+	v, err := d.string()
+	if err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
+
+// ident decodes a string into *s as text does, copied out of the request
+// since it may be kept after the request is done with.
+func (d *decoder) ident(s *string) error {
+	if err := d.text(s); err != nil {
+		return err
+	}
+	*s = strings.Clone(*s)
+	return nil
+}
+
+// number decodes an integer between lo and hi.
+func (d *decoder) number(lo, hi int64) (int64, error) {
+	d.space()
+	start := d.pos
+	neg := false
+	if d.pos < len(d.data) && d.data[d.pos] == '-' {
+		neg = true
+		d.pos++
+	}
+	var n uint64
+	digits := 0
+	for ; d.pos < len(d.data) && d.data[d.pos] >= '0' && d.data[d.pos] <= '9'; d.pos++ {
+		n = n*10 + uint64(d.data[d.pos]-'0')
+		if digits++; digits > 18 {
+			return 0, d.errorf("number out of range")
+		}
+	}
+	if digits == 0 {
+		return 0, d.errorf("expected a number")
+	}
+	if d.pos < len(d.data) {
+		if c := d.data[d.pos]; c == '.' || c == 'e' || c == 'E' {
+			for d.pos < len(d.data) && strings.IndexByte("+-.eE0123456789", d.data[d.pos]) >= 0 {
+				d.pos++
+			}
+			return 0, d.errorf("cannot decode number %s into an integer", d.data[start:d.pos])
+		}
+	}
+	v := int64(n)
+	if neg {
+		v = -v
+	}
+	if v < lo || v > hi {
+		return 0, d.errorf("number %d out of range", v)
+	}
+	return v, nil
+}
+
+func (d *decoder) int(p *int) error {
+	if d.null() {
+		return nil
+	}
+	v, err := d.number(math.MinInt, math.MaxInt)
+	*p = int(v)
+	return err
+}
+
+func (d *decoder) int32(p *int32) error {
+	if d.null() {
+		return nil
+	}
+	v, err := d.number(math.MinInt32, math.MaxInt32)
+	*p = int32(v)
+	return err
+}
+
+func (d *decoder) bool(p *bool) error {
+	switch {
+	case d.null():
+	case bytes.HasPrefix(d.data[d.pos:], []byte("true")):
+		*p = true
+		d.pos += 4
+	case bytes.HasPrefix(d.data[d.pos:], []byte("false")):
+		*p = false
+		d.pos += 5
+	default:
+		return d.errorf("expected a boolean")
+	}
+	return nil
+}
+
+// skip consumes a value of any kind.
+func (d *decoder) skip() error {
+	switch c := d.peek(); {
+	case c == '{':
+		return d.object(func(string) error { return d.skip() })
+	case c == '[':
+		_, err := d.array(d.skip)
+		return err
+	case c == '"':
+		_, err := d.string()
+		return err
+	case c == 't' || c == 'f':
+		var b bool
+		return d.bool(&b)
+	case c == 'n':
+		if !d.null() {
+			return d.errorf("expected null")
+		}
+		return nil
+	case c == '-' || c >= '0' && c <= '9':
+		for d.pos < len(d.data) && strings.IndexByte("+-.eE0123456789", d.data[d.pos]) >= 0 {
+			d.pos++
+		}
+		return nil
+	}
+	return d.errorf("unexpected input")
+}
+
+func (d *decoder) game(g *Game) error {
+	return d.object(func(key string) error {
+		switch {
+		case is(key, "id"):
+			return d.ident(&g.ID)
+		case is(key, "ruleset"):
+			return d.ruleset(&g.Ruleset)
+		case is(key, "map"):
+			return d.text(&g.Map)
+		case is(key, "source"):
+			return d.text(&g.Source)
+		case is(key, "timeout"):
+			return d.int32(&g.Timeout)
+		}
+		return d.skip()
+	})
+}
+
+func (d *decoder) ruleset(r *Ruleset) error {
+	return d.object(func(key string) error {
+		switch {
+		case is(key, "name"):
+			return d.text(&r.Name)
+		case is(key, "version"):
+			return d.text(&r.Version)
+		case is(key, "settings"):
+			return d.settings(&r.Settings)
+		}
+		return d.skip()
+	})
+}
+
+func (d *decoder) settings(s *RulesetSettings) error {
+	return d.object(func(key string) error {
+		switch {
+		case is(key, "foodSpawnChance"):
+			return d.int32(&s.FoodSpawnChance)
+		case is(key, "minimumFood"):
+			return d.int32(&s.MinimumFood)
+		case is(key, "hazardDamagePerTurn"):
+			return d.int32(&s.HazardDamagePerTurn)
+		case is(key, "royale"):
+			return d.object(func(key string) error {
+				if is(key, "shrinkEveryNTurns") {
+					return d.int32(&s.Royale.ShrinkEveryNTurns)
+				}
+				return d.skip()
+			})
+		case is(key, "squad"):
+			return d.object(func(key string) error {
+				switch {
+				case is(key, "allowBodyCollisions"):
+					return d.bool(&s.Squad.AllowBodyCollisions)
+				case is(key, "sharedElimination"):
+					return d.bool(&s.Squad.SharedElimination)
+				case is(key, "sharedHealth"):
+					return d.bool(&s.Squad.SharedHealth)
+				case is(key, "sharedLength"):
+					return d.bool(&s.Squad.SharedLength)
+				}
+				return d.skip()
+			})
+		}
+		return d.skip()
+	})
+}
+
+func (d *decoder) board(b *Board) error {
+	return d.object(func(key string) error {
+		switch {
+		case is(key, "height"):
+			return d.int(&b.Height)
+		case is(key, "width"):
+			return d.int(&b.Width)
+		case is(key, "food"):
+			return d.coordList(&b.Food)
+		case is(key, "hazards"):
+			return d.coordList(&b.Hazards)
+		case is(key, "snakes"):
+			b.Snakes = b.Snakes[:0]
+			ok, err := d.array(func() error {
+				b.Snakes = append(b.Snakes, Battlesnake{})
+				return d.snake(&b.Snakes[len(b.Snakes)-1])
+			})
+			switch {
+			case !ok:
+				b.Snakes = nil
+			case b.Snakes == nil:
+				b.Snakes = []Battlesnake{}
+			}
+			return err
+		}
+		return d.skip()
+	})
+}
+
+func (d *decoder) snake(s *Battlesnake) error {
+	return d.object(func(key string) error {
+		switch {
+		case is(key, "id"):
+			return d.ident(&s.ID)
+		case is(key, "name"):
+			return d.ident(&s.Name)
+		case is(key, "health"):
+			return d.int32(&s.Health)
+		case is(key, "body"):
+			return d.coordList(&s.Body)
+		case is(key, "head"):
+			return d.coord(&s.Head)
+		case is(key, "length"):
+			return d.int32(&s.Length)
+		case is(key, "latency"):
+			return d.text(&s.Latency)
+		case is(key, "shout"):
+			return d.text(&s.Shout)
+		}
+		return d.skip()
+	})
+}
+
+// coordList decodes an array of coordinates into the shared array, setting
+// *p to them. Its capacity ends with them, so appending to it can't
+// overwrite the next list.
+func (d *decoder) coordList(p *[]Coord) error {
+	start := len(d.coords)
+	ok, err := d.array(func() error {
+		d.coords = append(d.coords, Coord{})
+		return d.coord(&d.coords[len(d.coords)-1])
+	})
+	switch {
+	case err != nil:
+		return err
+	case !ok:
+		*p = nil
+	default:
+		*p = d.coords[start:len(d.coords):len(d.coords)]
+	}
+	return nil
+}
+
+func (d *decoder) coord(c *Coord) error {
+	return d.object(func(key string) error {
+		switch {
+		case is(key, "x"):
+			return d.int(&c.X)
+		case is(key, "y"):
+			return d.int(&c.Y)
+		}
+		return d.skip()
+	})
+}
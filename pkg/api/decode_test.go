@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// reflectRequest has GameRequest's fields but not its UnmarshalJSON, so it
+// is decoded by reflection as encoding/json would.
+type reflectRequest GameRequest
+
+// engineRequest is a move request as the engine sends it.
+const engineRequest = `{
+  "game": {
+    "id": "totally-unique-game-id",
+    "ruleset": {
+      "name": "standard",
+      "version": "v1.2.3",
+      "settings": {
+        "foodSpawnChance": 25,
+        "minimumFood": 1,
+        "hazardDamagePerTurn": 14,
+        "royale": {"shrinkEveryNTurns": 5},
+        "squad": {"allowBodyCollisions": true, "sharedElimination": false, "sharedHealth": true, "sharedLength": false}
+      }
+    },
+    "map": "standard",
+    "source": "league",
+    "timeout": 500
+  },
+  "turn": 14,
+  "board": {
+    "height": 11,
+    "width": 11,
+    "food": [{"x": 5, "y": 5}, {"x": 9, "y": 0}, {"x": 2, "y": 6}],
+    "hazards": [{"x": 3, "y": 2}],
+    "snakes": [
+      {
+        "id": "snake-508e96ac-94ad-11ea-bb37",
+        "name": "My Snake",
+        "health": 54,
+        "body": [{"x": 0, "y": 0}, {"x": 1, "y": 0}, {"x": 2, "y": 0}],
+        "latency": "111",
+        "head": {"x": 0, "y": 0},
+        "length": 3,
+        "shout": "why are we shouting??",
+        "customizations": {"color": "#FF0000", "head": "pixel", "tail": "pixel"}
+      },
+      {
+        "id": "snake-b67f4906-94ae-11ea-bb37",
+        "name": "Another Snake",
+        "health": 16,
+        "body": [{"x": 5, "y": 4}, {"x": 5, "y": 3}, {"x": 6, "y": 3}, {"x": 6, "y": 2}],
+        "latency": "222",
+        "head": {"x": 5, "y": 4},
+        "length": 4,
+        "shout": "I'm not really sure..."
+      }
+    ]
+  },
+  "you": {
+    "id": "snake-508e96ac-94ad-11ea-bb37",
+    "name": "My Snake",
+    "health": 54,
+    "body": [{"x": 0, "y": 0}, {"x": 1, "y": 0}, {"x": 2, "y": 0}],
+    "latency": "111",
+    "head": {"x": 0, "y": 0},
+    "length": 3,
+    "shout": "why are we shouting??"
+  }
+}`
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"engine", engineRequest},
+		{"empty", `{}`},
+		{"null fields", `{"game": null, "board": {"food": null, "snakes": null}, "you": {"body": null}}`},
+		{"empty lists", `{"board": {"food": [], "hazards": [], "snakes": []}}`},
+		{"key case", `{"GAME": {"Id": "g"}, "Turn": 3, "board": {"WIDTH": 7}}`},
+		{"escapes", `{"game": {"id": "a\"b\\cé"}, "you": {"name": "tab\there", "shout": "🐍"}}`},
+		{"invalid UTF-8", "{\"game\": {\"id\": \"g\xff\xfe\"}, \"you\": {\"name\": \"\xe2\x82\"}}"},
+		{"control character", "{\"game\": {\"id\": \"a\tb\"}}"},
+		{"unknown keys", `{"extra": [1, {"a": [true, false, null]}, "s", -2.5e3], "turn": 2}`},
+		{"negative", `{"turn": -1, "you": {"head": {"x": -1, "y": 0}}}`},
+		{"float", `{"turn": 1.5}`},
+		{"out of range", `{"you": {"health": 3000000000}}`},
+		{"unterminated", `{"game": {"id": "g`},
+		{"trailing comma", `{"turn": 1,}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want reflectRequest
+			wantErr := json.Unmarshal([]byte(tt.data), &want)
+			var got GameRequest
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != (wantErr != nil) {
+				t.Fatalf("error %v, encoding/json's %v", err, wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, GameRequest(want)) {
+				t.Fatalf("decoded\n%+v\nencoding/json decoded\n%+v", got, GameRequest(want))
+			}
+		})
+	}
+}
+
+// TestIdentsCopied checks that the strings kept as keys after a request is
+// done with don't share the memory of the whole request.
+func TestIdentsCopied(t *testing.T) {
+	data := []byte(engineRequest)
+	d := decoder{data: data, doc: string(data)}
+	var r GameRequest
+	err := d.object(func(key string) error {
+		switch key {
+		case "game":
+			return d.game(&r.Game)
+		case "board":
+			return d.board(&r.Board)
+		case "you":
+			return d.snake(&r.You)
+		}
+		return d.skip()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := uintptr(unsafe.Pointer(unsafe.StringData(d.doc)))
+	inDoc := func(s string) bool {
+		p := uintptr(unsafe.Pointer(unsafe.StringData(s)))
+		return p >= start && p < start+uintptr(len(d.doc))
+	}
+	idents := map[string]string{"game ID": r.Game.ID, "our ID": r.You.ID, "our name": r.You.Name}
+	for _, s := range r.Board.Snakes {
+		idents["ID of "+s.Name] = s.ID
+		idents["name of "+s.Name] = s.Name
+	}
+	for what, s := range idents {
+		if inDoc(s) {
+			t.Errorf("%s %q shares the request's memory", what, s)
+		}
+	}
+	// Other strings are still cut from it.
+	if !inDoc(r.You.Latency) {
+		t.Errorf("latency %q was copied", r.You.Latency)
+	}
+}
+
+// largeRequest returns a request on a large board crowded with snakes and
+// hazards, as the decoder is slowest on.
+func largeRequest() []byte {
+	const width, height = 19, 21
+	r := GameRequest{
+		Game:  Game{ID: "large", Ruleset: Ruleset{Name: "wrapped"}, Map: "arcade_maze", Timeout: 500},
+		Turn:  150,
+		Board: Board{Width: width, Height: height},
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x == 0 || y == 0 || x == width-1 || y == height-1 || x%4 == 2 && y%3 != 1 {
+				r.Board.Hazards = append(r.Board.Hazards, Coord{X: x, Y: y})
+			}
+		}
+	}
+	for i := 0; i < 4; i++ {
+		s := Battlesnake{ID: strings.Repeat("s", i+1), Name: "snake", Health: 90, Latency: "42", Length: 20}
+		for j := 0; j < 20; j++ {
+			s.Body = append(s.Body, Coord{X: 1 + 4*i, Y: j})
+		}
+		s.Head = s.Body[0]
+		r.Board.Snakes = append(r.Board.Snakes, s)
+	}
+	r.Board.Food = []Coord{{X: 1, Y: 19}, {X: 17, Y: 19}}
+	r.You = r.Board.Snakes[0]
+	data, _ := json.Marshal(r)
+	return data
+}
+
+func BenchmarkDecodeRequest(b *testing.B) {
+	data := largeRequest()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		var request GameRequest
+		if err := json.Unmarshal(data, &request); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeRequestReflect(b *testing.B) {
+	data := largeRequest()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		var request reflectRequest
+		if err := json.Unmarshal(data, &request); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
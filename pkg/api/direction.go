@@ -0,0 +1,86 @@
+package api
+
+import "fmt"
+
+// Direction is one of the four moves a snake can make.
+type Direction uint8
+
+const (
+	Up Direction = iota
+	Down
+	Left
+	Right
+)
+
+// Directions lists every Direction.
+var Directions = [...]Direction{Up, Down, Left, Right}
+
+var (
+	directionNames  = [...]string{"up", "down", "left", "right"}
+	directionDeltas = [...]Coord{{X: 0, Y: 1}, {X: 0, Y: -1}, {X: -1, Y: 0}, {X: 1, Y: 0}}
+)
+
+func (d Direction) String() string {
+	return directionNames[d]
+}
+
+// Delta returns the change in position made by moving in d.
+func (d Direction) Delta() Coord {
+	return directionDeltas[d]
+}
+
+// Opposite returns the direction reversing d. Opposite directions are
+// declared in adjacent pairs, so this flips the lowest bit.
+func (d Direction) Opposite() Direction {
+	return d ^ 1
+}
+
+// MarshalText encodes d as its lowercase name, as the engine expects.
+func (d Direction) MarshalText() ([]byte, error) {
+	if int(d) >= len(directionNames) {
+		return nil, fmt.Errorf("api: invalid direction %d", d)
+	}
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText decodes a direction from its lowercase name.
+func (d *Direction) UnmarshalText(text []byte) error {
+	parsed, ok := ParseDirection(string(text))
+	if !ok {
+		return fmt.Errorf("api: invalid direction %q", text)
+	}
+	*d = parsed
+	return nil
+}
+
+// ParseDirection returns the Direction named s, or false if s isn't one of
+// "up", "down", "left" or "right".
+func ParseDirection(s string) (Direction, bool) {
+	for d, name := range directionNames {
+		if name == s {
+			return Direction(d), true
+		}
+	}
+	return 0, false
+}
+
+// Add returns the sum of c and o.
+func (c Coord) Add(o Coord) Coord {
+	return Coord{X: c.X + o.X, Y: c.Y + o.Y}
+}
+
+// Move returns the cell one step from c in direction d.
+func (c Coord) Move(d Direction) Coord {
+	return c.Add(d.Delta())
+}
+
+// DirectionTo returns the direction leading from c to the adjacent cell o,
+// or false if o isn't adjacent to c.
+func (c Coord) DirectionTo(o Coord) (Direction, bool) {
+	for _, d := range Directions {
+		if c.Move(d) == o {
+			return d, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Version is a revision of the JSON wire format. It converts the payloads
+// of its revision to and from the types of this package, so that the rest
+// of the snake plays the same whichever version a request speaks.
+type Version interface {
+	// Name is the version as reported in the info response's apiversion.
+	Name() string
+	// DecodeRequest decodes the body of a start, move or end request.
+	DecodeRequest(data []byte, r *GameRequest) error
+	// Info returns the info response as the version encodes it.
+	Info(info BattlesnakeInfoResponse) any
+	// Start returns the response to a start request as the version
+	// encodes it, given the info response, or nil if it has no body.
+	Start(info BattlesnakeInfoResponse) any
+	// Move returns the move response as the version encodes it.
+	Move(move MoveResponse) any
+}
+
+// V1 is the current version of the API, which the types of this package
+// follow exactly.
+var V1 Version = v1{}
+
+var versions = map[string]Version{}
+
+// RegisterVersion makes v available to LookupVersion by its name.
+func RegisterVersion(v Version) {
+	versions[v.Name()] = v
+}
+
+// LookupVersion returns the version with the given name.
+func LookupVersion(name string) (Version, bool) {
+	v, ok := versions[name]
+	return v, ok
+}
+
+// VersionNames returns the names of the registered versions, sorted.
+func VersionNames() []string {
+	names := make([]string, 0, len(versions))
+	for name := range versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterVersion(V1)
+	RegisterVersion(legacy{})
+}
+
+type v1 struct{}
+
+func (v1) Name() string { return "1" }
+
+func (v1) DecodeRequest(data []byte, r *GameRequest) error {
+	return json.Unmarshal(data, r)
+}
+
+func (v1) Info(info BattlesnakeInfoResponse) any { return info }
+
+func (v1) Start(BattlesnakeInfoResponse) any { return nil }
+
+func (v1) Move(move MoveResponse) any { return move }
+
+// legacy is the API before versioning (2019), still spoken by old local
+// engines and test harnesses: (0, 0) is the top left corner, with up
+// towards lower y, snakes come without head or length, and the snake's
+// color and customizations, headType and tailType, answer the start
+// request rather than the index.
+type legacy struct{}
+
+func (legacy) Name() string { return "0" }
+
+func (legacy) DecodeRequest(data []byte, r *GameRequest) error {
+	if err := json.Unmarshal(data, r); err != nil {
+		return err
+	}
+	flip := func(cs []Coord) {
+		for i := range cs {
+			cs[i].Y = r.Board.Height - 1 - cs[i].Y
+		}
+	}
+	flip(r.Board.Food)
+	flip(r.Board.Hazards)
+	for i := range r.Board.Snakes {
+		flip(r.Board.Snakes[i].Body)
+		fillSnake(&r.Board.Snakes[i])
+	}
+	flip(r.You.Body)
+	fillSnake(&r.You)
+	return nil
+}
+
+// fillSnake sets the head and length of a snake from its body.
+func fillSnake(s *Battlesnake) {
+	if len(s.Body) == 0 {
+		return
+	}
+	s.Head = s.Body[0]
+	s.Length = int32(len(s.Body))
+}
+
+// legacyInfo is the start response of the legacy API.
+type legacyInfo struct {
+	Color    string `json:"color"`
+	HeadType string `json:"headType"`
+	TailType string `json:"tailType"`
+}
+
+// Info answers the index as V1 does: the legacy engine never asks for it.
+func (legacy) Info(info BattlesnakeInfoResponse) any { return info }
+
+func (legacy) Start(info BattlesnakeInfoResponse) any {
+	return legacyInfo{Color: info.Color, HeadType: info.Head, TailType: info.Tail}
+}
+
+// Move needs no conversion: with y flipped on the way in, up is towards
+// the top of the board as on the legacy board, where it lowers y.
+func (legacy) Move(move MoveResponse) any { return move }
@@ -0,0 +1,117 @@
+package api_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+)
+
+// legacyMove is a move request of the 2019 API, as its engine sent it: our
+// head is in the top left corner, where up and left run off the board and
+// right is our neck, and the other snake is in the bottom right corner.
+const legacyMove = `{
+  "game": {"id": "game-00fe20da-94ad-11ea-bb37"},
+  "turn": 4,
+  "board": {
+    "height": 11,
+    "width": 11,
+    "food": [{"x": 5, "y": 2}],
+    "snakes": [
+      {"id": "snake-508e96ac-94ad-11ea-bb37", "name": "Sneky Snek", "health": 90,
+       "body": [{"x": 0, "y": 0}, {"x": 1, "y": 0}, {"x": 2, "y": 0}]},
+      {"id": "snake-b67f4906-94ae-11ea-bb37", "name": "Other Snek", "health": 80,
+       "body": [{"x": 10, "y": 10}, {"x": 10, "y": 9}, {"x": 9, "y": 9}, {"x": 8, "y": 9}]}
+    ]
+  },
+  "you": {"id": "snake-508e96ac-94ad-11ea-bb37", "name": "Sneky Snek", "health": 90,
+    "body": [{"x": 0, "y": 0}, {"x": 1, "y": 0}, {"x": 2, "y": 0}]}
+}`
+
+func TestLegacyRoundTrip(t *testing.T) {
+	legacy, ok := api.LookupVersion("0")
+	if !ok {
+		t.Fatal("legacy version not registered")
+	}
+	var game api.GameRequest
+	if err := legacy.DecodeRequest([]byte(legacyMove), &game); err != nil {
+		t.Fatal(err)
+	}
+
+	// The top left corner is (0, 10) once y points up.
+	tests := []struct {
+		name        string
+		snake       api.Battlesnake
+		head        api.Coord
+		length      int32
+		body        []api.Coord
+		legacyMoves []string
+	}{
+		{"you", game.You, api.Coord{X: 0, Y: 10}, 3, []api.Coord{{X: 0, Y: 10}, {X: 1, Y: 10}, {X: 2, Y: 10}}, []string{"down"}},
+		{"us on the board", game.Board.Snakes[0], api.Coord{X: 0, Y: 10}, 3, nil, nil},
+		{"opponent", game.Board.Snakes[1], api.Coord{X: 10, Y: 0}, 4, []api.Coord{{X: 10, Y: 0}, {X: 10, Y: 1}, {X: 9, Y: 1}, {X: 8, Y: 1}}, []string{"left"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.snake.Head != tt.head || tt.snake.Length != tt.length {
+				t.Errorf("head %v, length %d, want %v, %d", tt.snake.Head, tt.snake.Length, tt.head, tt.length)
+			}
+			if tt.body != nil && !reflect.DeepEqual(tt.snake.Body, tt.body) {
+				t.Errorf("body %v, want %v", tt.snake.Body, tt.body)
+			}
+			if tt.legacyMoves == nil {
+				return
+			}
+			// The moves that are safe on the flipped board, encoded for
+			// the legacy engine, are those safe on its board.
+			grid := board.NewGrid(game.Board)
+			var moves []string
+			for _, d := range grid.ValidMoves(tt.snake.Head) {
+				data, err := json.Marshal(legacy.Move(api.MoveResponse{Move: d}))
+				if err != nil {
+					t.Fatal(err)
+				}
+				var response struct{ Move string }
+				if err := json.Unmarshal(data, &response); err != nil {
+					t.Fatal(err)
+				}
+				moves = append(moves, response.Move)
+			}
+			if !reflect.DeepEqual(moves, tt.legacyMoves) {
+				t.Errorf("legacy moves %v, want %v", moves, tt.legacyMoves)
+			}
+		})
+	}
+	if want := (api.Coord{X: 5, Y: 8}); !reflect.DeepEqual(game.Board.Food, []api.Coord{want}) {
+		t.Errorf("food %v, want [%v]", game.Board.Food, want)
+	}
+}
+
+func TestStartResponse(t *testing.T) {
+	info := api.BattlesnakeInfoResponse{APIVersion: "1", Color: "#123456", Head: "beluga", Tail: "curled"}
+	tests := []struct {
+		version string
+		// start is the start response encoded, or "null" for none.
+		start string
+	}{
+		{"1", "null"},
+		{"0", `{"color":"#123456","headType":"beluga","tailType":"curled"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			v, ok := api.LookupVersion(tt.version)
+			if !ok {
+				t.Fatalf("version %q not registered", tt.version)
+			}
+			data, err := json.Marshal(v.Start(info))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.start {
+				t.Errorf("start response %s, want %s", data, tt.start)
+			}
+		})
+	}
+}
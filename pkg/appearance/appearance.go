@@ -0,0 +1,152 @@
+// Package appearance chooses how our snake looks on the board, rotating
+// skins by schedule or at random instead of advertising fixed constants.
+package appearance
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// Appearance is how a snake looks on the board. Empty fields mean "no
+// preference" when one Appearance is laid over another.
+type Appearance struct {
+	Color string `json:"color,omitempty"`
+	Head  string `json:"head,omitempty"`
+	Tail  string `json:"tail,omitempty"`
+}
+
+// Over returns a with its empty fields filled in from base.
+func (a Appearance) Over(base Appearance) Appearance {
+	if a.Color == "" {
+		a.Color = base.Color
+	}
+	if a.Head == "" {
+		a.Head = base.Head
+	}
+	if a.Tail == "" {
+		a.Tail = base.Tail
+	}
+	return a
+}
+
+// Provider chooses the appearance to advertise at a given time.
+type Provider interface {
+	At(now time.Time) Appearance
+}
+
+// Season is a skin worn between two dates each year, inclusive. Dates are
+// written "MM-DD"; a season whose To precedes its From spans the new year.
+// If Weekday is set the skin is only worn on that day of the week.
+type Season struct {
+	From    string     `json:"from"`
+	To      string     `json:"to"`
+	Weekday string     `json:"weekday,omitempty"`
+	Skin    Appearance `json:"skin"`
+
+	from, to monthDay
+}
+
+type monthDay struct{ month, day int }
+
+func parseMonthDay(s string) (monthDay, error) {
+	t, err := time.Parse("01-02", s)
+	if err != nil {
+		return monthDay{}, fmt.Errorf("appearance: bad date %q, want MM-DD", s)
+	}
+	return monthDay{int(t.Month()), t.Day()}, nil
+}
+
+func (md monthDay) before(o monthDay) bool {
+	return md.month < o.month || md.month == o.month && md.day < o.day
+}
+
+func (s Season) contains(now time.Time) bool {
+	if s.Weekday != "" && s.Weekday != now.Weekday().String() {
+		return false
+	}
+	today := monthDay{int(now.Month()), now.Day()}
+	if s.to.before(s.from) {
+		return !today.before(s.from) || !s.to.before(today)
+	}
+	return !today.before(s.from) && !s.to.before(today)
+}
+
+// Config configures a Schedule.
+type Config struct {
+	// Rotate chooses how Skins are rotated: "restart" picks one at random
+	// each time the server starts, "daily" changes skin every day, and ""
+	// wears the first skin.
+	Rotate string       `json:"rotate"`
+	Skins  []Appearance `json:"skins"`
+	// Seasons take precedence over Skins while they are in effect; the
+	// first matching season wins.
+	Seasons []Season `json:"seasons"`
+}
+
+// Schedule is a Provider wearing seasonal skins when in season and rotating
+// through a list of skins otherwise.
+type Schedule struct {
+	cfg     Config
+	restart int
+}
+
+// New returns the Schedule described by cfg.
+func New(cfg Config) (*Schedule, error) {
+	switch cfg.Rotate {
+	case "", "restart", "daily":
+	default:
+		return nil, fmt.Errorf("appearance: unknown rotation %q", cfg.Rotate)
+	}
+	for i := range cfg.Seasons {
+		season := &cfg.Seasons[i]
+		var err error
+		if season.from, err = parseMonthDay(season.From); err != nil {
+			return nil, err
+		}
+		if season.to, err = parseMonthDay(season.To); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Schedule{cfg: cfg}
+	if len(cfg.Skins) > 0 {
+		s.restart = rand.Intn(len(cfg.Skins))
+	}
+	return s, nil
+}
+
+// Load returns the Schedule described by the JSON Config in the file at
+// path.
+func Load(path string) (*Schedule, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return New(cfg)
+}
+
+func (s *Schedule) At(now time.Time) Appearance {
+	for _, season := range s.cfg.Seasons {
+		if season.contains(now) {
+			return season.Skin
+		}
+	}
+	if len(s.cfg.Skins) == 0 {
+		return Appearance{}
+	}
+	switch s.cfg.Rotate {
+	case "restart":
+		return s.cfg.Skins[s.restart]
+	case "daily":
+		days := int(now.Unix() / (24 * 60 * 60))
+		return s.cfg.Skins[days%len(s.cfg.Skins)]
+	}
+	return s.cfg.Skins[0]
+}
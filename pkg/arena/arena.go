@@ -0,0 +1,129 @@
+// Package arena provides bump allocation for the scratch memory of deciding
+// a move: move lists, search buffers and the distance, label and queue
+// arrays of flood fills. Everything is carved out of a few large buffers
+// that are reset once the move is decided, so a deep search or evaluating
+// many positions doesn't hand the garbage collector thousands of short-lived
+// slices per turn.
+package arena
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+// minSlab is the fewest elements a slab buffer holds, enough for several
+// arrays over the largest boards before it has to grow.
+const minSlab = 4096
+
+// slab hands out slices of T from one buffer. Once the buffer is used up
+// it is replaced by one twice as large, leaving the slices already handed
+// out in the old one, so that after a few turns one buffer serves a whole
+// turn.
+type slab[T any] struct {
+	buf  []T
+	used int
+}
+
+func (s *slab[T]) make(n int) []T {
+	if s.used+n > len(s.buf) {
+		s.buf = make([]T, max(2*len(s.buf), n, minSlab))
+		s.used = 0
+	}
+	b := s.buf[s.used : s.used+n : s.used+n]
+	s.used += n
+	clear(b)
+	return b
+}
+
+// Arena allocates zeroed slices that stay valid until Reset. A nil *Arena
+// allocates from the heap instead, so code can take one from a context
+// that may not carry any. It is not safe for concurrent use.
+type Arena struct {
+	ints   slab[int]
+	int8s  slab[int8]
+	bools  slab[bool]
+	coords slab[api.Coord]
+	dirs   slab[api.Direction]
+}
+
+// Ints returns a zeroed slice of n ints.
+func (a *Arena) Ints(n int) []int {
+	if a == nil {
+		return make([]int, n)
+	}
+	return a.ints.make(n)
+}
+
+// Int8s returns a zeroed slice of n int8s.
+func (a *Arena) Int8s(n int) []int8 {
+	if a == nil {
+		return make([]int8, n)
+	}
+	return a.int8s.make(n)
+}
+
+// Bools returns a slice of n false values.
+func (a *Arena) Bools(n int) []bool {
+	if a == nil {
+		return make([]bool, n)
+	}
+	return a.bools.make(n)
+}
+
+// Coords returns a zeroed slice of n coordinates.
+func (a *Arena) Coords(n int) []api.Coord {
+	if a == nil {
+		return make([]api.Coord, n)
+	}
+	return a.coords.make(n)
+}
+
+// Directions returns a zeroed slice of n directions.
+func (a *Arena) Directions(n int) []api.Direction {
+	if a == nil {
+		return make([]api.Direction, n)
+	}
+	return a.dirs.make(n)
+}
+
+// Reset makes all of the arena's memory available again. Slices it handed
+// out before must no longer be used.
+func (a *Arena) Reset() {
+	a.ints.used = 0
+	a.int8s.used = 0
+	a.bools.used = 0
+	a.coords.used = 0
+	a.dirs.used = 0
+}
+
+var pool = sync.Pool{
+	New: func() interface{} { return &Arena{} },
+}
+
+// Get returns an empty arena, reusing one put back earlier when possible.
+func Get() *Arena {
+	return pool.Get().(*Arena)
+}
+
+// Put resets a and returns it for reuse. Neither a nor any slice it handed
+// out may be used afterwards.
+func Put(a *Arena) {
+	a.Reset()
+	pool.Put(a)
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying a, for the scratch memory of the
+// move being decided with it.
+func NewContext(ctx context.Context, a *Arena) context.Context {
+	return context.WithValue(ctx, contextKey{}, a)
+}
+
+// FromContext returns the arena ctx carries, or nil.
+func FromContext(ctx context.Context) *Arena {
+	a, _ := ctx.Value(contextKey{}).(*Arena)
+	return a
+}
@@ -0,0 +1,166 @@
+// Package bench holds benchmarks for the hot paths of the engine. They are
+// run through the "bench" subcommand so they can be compared on the machine
+// the snake is deployed to. Its tests gate the time moves take.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/arena"
+	"github.com/jayuuza/battlesnake/pkg/search"
+	"github.com/jayuuza/battlesnake/pkg/sim"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// Benchmark is a named benchmark function.
+type Benchmark struct {
+	Name string
+	F    func(b *testing.B)
+}
+
+// Benchmarks lists every benchmark run by Run.
+var Benchmarks = []Benchmark{
+	{"sim/New", benchmarkNew},
+	{"sim/Acquire", benchmarkAcquire},
+	{"sim/ApplyUndo", benchmarkApplyUndo},
+	{"sim/Perft2", benchmarkPerft},
+	{"search/Survival3", benchmarkSurvival},
+	{"board/Reachable", benchmarkReachable},
+	{"board/SafeReachable", benchmarkSafeReachable},
+	{"board/ReachableWrappedMaze", benchmarkReachableWrappedMaze},
+	{"board/ComponentsMaze", benchmarkComponentsMaze},
+	{"board/PathArcadeMaze", benchmarkPathArcadeMaze},
+	{"board/PathToWrappedMaze", benchmarkPathToWrappedMaze},
+	{"strategy/Heuristic", benchmarkHeuristic},
+	{"strategy/HeuristicArena", benchmarkHeuristicArena},
+	{"api/DecodeRequest", benchmarkDecodeRequest},
+	{"api/DecodeRequestReflect", benchmarkDecodeRequestReflect},
+}
+
+// Run runs every benchmark and writes a line of results for each to w.
+func Run(w io.Writer) {
+	for _, bm := range Benchmarks {
+		r := testing.Benchmark(bm.F)
+		fmt.Fprintf(w, "%-28s %s\t%s\n", bm.Name, r.String(), r.MemString())
+	}
+}
+
+func benchmarkNew(b *testing.B) {
+	game := Position()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sim.New(game)
+	}
+}
+
+func benchmarkAcquire(b *testing.B) {
+	game := Position()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sim.Release(sim.Acquire(game))
+	}
+}
+
+func benchmarkApplyUndo(b *testing.B) {
+	s := sim.New(Position())
+	moves := []api.Direction{api.Up, api.Down, api.Right, api.Left}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Apply(moves)
+		s.Undo()
+	}
+}
+
+func benchmarkPerft(b *testing.B) {
+	s := sim.New(Position())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sim.Perft(s, 2)
+	}
+}
+
+func benchmarkHeuristic(b *testing.B) {
+	game := Position()
+	heuristic, _ := strategy.New("heuristic")
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		heuristic.Move(ctx, game)
+	}
+}
+
+// benchmarkHeuristicArena decides moves as the server does, with scratch
+// memory from an arena reset after every move.
+func benchmarkHeuristicArena(b *testing.B) {
+	game := Position()
+	heuristic, _ := strategy.New("heuristic")
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		scratch := arena.Get()
+		heuristic.Move(arena.NewContext(ctx, scratch), game)
+		arena.Put(scratch)
+	}
+}
+
+// Position returns a mid-game reference position: four snakes on an 11x11
+// board with food and hazards.
+func Position() api.GameRequest {
+	snakes := []api.Battlesnake{
+		snake("you", 80, api.Coord{X: 5, Y: 5}, api.Coord{X: 5, Y: 4}, api.Coord{X: 5, Y: 3}, api.Coord{X: 4, Y: 3}, api.Coord{X: 3, Y: 3}),
+		snake("b", 64, api.Coord{X: 1, Y: 8}, api.Coord{X: 1, Y: 7}, api.Coord{X: 1, Y: 6}, api.Coord{X: 2, Y: 6}),
+		snake("c", 92, api.Coord{X: 8, Y: 2}, api.Coord{X: 9, Y: 2}, api.Coord{X: 9, Y: 1}, api.Coord{X: 9, Y: 0}, api.Coord{X: 8, Y: 0}, api.Coord{X: 7, Y: 0}),
+		snake("d", 31, api.Coord{X: 8, Y: 8}, api.Coord{X: 8, Y: 9}, api.Coord{X: 9, Y: 9}),
+	}
+	return api.GameRequest{
+		Game: api.Game{
+			ID:      "bench",
+			Ruleset: api.Ruleset{Name: "standard", Settings: api.RulesetSettings{HazardDamagePerTurn: 14}},
+			Timeout: 500,
+		},
+		Turn: 40,
+		Board: api.Board{
+			Width:   11,
+			Height:  11,
+			Food:    []api.Coord{{X: 5, Y: 6}, {X: 0, Y: 0}, {X: 10, Y: 10}},
+			Hazards: []api.Coord{{X: 0, Y: 10}, {X: 1, Y: 10}, {X: 2, Y: 10}},
+			Snakes:  snakes,
+		},
+		You: snakes[0],
+	}
+}
+
+// snake returns a snake named id with the given body, head first.
+func snake(id string, health int32, body ...api.Coord) api.Battlesnake {
+	return api.Battlesnake{
+		ID:     id,
+		Name:   id,
+		Health: health,
+		Body:   body,
+		Head:   body[0],
+		Length: int32(len(body)),
+	}
+}
+
+func benchmarkSurvival(b *testing.B) {
+	game := Position()
+	ctx, stats := search.WithStats(context.Background())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		search.Survival(ctx, game, 3)
+	}
+	reportNodes(b, stats)
+}
+
+// reportNodes reports the nodes per second of the searches in stats.
+func reportNodes(b *testing.B, stats *search.Stats) {
+	if nodes := stats.Summary().Nodes; nodes > 0 {
+		b.ReportMetric(float64(nodes)/b.Elapsed().Seconds(), "nodes/s")
+	}
+}
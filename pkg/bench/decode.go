@@ -0,0 +1,38 @@
+package bench
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+// reflectRequest has GameRequest's fields but not its UnmarshalJSON, so it
+// is decoded by reflection as every request used to be.
+type reflectRequest api.GameRequest
+
+func benchmarkDecodeRequest(b *testing.B) {
+	data, _ := json.Marshal(ArcadeMaze())
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var request api.GameRequest
+		if err := json.Unmarshal(data, &request); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkDecodeRequestReflect(b *testing.B) {
+	data, _ := json.Marshal(ArcadeMaze())
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var request reflectRequest
+		if err := json.Unmarshal(data, &request); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
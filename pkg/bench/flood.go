@@ -0,0 +1,49 @@
+package bench
+
+import (
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+)
+
+func benchmarkReachable(b *testing.B) {
+	game := Position()
+	grid := board.GridFor(game)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.Reachable(game.You.Head, 0, nil)
+	}
+}
+
+func benchmarkSafeReachable(b *testing.B) {
+	game := Position()
+	grid := board.GridFor(game)
+	danger := board.NewDangerMap(game)
+	passable := func(p api.Coord) bool { return !danger.IsLethal(p) }
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.Reachable(game.You.Head, 0, passable)
+	}
+}
+
+func benchmarkReachableWrappedMaze(b *testing.B) {
+	game := ArcadeMaze()
+	grid := board.GridFor(game)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.Reachable(game.You.Head, 0, nil)
+	}
+}
+
+func benchmarkComponentsMaze(b *testing.B) {
+	grid := board.GridFor(ArcadeMaze())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.Components()
+	}
+}
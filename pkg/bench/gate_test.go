@@ -0,0 +1,122 @@
+package bench
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/arena"
+	"github.com/jayuuza/battlesnake/pkg/search"
+	"github.com/jayuuza/battlesnake/pkg/sim"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// BenchmarkGate guards the move budget:
+//
+//	go test -run '^$' -bench Gate ./pkg/bench
+//
+// times moves decided on the reference positions, as the server decides
+// them but without a deadline so that every run searches as deep, and fails
+// if any takes more than its limit as a multiple of the time the reference
+// workload takes. The two are timed alternately in the same loop, so the
+// machine and whatever else it is running slow both alike and the ratio
+// holds where absolute times wouldn't.
+//
+// The verdict is only given on a run of at least gateMinTime, not the short
+// ones the benchmark is first called with to size b.N.
+func BenchmarkGate(b *testing.B) {
+	for _, g := range gates {
+		b.Run(g.name, func(b *testing.B) {
+			reference, op := gateReference(), g.op()
+			// Warm up caches and pools before anything is timed.
+			reference()
+			op()
+			var referenceTime, opTime time.Duration
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				start := time.Now()
+				reference()
+				mid := time.Now()
+				op()
+				opTime += time.Since(mid)
+				referenceTime += mid.Sub(start)
+			}
+			ratio := float64(opTime) / float64(referenceTime)
+			b.ReportMetric(ratio, "x-ref")
+			if opTime+referenceTime >= gateMinTime && ratio > g.limit {
+				b.Errorf("took %.2fx the reference, limit %.2fx", ratio, g.limit)
+			}
+		})
+	}
+}
+
+// gateMinTime is the least time a run of a gate benchmark must take for its
+// ratio to be checked.
+const gateMinTime = 500 * time.Millisecond
+
+// gate is a benchmark checked by BenchmarkGate.
+type gate struct {
+	name string
+	// limit is the most time an operation may take, as a multiple of the
+	// reference workload's: the ratio measured when it was last accepted,
+	// plus 20%. Ratios vary by 5-10% between runs.
+	limit float64
+	// op returns the operation, set up.
+	op func() func()
+}
+
+var gates = []gate{
+	{"move/Heuristic", 1.5, moveOp("heuristic", Position)},
+	{"move/HeuristicArcadeMaze", 3.25, moveOp("heuristic", ArcadeMaze)},
+	{"move/Duel", 1.95, moveOp("duel", duelPosition)},
+	{"move/HeuristicKill", 1.65, moveOp("heuristic", killPosition)},
+	{"search/Survival3", 610, func() func() {
+		game := Position()
+		return func() { search.Survival(context.Background(), game, 3) }
+	}},
+}
+
+// gateReference returns the reference workload: counting the positions one
+// turn deep from Position, which exercises the simulator the searches run
+// on but none of the evaluation.
+func gateReference() func() {
+	s := sim.New(Position())
+	return func() { sim.Perft(s, 1) }
+}
+
+// moveOp returns an operation deciding a move with strategy name on the
+// position returned by position, with scratch memory from an arena as the
+// server does.
+func moveOp(name string, position func() api.GameRequest) func() func() {
+	return func() func() {
+		game := position()
+		strat, err := strategy.New(name)
+		if err != nil {
+			panic(err)
+		}
+		ctx := context.Background()
+		return func() {
+			scratch := arena.Get()
+			strat.Move(arena.NewContext(ctx, scratch), game)
+			arena.Put(scratch)
+		}
+	}
+}
+
+// duelPosition returns Position with only us and the snake next to us left.
+func duelPosition() api.GameRequest {
+	game := Position()
+	game.Board.Snakes = game.Board.Snakes[:2]
+	return game
+}
+
+// killPosition returns Position with a snake cornered where we can take
+// its last square, so that deciding the move searches the kill.
+func killPosition() api.GameRequest {
+	game := Position()
+	game.Board.Snakes[0] = snake("you", 80, api.Coord{X: 1, Y: 1}, api.Coord{X: 2, Y: 1}, api.Coord{X: 3, Y: 1}, api.Coord{X: 3, Y: 2}, api.Coord{X: 3, Y: 3})
+	game.Board.Snakes[1] = snake("b", 64, api.Coord{X: 0, Y: 0}, api.Coord{X: 0, Y: 1}, api.Coord{X: 0, Y: 2})
+	game.You = game.Board.Snakes[0]
+	return game
+}
@@ -0,0 +1,91 @@
+package bench
+
+import (
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+)
+
+// arcadeMaze is the hazard layout of the arcade_maze map, top row first:
+// '#' is a hazard wall and '.' open floor. The middle row's open ends are
+// the tunnels used on wrapped boards.
+var arcadeMaze = []string{
+	"###################",
+	"#........#........#",
+	"#.##.###.#.###.##.#",
+	"#.................#",
+	"#.##.#.#####.#.##.#",
+	"#....#...#...#....#",
+	"####.###.#.###.####",
+	"####.#.......#.####",
+	"####.#.##.##.#.####",
+	"......#.....#......",
+	"####.#.#####.#.####",
+	"####.#.......#.####",
+	"####.#.#####.#.####",
+	"#........#........#",
+	"#.##.###.#.###.##.#",
+	"#..#...........#..#",
+	"##.#.#.#####.#.#.##",
+	"#....#...#...#....#",
+	"#.######.#.######.#",
+	"#.................#",
+	"###################",
+}
+
+// ArcadeMaze returns an early position on the arcade_maze map: two snakes
+// at opposite ends of the maze with food in its far corners, so that the
+// only paths to food run through the corridors.
+func ArcadeMaze() api.GameRequest {
+	height := len(arcadeMaze)
+	var hazards []api.Coord
+	for row, line := range arcadeMaze {
+		for x := 0; x < len(line); x++ {
+			if line[x] == '#' {
+				hazards = append(hazards, api.Coord{X: x, Y: height - 1 - row})
+			}
+		}
+	}
+	snakes := []api.Battlesnake{
+		snake("you", 100, api.Coord{X: 9, Y: 1}, api.Coord{X: 9, Y: 1}, api.Coord{X: 9, Y: 1}),
+		snake("b", 100, api.Coord{X: 9, Y: 17}, api.Coord{X: 9, Y: 17}, api.Coord{X: 9, Y: 17}),
+	}
+	return api.GameRequest{
+		Game: api.Game{
+			ID:      "arcade-maze",
+			Ruleset: api.Ruleset{Name: "wrapped", Settings: api.RulesetSettings{HazardDamagePerTurn: 100}},
+			Map:     board.ArcadeMaze,
+			Timeout: 500,
+		},
+		Board: api.Board{
+			Width:   len(arcadeMaze[0]),
+			Height:  height,
+			Food:    []api.Coord{{X: 1, Y: 19}, {X: 17, Y: 19}, {X: 1, Y: 7}, {X: 17, Y: 7}},
+			Hazards: hazards,
+			Snakes:  snakes,
+		},
+		You: snakes[0],
+	}
+}
+
+func benchmarkPathToWrappedMaze(b *testing.B) {
+	game := ArcadeMaze()
+	grid := board.GridFor(game)
+	// The tunnel row leads from one side of the maze across the edge to
+	// the other.
+	from, to := api.Coord{X: 5, Y: 11}, api.Coord{X: 13, Y: 11}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		grid.PathTo(from, to)
+	}
+}
+
+func benchmarkPathArcadeMaze(b *testing.B) {
+	game := ArcadeMaze()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		grid := board.GridFor(game)
+		grid.Path(game.You.Head, grid.IsFood)
+	}
+}
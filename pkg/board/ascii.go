@@ -0,0 +1,241 @@
+package board
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+// Positions are written as the official CLI prints them with --view-map
+// and without color: a line of hazards, of food and of each snake, then the
+// board from the top row down, one rune per cell:
+//
+//	Ruleset: standard, Turn: 3
+//	Hazards ░: []
+//	Food ⚕: [{5 8}]
+//	us ■: {ID:s1 Body:[{2 0} {1 0} {0 0}] Health:90}
+//	them ⌀: {ID:s2 Body:[{5 7} {5 6} {5 5}] Health:100}
+//	◦◦◦◦◦⚕◦◦◦◦◦
+//	...
+//	■■■◦◦◦◦◦◦◦◦
+//
+// ParseASCII also reads boards drawn by hand, with or without spaces
+// between cells: '.' is empty, '*' food and '~' hazard, and each snake is a
+// letter, upper case at its head and lower case along its body.
+
+// Cell runes.
+const (
+	asciiEmpty  = '◦'
+	asciiFood   = '⚕'
+	asciiHazard = '░'
+)
+
+// snakeSymbols are the runes the CLI draws snakes with, in order.
+var snakeSymbols = []rune{'■', '⌀', '●', '☻', '◘', '☺', '□', '⍟'}
+
+var (
+	ansiEscape  = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	turnLine    = regexp.MustCompile(`Turn: (\d+)`)
+	rulesetLine = regexp.MustCompile(`Ruleset: ([\w-]+)`)
+	coordsLine  = regexp.MustCompile(`^(Hazards|Food) \S: \[(.*)\]$`)
+	snakeLine   = regexp.MustCompile(`^(.*) \S: \{ID:(\S+) Body:\[(.*?)\] Health:(-?\d+)`)
+	coord       = regexp.MustCompile(`\{(-?\d+) (-?\d+)\}`)
+)
+
+// FormatASCII writes game as the CLI prints it.
+func FormatASCII(game api.GameRequest) string {
+	b := game.Board
+	var o strings.Builder
+	if game.Game.Ruleset.Name != "" {
+		fmt.Fprintf(&o, "Ruleset: %s, ", game.Game.Ruleset.Name)
+	}
+	fmt.Fprintf(&o, "Turn: %d\n", game.Turn)
+	fmt.Fprintf(&o, "Hazards %c: %s\n", asciiHazard, formatCoords(b.Hazards))
+	fmt.Fprintf(&o, "Food %c: %s\n", asciiFood, formatCoords(b.Food))
+
+	cells := make([]rune, b.Width*b.Height)
+	for i := range cells {
+		cells[i] = asciiEmpty
+	}
+	set := func(c api.Coord, r rune) {
+		if InBounds(c, b.Width, b.Height) {
+			cells[c.Y*b.Width+c.X] = r
+		}
+	}
+	for _, c := range b.Hazards {
+		set(c, asciiHazard)
+	}
+	for _, c := range b.Food {
+		set(c, asciiFood)
+	}
+	for i, snake := range b.Snakes {
+		symbol := snakeSymbols[i%len(snakeSymbols)]
+		for _, c := range snake.Body {
+			set(c, symbol)
+		}
+		fmt.Fprintf(&o, "%s %c: {ID:%s Body:%s Health:%d}\n", snake.Name, symbol, snake.ID, formatCoords(snake.Body), snake.Health)
+	}
+	for y := b.Height - 1; y >= 0; y-- {
+		o.WriteString(string(cells[y*b.Width : (y+1)*b.Width]))
+		o.WriteByte('\n')
+	}
+	return o.String()
+}
+
+func formatCoords(cs []api.Coord) string {
+	parts := make([]string, len(cs))
+	for i, c := range cs {
+		parts[i] = fmt.Sprintf("{%d %d}", c.X, c.Y)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// ParseASCII reads a position written by FormatASCII, printed by the CLI
+// or drawn by hand. We are the first snake listed or, on a hand-drawn
+// board, the first letter in the alphabet. Lines of hazards, food and
+// snakes take precedence over what the board shows, since the board can't
+// show a hazard under a snake or the order of a snake's body.
+func ParseASCII(s string) (api.GameRequest, error) {
+	game := api.GameRequest{
+		Game: api.Game{Ruleset: api.Ruleset{Name: "standard"}, Timeout: 500},
+	}
+	var rows [][]rune
+	var food, hazards []api.Coord
+	haveFood, haveHazards := false, false
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(ansiEscape.ReplaceAllString(line, ""))
+		if line == "" {
+			continue
+		}
+		if !strings.Contains(line, ":") {
+			rows = append(rows, []rune(strings.ReplaceAll(line, " ", "")))
+			continue
+		}
+		if m := turnLine.FindStringSubmatch(line); m != nil {
+			game.Turn, _ = strconv.Atoi(m[1])
+		}
+		if m := rulesetLine.FindStringSubmatch(line); m != nil {
+			game.Game.Ruleset.Name = m[1]
+		}
+		if m := coordsLine.FindStringSubmatch(line); m != nil {
+			if m[1] == "Food" {
+				food, haveFood = parseCoords(m[2]), true
+			} else {
+				hazards, haveHazards = parseCoords(m[2]), true
+			}
+			continue
+		}
+		if m := snakeLine.FindStringSubmatch(line); m != nil {
+			health, _ := strconv.Atoi(m[4])
+			game.Board.Snakes = append(game.Board.Snakes, newSnake(m[2], m[1], int32(health), parseCoords(m[3])))
+		}
+	}
+	if len(rows) == 0 {
+		return game, fmt.Errorf("board: no board rows")
+	}
+	b := &game.Board
+	b.Height, b.Width = len(rows), len(rows[0])
+
+	heads := map[rune]api.Coord{}
+	bodies := map[rune]map[api.Coord]bool{}
+	for i, row := range rows {
+		if len(row) != b.Width {
+			return game, fmt.Errorf("board: row %d is %d cells wide, want %d", i+1, len(row), b.Width)
+		}
+		y := b.Height - 1 - i
+		for x, r := range row {
+			c := api.Coord{X: x, Y: y}
+			switch {
+			case r == asciiFood || r == '*':
+				if !haveFood {
+					food = append(food, c)
+				}
+			case r == asciiHazard || r == '~':
+				if !haveHazards {
+					hazards = append(hazards, c)
+				}
+			case unicode.IsUpper(r):
+				if _, ok := heads[r]; ok {
+					return game, fmt.Errorf("board: snake %c has two heads", r)
+				}
+				heads[r] = c
+			case unicode.IsLower(r):
+				if bodies[unicode.ToUpper(r)] == nil {
+					bodies[unicode.ToUpper(r)] = map[api.Coord]bool{}
+				}
+				bodies[unicode.ToUpper(r)][c] = true
+			}
+		}
+	}
+	b.Food, b.Hazards = food, hazards
+
+	if len(b.Snakes) == 0 {
+		letters := make([]rune, 0, len(heads))
+		for r := range heads {
+			letters = append(letters, r)
+		}
+		sort.Slice(letters, func(i, j int) bool { return letters[i] < letters[j] })
+		for _, r := range letters {
+			body := []api.Coord{heads[r]}
+			if !traceBody(&body, bodies[r]) {
+				return game, fmt.Errorf("board: can't trace snake %c's body from its head", r)
+			}
+			delete(bodies, r)
+			id := string(unicode.ToLower(r))
+			b.Snakes = append(b.Snakes, newSnake(id, id, 100, body))
+		}
+		for r := range bodies {
+			return game, fmt.Errorf("board: snake %c has no head", r)
+		}
+	}
+	if len(b.Snakes) > 0 {
+		game.You = b.Snakes[0]
+	}
+	return game, nil
+}
+
+func parseCoords(s string) []api.Coord {
+	var cs []api.Coord
+	for _, m := range coord.FindAllStringSubmatch(s, -1) {
+		x, _ := strconv.Atoi(m[1])
+		y, _ := strconv.Atoi(m[2])
+		cs = append(cs, api.Coord{X: x, Y: y})
+	}
+	return cs
+}
+
+func newSnake(id, name string, health int32, body []api.Coord) api.Battlesnake {
+	snake := api.Battlesnake{ID: id, Name: name, Health: health, Body: body, Length: int32(len(body))}
+	if len(body) > 0 {
+		snake.Head = body[0]
+	}
+	return snake
+}
+
+// traceBody extends body, from its head, through every cell in rest, each
+// adjacent to the one before, reporting whether there is such a path.
+func traceBody(body *[]api.Coord, rest map[api.Coord]bool) bool {
+	if len(rest) == 0 {
+		return true
+	}
+	last := (*body)[len(*body)-1]
+	for _, d := range api.Directions {
+		next := last.Move(d)
+		if !rest[next] {
+			continue
+		}
+		delete(rest, next)
+		*body = append(*body, next)
+		if traceBody(body, rest) {
+			return true
+		}
+		*body = (*body)[:len(*body)-1]
+		rest[next] = true
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package board
+
+import (
+	"container/heap"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+// PathTo returns the moves along a shortest path from pos to target
+// through valid cells, found by A* search guided by Distance, or nil if
+// target can't be reached. It is cheaper than Path when the target is
+// known.
+func (g *Grid) PathTo(pos, target api.Coord) []api.Direction {
+	if !g.InBounds(pos) || !g.IsValid(target) || pos == target {
+		return nil
+	}
+	from := g.Scratch.Int8s(len(g.cells))
+	cost := g.Scratch.Ints(len(g.cells))
+	for i := range from {
+		from[i] = -1
+		cost[i] = -1
+	}
+	cost[pos.Y*g.Width+pos.X] = 0
+	open := &frontier{{pos: pos, f: g.Distance(pos, target)}}
+	for open.Len() > 0 {
+		cur := heap.Pop(open).(frontierNode).pos
+		if cur == target {
+			return g.backtrack(from, target, pos)
+		}
+		c := cost[cur.Y*g.Width+cur.X]
+		for _, d := range api.Directions {
+			next := g.Step(cur, d)
+			if !g.IsValid(next) {
+				continue
+			}
+			i := next.Y*g.Width + next.X
+			if cost[i] >= 0 && cost[i] <= c+1 {
+				continue
+			}
+			cost[i], from[i] = c+1, int8(d)
+			heap.Push(open, frontierNode{pos: next, f: c + 1 + g.Distance(next, target)})
+		}
+	}
+	return nil
+}
+
+// frontierNode is a cell awaiting expansion by A*, with its estimated
+// total path length.
+type frontierNode struct {
+	pos api.Coord
+	f   int
+}
+
+// frontier is a min-heap of frontierNodes by f.
+type frontier []frontierNode
+
+func (h frontier) Len() int           { return len(h) }
+func (h frontier) Less(i, j int) bool { return h[i].f < h[j].f }
+func (h frontier) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *frontier) Push(x any)        { *h = append(*h, x.(frontierNode)) }
+func (h *frontier) Pop() any {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
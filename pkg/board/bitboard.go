@@ -0,0 +1,237 @@
+package board
+
+import (
+	"math/bits"
+	"sync"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+const bitsWords = 10
+
+// MaxCells is the largest board area a Bitboard can represent, enough for
+// the 25x25 boards the official engine uses.
+const MaxCells = bitsWords * 64
+
+// Bits is a fixed-size bitset with one bit per board cell, indexed by
+// y*width+x. It is a value type so copying a position costs a few words and
+// no allocation.
+type Bits [bitsWords]uint64
+
+func (b *Bits) Set(i int)     { b[i>>6] |= 1 << uint(i&63) }
+func (b *Bits) Clear(i int)   { b[i>>6] &^= 1 << uint(i&63) }
+func (b Bits) Has(i int) bool { return b[i>>6]&(1<<uint(i&63)) != 0 }
+
+// The set operations are written out word by word, rather than through a
+// shared helper taking the operator, so the compiler can inline them into
+// flood fills.
+
+func (b Bits) Or(o Bits) Bits {
+	for i := range b {
+		b[i] |= o[i]
+	}
+	return b
+}
+
+func (b Bits) And(o Bits) Bits {
+	for i := range b {
+		b[i] &= o[i]
+	}
+	return b
+}
+
+func (b Bits) AndNot(o Bits) Bits {
+	for i := range b {
+		b[i] &^= o[i]
+	}
+	return b
+}
+
+// Count returns the number of set bits.
+func (b Bits) Count() int {
+	n := 0
+	for _, w := range b {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Lowest returns the index of the lowest set bit, or -1 if none is set.
+func (b Bits) Lowest() int {
+	for i, w := range b {
+		if w != 0 {
+			return i<<6 + bits.TrailingZeros64(w)
+		}
+	}
+	return -1
+}
+
+// IsZero reports whether no bits are set.
+func (b Bits) IsZero() bool {
+	return b == Bits{}
+}
+
+// shl shifts every bit n places towards higher indices.
+func (b Bits) shl(n int) Bits {
+	var r Bits
+	w, s := n>>6, uint(n&63)
+	for i := len(b) - 1; i >= w; i-- {
+		r[i] = b[i-w] << s
+		if s > 0 && i-w > 0 {
+			r[i] |= b[i-w-1] >> (64 - s)
+		}
+	}
+	return r
+}
+
+// shr shifts every bit n places towards lower indices.
+func (b Bits) shr(n int) Bits {
+	var r Bits
+	w, s := n>>6, uint(n&63)
+	for i := 0; i+w < len(b); i++ {
+		r[i] = b[i+w] >> s
+		if s > 0 && i+w+1 < len(b) {
+			r[i] |= b[i+w+1] << (64 - s)
+		}
+	}
+	return r
+}
+
+// Layout holds the masks needed for bit-parallel operations on boards of one
+// size. It is immutable and can be shared by every Bitboard of that size.
+type Layout struct {
+	Width  int
+	Height int
+	// All has a bit set for every cell on the board.
+	All Bits
+
+	// words is the number of words of a Bits the board's cells occupy.
+	words int
+
+	notFirstCol Bits
+	notLastCol  Bits
+	firstCol    Bits
+	lastCol     Bits
+	bottomRow   Bits
+	topRow      Bits
+}
+
+// NewLayout returns the Layout for a width x height board. It panics if the
+// board has more than MaxCells cells.
+func NewLayout(width, height int) *Layout {
+	if width*height > MaxCells {
+		panic("board: board too large for bitboard")
+	}
+	l := &Layout{Width: width, Height: height, words: (width*height + 63) / 64}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := l.Index(api.Coord{X: x, Y: y})
+			l.All.Set(i)
+			if x != 0 {
+				l.notFirstCol.Set(i)
+			} else {
+				l.firstCol.Set(i)
+			}
+			if x != width-1 {
+				l.notLastCol.Set(i)
+			} else {
+				l.lastCol.Set(i)
+			}
+			if y == 0 {
+				l.bottomRow.Set(i)
+			}
+			if y == height-1 {
+				l.topRow.Set(i)
+			}
+		}
+	}
+	return l
+}
+
+var layouts sync.Map // [2]int{width, height} -> *Layout
+
+// LayoutFor returns the shared Layout for a width x height board, building
+// it on first use.
+func LayoutFor(width, height int) *Layout {
+	key := [2]int{width, height}
+	if l, ok := layouts.Load(key); ok {
+		return l.(*Layout)
+	}
+	l, _ := layouts.LoadOrStore(key, NewLayout(width, height))
+	return l.(*Layout)
+}
+
+// Index returns the bit index of pos.
+func (l *Layout) Index(pos api.Coord) int {
+	return pos.Y*l.Width + pos.X
+}
+
+// Coord returns the cell at bit index i.
+func (l *Layout) Coord(i int) api.Coord {
+	return api.Coord{X: i % l.Width, Y: i / l.Width}
+}
+
+// Neighbors returns every on-board cell orthogonally adjacent to a cell in b.
+func (l *Layout) Neighbors(b Bits) Bits {
+	right := b.shl(1).And(l.notFirstCol)
+	left := b.shr(1).And(l.notLastCol)
+	up := b.shl(l.Width)
+	down := b.shr(l.Width)
+	return right.Or(left).Or(up).Or(down).And(l.All)
+}
+
+// Bitboard encodes a position as bitsets so that search can copy and expand
+// it cheaply.
+type Bitboard struct {
+	*Layout
+	Snakes  Bits
+	Food    Bits
+	Hazards Bits
+}
+
+// NewBitboard encodes board.
+func NewBitboard(board api.Board) *Bitboard {
+	bb := &Bitboard{}
+	bb.Reset(board)
+	return bb
+}
+
+// Reset replaces the contents of bb with an encoding of board.
+func (bb *Bitboard) Reset(board api.Board) {
+	*bb = Bitboard{Layout: LayoutFor(board.Width, board.Height)}
+	for _, snake := range board.Snakes {
+		for _, coord := range snake.Body {
+			bb.set(&bb.Snakes, coord)
+		}
+	}
+	for _, coord := range board.Food {
+		bb.set(&bb.Food, coord)
+	}
+	for _, coord := range board.Hazards {
+		bb.set(&bb.Hazards, coord)
+	}
+}
+
+func (bb *Bitboard) set(b *Bits, pos api.Coord) {
+	if InBounds(pos, bb.Width, bb.Height) {
+		b.Set(bb.Index(pos))
+	}
+}
+
+// Copy returns an independent copy of bb sharing its Layout.
+func (bb *Bitboard) Copy() *Bitboard {
+	c := *bb
+	return &c
+}
+
+// Free returns every on-board cell not occupied by a snake.
+func (bb *Bitboard) Free() Bits {
+	return bb.All.AndNot(bb.Snakes)
+}
+
+// Reachable returns the free cells reachable from pos, excluding pos itself.
+func (bb *Bitboard) Reachable(pos api.Coord) Bits {
+	var seed Bits
+	seed.Set(bb.Index(pos))
+	return bb.FloodFill(seed, bb.Free()).AndNot(seed)
+}
@@ -0,0 +1,42 @@
+// Package board answers questions about cells on a Battlesnake board.
+package board
+
+import "github.com/jayuuza/battlesnake/pkg/api"
+
+// ValidMoves returns moves that won't result in death for a given position.
+// Callers making several queries should build a Grid once instead.
+func ValidMoves(pos api.Coord, board api.Board) []api.Direction {
+	return NewGrid(board).ValidMoves(pos)
+}
+
+// IsValid reports whether pos is on the board and not occupied by a snake.
+func IsValid(pos api.Coord, board api.Board) bool {
+	return !IsEdge(pos, board) && !IsSnake(pos, board)
+}
+
+// IsEdge reports whether pos lies outside the board.
+func IsEdge(pos api.Coord, board api.Board) bool {
+	return !InBounds(pos, board.Width, board.Height)
+}
+
+// IsFood reports whether pos holds food.
+func IsFood(pos api.Coord, board api.Board) bool {
+	for _, coord := range board.Food {
+		if coord.Y == pos.Y && coord.X == pos.X {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSnake reports whether pos is occupied by any snake segment.
+func IsSnake(pos api.Coord, board api.Board) bool {
+	for _, snake := range board.Snakes {
+		for _, coord := range snake.Body {
+			if coord.Y == pos.Y && coord.X == pos.X {
+				return true
+			}
+		}
+	}
+	return false
+}
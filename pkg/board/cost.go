@@ -0,0 +1,93 @@
+package board
+
+import "github.com/jayuuza/battlesnake/pkg/api"
+
+// maxHealth is the health a snake is restored to by eating.
+const maxHealth = 100
+
+// pathNode is a cell reached by HealthPath, with the health left on
+// arriving and the node it was reached from.
+type pathNode struct {
+	pos    api.Coord
+	health int
+	steps  int
+	parent int
+	move   api.Direction
+}
+
+// HealthPath is Path with health arithmetic: a snake starting at pos with
+// health loses one a step plus the stacked damage of every hazard it
+// enters, and is restored to full by food. Cells forecast to become
+// hazards count as hazards from the step the sauce is predicted to arrive,
+// so routes aren't planned through soon-to-be sauce. Routes through
+// hazards are permitted only while the predicted health on leaving each
+// hazard cell stays at least minHealth, and no route may starve. It
+// returns the shortest such route to the nearest goal cell, with the
+// health projected at every step, or a Route with no moves if there is
+// none.
+func (g *Grid) HealthPath(pos api.Coord, health, minHealth int, goal func(api.Coord) bool) Route {
+	if !g.InBounds(pos) {
+		return Route{}
+	}
+	// best is the most health any path has arrived at each cell with; a
+	// longer path is only worth expanding if it arrives healthier.
+	best := g.Scratch.Ints(len(g.cells))
+	best[pos.Y*g.Width+pos.X] = health
+	nodes := []pathNode{{pos: pos, health: health, parent: -1}}
+	for head := 0; head < len(nodes); head++ {
+		cur := nodes[head]
+		for _, d := range api.Directions {
+			next := g.Step(cur.pos, d)
+			if !g.IsValid(next) {
+				continue
+			}
+			h := g.stepHealth(next, cur.health, cur.steps+1)
+			if g.damageAt(next, cur.steps+1) > 0 && !g.IsFood(next) && h < minHealth {
+				continue
+			}
+			i := next.Y*g.Width + next.X
+			if h <= 0 || h <= best[i] {
+				continue
+			}
+			best[i] = h
+			nodes = append(nodes, pathNode{pos: next, health: h, steps: cur.steps + 1, parent: head, move: d})
+			if goal(next) {
+				return g.Project(pos, health, backtrackNodes(nodes, len(nodes)-1))
+			}
+		}
+	}
+	return Route{}
+}
+
+// stepHealth returns the health left after moving onto pos with health,
+// steps turns from now.
+func (g *Grid) stepHealth(pos api.Coord, health, steps int) int {
+	if g.IsFood(pos) {
+		return maxHealth
+	}
+	return min(health-1-g.damageAt(pos, steps), maxHealth)
+}
+
+// damageAt returns the hazard damage dealt on entering pos steps turns from
+// now, counting a forecast hazard as a single stack once it has arrived.
+func (g *Grid) damageAt(pos api.Coord, steps int) int {
+	if damage := g.Damage(pos); damage != 0 || g.HazardDamage <= 0 {
+		return damage
+	}
+	if in := g.HazardIn(pos); in > 0 && in <= steps {
+		return g.HazardDamage
+	}
+	return 0
+}
+
+// backtrackNodes returns the moves leading from the first node to nodes[i].
+func backtrackNodes(nodes []pathNode, i int) []api.Direction {
+	var path []api.Direction
+	for ; nodes[i].parent >= 0; i = nodes[i].parent {
+		path = append(path, nodes[i].move)
+	}
+	for l, r := 0, len(path)-1; l < r; l, r = l+1, r-1 {
+		path[l], path[r] = path[r], path[l]
+	}
+	return path
+}
@@ -0,0 +1,152 @@
+package board
+
+import "github.com/jayuuza/battlesnake/pkg/api"
+
+// Threat is how dangerous enemy heads make a cell on their next move.
+type Threat uint8
+
+const (
+	// NoThreat cells can't be reached by any enemy head next turn.
+	NoThreat Threat = iota
+	// Weaker cells can only be reached by enemies shorter than us, who
+	// would lose a head-on collision there.
+	Weaker
+	// Deterred cells can be reached by enemies at least as long as us, but
+	// the longest of them could each be killed there by another: two or
+	// more equally long enemies contest the cell, so every snake entering
+	// it risks dying and a sensible enemy stays away.
+	Deterred
+	// Lethal cells can be reached by an enemy at least as long as us that
+	// would survive the crash.
+	Lethal
+)
+
+// DangerMap records the threat enemy heads pose to each cell next turn.
+//
+// When several snakes' heads meet, every snake at least as short as
+// another there dies, so a crash can have at most one survivor. The map
+// accounts for this rather than judging each enemy against us alone.
+//
+// Food cells are also annotated with how near they are to the head of an
+// enemy that would still be at least as long as us once we had eaten
+// there, since the food invites a losing head-to-head: that enemy can take
+// the cell with us, or close in on our head the turn after.
+type DangerMap struct {
+	Width   int
+	Height  int
+	threats []Threat
+	// baits holds the moves the nearest such enemy head needs to reach
+	// each food cell, if one or two.
+	baits []uint8
+}
+
+// baitRange is the number of moves within which an enemy head makes food
+// a bait.
+const baitRange = 2
+
+// contest tracks the enemies able to reach a cell.
+type contest struct {
+	longest int32
+	// count is how many enemies of the longest length can reach the cell.
+	count int
+}
+
+// NewDangerMap builds the danger map for our snake in game.
+func NewDangerMap(game api.GameRequest) *DangerMap {
+	m := &DangerMap{
+		Width:   game.Board.Width,
+		Height:  game.Board.Height,
+		threats: make([]Threat, game.Board.Width*game.Board.Height),
+	}
+	wrapped := IsWrapped(game.Game.Ruleset.Name)
+	contests := make([]contest, len(m.threats))
+	for _, snake := range game.Board.Snakes {
+		if snake.ID == game.You.ID {
+			continue
+		}
+		for _, d := range api.Directions {
+			pos := snake.Head.Move(d)
+			if wrapped {
+				pos.X = (pos.X + m.Width) % m.Width
+				pos.Y = (pos.Y + m.Height) % m.Height
+			}
+			if !InBounds(pos, m.Width, m.Height) {
+				continue
+			}
+			c := &contests[pos.Y*m.Width+pos.X]
+			switch {
+			case snake.Length > c.longest:
+				c.longest, c.count = snake.Length, 1
+			case snake.Length == c.longest:
+				c.count++
+			}
+		}
+	}
+	for i, c := range contests {
+		switch {
+		case c.count == 0:
+			m.threats[i] = NoThreat
+		case c.longest < game.You.Length:
+			m.threats[i] = Weaker
+		case c.count > 1:
+			m.threats[i] = Deterred
+		default:
+			m.threats[i] = Lethal
+		}
+	}
+	m.annotateFood(game, wrapped)
+	return m
+}
+
+// annotateFood records how near each food cell is to the nearest enemy head
+// that would be at least as long as us once we had eaten.
+func (m *DangerMap) annotateFood(game api.GameRequest, wrapped bool) {
+	for _, food := range game.Board.Food {
+		if !InBounds(food, m.Width, m.Height) {
+			continue
+		}
+		for _, snake := range game.Board.Snakes {
+			if snake.ID == game.You.ID || snake.Length < game.You.Length+1 {
+				continue
+			}
+			d := Manhattan(snake.Head, food)
+			if wrapped {
+				d = WrappedManhattan(snake.Head, food, m.Width, m.Height)
+			}
+			if d == 0 || d > baitRange {
+				continue
+			}
+			if m.baits == nil {
+				m.baits = make([]uint8, len(m.threats))
+			}
+			i := food.Y*m.Width + food.X
+			if m.baits[i] == 0 || uint8(d) < m.baits[i] {
+				m.baits[i] = uint8(d)
+			}
+		}
+	}
+}
+
+// Bait returns the number of moves, one or two, the nearest enemy head
+// that would be at least as long as us after we ate the food at pos needs
+// to reach it, or 0 if pos holds no such food.
+func (m *DangerMap) Bait(pos api.Coord) int {
+	if m.baits == nil || !InBounds(pos, m.Width, m.Height) {
+		return 0
+	}
+	return int(m.baits[pos.Y*m.Width+pos.X])
+}
+
+// At returns the threat to pos, or NoThreat if pos is off the board.
+func (m *DangerMap) At(pos api.Coord) Threat {
+	if !InBounds(pos, m.Width, m.Height) {
+		return NoThreat
+	}
+	return m.threats[pos.Y*m.Width+pos.X]
+}
+
+// IsLethal reports whether an enemy at least as long as us could move to
+// pos next turn and survive doing so.
+func (m *DangerMap) IsLethal(pos api.Coord) bool {
+	return m.At(pos) == Lethal
+}
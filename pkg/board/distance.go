@@ -0,0 +1,112 @@
+package board
+
+import "github.com/jayuuza/battlesnake/pkg/api"
+
+// Coordinates follow the v1 API: (0, 0) is the bottom-left cell, x grows to
+// the right and y grows upwards, so the top-right cell of a width x height
+// board is (width-1, height-1).
+
+// InBounds reports whether pos lies on a width x height board.
+func InBounds(pos api.Coord, width, height int) bool {
+	return pos.X >= 0 && pos.Y >= 0 && pos.X < width && pos.Y < height
+}
+
+// Manhattan returns the number of moves between a and b on an unwrapped
+// board, ignoring obstacles.
+func Manhattan(a, b api.Coord) int {
+	return abs(a.X-b.X) + abs(a.Y-b.Y)
+}
+
+// WrappedManhattan returns the number of moves between a and b on a
+// width x height board whose edges wrap, ignoring obstacles.
+func WrappedManhattan(a, b api.Coord, width, height int) int {
+	return wrappedAxis(a.X, b.X, width) + wrappedAxis(a.Y, b.Y, height)
+}
+
+// Distance returns the Manhattan distance between a and b under the
+// topology of g.
+func (g *Geometry) Distance(a, b api.Coord) int {
+	if g.Wrapped {
+		return WrappedManhattan(a, b, g.Width, g.Height)
+	}
+	return Manhattan(a, b)
+}
+
+func wrappedAxis(a, b, size int) int {
+	d := abs(a - b)
+	if size-d < d {
+		return size - d
+	}
+	return d
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Rect is an axis-aligned rectangle of cells including both Min and Max.
+type Rect struct {
+	Min api.Coord
+	Max api.Coord
+}
+
+// BoardRect returns the rectangle covering a width x height board.
+func BoardRect(width, height int) Rect {
+	return Rect{Max: api.Coord{X: width - 1, Y: height - 1}}
+}
+
+// Empty reports whether r contains no cells.
+func (r Rect) Empty() bool {
+	return r.Min.X > r.Max.X || r.Min.Y > r.Max.Y
+}
+
+// Contains reports whether pos lies inside r.
+func (r Rect) Contains(pos api.Coord) bool {
+	return pos.X >= r.Min.X && pos.X <= r.Max.X && pos.Y >= r.Min.Y && pos.Y <= r.Max.Y
+}
+
+// Inset returns r shrunk by n cells on every side.
+func (r Rect) Inset(n int) Rect {
+	return Rect{
+		Min: api.Coord{X: r.Min.X + n, Y: r.Min.Y + n},
+		Max: api.Coord{X: r.Max.X - n, Y: r.Max.Y - n},
+	}
+}
+
+// Each calls f for every cell in r, row by row from the bottom.
+func (r Rect) Each(f func(api.Coord)) {
+	for y := r.Min.Y; y <= r.Max.Y; y++ {
+		for x := r.Min.X; x <= r.Max.X; x++ {
+			f(api.Coord{X: x, Y: y})
+		}
+	}
+}
+
+// EachBorder calls f once for every cell on the perimeter of r.
+func (r Rect) EachBorder(f func(api.Coord)) {
+	if r.Empty() {
+		return
+	}
+	for x := r.Min.X; x <= r.Max.X; x++ {
+		f(api.Coord{X: x, Y: r.Min.Y})
+		if r.Max.Y != r.Min.Y {
+			f(api.Coord{X: x, Y: r.Max.Y})
+		}
+	}
+	for y := r.Min.Y + 1; y < r.Max.Y; y++ {
+		f(api.Coord{X: r.Min.X, Y: y})
+		if r.Max.X != r.Min.X {
+			f(api.Coord{X: r.Max.X, Y: y})
+		}
+	}
+}
+
+// EachRing calls f for every cell in ring n of a width x height board,
+// where ring 0 is the outer edge and each further ring lies one cell
+// inside the last.
+func EachRing(width, height, n int, f func(api.Coord)) {
+	BoardRect(width, height).Inset(n).EachBorder(f)
+}
@@ -0,0 +1,147 @@
+package board
+
+import (
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+func TestDistances(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      api.Coord
+		manhattan int
+		// wrapped is the distance on an 11x11 board whose edges wrap.
+		wrapped int
+	}{
+		{"same", api.Coord{X: 3, Y: 3}, api.Coord{X: 3, Y: 3}, 0, 0},
+		{"neighbor", api.Coord{X: 3, Y: 3}, api.Coord{X: 3, Y: 4}, 1, 1},
+		{"diagonal", api.Coord{X: 3, Y: 3}, api.Coord{X: 4, Y: 4}, 2, 2},
+		{"across x", api.Coord{X: 0, Y: 5}, api.Coord{X: 10, Y: 5}, 10, 1},
+		{"across y", api.Coord{X: 5, Y: 0}, api.Coord{X: 5, Y: 10}, 10, 1},
+		{"corners", api.Coord{X: 0, Y: 0}, api.Coord{X: 10, Y: 10}, 20, 2},
+		{"half way", api.Coord{X: 0, Y: 0}, api.Coord{X: 5, Y: 6}, 11, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Manhattan(tt.a, tt.b); got != tt.manhattan {
+				t.Errorf("Manhattan = %d, want %d", got, tt.manhattan)
+			}
+			if got := Manhattan(tt.b, tt.a); got != tt.manhattan {
+				t.Errorf("Manhattan reversed = %d, want %d", got, tt.manhattan)
+			}
+			if got := WrappedManhattan(tt.a, tt.b, 11, 11); got != tt.wrapped {
+				t.Errorf("WrappedManhattan = %d, want %d", got, tt.wrapped)
+			}
+			if got := GeometryFor(11, 11, false).Distance(tt.a, tt.b); got != tt.manhattan {
+				t.Errorf("unwrapped Geometry.Distance = %d, want %d", got, tt.manhattan)
+			}
+			if got := GeometryFor(11, 11, true).Distance(tt.a, tt.b); got != tt.wrapped {
+				t.Errorf("wrapped Geometry.Distance = %d, want %d", got, tt.wrapped)
+			}
+		})
+	}
+}
+
+func TestInBounds(t *testing.T) {
+	tests := []struct {
+		pos  api.Coord
+		want bool
+	}{
+		{api.Coord{X: 0, Y: 0}, true},
+		{api.Coord{X: 10, Y: 6}, true},
+		{api.Coord{X: 11, Y: 0}, false},
+		{api.Coord{X: 0, Y: 7}, false},
+		{api.Coord{X: -1, Y: 3}, false},
+		{api.Coord{X: 3, Y: -1}, false},
+	}
+	for _, tt := range tests {
+		if got := InBounds(tt.pos, 11, 7); got != tt.want {
+			t.Errorf("InBounds(%v, 11, 7) = %v, want %v", tt.pos, got, tt.want)
+		}
+	}
+}
+
+func TestGeometryStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		wrapped bool
+		pos     api.Coord
+		d       api.Direction
+		want    api.Coord
+		ok      bool
+	}{
+		// Up grows y, as (0, 0) is the bottom-left cell.
+		{"up", false, api.Coord{X: 2, Y: 2}, api.Up, api.Coord{X: 2, Y: 3}, true},
+		{"down", false, api.Coord{X: 2, Y: 2}, api.Down, api.Coord{X: 2, Y: 1}, true},
+		{"left", false, api.Coord{X: 2, Y: 2}, api.Left, api.Coord{X: 1, Y: 2}, true},
+		{"right", false, api.Coord{X: 2, Y: 2}, api.Right, api.Coord{X: 3, Y: 2}, true},
+		{"off the top", false, api.Coord{X: 2, Y: 4}, api.Up, api.Coord{}, false},
+		{"off the left", false, api.Coord{X: 0, Y: 2}, api.Left, api.Coord{}, false},
+		{"wrap the top", true, api.Coord{X: 2, Y: 4}, api.Up, api.Coord{X: 2, Y: 0}, true},
+		{"wrap the bottom", true, api.Coord{X: 2, Y: 0}, api.Down, api.Coord{X: 2, Y: 4}, true},
+		{"wrap the left", true, api.Coord{X: 0, Y: 2}, api.Left, api.Coord{X: 5, Y: 2}, true},
+		{"wrap the right", true, api.Coord{X: 5, Y: 2}, api.Right, api.Coord{X: 0, Y: 2}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := GeometryFor(6, 5, tt.wrapped).Step(tt.pos, tt.d)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Step(%v, %v) = %v, %v; want %v, %v", tt.pos, tt.d, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRect(t *testing.T) {
+	r := BoardRect(5, 4)
+	if r.Empty() {
+		t.Fatal("BoardRect(5, 4) is empty")
+	}
+	cells := 0
+	r.Each(func(pos api.Coord) {
+		cells++
+		if !r.Contains(pos) || !InBounds(pos, 5, 4) {
+			t.Errorf("Each visits %v, outside the board", pos)
+		}
+	})
+	if cells != 20 {
+		t.Errorf("Each visits %d cells, want 20", cells)
+	}
+	if inner := r.Inset(1); inner.Contains(api.Coord{X: 0, Y: 0}) || !inner.Contains(api.Coord{X: 1, Y: 1}) {
+		t.Errorf("Inset(1) = %+v", inner)
+	}
+	if !r.Inset(2).Empty() {
+		t.Errorf("Inset(2) of a 5x4 board = %+v, want empty", r.Inset(2))
+	}
+}
+
+func TestEachRing(t *testing.T) {
+	tests := []struct {
+		width, height, n int
+		want             int
+	}{
+		{11, 11, 0, 40},
+		{11, 11, 1, 32},
+		{11, 11, 5, 1},
+		{11, 11, 6, 0},
+		{7, 3, 1, 5},
+		{1, 1, 0, 1},
+	}
+	for _, tt := range tests {
+		seen := map[api.Coord]bool{}
+		ring := BoardRect(tt.width, tt.height).Inset(tt.n)
+		EachRing(tt.width, tt.height, tt.n, func(pos api.Coord) {
+			if seen[pos] {
+				t.Errorf("EachRing(%d, %d, %d) visits %v twice", tt.width, tt.height, tt.n, pos)
+			}
+			seen[pos] = true
+			if !ring.Contains(pos) || ring.Inset(1).Contains(pos) {
+				t.Errorf("EachRing(%d, %d, %d) visits %v, off the ring", tt.width, tt.height, tt.n, pos)
+			}
+		})
+		if len(seen) != tt.want {
+			t.Errorf("EachRing(%d, %d, %d) visits %d cells, want %d", tt.width, tt.height, tt.n, len(seen), tt.want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package board
+
+import "math/bits"
+
+// Flood fills work on whole words of a Bits at a time: each step shifts
+// the filled cells one place in every direction at once and keeps the
+// passable ones, so a fill takes as many steps as its longest path rather
+// than one per cell, and never allocates. Only the words the board's
+// cells occupy are touched.
+
+// FloodFill returns every cell reachable from seed moving only through
+// passable cells. The seed cells are included whether or not they are
+// passable.
+func (l *Layout) FloodFill(seed, passable Bits) Bits {
+	filled, _ := l.Flood(seed, passable, false, 0)
+	return filled
+}
+
+// Flood is FloodFill across the edges of the board if wrapped, stopping
+// once limit cells beyond the seed are filled unless limit is 0. It returns
+// the cells filled and how many of them aren't in seed.
+func (l *Layout) Flood(seed, passable Bits, wrapped bool, limit int) (Bits, int) {
+	return l.flood(seed, passable, wrapped, limit, nil)
+}
+
+// flood is Flood, also keeping out the cells for which admit is false if
+// it isn't nil. Admit is only asked about cells as they are reached.
+func (l *Layout) flood(seed, passable Bits, wrapped bool, limit int, admit func(i int) bool) (Bits, int) {
+	passable = passable.And(l.All)
+	filled := seed
+	seeded := l.count(&seed)
+	for {
+		next := l.step(&filled, &passable, wrapped)
+		if admit != nil {
+			for w := 0; w < l.words; w++ {
+				for fresh := next[w] &^ filled[w]; fresh != 0; fresh &= fresh - 1 {
+					if i := w<<6 + bits.TrailingZeros64(fresh); !admit(i) {
+						next.Clear(i)
+						passable.Clear(i)
+					}
+				}
+			}
+		}
+		if next == filled {
+			return filled, l.count(&filled) - seeded
+		}
+		filled = next
+		if limit > 0 && l.count(&filled)-seeded >= limit {
+			return filled, limit
+		}
+	}
+}
+
+// Connected reports whether cells a and b are joined by a path through
+// passable cells, across the edges if wrapped. Neither a nor b need be
+// passable themselves.
+func (l *Layout) Connected(a, b int, passable Bits, wrapped bool) bool {
+	var filled Bits
+	filled.Set(a)
+	passable.Set(b)
+	passable = passable.And(l.All)
+	for !filled.Has(b) {
+		next := l.step(&filled, &passable, wrapped)
+		if next == filled {
+			return false
+		}
+		filled = next
+	}
+	return true
+}
+
+// step returns filled with the passable neighbors of its cells added.
+func (l *Layout) step(filled, passable *Bits, wrapped bool) Bits {
+	n := l.words
+	var grown, shifted Bits
+	shiftUp(&shifted, filled, 1, n)
+	for i := 0; i < n; i++ {
+		grown[i] = shifted[i] & l.notFirstCol[i]
+	}
+	shiftDown(&shifted, filled, 1, n)
+	for i := 0; i < n; i++ {
+		grown[i] |= shifted[i] & l.notLastCol[i]
+	}
+	shiftUp(&shifted, filled, l.Width, n)
+	for i := 0; i < n; i++ {
+		grown[i] |= shifted[i]
+	}
+	shiftDown(&shifted, filled, l.Width, n)
+	for i := 0; i < n; i++ {
+		grown[i] |= shifted[i]
+	}
+	if wrapped {
+		across := (l.Height - 1) * l.Width
+		l.wrap(&grown, filled, &l.lastCol, l.Width-1, false, n)
+		l.wrap(&grown, filled, &l.firstCol, l.Width-1, true, n)
+		l.wrap(&grown, filled, &l.topRow, across, false, n)
+		l.wrap(&grown, filled, &l.bottomRow, across, true, n)
+	}
+	next := *filled
+	for i := 0; i < n; i++ {
+		next[i] |= grown[i] & passable[i]
+	}
+	return next
+}
+
+// wrap adds to grown the cells of filled on edge, moved by places to the
+// opposite edge: towards higher indices if up, else lower.
+func (l *Layout) wrap(grown, filled, edge *Bits, places int, up bool, n int) {
+	var on, shifted Bits
+	var edged uint64
+	for i := 0; i < n; i++ {
+		on[i] = filled[i] & edge[i]
+		edged |= on[i]
+	}
+	if edged == 0 {
+		return
+	}
+	if up {
+		shiftUp(&shifted, &on, places, n)
+	} else {
+		shiftDown(&shifted, &on, places, n)
+	}
+	for i := 0; i < n; i++ {
+		grown[i] |= shifted[i]
+	}
+}
+
+func (l *Layout) count(b *Bits) int {
+	c := 0
+	for i := 0; i < l.words; i++ {
+		c += bits.OnesCount64(b[i])
+	}
+	return c
+}
+
+// shiftUp sets the first n words of dst to those of src shifted s places
+// towards higher indices.
+func shiftUp(dst, src *Bits, s, n int) {
+	w, r := s>>6, uint(s&63)
+	for i := n - 1; i >= 0; i-- {
+		j := i - w
+		if j < 0 {
+			dst[i] = 0
+			continue
+		}
+		dst[i] = src[j] << r
+		if r > 0 && j > 0 {
+			dst[i] |= src[j-1] >> (64 - r)
+		}
+	}
+}
+
+// shiftDown sets the first n words of dst to those of src shifted s places
+// towards lower indices.
+func shiftDown(dst, src *Bits, s, n int) {
+	w, r := s>>6, uint(s&63)
+	for i := 0; i < n; i++ {
+		j := i + w
+		if j >= n {
+			dst[i] = 0
+			continue
+		}
+		dst[i] = src[j] >> r
+		if r > 0 && j+1 < n {
+			dst[i] |= src[j+1] << (64 - r)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package board_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/bench"
+	"github.com/jayuuza/battlesnake/pkg/board"
+)
+
+// reachable counts the cells reachable from pos one at a time, for the
+// flood fill to be checked against.
+func reachable(grid *board.Grid, pos api.Coord, passable func(api.Coord) bool) int {
+	seen := map[api.Coord]bool{pos: true}
+	queue := []api.Coord{pos}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, d := range api.Directions {
+			next := grid.Step(p, d)
+			if seen[next] || !grid.IsValid(next) || passable != nil && !passable(next) {
+				continue
+			}
+			seen[next] = true
+			queue = append(queue, next)
+		}
+	}
+	return len(seen) - 1
+}
+
+func TestReachable(t *testing.T) {
+	wrapped, err := board.ParseASCII(`
+Ruleset: wrapped, Turn: 3
+b . . . .
+b b B . .
+. . . . .
+a A . . .
+. . . . .
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		game api.GameRequest
+		// safe keeps the fill out of cells a longer enemy head can take.
+		safe bool
+	}{
+		{"standard", bench.Position(), false},
+		{"standard/safe", bench.Position(), true},
+		{"wrapped", wrapped, false},
+		{"wrapped/safe", wrapped, true},
+		{"arcade_maze", bench.ArcadeMaze(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid := board.GridFor(tt.game)
+			var passable func(api.Coord) bool
+			if tt.safe {
+				danger := board.NewDangerMap(tt.game)
+				passable = func(p api.Coord) bool { return !danger.IsLethal(p) }
+			}
+			head := tt.game.You.Head
+			want := reachable(grid, head, passable)
+			if got := grid.Reachable(head, 0, passable); got != want {
+				t.Fatalf("Reachable = %d, want %d", got, want)
+			}
+			// A limit stops the count once reached.
+			if limit := want / 2; limit > 0 {
+				if got := grid.Reachable(head, limit, passable); got != limit {
+					t.Errorf("Reachable with limit %d = %d", limit, got)
+				}
+			}
+		})
+	}
+}
+
+func TestComponents(t *testing.T) {
+	grid := board.GridFor(bench.ArcadeMaze())
+	labels, sizes := grid.Components()
+	for y := 0; y < grid.Height; y++ {
+		for x := 0; x < grid.Width; x++ {
+			pos := api.Coord{X: x, Y: y}
+			label := labels[y*grid.Width+x]
+			if !grid.IsValid(pos) {
+				if label != -1 {
+					t.Fatalf("invalid cell %v labelled %d", pos, label)
+				}
+				continue
+			}
+			if want := reachable(grid, pos, nil) + 1; sizes[label] != want {
+				t.Fatalf("component of %v has size %d, want %d", pos, sizes[label], want)
+			}
+		}
+	}
+}
+
+// distances finds the distances from pos one cell at a time, for the
+// layered fills to be checked against: through valid cells, or if timed
+// through segments vacated by the turn a step arrives on.
+func distances(grid *board.Grid, pos api.Coord, elapsed int, timed bool) []int {
+	dist := make([]int, grid.Width*grid.Height)
+	for i := range dist {
+		dist[i] = -1
+	}
+	dist[pos.Y*grid.Width+pos.X] = 0
+	queue := []api.Coord{pos}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		step := dist[p.Y*grid.Width+p.X] + 1
+		for _, d := range api.Directions {
+			next := grid.Step(p, d)
+			if !grid.InBounds(next) || dist[next.Y*grid.Width+next.X] >= 0 {
+				continue
+			}
+			open := grid.IsValid(next)
+			if timed {
+				open = !grid.IsWall(next) && (!grid.IsSnake(next) || grid.VacatedIn(next) <= elapsed+step)
+			}
+			if open {
+				dist[next.Y*grid.Width+next.X] = step
+				queue = append(queue, next)
+			}
+		}
+	}
+	return dist
+}
+
+func TestGridDistances(t *testing.T) {
+	wrapped, err := board.ParseASCII(`
+Ruleset: wrapped, Turn: 3
+b . . . .
+b b B . .
+. . . . .
+a A . . .
+. . . . .
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		game api.GameRequest
+	}{
+		{"standard", bench.Position()},
+		{"wrapped", wrapped},
+		{"arcade_maze", bench.ArcadeMaze()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid := board.GridFor(tt.game)
+			var from []api.Coord
+			for _, snake := range tt.game.Board.Snakes {
+				from = append(from, snake.Head, snake.Body[len(snake.Body)-1])
+			}
+			from = append(from, tt.game.Board.Food...)
+			for _, pos := range from {
+				if got, want := grid.Distances(pos), distances(grid, pos, 0, false); !slices.Equal(got, want) {
+					t.Errorf("Distances from %v = %v, want %v", pos, got, want)
+				}
+				for elapsed := range 3 {
+					want := distances(grid, pos, elapsed, true)
+					if got := grid.TimedDistances(pos, elapsed); !slices.Equal(got, want) {
+						t.Errorf("TimedDistances from %v after %d turns = %v, want %v", pos, elapsed, got, want)
+					}
+					for i, d := range want {
+						to := api.Coord{X: i % grid.Width, Y: i / grid.Width}
+						if got := grid.TimedDistance(pos, to, elapsed); got != d {
+							t.Errorf("TimedDistance from %v to %v after %d turns = %d, want %d", pos, to, elapsed, got, d)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+// voronoi partitions the board one cell at a time, for Voronoi to be
+// checked against.
+func voronoi(grid *board.Grid, heads []api.Coord) []int {
+	owner := make([]int, grid.Width*grid.Height)
+	dist := make([]int, len(owner))
+	for i := range owner {
+		owner[i], dist[i] = -1, -1
+	}
+	var queue []api.Coord
+	for i, head := range heads {
+		owner[head.Y*grid.Width+head.X], dist[head.Y*grid.Width+head.X] = i, 0
+		queue = append(queue, head)
+	}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		c := p.Y*grid.Width + p.X
+		if owner[c] < 0 {
+			continue
+		}
+		for _, d := range api.Directions {
+			next := grid.Step(p, d)
+			if !grid.IsValid(next) {
+				continue
+			}
+			n := next.Y*grid.Width + next.X
+			switch {
+			case dist[n] < 0:
+				owner[n], dist[n] = owner[c], dist[c]+1
+				queue = append(queue, next)
+			case dist[n] == dist[c]+1 && owner[n] != owner[c]:
+				owner[n] = -1
+			}
+		}
+	}
+	return owner
+}
+
+func TestVoronoi(t *testing.T) {
+	wrapped, err := board.ParseASCII(`
+Ruleset: wrapped, Turn: 3
+b . . . .
+b b B . .
+. . . . .
+a A . . .
+. . . . .
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		game api.GameRequest
+	}{
+		{"standard", bench.Position()},
+		{"wrapped", wrapped},
+		{"arcade_maze", bench.ArcadeMaze()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid := board.GridFor(tt.game)
+			var heads []api.Coord
+			for _, snake := range tt.game.Board.Snakes {
+				heads = append(heads, snake.Head)
+			}
+			if got, want := grid.Voronoi(heads), voronoi(grid, heads); !slices.Equal(got, want) {
+				t.Errorf("Voronoi = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func BenchmarkVoronoi(b *testing.B) {
+	game := bench.Position()
+	grid := board.GridFor(game)
+	var heads []api.Coord
+	for _, snake := range game.Board.Snakes {
+		heads = append(heads, snake.Head)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.Voronoi(heads)
+	}
+}
+
+func BenchmarkDistances(b *testing.B) {
+	game := bench.Position()
+	grid := board.GridFor(game)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.Distances(game.You.Head)
+	}
+}
+
+func BenchmarkTimedDistances(b *testing.B) {
+	game := bench.Position()
+	grid := board.GridFor(game)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.TimedDistances(game.You.Head, 1)
+	}
+}
+
+func BenchmarkDistancesWrappedMaze(b *testing.B) {
+	game := bench.ArcadeMaze()
+	grid := board.GridFor(game)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.Distances(game.You.Head)
+	}
+}
+
+func BenchmarkReachable(b *testing.B) {
+	game := bench.Position()
+	grid := board.GridFor(game)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.Reachable(game.You.Head, 0, nil)
+	}
+}
+
+func BenchmarkSafeReachable(b *testing.B) {
+	game := bench.Position()
+	grid := board.GridFor(game)
+	danger := board.NewDangerMap(game)
+	passable := func(p api.Coord) bool { return !danger.IsLethal(p) }
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.Reachable(game.You.Head, 0, passable)
+	}
+}
+
+func BenchmarkReachableWrappedMaze(b *testing.B) {
+	game := bench.ArcadeMaze()
+	grid := board.GridFor(game)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.Reachable(game.You.Head, 0, nil)
+	}
+}
+
+func BenchmarkComponentsMaze(b *testing.B) {
+	grid := board.GridFor(bench.ArcadeMaze())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.Components()
+	}
+}
@@ -0,0 +1,96 @@
+package board
+
+import (
+	"sync"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+// NoCell is the neighbor index of a step that leaves the board.
+const NoCell = -1
+
+// Geometry holds the precomputed neighbors of every cell for one board size
+// and topology. Cells are indexed by y*width+x, as in Bits. A Geometry is
+// immutable and shared by every board of that shape.
+type Geometry struct {
+	Width   int
+	Height  int
+	Wrapped bool
+
+	neighbors [][4]int32
+}
+
+type geometryKey struct {
+	width, height int
+	wrapped       bool
+}
+
+var geometries sync.Map // geometryKey -> *Geometry
+
+// GeometryFor returns the shared Geometry for a width x height board, with
+// moves across an edge wrapping to the opposite edge if wrapped is set.
+func GeometryFor(width, height int, wrapped bool) *Geometry {
+	key := geometryKey{width, height, wrapped}
+	if g, ok := geometries.Load(key); ok {
+		return g.(*Geometry)
+	}
+	g, _ := geometries.LoadOrStore(key, newGeometry(width, height, wrapped))
+	return g.(*Geometry)
+}
+
+func newGeometry(width, height int, wrapped bool) *Geometry {
+	g := &Geometry{
+		Width:     width,
+		Height:    height,
+		Wrapped:   wrapped,
+		neighbors: make([][4]int32, width*height),
+	}
+	for i := range g.neighbors {
+		pos := g.Coord(i)
+		for _, d := range api.Directions {
+			next := pos.Move(d)
+			if wrapped {
+				next.X = (next.X + width) % width
+				next.Y = (next.Y + height) % height
+			}
+			if next.X < 0 || next.Y < 0 || next.X >= width || next.Y >= height {
+				g.neighbors[i][d] = NoCell
+				continue
+			}
+			g.neighbors[i][d] = int32(g.Index(next))
+		}
+	}
+	return g
+}
+
+// Index returns the cell index of pos.
+func (g *Geometry) Index(pos api.Coord) int {
+	return pos.Y*g.Width + pos.X
+}
+
+// Coord returns the cell at index i.
+func (g *Geometry) Coord(i int) api.Coord {
+	return api.Coord{X: i % g.Width, Y: i / g.Width}
+}
+
+// Neighbor returns the index of the cell one step from cell i in direction
+// d, or NoCell if that step leaves the board.
+func (g *Geometry) Neighbor(i int, d api.Direction) int {
+	return int(g.neighbors[i][d])
+}
+
+// Neighbors returns the neighbor of cell i in each direction, indexed by
+// Direction. Steps that leave the board are NoCell.
+func (g *Geometry) Neighbors(i int) [4]int32 {
+	return g.neighbors[i]
+}
+
+// Step returns the cell one step from pos in direction d, or false if that
+// step leaves the board.
+func (g *Geometry) Step(pos api.Coord, d api.Direction) (api.Coord, bool) {
+	n := g.Neighbor(g.Index(pos), d)
+	if n == NoCell {
+		return api.Coord{}, false
+	}
+	return g.Coord(n), true
+}
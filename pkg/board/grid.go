@@ -0,0 +1,263 @@
+package board
+
+import (
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/arena"
+	"github.com/jayuuza/battlesnake/pkg/hazard"
+	"github.com/jayuuza/battlesnake/pkg/maps"
+)
+
+// Cell describes what occupies a square of the board.
+type Cell uint8
+
+// Empty is a cell with nothing in it; the other Cell values are flags.
+const Empty Cell = 0
+
+const (
+	Food Cell = 1 << iota
+	Snake
+	Hazard
+	// Wall marks a hazard that is impassable on the game's map.
+	Wall
+)
+
+// Grid is an occupancy grid for a Board, built once per request so that
+// cell lookups are O(1) rather than a scan over every snake segment.
+type Grid struct {
+	Width  int
+	Height int
+	// HazardDamage is the damage dealt per stacked hazard, negative where
+	// hazards heal. It is set by GridFor.
+	HazardDamage int
+	// Wrapped is set when moves wrap around the edges of the board, as
+	// GridFor does for the wrapped ruleset. Moves, paths, flood fills and
+	// Voronoi partitions then all cross the edges.
+	Wrapped bool
+	// Scratch, if set, supplies the buffers of paths and flood fills, and
+	// the slices returned by Distances, Voronoi and Components, which must
+	// then not be used once it is reset.
+	Scratch *arena.Arena
+
+	cells []Cell
+	// stacks counts the hazard entries on each cell; it is nil if the
+	// board has no hazards.
+	stacks []uint8
+	// forecast holds the turns until each cell is predicted to become a
+	// hazard, as hazard.Forecast; it is nil unless set by GridFor.
+	forecast []int
+	// vacate holds the turns until each cell's snake segments move away,
+	// as VacatedIn.
+	vacate []uint16
+	// head and tail are our head and the cell of our tail, set by GridFor
+	// if the tail moves away this turn so that ValidMoves lets our head
+	// follow it; tail is -1 otherwise.
+	head api.Coord
+	tail int
+	// open has a bit set for every valid cell, for flood fills over the
+	// bits of layout. layout is nil on boards too large for a Bits.
+	layout *Layout
+	open   Bits
+}
+
+// NewGrid builds the occupancy grid for board.
+func NewGrid(board api.Board) *Grid {
+	g := &Grid{
+		Width:  board.Width,
+		Height: board.Height,
+		cells:  make([]Cell, board.Width*board.Height),
+		tail:   -1,
+	}
+	for _, coord := range board.Food {
+		g.set(coord, Food)
+	}
+	if len(board.Hazards) > 0 {
+		g.stacks = make([]uint8, len(g.cells))
+	}
+	for _, coord := range board.Hazards {
+		g.set(coord, Hazard)
+		if g.InBounds(coord) && g.stacks[coord.Y*g.Width+coord.X] < 255 {
+			g.stacks[coord.Y*g.Width+coord.X]++
+		}
+	}
+	g.vacate = make([]uint16, len(g.cells))
+	for _, snake := range board.Snakes {
+		for i, coord := range snake.Body {
+			g.set(coord, Snake)
+			if g.InBounds(coord) {
+				t := uint16(min(len(snake.Body)-i, 1<<16-1))
+				j := coord.Y*g.Width + coord.X
+				g.vacate[j] = max(g.vacate[j], t)
+			}
+		}
+	}
+	g.indexOpen()
+	return g
+}
+
+// GridFor builds the occupancy grid for game's board. Hazards become walls
+// where their stacked damage would kill us outright, and wherever the map's
+// hooks (package maps) forbid entering them with the health we'd have
+// left, such as arcade_maze's walls and fresh snail trails.
+func GridFor(game api.GameRequest) *Grid {
+	m := maps.Lookup(game.Game.Map)
+	g := NewGrid(game.Board)
+	g.HazardDamage = int(game.Game.Ruleset.Settings.HazardDamagePerTurn)
+	g.Wrapped = IsWrapped(game.Game.Ruleset.Name)
+	g.forecast = hazard.Forecast(game, g.Width+g.Height, m.Predict)
+	// Our tail moves away this turn unless we have just eaten, which
+	// stacks it and makes it vacate a turn later: we can't eat on the
+	// move into it. A snake of two has its tail for a neck, and turning
+	// back onto it isn't following it.
+	if body := game.You.Body; len(body) > 2 {
+		if tail := body[len(body)-1]; g.InBounds(tail) && g.VacatedIn(tail) == 1 {
+			g.head, g.tail = game.You.Head, tail.Y*g.Width+tail.X
+		}
+	}
+	for i, c := range g.cells {
+		if c&Hazard == 0 {
+			continue
+		}
+		left := int(game.You.Health) - 1 - int(g.stacks[i])*g.HazardDamage
+		if !m.CanPass(left) || left <= 0 && c&Food == 0 {
+			g.cells[i] |= Wall
+			if g.layout != nil {
+				g.open.Clear(i)
+			}
+		}
+	}
+	return g
+}
+
+// indexOpen records the valid cells in open.
+func (g *Grid) indexOpen() {
+	if g.Width*g.Height > MaxCells {
+		return
+	}
+	g.layout = LayoutFor(g.Width, g.Height)
+	g.open = Bits{}
+	for i, c := range g.cells {
+		if c&(Snake|Wall) == 0 {
+			g.open.Set(i)
+		}
+	}
+}
+
+func (g *Grid) set(pos api.Coord, c Cell) {
+	if g.InBounds(pos) {
+		g.cells[pos.Y*g.Width+pos.X] |= c
+	}
+}
+
+// Step returns the cell one move from pos in direction d, wrapping around
+// the edges if the grid is wrapped.
+func (g *Grid) Step(pos api.Coord, d api.Direction) api.Coord {
+	pos = pos.Move(d)
+	if g.Wrapped {
+		pos.X = (pos.X + g.Width) % g.Width
+		pos.Y = (pos.Y + g.Height) % g.Height
+	}
+	return pos
+}
+
+// Distance returns the number of moves between a and b ignoring obstacles,
+// across the edges if the grid is wrapped.
+func (g *Grid) Distance(a, b api.Coord) int {
+	if g.Wrapped {
+		return WrappedManhattan(a, b, g.Width, g.Height)
+	}
+	return Manhattan(a, b)
+}
+
+// At returns the contents of pos, or Empty if pos is off the board.
+func (g *Grid) At(pos api.Coord) Cell {
+	if !g.InBounds(pos) {
+		return Empty
+	}
+	return g.cells[pos.Y*g.Width+pos.X]
+}
+
+// InBounds reports whether pos lies on the board.
+func (g *Grid) InBounds(pos api.Coord) bool {
+	return InBounds(pos, g.Width, g.Height)
+}
+
+// IsFood reports whether pos holds food.
+func (g *Grid) IsFood(pos api.Coord) bool {
+	return g.At(pos)&Food != 0
+}
+
+// IsSnake reports whether pos is occupied by any snake segment.
+func (g *Grid) IsSnake(pos api.Coord) bool {
+	return g.At(pos)&Snake != 0
+}
+
+// IsHazard reports whether pos is a hazard.
+func (g *Grid) IsHazard(pos api.Coord) bool {
+	return g.At(pos)&Hazard != 0
+}
+
+// HazardStack returns the number of hazard entries on pos. Hazard damage
+// stacks, and on snail_mode it is also the number of turns until a trail
+// disappears.
+func (g *Grid) HazardStack(pos api.Coord) int {
+	if g.stacks == nil || !g.InBounds(pos) {
+		return 0
+	}
+	return int(g.stacks[pos.Y*g.Width+pos.X])
+}
+
+// Damage returns the hazard damage dealt on entering pos without eating,
+// which is negative if it heals.
+func (g *Grid) Damage(pos api.Coord) int {
+	return g.HazardStack(pos) * g.HazardDamage
+}
+
+// HazardIn returns the number of turns until pos is predicted to become a
+// hazard, 0 if it is one already, or hazard.Never.
+func (g *Grid) HazardIn(pos api.Coord) int {
+	if !g.InBounds(pos) {
+		return hazard.Never
+	}
+	if g.forecast == nil {
+		if g.At(pos)&Hazard != 0 {
+			return 0
+		}
+		return hazard.Never
+	}
+	return g.forecast[pos.Y*g.Width+pos.X]
+}
+
+// IsHealing reports whether entering pos restores health.
+func (g *Grid) IsHealing(pos api.Coord) bool {
+	return g.Damage(pos) < 0
+}
+
+// IsWall reports whether pos is an impassable hazard.
+func (g *Grid) IsWall(pos api.Coord) bool {
+	return g.At(pos)&Wall != 0
+}
+
+// IsValid reports whether pos is on the board and neither occupied by a
+// snake nor a wall.
+func (g *Grid) IsValid(pos api.Coord) bool {
+	return g.InBounds(pos) && g.At(pos)&(Snake|Wall) == 0
+}
+
+// ValidMoves returns moves from pos that won't result in death. From our
+// head on a grid built by GridFor, that includes following our tail as it
+// moves away.
+func (g *Grid) ValidMoves(pos api.Coord) []api.Direction {
+	var moves []api.Direction
+	for _, d := range api.Directions {
+		if next := g.Step(pos, d); g.IsValid(next) || g.followsTail(pos, next) {
+			moves = append(moves, d)
+		}
+	}
+	return moves
+}
+
+// followsTail reports whether moving from pos to next is our head moving
+// onto our tail as it moves away.
+func (g *Grid) followsTail(pos, next api.Coord) bool {
+	return g.tail >= 0 && pos == g.head && g.InBounds(next) && next.Y*g.Width+next.X == g.tail && g.At(next)&Wall == 0
+}
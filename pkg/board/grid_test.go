@@ -0,0 +1,55 @@
+package board_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+)
+
+func TestValidMovesFollowsTail(t *testing.T) {
+	// Our head at (1, 1) with our tail, or theirs, just to its right.
+	coiled := []api.Coord{{X: 1, Y: 1}, {X: 1, Y: 2}, {X: 2, Y: 2}, {X: 2, Y: 1}}
+	tests := []struct {
+		name string
+		you  []api.Coord
+		// other is another snake's body, if any.
+		other []api.Coord
+		// hazard is a lethal hazard under our tail.
+		hazard bool
+		want   []api.Direction
+	}{
+		{"own tail", coiled, nil, false, []api.Direction{api.Down, api.Left, api.Right}},
+		{"just ate", append(slices.Clone(coiled), api.Coord{X: 2, Y: 1}), nil, false, []api.Direction{api.Down, api.Left}},
+		{"hazard under tail", coiled, nil, true, []api.Direction{api.Down, api.Left}},
+		{"their tail", []api.Coord{{X: 1, Y: 1}, {X: 1, Y: 2}, {X: 1, Y: 3}},
+			[]api.Coord{{X: 4, Y: 4}, {X: 3, Y: 4}, {X: 3, Y: 3}, {X: 3, Y: 2}, {X: 3, Y: 1}, {X: 2, Y: 1}}, false,
+			[]api.Direction{api.Down, api.Left}},
+		{"two long", []api.Coord{{X: 1, Y: 1}, {X: 2, Y: 1}}, nil, false, []api.Direction{api.Up, api.Down, api.Left}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			you := api.Battlesnake{ID: "you", Health: 90, Body: tt.you, Head: tt.you[0], Length: int32(len(tt.you))}
+			game := api.GameRequest{
+				Game:  api.Game{Ruleset: api.Ruleset{Name: "standard", Settings: api.RulesetSettings{HazardDamagePerTurn: 100}}},
+				Board: api.Board{Width: 7, Height: 7, Snakes: []api.Battlesnake{you}},
+				You:   you,
+			}
+			if tt.other != nil {
+				game.Board.Snakes = append(game.Board.Snakes, api.Battlesnake{ID: "other", Health: 90, Body: tt.other, Head: tt.other[0], Length: int32(len(tt.other))})
+			}
+			if tt.hazard {
+				game.Board.Hazards = []api.Coord{{X: 2, Y: 1}}
+			}
+			if got := board.GridFor(game).ValidMoves(you.Head); !slices.Equal(got, tt.want) {
+				t.Errorf("ValidMoves = %v, want %v", got, tt.want)
+			}
+			// A grid that doesn't know which snake is ours keeps every
+			// body segment blocked.
+			if got := board.NewGrid(game.Board).ValidMoves(you.Head); slices.Contains(got, api.Right) {
+				t.Errorf("NewGrid's ValidMoves = %v, entering a tail", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package board
+
+import "github.com/jayuuza/battlesnake/pkg/maps"
+
+// Maps with special handling, registered in package maps.
+const (
+	ArcadeMaze = maps.ArcadeMaze
+	SnailMode  = maps.SnailMode
+)
+
+// IsWrapped reports whether moves wrap around the edges of the board under
+// the named ruleset.
+func IsWrapped(ruleset string) bool {
+	return ruleset == "wrapped"
+}
+
+// HazardTrails reports whether hazards on the named map are decaying trails
+// left by snakes.
+func HazardTrails(mapName string) bool {
+	return maps.Lookup(mapName).Trails
+}
@@ -0,0 +1,112 @@
+package board_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/bench"
+	"github.com/jayuuza/battlesnake/pkg/board"
+)
+
+// loadMaze reads the fixture position name from testdata/arcade_maze, on
+// mapName with hazards dealing damage each.
+func loadMaze(t *testing.T, name, mapName string, damage int32) api.GameRequest {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", "arcade_maze", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	game, err := board.ParseASCII(string(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	game.Game.Map = mapName
+	game.Game.Ruleset.Settings.HazardDamagePerTurn = damage
+	return game
+}
+
+func TestArcadeMazeWalls(t *testing.T) {
+	tests := []struct {
+		fixture string
+		mapName string
+		damage  int32
+		// moves are our valid moves, and path the length of the shortest
+		// path to food.
+		moves []api.Direction
+		path  int
+	}{
+		// Along the corridors, not through the walls between them.
+		{"corridor.txt", board.ArcadeMaze, 0, []api.Direction{api.Up}, 12},
+		{"corridor.txt", board.ArcadeMaze, 100, []api.Direction{api.Up}, 12},
+		// The same hazards on a standard map only cost health.
+		{"corridor.txt", "", 14, []api.Direction{api.Up, api.Down, api.Left}, 4},
+		// Through the tunnel across the wrapped edge, the walls on either
+		// side of it closed.
+		{"tunnel.txt", board.ArcadeMaze, 0, []api.Direction{api.Right}, 3},
+		{"tunnel.txt", "", 14, []api.Direction{api.Up, api.Down, api.Right}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture+"/"+tt.mapName, func(t *testing.T) {
+			game := loadMaze(t, tt.fixture, tt.mapName, tt.damage)
+			grid := board.GridFor(game)
+			if got := grid.ValidMoves(game.You.Head); !slices.Equal(got, tt.moves) {
+				t.Errorf("ValidMoves = %v, want %v", got, tt.moves)
+			}
+			path := grid.Path(game.You.Head, grid.IsFood)
+			if got := len(path); got != tt.path {
+				t.Errorf("Path to food takes %d moves (%v), want %d", got, path, tt.path)
+			}
+			if tt.mapName == board.ArcadeMaze {
+				checkAvoidsHazards(t, game, grid, game.You.Head, path)
+			}
+		})
+	}
+}
+
+// TestArcadeMazeTunnel crosses the full maze's tunnel row, which the walls
+// at both ends of its middle section leave as the only way across.
+func TestArcadeMazeTunnel(t *testing.T) {
+	game := bench.ArcadeMaze()
+	grid := board.GridFor(game)
+	from, to := api.Coord{X: 5, Y: 11}, api.Coord{X: 13, Y: 11}
+	path := grid.PathTo(from, to)
+	if len(path) != 11 {
+		t.Fatalf("PathTo(%v, %v) = %v, want 11 moves across the wrapped edge", from, to, path)
+	}
+	checkAvoidsHazards(t, game, grid, from, path)
+
+	// Every cell of the maze is a wall or open floor: no open cell is a
+	// hazard, and every hazard is a wall.
+	hazards := map[api.Coord]bool{}
+	for _, h := range game.Board.Hazards {
+		hazards[h] = true
+		if grid.IsValid(h) {
+			t.Errorf("hazard %v is passable", h)
+		}
+	}
+	for y := range game.Board.Height {
+		for x := range game.Board.Width {
+			pos := api.Coord{X: x, Y: y}
+			for _, d := range grid.ValidMoves(pos) {
+				if next := grid.Step(pos, d); hazards[next] {
+					t.Errorf("ValidMoves(%v) includes %v into the wall at %v", pos, d, next)
+				}
+			}
+		}
+	}
+}
+
+// checkAvoidsHazards fails t if path from pos enters a hazard.
+func checkAvoidsHazards(t *testing.T, game api.GameRequest, grid *board.Grid, pos api.Coord, path []api.Direction) {
+	t.Helper()
+	for _, d := range path {
+		pos = grid.Step(pos, d)
+		if slices.Contains(game.Board.Hazards, pos) {
+			t.Errorf("path %v enters the wall at %v", path, pos)
+			return
+		}
+	}
+}
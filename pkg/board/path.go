@@ -0,0 +1,246 @@
+package board
+
+import (
+	"math/bits"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+// Distances returns the length of the shortest path from pos to every cell,
+// indexed by y*Width+x, or -1 for cells that can't be reached. Paths pass
+// only through valid cells; pos itself needn't be valid.
+func (g *Grid) Distances(pos api.Coord) []int {
+	dist := g.Scratch.Ints(len(g.cells))
+	for i := range dist {
+		dist[i] = -1
+	}
+	if !g.InBounds(pos) {
+		return dist
+	}
+	dist[pos.Y*g.Width+pos.X] = 0
+	if g.layout != nil {
+		g.layerDistances(dist, pos, 0, false, -1)
+		return dist
+	}
+	queue := append(g.Scratch.Coords(len(g.cells))[:0], pos)
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, d := range api.Directions {
+			next := g.Step(cur, d)
+			if !g.IsValid(next) || dist[next.Y*g.Width+next.X] >= 0 {
+				continue
+			}
+			dist[next.Y*g.Width+next.X] = dist[cur.Y*g.Width+cur.X] + 1
+			queue = append(queue, next)
+		}
+	}
+	return dist
+}
+
+// layerDistances sets in dist the distances from pos, on a board small
+// enough for Bits, a layer of cells at a time: each step adds the cells
+// next to the last layer that are passable and not yet reached, which are
+// the cells a breadth-first search would reach at that distance. If timed,
+// snake segments turn passable once vacated, counting elapsed turns, as
+// TimedDistances. If to is a cell's index rather than -1, it stops there
+// and returns the cell's distance; it returns -1 if it doesn't reach to,
+// and dist may then be nil.
+func (g *Grid) layerDistances(dist []int, pos api.Coord, elapsed int, timed bool, to int) int {
+	l := g.layout
+	passable := g.open
+	// closed holds the segments not yet passable, while timed.
+	var closed []int
+	if timed {
+		closed = g.Scratch.Ints(len(g.cells))[:0]
+		for i, c := range g.cells {
+			if c&Snake != 0 && c&Wall == 0 {
+				closed = append(closed, i)
+			}
+		}
+	}
+	var reached, layer Bits
+	reached.Set(l.Index(pos))
+	if to >= 0 && reached.Has(to) {
+		return 0
+	}
+	layer = reached
+	for step := 1; !layer.IsZero(); step++ {
+		for j := 0; j < len(closed); {
+			if i := closed[j]; int(g.vacate[i]) <= elapsed+step {
+				passable.Set(i)
+				closed[j] = closed[len(closed)-1]
+				closed = closed[:len(closed)-1]
+				continue
+			}
+			j++
+		}
+		grown := l.step(&layer, &passable, g.Wrapped)
+		for w := 0; w < l.words; w++ {
+			layer[w] = grown[w] &^ reached[w]
+			reached[w] |= layer[w]
+			for fresh := layer[w]; fresh != 0 && dist != nil; fresh &= fresh - 1 {
+				dist[w<<6+bits.TrailingZeros64(fresh)] = step
+			}
+		}
+		if to >= 0 && layer.Has(to) {
+			return step
+		}
+	}
+	return -1
+}
+
+// Path returns the moves along a shortest path from pos to the nearest cell
+// for which goal is true, or nil if no such cell can be reached. Paths pass
+// only through valid cells.
+func (g *Grid) Path(pos api.Coord, goal func(api.Coord) bool) []api.Direction {
+	if !g.InBounds(pos) {
+		return nil
+	}
+	// from records the move that first reached each cell.
+	from := g.Scratch.Int8s(len(g.cells))
+	for i := range from {
+		from[i] = -1
+	}
+	start := pos.Y*g.Width + pos.X
+	queue := append(g.Scratch.Coords(len(g.cells))[:0], pos)
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, d := range api.Directions {
+			next := g.Step(cur, d)
+			i := next.Y*g.Width + next.X
+			if !g.IsValid(next) || i == start || from[i] >= 0 {
+				continue
+			}
+			from[i] = int8(d)
+			if goal(next) {
+				return g.backtrack(from, next, pos)
+			}
+			queue = append(queue, next)
+		}
+	}
+	return nil
+}
+
+// backtrack follows from back from end to start, returning the moves taken.
+func (g *Grid) backtrack(from []int8, end, start api.Coord) []api.Direction {
+	var path []api.Direction
+	for end != start {
+		d := api.Direction(from[end.Y*g.Width+end.X])
+		path = append(path, d)
+		end = g.Step(end, d.Opposite())
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// Reachable returns the number of cells reachable from pos through valid
+// cells for which passable is true, not counting pos itself. Passable may
+// be nil to allow every valid cell. The count stops early once it reaches
+// limit, unless limit is 0.
+func (g *Grid) Reachable(pos api.Coord, limit int, passable func(api.Coord) bool) int {
+	if !g.InBounds(pos) {
+		return 0
+	}
+	if g.layout == nil {
+		return g.reachableBFS(pos, limit, passable)
+	}
+	var admit func(i int) bool
+	if passable != nil {
+		admit = func(i int) bool { return passable(g.layout.Coord(i)) }
+	}
+	var seed Bits
+	seed.Set(g.layout.Index(pos))
+	through := g.open
+	through.Clear(g.layout.Index(pos))
+	_, n := g.layout.flood(seed, through, g.Wrapped, limit, admit)
+	return n
+}
+
+// reachableBFS is Reachable by breadth-first search, for boards too large
+// for Bits.
+func (g *Grid) reachableBFS(pos api.Coord, limit int, passable func(api.Coord) bool) int {
+	seen := g.Scratch.Bools(len(g.cells))
+	seen[pos.Y*g.Width+pos.X] = true
+	queue := append(g.Scratch.Coords(len(g.cells))[:0], pos)
+	count := 0
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, d := range api.Directions {
+			next := g.Step(cur, d)
+			if !g.IsValid(next) || seen[next.Y*g.Width+next.X] || passable != nil && !passable(next) {
+				continue
+			}
+			seen[next.Y*g.Width+next.X] = true
+			count++
+			if count == limit {
+				return count
+			}
+			queue = append(queue, next)
+		}
+	}
+	return count
+}
+
+// Components labels the connected regions of valid cells. It returns the
+// label of every cell, indexed by y*Width+x and -1 for invalid cells, and
+// the size of each labelled region.
+func (g *Grid) Components() (labels []int, sizes []int) {
+	labels = g.Scratch.Ints(len(g.cells))
+	for i := range labels {
+		labels[i] = -1
+	}
+	if g.layout == nil {
+		return g.componentsBFS(labels)
+	}
+	for rest := g.open; !rest.IsZero(); {
+		var seed Bits
+		seed.Set(rest.Lowest())
+		region, _ := g.layout.Flood(seed, rest, g.Wrapped, 0)
+		rest = rest.AndNot(region)
+		label := len(sizes)
+		sizes = append(sizes, region.Count())
+		for !region.IsZero() {
+			i := region.Lowest()
+			region.Clear(i)
+			labels[i] = label
+		}
+	}
+	return labels, sizes
+}
+
+// componentsBFS is Components by breadth-first search, for boards too
+// large for Bits.
+func (g *Grid) componentsBFS(labels []int) ([]int, []int) {
+	var sizes []int
+	queue := g.Scratch.Coords(len(g.cells))
+	for i := range labels {
+		pos := api.Coord{X: i % g.Width, Y: i / g.Width}
+		if labels[i] >= 0 || !g.IsValid(pos) {
+			continue
+		}
+		label := len(sizes)
+		labels[i] = label
+		size := 1
+		queue = append(queue[:0], pos)
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			for _, d := range api.Directions {
+				next := g.Step(cur, d)
+				if !g.IsValid(next) || labels[next.Y*g.Width+next.X] >= 0 {
+					continue
+				}
+				labels[next.Y*g.Width+next.X] = label
+				size++
+				queue = append(queue, next)
+			}
+		}
+		sizes = append(sizes, size)
+	}
+	return labels, sizes
+}
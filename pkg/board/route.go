@@ -0,0 +1,86 @@
+package board
+
+import "github.com/jayuuza/battlesnake/pkg/api"
+
+// Route is a path with the health projected at every step, so routes can
+// be compared by survivability rather than length alone.
+type Route struct {
+	Moves []api.Direction
+	// Health is the health projected after each move, counting starvation,
+	// hazard damage and healing, and food. It is 0 or less where the snake
+	// would die.
+	Health []int
+	// Eats counts the food picked up along the route and HazardSteps the
+	// moves onto damaging hazards.
+	Eats        int
+	HazardSteps int
+}
+
+// Project follows moves from pos for a snake with health, projecting its
+// health after each step as HealthPath does. Moves onto invalid cells are
+// projected as fatal.
+func (g *Grid) Project(pos api.Coord, health int, moves []api.Direction) Route {
+	r := Route{Moves: moves, Health: make([]int, len(moves))}
+	for i, d := range moves {
+		pos = g.Step(pos, d)
+		switch {
+		case !g.IsValid(pos):
+			health = 0
+		case g.IsFood(pos):
+			r.Eats++
+		case g.damageAt(pos, i+1) > 0:
+			r.HazardSteps++
+		}
+		if health > 0 {
+			health = g.stepHealth(pos, health, i+1)
+		}
+		r.Health[i] = health
+	}
+	return r
+}
+
+// Found reports whether the route leads anywhere.
+func (r Route) Found() bool {
+	return len(r.Moves) > 0
+}
+
+// Final returns the health projected at the end of the route, or 0 for an
+// empty route.
+func (r Route) Final() int {
+	if len(r.Health) == 0 {
+		return 0
+	}
+	return r.Health[len(r.Health)-1]
+}
+
+// Lowest returns the lowest health projected along the route, or 0 for an
+// empty route.
+func (r Route) Lowest() int {
+	if len(r.Health) == 0 {
+		return 0
+	}
+	lowest := r.Health[0]
+	for _, h := range r.Health[1:] {
+		lowest = min(lowest, h)
+	}
+	return lowest
+}
+
+// Survives reports whether the snake is projected to survive the whole
+// route.
+func (r Route) Survives() bool {
+	return r.Found() && r.Lowest() > 0
+}
+
+// Safer reports whether r is more survivable than o: it survives where o
+// doesn't, or keeps more health at its lowest point, or failing that is
+// shorter.
+func (r Route) Safer(o Route) bool {
+	switch {
+	case r.Survives() != o.Survives():
+		return r.Survives()
+	case r.Lowest() != o.Lowest():
+		return r.Lowest() > o.Lowest()
+	}
+	return len(r.Moves) < len(o.Moves)
+}
@@ -0,0 +1,80 @@
+package board
+
+import "github.com/jayuuza/battlesnake/pkg/api"
+
+// VacatedIn returns the number of turns until the snake segments on pos have
+// all moved away, if their snake doesn't eat meanwhile: 1 for a tail, which
+// may be entered next turn, counting up towards the head. A tail stacked by
+// eating stays a turn longer. It is 0 for cells without a segment.
+func (g *Grid) VacatedIn(pos api.Coord) int {
+	if !g.InBounds(pos) {
+		return 0
+	}
+	return int(g.vacate[pos.Y*g.Width+pos.X])
+}
+
+// passableAt reports whether the cell i can be entered at the given turn
+// from the grid's board: it is valid, or only snake segments that will have
+// moved away by then occupy it.
+func (g *Grid) passableAt(i, turn int) bool {
+	c := g.cells[i]
+	if c&Wall != 0 {
+		return false
+	}
+	return c&Snake == 0 || int(g.vacate[i]) <= turn
+}
+
+// TimedDistances returns the length of the shortest path from pos to every
+// cell as Distances, but through snake segments as well once they will have
+// moved away: a step that arrives on turn t, counting the elapsed turns
+// already played since the grid's board, may enter a segment vacated in at
+// most t turns. Paths to and along tails, our own included, are therefore
+// counted as they will play out rather than blocked.
+func (g *Grid) TimedDistances(pos api.Coord, elapsed int) []int {
+	dist := g.Scratch.Ints(len(g.cells))
+	for i := range dist {
+		dist[i] = -1
+	}
+	if !g.InBounds(pos) {
+		return dist
+	}
+	dist[pos.Y*g.Width+pos.X] = 0
+	if g.layout != nil {
+		g.layerDistances(dist, pos, elapsed, true, -1)
+		return dist
+	}
+	queue := append(g.Scratch.Coords(len(g.cells))[:0], pos)
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		step := dist[cur.Y*g.Width+cur.X] + 1
+		for _, d := range api.Directions {
+			next := g.Step(cur, d)
+			if !g.InBounds(next) {
+				continue
+			}
+			// A cell blocked now may open on a later step, so it is only
+			// marked once entered.
+			i := next.Y*g.Width + next.X
+			if dist[i] >= 0 || !g.passableAt(i, elapsed+step) {
+				continue
+			}
+			dist[i] = step
+			queue = append(queue, next)
+		}
+	}
+	return dist
+}
+
+// TimedDistance returns the length of the shortest path from pos to to as
+// TimedDistances, or -1 if there is none, searching only as far as it must
+// to reach to.
+func (g *Grid) TimedDistance(pos, to api.Coord, elapsed int) int {
+	if !g.InBounds(pos) || !g.InBounds(to) {
+		return -1
+	}
+	if g.layout == nil {
+		return g.TimedDistances(pos, elapsed)[to.Y*g.Width+to.X]
+	}
+	return g.layerDistances(nil, pos, elapsed, true, g.layout.Index(to))
+}
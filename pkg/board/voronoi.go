@@ -0,0 +1,117 @@
+package board
+
+import (
+	"math/bits"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+// Voronoi assigns each valid cell to the head that can reach it first
+// through valid cells. It returns the index into heads of every cell's
+// owner, indexed by y*Width+x, or -1 for cells that are unreachable or
+// reached first by more than one head at once.
+func (g *Grid) Voronoi(heads []api.Coord) []int {
+	owner := g.Scratch.Ints(len(g.cells))
+	for i := range owner {
+		owner[i] = -1
+	}
+	if g.layout != nil {
+		g.voronoiByLayer(owner, heads)
+		return owner
+	}
+	return g.voronoiBFS(owner, heads)
+}
+
+// voronoiByLayer sets owner as Voronoi does on a board small enough for
+// Bits, growing every head's region a layer at a time: a cell reached by
+// one head's layer alone joins its region, and one reached by several at
+// once is contested and extends no region.
+func (g *Grid) voronoiByLayer(owner []int, heads []api.Coord) {
+	l := g.layout
+	var buf [8]Bits
+	layers := buf[:0]
+	if len(heads) > len(buf) {
+		layers = make([]Bits, 0, len(heads))
+	}
+	var reached Bits
+	for i, head := range heads {
+		var layer Bits
+		if g.InBounds(head) {
+			j := l.Index(head)
+			owner[j] = i
+			layer.Set(j)
+			reached.Set(j)
+		}
+		layers = append(layers, layer)
+	}
+	for {
+		// grown and contested gather the cells each layer reaches.
+		var grown, contested Bits
+		for i := range layers {
+			if layers[i].IsZero() {
+				continue
+			}
+			next := l.step(&layers[i], &g.open, g.Wrapped)
+			for w := 0; w < l.words; w++ {
+				next[w] &^= reached[w]
+				contested[w] |= grown[w] & next[w]
+				grown[w] |= next[w]
+			}
+			layers[i] = next
+		}
+		if grown.IsZero() {
+			return
+		}
+		for i := range layers {
+			for w := 0; w < l.words; w++ {
+				layers[i][w] &^= contested[w]
+				for fresh := layers[i][w]; fresh != 0; fresh &= fresh - 1 {
+					owner[w<<6+bits.TrailingZeros64(fresh)] = i
+				}
+			}
+		}
+		reached = reached.Or(grown)
+	}
+}
+
+// voronoiBFS sets owner as Voronoi does by breadth-first search, for
+// boards too large for Bits.
+func (g *Grid) voronoiBFS(owner []int, heads []api.Coord) []int {
+	dist := g.Scratch.Ints(len(g.cells))
+	for i := range dist {
+		dist[i] = -1
+	}
+	queue := g.Scratch.Coords(len(g.cells))[:0]
+	for i, head := range heads {
+		if !g.InBounds(head) {
+			continue
+		}
+		j := head.Y*g.Width + head.X
+		owner[j], dist[j] = i, 0
+		queue = append(queue, head)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		c := cur.Y*g.Width + cur.X
+		if owner[c] < 0 {
+			// Contested cells don't extend anyone's region.
+			continue
+		}
+		for _, d := range api.Directions {
+			next := g.Step(cur, d)
+			if !g.IsValid(next) {
+				continue
+			}
+			n := next.Y*g.Width + next.X
+			switch {
+			case dist[n] < 0:
+				owner[n], dist[n] = owner[c], dist[c]+1
+				queue = append(queue, next)
+			case dist[n] == dist[c]+1 && owner[n] != owner[c]:
+				owner[n] = -1
+			}
+		}
+	}
+	return owner
+}
@@ -0,0 +1,277 @@
+// Package config reads the snake's configuration file, written in a subset
+// of TOML: [tables] and [[arrays of tables]] of key = value pairs, where a
+// value is a string, integer, float, boolean or a one-line array of them.
+//
+// Any value in a table may be overridden by an environment variable named
+// after it, BATTLESNAKE_<TABLE>_<KEY> in upper case with dashes and dots as
+// underscores, so a deployment can adjust a shared file.
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix prefixes the environment variables overriding the file.
+const EnvPrefix = "BATTLESNAKE_"
+
+// Table is a set of key = value pairs. Values are string, int64, float64,
+// bool or []any of those.
+type Table map[string]any
+
+// File is a parsed configuration file.
+type File struct {
+	// Tables holds the [tables] by name; keys before the first table are
+	// in the table "".
+	Tables map[string]Table
+	// Arrays holds the [[arrays of tables]] by name.
+	Arrays map[string][]Table
+}
+
+// Load parses the configuration file at path.
+func Load(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	file, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return file, nil
+}
+
+// Parse parses a configuration file.
+func Parse(r io.Reader) (*File, error) {
+	f := &File{Tables: map[string]Table{"": {}}, Arrays: map[string][]Table{}}
+	current := f.Tables[""]
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "[["):
+			name, ok := strings.CutSuffix(line[2:], "]]")
+			if !ok {
+				return nil, fmt.Errorf("line %d: unterminated array of tables", n)
+			}
+			name = strings.TrimSpace(name)
+			current = Table{}
+			f.Arrays[name] = append(f.Arrays[name], current)
+		case strings.HasPrefix(line, "["):
+			name, ok := strings.CutSuffix(line[1:], "]")
+			if !ok {
+				return nil, fmt.Errorf("line %d: unterminated table", n)
+			}
+			name = strings.TrimSpace(name)
+			if _, ok := f.Tables[name]; !ok {
+				f.Tables[name] = Table{}
+			}
+			current = f.Tables[name]
+		default:
+			key, raw, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, fmt.Errorf("line %d: want key = value", n)
+			}
+			key = unquoteKey(strings.TrimSpace(key))
+			value, err := parseValue(strings.TrimSpace(raw))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s: %v", n, key, err)
+			}
+			current[key] = value
+		}
+	}
+	return f, scanner.Err()
+}
+
+// stripComment drops a # comment from line, unless the # is quoted.
+func stripComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, c := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func unquoteKey(key string) string {
+	if s, err := parseValue(key); err == nil {
+		if s, ok := s.(string); ok {
+			return s
+		}
+	}
+	return key
+}
+
+func parseValue(raw string) (any, error) {
+	switch {
+	case raw == "":
+		return nil, fmt.Errorf("missing value")
+	case raw == "true":
+		return true, nil
+	case raw == "false":
+		return false, nil
+	case raw[0] == '"':
+		return strconv.Unquote(raw)
+	case raw[0] == '\'':
+		s, ok := strings.CutSuffix(raw[1:], "'")
+		if !ok || strings.Contains(s, "'") {
+			return nil, fmt.Errorf("bad literal string %s", raw)
+		}
+		return s, nil
+	case raw[0] == '[':
+		return parseArray(raw)
+	}
+	number := strings.ReplaceAll(raw, "_", "")
+	if i, err := strconv.ParseInt(number, 0, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(number, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("bad value %s", raw)
+}
+
+// parseArray parses a one-line array, whose elements may not themselves be
+// arrays.
+func parseArray(raw string) ([]any, error) {
+	inner, ok := strings.CutSuffix(raw[1:], "]")
+	if !ok {
+		return nil, fmt.Errorf("unterminated array %s", raw)
+	}
+	var values []any
+	for _, element := range splitArray(inner) {
+		element = strings.TrimSpace(element)
+		if element == "" {
+			continue
+		}
+		value, err := parseValue(element)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := value.([]any); ok {
+			return nil, fmt.Errorf("nested arrays are not supported")
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// splitArray splits the inside of an array at commas outside quotes.
+func splitArray(s string) []string {
+	var parts []string
+	var quote rune
+	escaped := false
+	start := 0
+	for i, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// Get returns the value of key in table as a string, as it would be given
+// on the command line, preferring its environment variable to the file.
+// Arrays are joined with commas.
+func (f *File) Get(table, key string) (string, bool) {
+	if v, ok := os.LookupEnv(EnvName(table, key)); ok {
+		return v, true
+	}
+	value, ok := f.Tables[table][key]
+	if !ok {
+		return "", false
+	}
+	return Format(value), true
+}
+
+// EnvName returns the environment variable overriding key in table.
+func EnvName(table, key string) string {
+	name := key
+	if table != "" {
+		name = table + "_" + key
+	}
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+// Format formats a value as it would be given on the command line.
+func Format(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []any:
+		parts := make([]string, len(v))
+		for i, e := range v {
+			parts[i] = Format(e)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+// String returns the string value of key, or "" if it is missing.
+func (t Table) String(key string) string {
+	if v, ok := t[key]; ok {
+		return Format(v)
+	}
+	return ""
+}
+
+// Float returns the numeric value of key, or def if it is missing or not
+// a number.
+func (t Table) Float(key string, def float64) float64 {
+	switch v := t[key].(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return def
+}
+
+// Strings returns the value of key as a list of strings.
+func (t Table) Strings(key string) []string {
+	switch v := t[key].(type) {
+	case []any:
+		s := make([]string, len(v))
+		for i, e := range v {
+			s[i] = Format(e)
+		}
+		return s
+	case nil:
+		return nil
+	default:
+		return []string{Format(v)}
+	}
+}
@@ -0,0 +1,172 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/config"
+)
+
+const document = `
+# Top-level keys are in the table "".
+strategy = "search"   # trailing comment
+port = 8_000
+
+[server]
+"data-dir" = 'C:\games'
+timeout = 0x1f4
+ratio = 1.5e-1
+debug = false
+hash = "#not a comment"
+quote = "say \"hi\" # still not"
+
+[server]
+tags = ["a, b", 'c', 3, true, ]
+
+[[snakes]]
+name = "alpha"
+
+[[ snakes ]]
+name = "beta"
+empty = []
+`
+
+func TestParse(t *testing.T) {
+	f, err := config.Parse(strings.NewReader(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &config.File{
+		Tables: map[string]config.Table{
+			"": {"strategy": "search", "port": int64(8000)},
+			"server": {
+				"data-dir": `C:\games`,
+				"timeout":  int64(500),
+				"ratio":    0.15,
+				"debug":    false,
+				"hash":     "#not a comment",
+				"quote":    `say "hi" # still not`,
+				// A table named again is added to.
+				"tags": []any{"a, b", "c", int64(3), true},
+			},
+		},
+		Arrays: map[string][]config.Table{
+			"snakes": {{"name": "alpha"}, {"name": "beta", "empty": []any(nil)}},
+		},
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("parsed\n%#v\nwant\n%#v", f, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"no value", "[t]\nkey =", "line 2: key: missing value"},
+		{"no equals", "a = 1\njust words", "line 2: want key = value"},
+		{"unterminated table", "[table", "line 1: unterminated table"},
+		{"unterminated array of tables", "[[snakes]", "line 1: unterminated array of tables"},
+		{"unterminated string", `s = "open`, "line 1: s:"},
+		{"bad literal string", "s = 'it's'", "line 1: s: bad literal string"},
+		{"bad value", "n = 12abc", "line 1: n: bad value 12abc"},
+		{"unterminated array", "a = [1, 2", "line 1: a: unterminated array"},
+		{"nested array", "a = [[1], 2]", "line 1: a: "},
+		{"bad element", "a = [1, x]", "line 1: a: bad value x"},
+		{"comment in open string", `s = "a # b`, "line 1: s:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := config.Parse(strings.NewReader(tt.doc))
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snake.toml")
+	if err := os.WriteFile(path, []byte("[server]\nport = 9000\n[oops\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Load(path); err == nil || !strings.HasPrefix(err.Error(), path+": line 3:") {
+		t.Errorf("error %v doesn't name the file and line", err)
+	}
+	if _, err := config.Load(filepath.Join(dir, "missing.toml")); !os.IsNotExist(err) {
+		t.Errorf("loading a missing file: %v", err)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := []struct{ table, key, want string }{
+		{"", "strategy", "BATTLESNAKE_STRATEGY"},
+		{"server", "port", "BATTLESNAKE_SERVER_PORT"},
+		{"server", "data-dir", "BATTLESNAKE_SERVER_DATA_DIR"},
+		{"search.table", "size", "BATTLESNAKE_SEARCH_TABLE_SIZE"},
+	}
+	for _, tt := range tests {
+		if got := config.EnvName(tt.table, tt.key); got != tt.want {
+			t.Errorf("EnvName(%q, %q) = %s, want %s", tt.table, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	f, err := config.Parse(strings.NewReader(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BATTLESNAKE_SERVER_DATA_DIR", "/var/games")
+	// Set but empty still overrides the file.
+	t.Setenv("BATTLESNAKE_STRATEGY", "")
+	t.Setenv("BATTLESNAKE_SERVER_VERBOSE", "true")
+	tests := []struct {
+		table, key string
+		want       string
+		ok         bool
+	}{
+		{"server", "data-dir", "/var/games", true},
+		{"", "strategy", "", true},
+		{"server", "verbose", "true", true},
+		{"", "port", "8000", true},
+		{"server", "ratio", "0.15", true},
+		{"server", "tags", "a, b,c,3,true", true},
+		{"server", "missing", "", false},
+		{"nowhere", "port", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := f.Get(tt.table, tt.key)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Get(%q, %q) = %q, %v, want %q, %v", tt.table, tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestTable(t *testing.T) {
+	table := config.Table{"name": "alpha", "weight": int64(2), "bias": 0.5, "tags": []any{"x", int64(1)}}
+	if got := table.String("weight"); got != "2" {
+		t.Errorf("String(weight) = %q", got)
+	}
+	if got := table.String("missing"); got != "" {
+		t.Errorf("String(missing) = %q", got)
+	}
+	if got := table.Float("weight", 0) + table.Float("bias", 0) + table.Float("name", 10); got != 12.5 {
+		t.Errorf("Floats sum to %v, want 12.5", got)
+	}
+	if got := table.Strings("tags"); !reflect.DeepEqual(got, []string{"x", "1"}) {
+		t.Errorf("Strings(tags) = %q", got)
+	}
+	if got := table.Strings("name"); !reflect.DeepEqual(got, []string{"alpha"}) {
+		t.Errorf("Strings(name) = %q", got)
+	}
+	if got := table.Strings("missing"); got != nil {
+		t.Errorf("Strings(missing) = %q", got)
+	}
+}
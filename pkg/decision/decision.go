@@ -0,0 +1,190 @@
+// Package decision defines decision traces: what the snake saw, the moves
+// it considered with their scores, the move it chose and how long it took,
+// one per move. Traces have a versioned JSON schema of their own, separate
+// from the structs the server and strategies use internally, so that
+// notebooks and other tools reading them keep working as those change.
+//
+// Within a version fields are only ever added, never renamed, removed or
+// given another meaning; any other change is a new version, with its own
+// schema. Every trace names the schema it follows.
+package decision
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/eval"
+)
+
+// Version is the version of the schema this package writes.
+const Version = 1
+
+// schemaPrefix names the schema of a trace, followed by its version.
+const schemaPrefix = "battlesnake-decision/"
+
+// SchemaName is the schema written to the traces of Version.
+var SchemaName = schemaPrefix + strconv.Itoa(Version)
+
+// Schema is the JSON Schema of the traces of Version.
+//
+//go:embed schema-v1.json
+var Schema []byte
+
+// Trace is one decision.
+type Trace struct {
+	// Schema names the schema and version the trace follows.
+	Schema   string    `json:"schema"`
+	GameID   string    `json:"gameId"`
+	Turn     int       `json:"turn"`
+	Time     time.Time `json:"time"`
+	Strategy string    `json:"strategy"`
+	Ruleset  string    `json:"ruleset"`
+	Map      string    `json:"map"`
+	Board    Board     `json:"board"`
+	// Candidates are the four moves, whether legal or not.
+	Candidates []Candidate `json:"candidates"`
+	Chosen     string      `json:"chosen"`
+	// Plan describes what the strategy was playing for, if it said.
+	Plan   string `json:"plan,omitempty"`
+	Timing Timing `json:"timing"`
+}
+
+// Board is the position decided on.
+type Board struct {
+	Width   int     `json:"width"`
+	Height  int     `json:"height"`
+	Food    []Point `json:"food"`
+	Hazards []Point `json:"hazards"`
+	Snakes  []Snake `json:"snakes"`
+}
+
+// Point is a cell of the board, (0, 0) being bottom left.
+type Point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// Snake is a snake on the board.
+type Snake struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Health int     `json:"health"`
+	Length int     `json:"length"`
+	Body   []Point `json:"body"`
+	// You marks our snake.
+	You bool `json:"you"`
+}
+
+// Candidate is a move considered.
+type Candidate struct {
+	Move string `json:"move"`
+	// Legal reports whether the move doesn't immediately leave the board or
+	// run into a snake.
+	Legal bool `json:"legal"`
+	// Score is the move's evaluation, the sum of Terms, and Terms the
+	// weighted score of each evaluation term, with the configured weights
+	// and before any scaling the strategy applied to counter an opponent
+	// or in panic. Illegal moves aren't evaluated.
+	Score float64            `json:"score"`
+	Terms map[string]float64 `json:"terms,omitempty"`
+}
+
+// Timing is how long the decision took, in milliseconds.
+type Timing struct {
+	// TimeoutMs is the game's timeout, and BudgetMs the share of it the
+	// strategy was given.
+	TimeoutMs int     `json:"timeoutMs"`
+	BudgetMs  float64 `json:"budgetMs"`
+	// DecideMs is the time the strategy took, and TotalMs the time from
+	// the request's arrival to the response.
+	DecideMs float64 `json:"decideMs"`
+	TotalMs  float64 `json:"totalMs"`
+}
+
+// New returns the trace of choosing chosen in game, evaluating every legal
+// candidate.
+func New(game api.GameRequest, strategyName string, chosen api.Direction, plan string, timing Timing) Trace {
+	t := Trace{
+		Schema:   SchemaName,
+		GameID:   game.Game.ID,
+		Turn:     game.Turn,
+		Time:     time.Now().UTC(),
+		Strategy: strategyName,
+		Ruleset:  game.Game.Ruleset.Name,
+		Map:      game.Game.Map,
+		Board: Board{
+			Width:   game.Board.Width,
+			Height:  game.Board.Height,
+			Food:    points(game.Board.Food),
+			Hazards: points(game.Board.Hazards),
+			Snakes:  []Snake{},
+		},
+		Chosen: chosen.String(),
+		Plan:   plan,
+		Timing: timing,
+	}
+	for _, snake := range game.Board.Snakes {
+		t.Board.Snakes = append(t.Board.Snakes, Snake{
+			ID:     snake.ID,
+			Name:   snake.Name,
+			Health: int(snake.Health),
+			Length: int(snake.Length),
+			Body:   points(snake.Body),
+			You:    snake.ID == game.You.ID,
+		})
+	}
+
+	grid := board.GridFor(game)
+	legal := map[api.Direction]bool{}
+	for _, d := range grid.ValidMoves(game.You.Head) {
+		legal[d] = true
+	}
+	cache := eval.NewCache(game, grid)
+	for _, d := range api.Directions {
+		c := Candidate{Move: d.String(), Legal: legal[d]}
+		if c.Legal {
+			c.Terms = eval.Breakdown(eval.NewPosition(cache, d))
+			for _, score := range c.Terms {
+				c.Score += score
+			}
+		}
+		t.Candidates = append(t.Candidates, c)
+	}
+	return t
+}
+
+func points(coords []api.Coord) []Point {
+	ps := make([]Point, len(coords))
+	for i, c := range coords {
+		ps[i] = Point{X: c.X, Y: c.Y}
+	}
+	return ps
+}
+
+// Read reads traces written one per line, refusing those of a schema or
+// version this package doesn't know.
+func Read(r io.Reader) ([]Trace, error) {
+	var traces []Trace
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var t Trace
+		if err := json.Unmarshal(scanner.Bytes(), &t); err != nil {
+			return nil, fmt.Errorf("decision: line %d: %v", line, err)
+		}
+		version, ok := strings.CutPrefix(t.Schema, schemaPrefix)
+		if n, err := strconv.Atoi(version); !ok || err != nil || n < 1 || n > Version {
+			return nil, fmt.Errorf("decision: line %d: unsupported schema %q", line, t.Schema)
+		}
+		traces = append(traces, t)
+	}
+	return traces, scanner.Err()
+}
@@ -0,0 +1,146 @@
+package decision
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Record is a flat row of a trace, one per candidate move, for tools that
+// want a table rather than nested objects. Its columns, like the trace's
+// fields, are only ever added to within a version.
+type Record struct {
+	GameID    string
+	Turn      int
+	Time      time.Time
+	Strategy  string
+	Ruleset   string
+	Map       string
+	Width     int
+	Height    int
+	Snakes    int
+	Health    int
+	Length    int
+	Move      string
+	Legal     bool
+	Score     float64
+	Chosen    bool
+	Plan      string
+	TimeoutMs int
+	BudgetMs  float64
+	DecideMs  float64
+	TotalMs   float64
+	// Terms are the candidate's term scores, as columns named "term_"
+	// followed by the term's name.
+	Terms map[string]float64
+}
+
+// recordColumns are the columns of a Record before its terms.
+var recordColumns = []string{
+	"game_id", "turn", "time", "strategy", "ruleset", "map", "width", "height", "snakes",
+	"health", "length", "move", "legal", "score", "chosen", "plan",
+	"timeout_ms", "budget_ms", "decide_ms", "total_ms",
+}
+
+// Flatten returns a record of each of t's candidates.
+func Flatten(t Trace) []Record {
+	var you Snake
+	for _, snake := range t.Board.Snakes {
+		if snake.You {
+			you = snake
+		}
+	}
+	records := make([]Record, 0, len(t.Candidates))
+	for _, c := range t.Candidates {
+		records = append(records, Record{
+			GameID:    t.GameID,
+			Turn:      t.Turn,
+			Time:      t.Time,
+			Strategy:  t.Strategy,
+			Ruleset:   t.Ruleset,
+			Map:       t.Map,
+			Width:     t.Board.Width,
+			Height:    t.Board.Height,
+			Snakes:    len(t.Board.Snakes),
+			Health:    you.Health,
+			Length:    you.Length,
+			Move:      c.Move,
+			Legal:     c.Legal,
+			Score:     c.Score,
+			Chosen:    c.Move == t.Chosen,
+			Plan:      t.Plan,
+			TimeoutMs: t.Timing.TimeoutMs,
+			BudgetMs:  t.Timing.BudgetMs,
+			DecideMs:  t.Timing.DecideMs,
+			TotalMs:   t.Timing.TotalMs,
+			Terms:     c.Terms,
+		})
+	}
+	return records
+}
+
+// values returns the record's columns by name, the terms named after terms.
+func (r Record) values() map[string]any {
+	v := map[string]any{
+		"game_id": r.GameID, "turn": r.Turn, "time": r.Time, "strategy": r.Strategy,
+		"ruleset": r.Ruleset, "map": r.Map, "width": r.Width, "height": r.Height,
+		"snakes": r.Snakes, "health": r.Health, "length": r.Length, "move": r.Move,
+		"legal": r.Legal, "score": r.Score, "chosen": r.Chosen, "plan": r.Plan,
+		"timeout_ms": r.TimeoutMs, "budget_ms": r.BudgetMs, "decide_ms": r.DecideMs, "total_ms": r.TotalMs,
+	}
+	for name, score := range r.Terms {
+		v["term_"+name] = score
+	}
+	return v
+}
+
+// MarshalJSON encodes the record as one flat object with the columns of
+// WriteCSV.
+func (r Record) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.values())
+}
+
+// WriteCSV writes records as CSV with a header row: the fixed columns, then
+// a column for every term any record scores, sorted by name. Terms a
+// record doesn't score are left empty.
+func WriteCSV(w io.Writer, records []Record) error {
+	terms := map[string]bool{}
+	for _, r := range records {
+		for name := range r.Terms {
+			terms["term_"+name] = true
+		}
+	}
+	columns := append(slices.Clone(recordColumns), slices.Sorted(maps.Keys(terms))...)
+	out := csv.NewWriter(w)
+	if err := out.Write(columns); err != nil {
+		return err
+	}
+	row := make([]string, len(columns))
+	for _, r := range records {
+		values := r.values()
+		for i, column := range columns {
+			row[i] = ""
+			switch v := values[column].(type) {
+			case string:
+				row[i] = v
+			case int:
+				row[i] = strconv.Itoa(v)
+			case float64:
+				row[i] = strconv.FormatFloat(v, 'g', -1, 64)
+			case bool:
+				row[i] = strconv.FormatBool(v)
+			case time.Time:
+				row[i] = v.Format(time.RFC3339Nano)
+			}
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,49 @@
+package decision
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jayuuza/battlesnake/pkg/gamedir"
+)
+
+// FileName is the name of the file of a game's traces within its directory
+// of the data directory.
+const FileName = "decisions.jsonl"
+
+// Recorder appends traces to a file per game under Dir.
+type Recorder struct {
+	Dir string
+
+	mu sync.Mutex
+}
+
+// Path returns the traces file of gameID, in its directory of Dir.
+func (r *Recorder) Path(gameID string) string {
+	return filepath.Join(r.Dir, gamedir.Name(gameID), FileName)
+}
+
+// Record appends t to the traces of its game.
+func (r *Recorder) Record(t Trace) error {
+	line, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(r.Path(t.GameID)), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.Path(t.GameID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,30 @@
+package decision
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordStaysInDir(t *testing.T) {
+	for _, gameID := range []string{"g1", "../../escaped", "a/b", ".."} {
+		dir := filepath.Join(t.TempDir(), "games")
+		r := &Recorder{Dir: dir}
+		if err := r.Record(Trace{Schema: SchemaName, GameID: gameID, Turn: 2}); err != nil {
+			t.Fatalf("%q: %v", gameID, err)
+		}
+		path := r.Path(gameID)
+		if filepath.Dir(filepath.Dir(path)) != dir {
+			t.Errorf("Path(%q) = %q, outside %q", gameID, path, dir)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("%q: %v", gameID, err)
+		}
+		traces, err := Read(f)
+		f.Close()
+		if err != nil || len(traces) != 1 || traces[0].GameID != gameID {
+			t.Errorf("%q: read %+v, %v; want the recorded trace", gameID, traces, err)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package eval
+
+func init() {
+	Terms = append(Terms, Term{Name: "bait", Weight: 3, Score: Bait})
+}
+
+// Bait penalizes eating food near the head of an enemy that would still be
+// at least as long as us afterwards (board.DangerMap.Bait): fully if the
+// enemy can take the food with us, by half if it is a move further and
+// could meet our head the turn after.
+func Bait(p *Position) float64 {
+	if !p.Grid.IsFood(p.Head) {
+		return 0
+	}
+	switch p.Danger().Bait(p.Head) {
+	case 1:
+		return -1
+	case 2:
+		return -0.5
+	}
+	return 0
+}
@@ -0,0 +1,193 @@
+package eval
+
+import (
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+)
+
+// Cache holds analyses of one request's board, computed on first use and
+// shared by every term and candidate move. It is not safe for concurrent
+// use.
+type Cache struct {
+	Game api.GameRequest
+	Grid *board.Grid
+
+	danger     *board.DangerMap
+	labels     []int
+	sizes      []int
+	voronoi    []int
+	distances  map[api.Coord][]int
+	timed      map[api.Coord][]int
+	nearest    map[api.Coord]int
+	safeReach  map[safeReachKey]int
+	starvation *starvationResult
+	race       *Race
+	plan       *RacePlan
+	target     *int
+	// deniedBefore is the count Cache.targetDenied returns.
+	deniedBefore *int
+	traps        map[api.Direction]bool
+}
+
+type safeReachKey struct {
+	pos, avoid api.Coord
+}
+
+type starvationResult struct {
+	turns int
+	ok    bool
+}
+
+// NewCache returns an empty cache for game, whose occupancy grid is grid.
+func NewCache(game api.GameRequest, grid *board.Grid) *Cache {
+	return &Cache{Game: game, Grid: grid}
+}
+
+// Danger returns the danger map for our snake.
+func (c *Cache) Danger() *board.DangerMap {
+	if c.danger == nil {
+		c.danger = board.NewDangerMap(c.Game)
+	}
+	return c.danger
+}
+
+// Reachable returns the number of cells reachable from pos through valid
+// cells, not counting pos itself.
+func (c *Cache) Reachable(pos api.Coord) int {
+	if c.labels == nil {
+		c.labels, c.sizes = c.Grid.Components()
+	}
+	if c.Grid.IsValid(pos) {
+		return c.sizes[c.labels[pos.Y*c.Grid.Width+pos.X]] - 1
+	}
+	// An occupied cell reaches the regions of its free neighbors.
+	total := 0
+	var seen []int
+outer:
+	for _, d := range api.Directions {
+		next := c.Grid.Step(pos, d)
+		if !c.Grid.IsValid(next) {
+			continue
+		}
+		label := c.labels[next.Y*c.Grid.Width+next.X]
+		for _, l := range seen {
+			if l == label {
+				continue outer
+			}
+		}
+		seen = append(seen, label)
+		total += c.sizes[label]
+	}
+	return total
+}
+
+// SafeReachable returns the number of cells, up to limit, reachable from
+// pos through valid cells that no equal or longer enemy can move to next
+// turn, never passing through avoid.
+func (c *Cache) SafeReachable(pos, avoid api.Coord, limit int) int {
+	key := safeReachKey{pos, avoid}
+	if n, ok := c.safeReach[key]; ok {
+		return n
+	}
+	danger := c.Danger()
+	n := c.Grid.Reachable(pos, limit, func(p api.Coord) bool { return p != avoid && !danger.IsLethal(p) })
+	if c.safeReach == nil {
+		c.safeReach = map[safeReachKey]int{}
+	}
+	c.safeReach[key] = n
+	return n
+}
+
+// Distances returns the shortest path lengths from pos, as Grid.Distances.
+func (c *Cache) Distances(pos api.Coord) []int {
+	if dist, ok := c.distances[pos]; ok {
+		return dist
+	}
+	dist := c.Grid.Distances(pos)
+	if c.distances == nil {
+		c.distances = map[api.Coord][]int{}
+	}
+	c.distances[pos] = dist
+	return dist
+}
+
+// TimedDistances returns the shortest path lengths from pos a turn after
+// the cached board, as from a candidate move's head, through segments that
+// will have moved away as Grid.TimedDistances.
+func (c *Cache) TimedDistances(pos api.Coord) []int {
+	if dist, ok := c.timed[pos]; ok {
+		return dist
+	}
+	dist := c.Grid.TimedDistances(pos, 1)
+	if c.timed == nil {
+		c.timed = map[api.Coord][]int{}
+	}
+	c.timed[pos] = dist
+	return dist
+}
+
+// TimedDistance returns the shortest path length from pos to to a turn
+// after the cached board, as TimedDistances, using their distances if
+// they have been found already and otherwise searching only until to.
+func (c *Cache) TimedDistance(pos, to api.Coord) int {
+	if dist, ok := c.timed[pos]; ok {
+		return dist[to.Y*c.Grid.Width+to.X]
+	}
+	return c.Grid.TimedDistance(pos, to, 1)
+}
+
+// NearestFood returns the length of the shortest path from pos to food, or
+// -1 if none can be reached.
+func (c *Cache) NearestFood(pos api.Coord) int {
+	if d, ok := c.nearest[pos]; ok {
+		return d
+	}
+	nearest := -1
+	for i, d := range c.Distances(pos) {
+		if d >= 0 && (nearest < 0 || d < nearest) && c.Grid.IsFood(api.Coord{X: i % c.Grid.Width, Y: i / c.Grid.Width}) {
+			nearest = d
+		}
+	}
+	if c.nearest == nil {
+		c.nearest = map[api.Coord]int{}
+	}
+	c.nearest[pos] = nearest
+	return nearest
+}
+
+// Voronoi returns the owner of every cell as Grid.Voronoi, indexing the
+// board's snakes.
+func (c *Cache) Voronoi() []int {
+	if c.voronoi == nil {
+		heads := make([]api.Coord, len(c.Game.Board.Snakes))
+		for i, snake := range c.Game.Board.Snakes {
+			heads[i] = snake.Head
+		}
+		c.voronoi = c.Grid.Voronoi(heads)
+	}
+	return c.voronoi
+}
+
+// Starvation returns the number of turns until the last opponent starves,
+// and whether every opponent will starve before it can reach food.
+func (c *Cache) Starvation() (int, bool) {
+	if c.starvation == nil {
+		c.starvation = c.starve()
+	}
+	return c.starvation.turns, c.starvation.ok
+}
+
+func (c *Cache) starve() *starvationResult {
+	r := &starvationResult{}
+	for _, snake := range c.Game.Board.Snakes {
+		if snake.ID == c.Game.You.ID {
+			continue
+		}
+		if d := c.NearestFood(snake.Head); d >= 0 && d <= int(snake.Health) {
+			return &starvationResult{}
+		}
+		r.turns = max(r.turns, int(snake.Health))
+		r.ok = true
+	}
+	return r
+}
@@ -0,0 +1,42 @@
+package eval
+
+import "github.com/jayuuza/battlesnake/pkg/board"
+
+func init() {
+	Terms = append(Terms, Term{Name: "center", Weight: 1, Score: Center})
+}
+
+// centerPhaseTurns is the turn by which center control reaches full weight;
+// before it, food matters more than position.
+const centerPhaseTurns = 50
+
+// Center prefers heads nearer the middle of the board, where there are the
+// most options and hazards arrive last in royale. It grows with the game's
+// phase, counts double in royale and doesn't apply on wrapped boards, which
+// have no middle.
+func Center(p *Position) float64 {
+	game := p.Game
+	if board.IsWrapped(game.Game.Ruleset.Name) {
+		return 0
+	}
+	width, height := game.Board.Width, game.Board.Height
+	if width+height <= 2 {
+		return 0
+	}
+	// Doubling the coordinates keeps the center of even boards exact.
+	dist := abs(2*p.Head.X-(width-1)) + abs(2*p.Head.Y-(height-1))
+	score := 1 - float64(dist)/float64(width-1+height-1)
+
+	scale := 0.5 + 0.5*min(float64(game.Turn)/centerPhaseTurns, 1)
+	if game.Game.Ruleset.Name == "royale" {
+		scale *= 2
+	}
+	return score * scale
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,134 @@
+package eval
+
+import "github.com/jayuuza/battlesnake/pkg/api"
+
+// Race sizes up the length race in a duel: who reaches which food first,
+// and whether we can end up longer than the opponent before the board
+// fills.
+type Race struct {
+	// Opponent is the other snake.
+	Opponent api.Battlesnake
+	// OurFood and TheirFood count the food each snake reaches strictly
+	// first.
+	OurFood   int
+	TheirFood int
+	// OurLength and TheirLength are the lengths projected once each snake
+	// has eaten the food it reaches first.
+	OurLength   int
+	TheirLength int
+	// Territory is the number of free cells we reach first.
+	Territory int
+	// Starving is set if we would starve before reaching any of our food.
+	Starving bool
+	// CanOutLength is set if racing for food should leave us longer than
+	// the opponent with room to spare for our grown body.
+	CanOutLength bool
+}
+
+// Race returns the length race between us and the only opponent, or nil if
+// the game isn't a duel.
+func (c *Cache) Race() *Race {
+	if c.race != nil || len(c.Game.Board.Snakes) != 2 {
+		return c.race
+	}
+	us, them := 0, 1
+	if c.Game.Board.Snakes[0].ID != c.Game.You.ID {
+		us, them = 1, 0
+	}
+	owner := c.Voronoi()
+	r := &Race{Opponent: c.Game.Board.Snakes[them]}
+	for _, o := range owner {
+		if o == us {
+			r.Territory++
+		}
+	}
+	dist := c.Distances(c.Game.You.Head)
+	nearest := -1
+	for _, f := range c.Game.Board.Food {
+		if !c.Grid.InBounds(f) {
+			continue
+		}
+		i := f.Y*c.Grid.Width + f.X
+		switch owner[i] {
+		case us:
+			r.OurFood++
+			if nearest < 0 || dist[i] < nearest {
+				nearest = dist[i]
+			}
+		case them:
+			r.TheirFood++
+		}
+	}
+	r.OurLength = int(c.Game.You.Length) + r.OurFood
+	r.TheirLength = int(r.Opponent.Length) + r.TheirFood
+	r.Starving = nearest < 0 || nearest > int(c.Game.You.Health)
+	r.CanOutLength = r.OurLength > r.TheirLength && !r.Starving && r.Territory >= r.OurLength
+	c.race = r
+	return r
+}
+
+// Duel scores a move in a one-on-one game according to the length race:
+// while we can out-length the opponent it races for the food we reach
+// first, otherwise it squeezes them by claiming as much of the board as
+// possible. Late in the duel the race is planned out by Endgame instead.
+// It scores 0 outside duels.
+func Duel(p *Position) float64 {
+	r := p.Race()
+	if r == nil {
+		return 0
+	}
+	if p.Endgame() {
+		return Endgame(p)
+	}
+	if r.CanOutLength {
+		return RaceForLength(p)
+	}
+	return Squeeze(p)
+}
+
+// RaceForLength draws us to the nearest food we reach before the opponent.
+func RaceForLength(p *Position) float64 {
+	if p.Grid.IsFood(p.Head) {
+		return 1
+	}
+	them := p.Race().Opponent.Head
+	ours := p.Distances(p.Head)
+	theirs := p.Distances(them)
+	nearest := -1
+	for _, f := range p.Game.Board.Food {
+		if !p.Grid.InBounds(f) {
+			continue
+		}
+		i := f.Y*p.Grid.Width + f.X
+		if ours[i] >= 0 && (theirs[i] < 0 || ours[i] < theirs[i]) && (nearest < 0 || ours[i] < nearest) {
+			nearest = ours[i]
+		}
+	}
+	if nearest < 0 {
+		return 0
+	}
+	return 1 - float64(nearest)/float64(p.Grid.Width+p.Grid.Height)
+}
+
+// Squeeze prefers moves that leave us reaching more of the board before
+// the opponent. A move the opponent could meet head-on and win claims
+// nothing: the board we would reach first is only ours if we survive.
+func Squeeze(p *Position) float64 {
+	if p.Danger().IsLethal(p.Head) {
+		return 0
+	}
+	owner := p.Grid.Voronoi([]api.Coord{p.Head, p.Race().Opponent.Head})
+	ours, total := 0, 0
+	for _, o := range owner {
+		if o >= 0 {
+			total++
+		}
+		if o == 0 {
+			ours++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(ours) / float64(total)
+}
@@ -0,0 +1,56 @@
+package eval_test
+
+import (
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/eval"
+)
+
+// parse reads the hand-drawn position s and caches it for evaluation.
+func parse(t *testing.T, s string) *eval.Cache {
+	t.Helper()
+	game, err := board.ParseASCII(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return eval.NewCache(game, board.GridFor(game))
+}
+
+func TestSqueeze(t *testing.T) {
+	// The longer opponent's head is two cells to our right.
+	cache := parse(t, `
+. . . . . . . . . . .
+. . . . . . . . . . .
+. . . . . . . . . . .
+. . . . . . . . . . .
+. . . . . . . . . . .
+. . . a A . B b b b b
+. . . . . . . . . . .
+. . . . . . . . . . .
+. . . . . . . . . . .
+. . . . . . . . . . .
+. . . . . . . . . . .
+`)
+	tests := []struct {
+		move api.Direction
+		// lethal is set if the opponent could take our new head's cell.
+		lethal bool
+	}{
+		{api.Right, true},
+		{api.Up, false},
+		{api.Down, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.move.String(), func(t *testing.T) {
+			score := eval.Squeeze(eval.NewPosition(cache, tt.move))
+			switch {
+			case tt.lethal && score != 0:
+				t.Errorf("Squeeze = %.3f into a cell the opponent can take, want 0", score)
+			case !tt.lethal && (score <= 0 || score > 1):
+				t.Errorf("Squeeze = %.3f, want in (0, 1]", score)
+			}
+		})
+	}
+}
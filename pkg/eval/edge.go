@@ -0,0 +1,40 @@
+package eval
+
+import "github.com/jayuuza/battlesnake/pkg/board"
+
+func init() {
+	Terms = append(Terms, Term{Name: "edge", Weight: 1, Score: Edge})
+}
+
+// pinRange is how close, in moves, an enemy head must be to pin us against
+// a wall.
+const pinRange = 3
+
+// Edge penalizes heads on the edge of the board, and corners doubly, since
+// a wall halves our options and an enemy alongside can pin us to it. The
+// penalty grows by half for every enemy head within pinRange. Wrapped
+// boards have no edges.
+func Edge(p *Position) float64 {
+	game := p.Game
+	if board.IsWrapped(game.Game.Ruleset.Name) {
+		return 0
+	}
+	walls := 0
+	if p.Head.X == 0 || p.Head.X == game.Board.Width-1 {
+		walls++
+	}
+	if p.Head.Y == 0 || p.Head.Y == game.Board.Height-1 {
+		walls++
+	}
+	if walls == 0 {
+		return 0
+	}
+
+	enemies := 0
+	for _, snake := range game.Board.Snakes {
+		if snake.ID != game.You.ID && board.Manhattan(snake.Head, p.Head) <= pinRange {
+			enemies++
+		}
+	}
+	return -0.5 * float64(walls) * (1 + 0.5*float64(enemies))
+}
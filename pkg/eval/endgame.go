@@ -0,0 +1,150 @@
+package eval
+
+import "github.com/jayuuza/battlesnake/pkg/api"
+
+// endgameFill is the share of the board, as one over endgameFill, the two
+// snakes' bodies must fill for a duel to be played as an endgame.
+const endgameFill = 3
+
+// RacePlan is the length race of a late duel played out food by food: each
+// snake eats the food it reaches first, nearest first, until the snakes are
+// forced head to head, and whoever is longer then wins the meeting.
+type RacePlan struct {
+	// Horizon is the number of turns until the snakes are forced head to
+	// head: the cells we reach first, after which we have to enter the
+	// opponent's.
+	Horizon int
+	// Ours and Theirs are the turns at which each snake eats the food it
+	// reaches first, in order, as far as its health lasts.
+	Ours   []int
+	Theirs []int
+	// Target is the first food on our route, if Ours isn't empty.
+	Target api.Coord
+	// Grow is set if eating our food leaves us longer at the horizon while
+	// holding back wouldn't, so the plan is to race for it. Otherwise the
+	// plan is to contain the opponent.
+	Grow bool
+}
+
+// Lead returns how much longer we are than the opponent at the horizon,
+// eating our food or not.
+func (p *RacePlan) Lead(you, opponent api.Battlesnake, grow bool) int {
+	theirs := int(opponent.Length) + eatenBy(p.Theirs, p.Horizon)
+	ours := int(you.Length)
+	if grow {
+		ours += eatenBy(p.Ours, p.Horizon)
+	}
+	return ours - theirs
+}
+
+// Endgame reports whether the game is a duel late enough that the length
+// race is played out by RacePlan.
+func (c *Cache) Endgame() bool {
+	snakes := c.Game.Board.Snakes
+	if len(snakes) != 2 {
+		return false
+	}
+	filled := int(snakes[0].Length + snakes[1].Length)
+	return filled*endgameFill >= c.Grid.Width*c.Grid.Height
+}
+
+// RacePlan returns the length race of the late duel, or nil if the game
+// isn't one.
+func (c *Cache) RacePlan() *RacePlan {
+	if c.plan != nil || !c.Endgame() {
+		return c.plan
+	}
+	race := c.Race()
+	us := 0
+	if c.Game.Board.Snakes[0].ID != c.Game.You.ID {
+		us = 1
+	}
+	owner := c.Voronoi()
+	var ours, theirs []api.Coord
+	for _, f := range c.Game.Board.Food {
+		if !c.Grid.InBounds(f) {
+			continue
+		}
+		switch owner[f.Y*c.Grid.Width+f.X] {
+		case us:
+			ours = append(ours, f)
+		case 1 - us:
+			theirs = append(theirs, f)
+		}
+	}
+
+	you := c.Game.You
+	p := &RacePlan{Horizon: race.Territory}
+	p.Ours, p.Target = c.route(you.Head, int(you.Health), ours)
+	p.Theirs, _ = c.route(race.Opponent.Head, int(race.Opponent.Health), theirs)
+	// Holding back only wins if we are longer anyway and don't starve
+	// before the meeting.
+	holdBack := p.Lead(you, race.Opponent, false) > 0 && int(you.Health) > p.Horizon
+	p.Grow = !holdBack && len(p.Ours) > 0 && p.Lead(you, race.Opponent, true) > 0
+	c.plan = p
+	return p
+}
+
+// route returns the turns at which a snake at pos with health eats food,
+// going to the nearest remaining piece each time until it can't reach one
+// on its health, and the first piece it goes to.
+func (c *Cache) route(pos api.Coord, health int, food []api.Coord) ([]int, api.Coord) {
+	var turns []int
+	var first api.Coord
+	food = append([]api.Coord(nil), food...)
+	turn := 0
+	for len(food) > 0 {
+		dist := c.Distances(pos)
+		next := -1
+		for i, f := range food {
+			d := dist[f.Y*c.Grid.Width+f.X]
+			if d >= 0 && d <= health && (next < 0 || d < dist[food[next].Y*c.Grid.Width+food[next].X]) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		pos = food[next]
+		if turns == nil {
+			first = pos
+		}
+		turn += dist[pos.Y*c.Grid.Width+pos.X]
+		turns = append(turns, turn)
+		health = 100
+		food = append(food[:next], food[next+1:]...)
+	}
+	return turns, first
+}
+
+// eatenBy returns how many of the turns are at or before turn.
+func eatenBy(turns []int, turn int) int {
+	n := 0
+	for _, t := range turns {
+		if t <= turn {
+			n++
+		}
+	}
+	return n
+}
+
+// Endgame scores a move in a late duel according to its RacePlan: it
+// heads for the plan's first food while racing for length, and otherwise
+// squeezes the opponent. It scores 0 outside late duels.
+func Endgame(p *Position) float64 {
+	plan := p.RacePlan()
+	if plan == nil {
+		return 0
+	}
+	if !plan.Grow {
+		return Squeeze(p)
+	}
+	if p.Head == plan.Target {
+		return 1
+	}
+	d := p.Distances(p.Head)[plan.Target.Y*p.Grid.Width+plan.Target.X]
+	if d < 0 {
+		return 0
+	}
+	return 1 - float64(d)/float64(p.Grid.Width+p.Grid.Height)
+}
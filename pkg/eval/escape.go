@@ -0,0 +1,38 @@
+package eval
+
+import "github.com/jayuuza/battlesnake/pkg/api"
+
+func init() {
+	Terms = append(Terms, Term{Name: "escape", Weight: 2, Score: Escape})
+}
+
+// exitRoom is the number of cells beyond an exit that must be reachable
+// for it to count as a way out rather than a dead end.
+const exitRoom = 4
+
+// Escape counts the exits from our new head: neighbors that are free, that
+// no enemy at least as long as us can move to next turn, and that lead on
+// to at least exitRoom more cells. Positions with a single exit are
+// penalized heavily and those with none even more so.
+func Escape(p *Position) float64 {
+	danger := p.Danger()
+	exits := 0
+	for _, d := range api.Directions {
+		exit := p.Grid.Step(p.Head, d)
+		if !p.Grid.IsValid(exit) || danger.IsLethal(exit) {
+			continue
+		}
+		if p.SafeReachable(exit, p.Head, exitRoom) >= exitRoom {
+			exits++
+		}
+	}
+	switch exits {
+	case 0:
+		return -1
+	case 1:
+		return -0.75
+	case 2:
+		return -0.1
+	}
+	return 0
+}
@@ -0,0 +1,130 @@
+// Package eval scores candidate moves with a weighted sum of positional
+// terms.
+package eval
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+// Position is the position reached by one of our candidate moves, before
+// the other snakes move. Its Cache's Game and Grid describe the position
+// before the move.
+type Position struct {
+	*Cache
+	Move api.Direction
+	// Head is where the move takes our head.
+	Head api.Coord
+	// Risk is the personality's risk tolerance: 1 is neutral, above 1
+	// takes more risks and below 1 fewer.
+	Risk float64
+	// Scale multiplies the weights of the terms it names, to counter an
+	// opponent's style of play. Terms it doesn't name keep their weight.
+	Scale map[string]float64
+	// Weights replaces the weights of the terms it names, before scaling,
+	// for games played with other weights than the configured ones.
+	Weights map[string]float64
+}
+
+// NewPosition returns the position reached by playing move in the game
+// cached by c.
+func NewPosition(c *Cache, move api.Direction) *Position {
+	return &Position{
+		Cache: c,
+		Move:  move,
+		Head:  c.Grid.Step(c.Game.You.Head, move),
+		Risk:  1,
+	}
+}
+
+// Term is one consideration in evaluating a position. Score returns a value
+// in roughly [-1, 1], higher being better, which Evaluate multiplies by
+// Weight.
+type Term struct {
+	Name   string
+	Weight float64
+	Score  func(p *Position) float64
+}
+
+// Terms are the terms Evaluate sums. Their weights may only be changed
+// with SetWeight once moves are being evaluated.
+var Terms []Term
+
+// weightsMu guards the weights of Terms.
+var weightsMu sync.RWMutex
+
+// Evaluate returns the weighted sum of every term's score for p.
+func Evaluate(p *Position) float64 {
+	weightsMu.RLock()
+	defer weightsMu.RUnlock()
+	total := 0.0
+	for _, t := range Terms {
+		total += p.weight(t) * t.Score(p)
+	}
+	return total
+}
+
+// weight returns the weight of t in evaluating p.
+func (p *Position) weight(t Term) float64 {
+	weight := t.Weight
+	if w, ok := p.Weights[t.Name]; ok {
+		weight = w
+	}
+	if f, ok := p.Scale[t.Name]; ok {
+		return f * weight
+	}
+	return weight
+}
+
+// Breakdown returns each term's weighted score for p, by name.
+func Breakdown(p *Position) map[string]float64 {
+	weightsMu.RLock()
+	defer weightsMu.RUnlock()
+	scores := make(map[string]float64, len(Terms))
+	for _, t := range Terms {
+		scores[t.Name] = p.weight(t) * t.Score(p)
+	}
+	return scores
+}
+
+// SetWeight changes the weight of the term called name.
+func SetWeight(name string, weight float64) error {
+	weightsMu.Lock()
+	defer weightsMu.Unlock()
+	for i := range Terms {
+		if Terms[i].Name == name {
+			Terms[i].Weight = weight
+			return nil
+		}
+	}
+	return fmt.Errorf("eval: unknown term %q", name)
+}
+
+// Weights returns the weight of every term, by name.
+func Weights() map[string]float64 {
+	weightsMu.RLock()
+	defer weightsMu.RUnlock()
+	weights := make(map[string]float64, len(Terms))
+	for _, t := range Terms {
+		weights[t.Name] = t.Weight
+	}
+	return weights
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying weights, by term name, that replace
+// the configured weights of the terms they name in the moves decided with
+// it, so that games in one process can be played with different weights.
+func NewContext(ctx context.Context, weights map[string]float64) context.Context {
+	return context.WithValue(ctx, contextKey{}, weights)
+}
+
+// FromContext returns the weights carried by ctx, or nil.
+func FromContext(ctx context.Context) map[string]float64 {
+	weights, _ := ctx.Value(contextKey{}).(map[string]float64)
+	return weights
+}
@@ -0,0 +1,49 @@
+package eval
+
+func init() {
+	Terms = append(Terms, Term{Name: "follow", Weight: 1, Score: Follow})
+}
+
+// While following our tail, our head stays within followNear moves of it;
+// beyond followFar the term's penalty is at its largest.
+const (
+	followNear = 2
+	followFar  = 8
+)
+
+// followHealth is the health above which we feel no food pressure.
+const followHealth = 50
+
+// Follow keeps our head close behind our own tail while we're healthy and
+// at least as long as every opponent: circling our tail keeps an escape
+// route that frees as fast as we move. With food pressure it leaves the
+// Food term alone. Distances count segments, our own tail's included, as
+// passable once they will have moved away.
+func Follow(p *Position) float64 {
+	you := p.Game.You
+	if len(you.Body) < 3 || int(you.Health) <= followHealth {
+		return 0
+	}
+	for _, snake := range p.Game.Board.Snakes {
+		if snake.ID != you.ID && snake.Length > you.Length {
+			return 0
+		}
+	}
+
+	// After the move our tail is the segment before it, stacked if we eat;
+	// a stacked tail only leaves its cell a turn later, which matters only
+	// within followNear.
+	tail := you.Body[len(you.Body)-2]
+	if !p.Grid.InBounds(tail) || !p.Grid.InBounds(p.Head) {
+		return 0
+	}
+	dist := p.TimedDistance(p.Head, tail)
+	switch {
+	case dist < 0:
+		// Losing the tail altogether is the Tail term's to penalize.
+		return 0
+	case dist <= followNear:
+		return 0
+	}
+	return -float64(min(dist, followFar)-followNear) / float64(followFar-followNear)
+}
@@ -0,0 +1,89 @@
+package eval
+
+import (
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+)
+
+func init() {
+	Terms = append(Terms, Term{Name: "food", Weight: 1, Score: Food})
+}
+
+// FoodPolicy configures when we stop eating because we're already
+// dominant.
+type FoodPolicy struct {
+	// Lead is how many segments longer than every opponent we must be to
+	// count as dominant; 0 disables the policy.
+	Lead int
+	// MinHealth is the health above which a dominant snake avoids food.
+	MinHealth int
+}
+
+// FoodPolicies holds the food policy for each ruleset name, and
+// DefaultFoodPolicy the policy for rulesets not listed. Constrictor snakes
+// grow every turn anyway, and royale's shrinking board makes length even
+// more of a burden.
+var (
+	FoodPolicies = map[string]FoodPolicy{
+		"constrictor": {},
+		"royale":      {Lead: 1, MinHealth: 60},
+	}
+	DefaultFoodPolicy = FoodPolicy{Lead: 2, MinHealth: 50}
+)
+
+// denyRange is how close, in moves, an enemy head must be to food for
+// eating it to count as denying them.
+const denyRange = 2
+
+// Food draws us towards the nearest food, more strongly the hungrier we
+// are. Once we're dominant under the ruleset's FoodPolicy it instead
+// avoids eating, since length only makes us harder to maneuver, unless the
+// food is within an enemy's reach and eating it denies them.
+func Food(p *Position) float64 {
+	if dominant(p.Game) {
+		if p.Grid.IsFood(p.Head) && !contested(p.Game, p.Head) {
+			return -1
+		}
+		return 0
+	}
+
+	nearest := p.NearestFood(p.Head)
+	if nearest < 0 {
+		return 0
+	}
+	hunger := 1 - float64(p.Game.You.Health)/100
+	return hunger * (1 - float64(nearest)/float64(p.Grid.Width+p.Grid.Height))
+}
+
+// dominant reports whether we are long and healthy enough under the
+// ruleset's food policy to stop eating.
+func dominant(game api.GameRequest) bool {
+	policy, ok := FoodPolicies[game.Game.Ruleset.Name]
+	if !ok {
+		policy = DefaultFoodPolicy
+	}
+	if policy.Lead == 0 || int(game.You.Health) <= policy.MinHealth {
+		return false
+	}
+	opponents := 0
+	for _, snake := range game.Board.Snakes {
+		if snake.ID == game.You.ID {
+			continue
+		}
+		opponents++
+		if int(game.You.Length-snake.Length) < policy.Lead {
+			return false
+		}
+	}
+	return opponents > 0
+}
+
+// contested reports whether an enemy head is within denyRange of food.
+func contested(game api.GameRequest, food api.Coord) bool {
+	for _, snake := range game.Board.Snakes {
+		if snake.ID != game.You.ID && board.Manhattan(snake.Head, food) <= denyRange {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package eval
+
+import "github.com/jayuuza/battlesnake/pkg/board"
+
+func init() {
+	Terms = append(Terms, Term{Name: "outlast", Weight: 2, Score: Outlast})
+}
+
+// Outlast recognizes positions where every opponent will starve before it
+// can reach food while we can outlive them, so that surviving alone wins.
+// It then plays for time: it rewards open space and penalizes any cell an
+// enemy head could also move to, even a shorter one, since a fight is no
+// longer worth the risk. In other positions it scores 0.
+func Outlast(p *Position) float64 {
+	turns, ok := p.Starvation()
+	if !ok || !outlives(p, turns) {
+		return 0
+	}
+
+	score := float64(min(p.Reachable(p.Head), turns)) / float64(max(turns, 1))
+	if p.Danger().At(p.Head) != board.NoThreat {
+		score -= 1
+	}
+	return score
+}
+
+// outlives reports whether we can stay alive for turns more turns from p,
+// either on our current health or by reaching food.
+func outlives(p *Position, turns int) bool {
+	health := int(p.Game.You.Health) - 1
+	if p.Grid.IsFood(p.Head) || health > turns {
+		return true
+	}
+	d := p.NearestFood(p.Head)
+	return d >= 0 && d <= health
+}
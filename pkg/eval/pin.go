@@ -0,0 +1,76 @@
+package eval
+
+import (
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+)
+
+func init() {
+	Terms = append(Terms, Term{Name: "pin", Weight: 2, Score: Pin})
+}
+
+// pinLanes is how many lanes from a wall a race along it can pin us in: on
+// the wall itself, or one off it with the wall still closing the way out.
+const pinLanes = 2
+
+// lanes measures positions relative to one wall: lane is the distance from
+// it and along the position parallel to it.
+type lanes struct {
+	lane  func(c api.Coord) int
+	along func(c api.Coord) int
+}
+
+// walls returns the lanes of each wall of a width x height board.
+func walls(width, height int) [4]lanes {
+	x := func(c api.Coord) int { return c.X }
+	y := func(c api.Coord) int { return c.Y }
+	return [4]lanes{
+		{lane: x, along: y},
+		{lane: func(c api.Coord) int { return width - 1 - c.X }, along: y},
+		{lane: y, along: x},
+		{lane: func(c api.Coord) int { return height - 1 - c.Y }, along: x},
+	}
+}
+
+// Pin steers away from racing a longer snake along a wall. When our move
+// runs along a wall, within pinLanes of it, and a longer opponent runs the
+// same way in the lane just inside ours, the opponent can turn across our
+// path whenever it is level or ahead, leaving us the wall and whatever
+// corner it leads to. Turning inwards ends the race, so only moves that
+// continue it are penalized: fully once the opponent will be level or
+// ahead after its move, and by half while it is a length behind.
+func Pin(p *Position) float64 {
+	game := p.Game
+	you := game.You
+	if board.IsWrapped(game.Game.Ruleset.Name) || !p.Grid.InBounds(p.Head) {
+		return 0
+	}
+	worst := 0.0
+	for _, w := range walls(game.Board.Width, game.Board.Height) {
+		lane := w.lane(p.Head)
+		if lane >= pinLanes || w.lane(you.Head) != lane {
+			continue
+		}
+		// Our move runs along the wall in direction dir.
+		dir := w.along(p.Head) - w.along(you.Head)
+		for _, snake := range game.Board.Snakes {
+			if snake.ID == you.ID || snake.Length <= you.Length || len(snake.Body) < 2 {
+				continue
+			}
+			head, neck := snake.Body[0], snake.Body[1]
+			if w.lane(head) != lane+1 || w.lane(neck) != lane+1 || w.along(head)-w.along(neck) != dir {
+				continue
+			}
+			// How far ahead of our new head the opponent will be once it
+			// has moved on too.
+			lead := (w.along(head)-w.along(p.Head))*dir + 1
+			switch {
+			case lead >= 0:
+				return -1
+			case lead == -1:
+				worst = -0.5
+			}
+		}
+	}
+	return worst
+}
@@ -0,0 +1,23 @@
+package eval
+
+func init() {
+	Terms = append(Terms, Term{Name: "space", Weight: 10, Score: Space})
+}
+
+// Space penalizes moves into areas with fewer free cells than our length
+// plus any growth from eating on the way in, which are nearly always a slow
+// death even when each step is valid. Its weight is large enough to
+// outweigh every other term, effectively rejecting such moves whenever
+// there is an alternative.
+func Space(p *Position) float64 {
+	need := int(p.Game.You.Length)
+	if p.Grid.IsFood(p.Head) {
+		need++
+	}
+	// The new head is one cell of the space.
+	space := 1 + p.Reachable(p.Head)
+	if space >= need {
+		return 0
+	}
+	return -0.5 - 0.5*float64(need-space)/float64(need)
+}
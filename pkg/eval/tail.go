@@ -0,0 +1,35 @@
+package eval
+
+import "github.com/jayuuza/battlesnake/pkg/api"
+
+func init() {
+	Terms = append(Terms, Term{Name: "tail", Weight: 3, Score: Tail})
+}
+
+// Tail penalizes moves after which our tail, the traditional escape hatch,
+// can no longer be reached from our head. The tail cell frees as we move
+// unless we just ate, when the tail is stacked and stays put a turn
+// longer; stepping straight from the head into it is then not enough, and
+// some other neighbor of the tail must be reachable.
+func Tail(p *Position) float64 {
+	body := p.Game.You.Body
+	if len(body) < 2 {
+		return 0
+	}
+	tail := body[len(body)-1]
+	stacked := body[len(body)-2] == tail
+
+	dist := p.Distances(p.Head)
+	for _, d := range api.Directions {
+		next := p.Grid.Step(tail, d)
+		switch {
+		case next == p.Head:
+			if !stacked {
+				return 0
+			}
+		case p.Grid.IsValid(next) && dist[next.Y*p.Grid.Width+next.X] >= 0:
+			return 0
+		}
+	}
+	return -1
+}
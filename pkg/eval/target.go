@@ -0,0 +1,98 @@
+package eval
+
+func init() {
+	Terms = append(Terms, Term{Name: "target", Weight: 2, Score: Target})
+}
+
+// Target returns the index among the board's snakes of the opponent to
+// pressure in a game with several opponents, or -1 if there are fewer
+// (Duel squeezes the only one). It is the nearest opponent we are longer
+// than, the least territory breaking ties, since we can take its space
+// without risking a losing collision; failing that, the opponent holding
+// the most territory, which grows into the biggest threat if left alone.
+func (c *Cache) Target() int {
+	if c.target != nil {
+		return *c.target
+	}
+	target := -1
+	if len(c.Game.Board.Snakes) > 2 {
+		target = c.pickTarget()
+	}
+	c.target = &target
+	return target
+}
+
+func (c *Cache) pickTarget() int {
+	owner := c.Voronoi()
+	territory := make([]int, len(c.Game.Board.Snakes))
+	for _, o := range owner {
+		if o >= 0 {
+			territory[o]++
+		}
+	}
+	you := c.Game.You
+	weakest, near := -1, 0
+	strongest := -1
+	for i, snake := range c.Game.Board.Snakes {
+		if snake.ID == you.ID {
+			continue
+		}
+		if strongest < 0 || territory[i] > territory[strongest] {
+			strongest = i
+		}
+		if snake.Length >= you.Length {
+			continue
+		}
+		d := c.Grid.Distance(you.Head, snake.Head)
+		if weakest < 0 || d < near || d == near && territory[i] < territory[weakest] {
+			weakest, near = i, d
+		}
+	}
+	if weakest >= 0 {
+		return weakest
+	}
+	return strongest
+}
+
+// Target focuses area denial on the opponent chosen by Cache.Target: it
+// scores the share of the cells that opponent reaches before us that our
+// move takes away, so moves cutting into its space are preferred over ones
+// that spread the pressure across everyone. A move leaving it more cells
+// than before scores down to -1. It scores 0 without a target.
+func Target(p *Position) float64 {
+	target := p.Cache.Target()
+	if target < 0 {
+		return 0
+	}
+	theirs := p.Distances(p.Game.Board.Snakes[target].Head)
+	before := p.Cache.targetDenied()
+	if before == 0 {
+		return 0
+	}
+	// Paths from the new head are a turn on, through the segments that
+	// have moved away by then but not our old head.
+	after := denied(theirs, p.TimedDistances(p.Head))
+	return max(-1, min(1, float64(before-after)/float64(before)))
+}
+
+// targetDenied returns the cells the target opponent reaches before us on
+// the cached board, the same for every move. There must be a target.
+func (c *Cache) targetDenied() int {
+	if c.deniedBefore == nil {
+		n := denied(c.Distances(c.Game.Board.Snakes[c.Target()].Head), c.Distances(c.Game.You.Head))
+		c.deniedBefore = &n
+	}
+	return *c.deniedBefore
+}
+
+// denied counts the cells, their head aside, that a snake with distances
+// theirs reaches strictly before we do with distances ours.
+func denied(theirs, ours []int) int {
+	n := 0
+	for i, d := range theirs {
+		if d > 0 && (ours[i] < 0 || d < ours[i]) {
+			n++
+		}
+	}
+	return n
+}
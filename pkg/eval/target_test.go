@@ -0,0 +1,89 @@
+package eval_test
+
+import (
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/bench"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/eval"
+)
+
+// corridor returns an 8x3 board whose top and bottom rows are lethal
+// hazards, leaving the middle row a corridor:
+//
+//	c c c ~ ~ ~ ~ ~
+//	. . . A . . . B
+//	~ ~ a a ~ ~ ~ b
+//
+// We (A) are 3 long with our head at (3, 1), and target B, 2 long at the
+// far end. C, 3 long, lies in the hazards out of the way.
+func corridor() api.GameRequest {
+	snake := func(id string, body ...api.Coord) api.Battlesnake {
+		return api.Battlesnake{ID: id, Health: 90, Body: body, Head: body[0], Length: int32(len(body))}
+	}
+	you := snake("a", api.Coord{X: 3, Y: 1}, api.Coord{X: 3, Y: 0}, api.Coord{X: 2, Y: 0})
+	game := api.GameRequest{
+		Game: api.Game{Ruleset: api.Ruleset{Name: "standard", Settings: api.RulesetSettings{HazardDamagePerTurn: 100}}},
+		Board: api.Board{Width: 8, Height: 3, Snakes: []api.Battlesnake{
+			you,
+			snake("b", api.Coord{X: 7, Y: 1}, api.Coord{X: 7, Y: 0}),
+			snake("c", api.Coord{X: 0, Y: 2}, api.Coord{X: 1, Y: 2}, api.Coord{X: 2, Y: 2}),
+		}},
+		You: you,
+	}
+	for x := 0; x < 8; x++ {
+		game.Board.Hazards = append(game.Board.Hazards, api.Coord{X: x, Y: 0}, api.Coord{X: x, Y: 2})
+	}
+	return game
+}
+
+func TestTarget(t *testing.T) {
+	game := corridor()
+	cache := eval.NewCache(game, board.GridFor(game))
+	if target := cache.Target(); target != 1 {
+		t.Fatalf("Target() = %d, want 1", target)
+	}
+	// B reaches (6,1), (5,1) and (4,1) in 1, 2 and 3 moves, and we in 3, 2
+	// and 1: it gets (6,1) first, so 1 cell is denied us before we move.
+	tests := []struct {
+		move api.Direction
+		want float64
+	}{
+		// From (4,1) we reach (5,1) in 1 move and (6,1) in 2, so B still
+		// gets (6,1) first: 1 cell, as before.
+		{api.Right, 0},
+		// From (2,1) our old head blocks the corridor and B gets all 3
+		// cells, which is (1-3)/1 = -2 before clamping.
+		{api.Left, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.move.String(), func(t *testing.T) {
+			if got := eval.Target(eval.NewPosition(cache, tt.move)); got != tt.want {
+				t.Errorf("Target = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTermsInRange checks every term's score of every valid move on the
+// reference positions against the roughly [-1, 1] Terms promise.
+func TestTermsInRange(t *testing.T) {
+	positions := map[string]api.GameRequest{
+		"reference":   bench.Position(),
+		"arcade maze": bench.ArcadeMaze(),
+		"corridor":    corridor(),
+	}
+	for name, game := range positions {
+		grid := board.GridFor(game)
+		cache := eval.NewCache(game, grid)
+		for _, move := range grid.ValidMoves(game.You.Head) {
+			p := eval.NewPosition(cache, move)
+			for _, term := range eval.Terms {
+				if score := term.Score(p); score < -1 || score > 1 {
+					t.Errorf("%s, %s: %s scores %v", name, move, term.Name, score)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package eval
+
+import (
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/sim"
+)
+
+func init() {
+	Terms = append(Terms, Term{Name: "trap", Weight: 8, Score: Trap})
+}
+
+const (
+	// trapDepth is the most turns after eating that Trap simulates.
+	trapDepth = 12
+	// trapNodes bounds the turns Trap simulates for a move, past which the
+	// move is given the benefit of the doubt.
+	trapNodes = 4096
+)
+
+// Trap penalizes eating where the growth turns a pocket we could survive in
+// into a trap: eating keeps our tail in place a turn longer, so a pocket
+// just big enough for our body closes on us. It simulates the turns after
+// eating with the growth-aware simulator, the other snakes' bodies standing
+// as walls until their tails would have moved off, and scores -1 unless
+// some line of moves keeps us alive as many turns as our grown body is
+// long (up to trapDepth), after which we can follow our own tail. Space
+// already penalizes pockets smaller than our grown body, but can't tell
+// those our tail would open up in time from those it won't; pockets twice
+// its size aren't simulated.
+func Trap(p *Position) float64 {
+	if !p.Grid.IsFood(p.Head) {
+		return 0
+	}
+	need := int(p.Game.You.Length) + 1
+	if 1+p.Reachable(p.Head) >= 2*need {
+		return 0
+	}
+	if p.Trapped(p.Move) {
+		return -1
+	}
+	return 0
+}
+
+// Trapped reports whether no line of moves after playing move keeps us
+// alive for as many turns as our body will then be long, up to trapDepth,
+// the other snakes' bodies standing as walls until they move off.
+func (c *Cache) Trapped(move api.Direction) bool {
+	if trapped, ok := c.traps[move]; ok {
+		return trapped
+	}
+	solo := c.Game
+	solo.Board.Snakes = []api.Battlesnake{c.Game.You}
+	s := sim.Acquire(solo)
+	defer sim.Release(s)
+
+	t := &trapSearch{s: s, start: s.Turn, moves: make([]api.Direction, 1), walls: c.walls()}
+	depth := int(c.Game.You.Length)
+	if c.Grid.IsFood(c.Grid.Step(c.Game.You.Head, move)) {
+		depth++
+	}
+	trapped := !t.survives(move, min(depth, trapDepth))
+	if c.traps == nil {
+		c.traps = map[api.Direction]bool{}
+	}
+	c.traps[move] = trapped
+	return trapped
+}
+
+// walls returns, for each cell, the number of turns until the other snakes'
+// segments on it have moved away, or 0 if there are none.
+func (c *Cache) walls() []int {
+	walls := make([]int, c.Grid.Width*c.Grid.Height)
+	for _, snake := range c.Game.Board.Snakes {
+		if snake.ID == c.Game.You.ID {
+			continue
+		}
+		for i, seg := range snake.Body {
+			if c.Grid.InBounds(seg) {
+				j := seg.Y*c.Grid.Width + seg.X
+				walls[j] = max(walls[j], len(snake.Body)-i)
+			}
+		}
+	}
+	return walls
+}
+
+// trapSearch looks for a line of moves that keeps our snake, alone in s,
+// alive among walls.
+type trapSearch struct {
+	s     *sim.State
+	start int
+	moves []api.Direction
+	walls []int
+	nodes int
+}
+
+// survives reports whether playing move and then some line of moves keeps
+// us alive for depth turns in all.
+func (t *trapSearch) survives(move api.Direction, depth int) bool {
+	t.nodes++
+	t.moves[0] = move
+	t.s.Apply(t.moves)
+	defer t.s.Undo()
+
+	me := &t.s.Snakes[0]
+	if me.Eliminated || t.walls[t.s.Index(me.Head())] > t.s.Turn-t.start {
+		return false
+	}
+	if depth == 1 || t.nodes > trapNodes {
+		return true
+	}
+	for _, d := range api.Directions {
+		if me.Len() > 1 && me.Head().Move(d) == me.Segment(1) {
+			continue
+		}
+		if t.survives(d, depth-1) {
+			return true
+		}
+	}
+	return false
+}
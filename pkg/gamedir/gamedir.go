@@ -0,0 +1,26 @@
+// Package gamedir names the per-game directories that game data is written
+// to, from game IDs that arrive in request bodies and can't be trusted.
+package gamedir
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Safe reports whether a game ID can be used as a directory name without
+// escaping the directory it is joined to.
+func Safe(gameID string) bool {
+	return gameID != "" && gameID != "." && gameID != ".." && !strings.ContainsAny(gameID, `/\`)
+}
+
+// Name returns the directory name of a game: its ID if that is Safe, or
+// otherwise a name derived from a hash of it, so that every ID names a
+// directory of its own within the one it is joined to.
+func Name(gameID string) string {
+	if Safe(gameID) {
+		return gameID
+	}
+	sum := sha256.Sum256([]byte(gameID))
+	return "unsafe-" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,52 @@
+package gamedir
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		id   string
+		safe bool
+	}{
+		{"b3a0c1f2-7c4e-4d3a-9a51-0f1c2e3d4b5a", true},
+		{"game.1", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"../../x", false},
+		{"a/b", false},
+		{`..\x`, false},
+	}
+	for _, tt := range tests {
+		if got := Safe(tt.id); got != tt.safe {
+			t.Errorf("Safe(%q) = %v, want %v", tt.id, got, tt.safe)
+		}
+		name := Name(tt.id)
+		if tt.safe && name != tt.id {
+			t.Errorf("Name(%q) = %q, want the ID unchanged", tt.id, name)
+		}
+		if !Safe(name) {
+			t.Errorf("Name(%q) = %q, which isn't safe", tt.id, name)
+		}
+		if dir := filepath.Join("games", name); filepath.Dir(dir) != "games" {
+			t.Errorf("Name(%q) = %q escapes the games directory: %q", tt.id, name, dir)
+		}
+	}
+}
+
+func TestNameDistinct(t *testing.T) {
+	seen := map[string]string{}
+	for _, id := range []string{"", ".", "..", "../a", "../b", "a/b", "a/c"} {
+		name := Name(id)
+		if !strings.HasPrefix(name, "unsafe-") {
+			t.Errorf("Name(%q) = %q, want a derived name", id, name)
+		}
+		if other, ok := seen[name]; ok {
+			t.Errorf("Name(%q) = Name(%q) = %q", id, other, name)
+		}
+		seen[name] = id
+	}
+}
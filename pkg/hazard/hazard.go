@@ -0,0 +1,115 @@
+// Package hazard predicts where hazards will be in the turns ahead, so that
+// routes can avoid cells about to turn into sauce.
+package hazard
+
+import "github.com/jayuuza/battlesnake/pkg/api"
+
+// Never marks a cell not expected to become a hazard within the forecast.
+const Never = -1
+
+// A Predictor fills in arrival, indexed by y*Width+x, with the number of
+// turns from now until each cell is predicted to become a hazard, up to
+// horizon turns ahead. Current hazards are already 0 and every other cell
+// Never when it is called.
+type Predictor func(game api.GameRequest, horizon int, arrival []int)
+
+// Forecast returns, for every cell of game's board indexed by y*Width+x,
+// the number of turns until it is predicted to become a hazard: 0 for
+// current hazards and Never for cells not expected to within horizon
+// turns. predict is the predictor of the game's map (see package maps), or
+// nil for maps whose hazards are static or unpredictable, like scatter,
+// which are forecast to stay as they are.
+func Forecast(game api.GameRequest, horizon int, predict Predictor) []int {
+	width, height := game.Board.Width, game.Board.Height
+	arrival := make([]int, width*height)
+	for i := range arrival {
+		arrival[i] = Never
+	}
+	for _, h := range game.Board.Hazards {
+		if h.X >= 0 && h.Y >= 0 && h.X < width && h.Y < height {
+			arrival[h.Y*width+h.X] = 0
+		}
+	}
+	if predict != nil {
+		predict(game, horizon, arrival)
+	}
+	return arrival
+}
+
+// defaultShrinkEvery is the number of turns between hazard changes when
+// the ruleset doesn't say.
+const defaultShrinkEvery = 25
+
+// changes returns how many turns from now each of the hazard changes within
+// horizon turns happens, given that hazards change on every turn that is a
+// multiple of the ruleset's ShrinkEveryNTurns.
+func changes(game api.GameRequest, horizon int) []int {
+	every := int(game.Game.Ruleset.Settings.Royale.ShrinkEveryNTurns)
+	if every <= 0 {
+		every = defaultShrinkEvery
+	}
+	var turns []int
+	for t := (game.Turn/every + 1) * every; t-game.Turn <= horizon; t += every {
+		turns = append(turns, t-game.Turn)
+	}
+	return turns
+}
+
+// Shrink predicts royale, where each change turns one edge row or column of
+// the safe area into hazard. The side is chosen at random, so every cell k
+// rows or columns inside the safe area's edge is predicted to become a
+// hazard at the k-th change.
+func Shrink(game api.GameRequest, horizon int, arrival []int) {
+	width, height := game.Board.Width, game.Board.Height
+	minX, minY, maxX, maxY := width, height, -1, -1
+	for i, a := range arrival {
+		if a != 0 {
+			x, y := i%width, i/width
+			minX, minY = min(minX, x), min(minY, y)
+			maxX, maxY = max(maxX, x), max(maxY, y)
+		}
+	}
+	turns := changes(game, horizon)
+	for i, a := range arrival {
+		if a == 0 {
+			continue
+		}
+		x, y := i%width, i/width
+		depth := min(x-minX, maxX-x, y-minY, maxY-y)
+		if depth < len(turns) {
+			arrival[i] = turns[depth]
+		}
+	}
+}
+
+// Grow predicts maps whose hazards spread outwards from those already on
+// the board, such as spirals and expanding boxes. Every cell within k
+// cells, diagonals included, of a current hazard is predicted to become
+// one at the k-th change; this overestimates spirals, which add a cell at
+// a time, but never misses where they go next.
+func Grow(game api.GameRequest, horizon int, arrival []int) {
+	width, height := game.Board.Width, game.Board.Height
+	var ring []int
+	for i, a := range arrival {
+		if a == 0 {
+			ring = append(ring, i)
+		}
+	}
+	for _, turn := range changes(game, horizon) {
+		var next []int
+		for _, i := range ring {
+			x, y := i%width, i/width
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := x+dx, y+dy
+					if nx < 0 || ny < 0 || nx >= width || ny >= height || arrival[ny*width+nx] != Never {
+						continue
+					}
+					arrival[ny*width+nx] = turn
+					next = append(next, ny*width+nx)
+				}
+			}
+		}
+		ring = next
+	}
+}
@@ -0,0 +1,181 @@
+package history
+
+import (
+	"compress/gzip"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CompressedFileName is the name of a history file once compressed.
+const CompressedFileName = FileName + ".gz"
+
+// Retention decides which recorded games keep their history, so storage
+// stays bounded while the interesting games are always kept: every loss
+// and draw is kept, but only a sample of wins. Wins are sampled by a hash
+// of the game ID, so the server and the gc subcommand agree on which to
+// keep, however often the policy is enforced.
+type Retention struct {
+	// WinRate is the fraction (0-1) of won games whose history is kept.
+	WinRate float64
+	// CompressAfter is how long after it was last written a history file
+	// is compressed, or 0 to never compress.
+	CompressAfter time.Duration
+}
+
+// sampleScale is the resolution of the win sample.
+const sampleScale = 1 << 20
+
+// Keep reports whether to keep the history of gameID, which was won or not.
+func (r Retention) Keep(gameID string, won bool) bool {
+	if !won {
+		return true
+	}
+	h := fnv.New64a()
+	h.Write([]byte(gameID))
+	return float64(h.Sum64()%sampleScale)/sampleScale < r.WinRate
+}
+
+// Exists reports whether dir holds a history file, compressed or not.
+func Exists(dir string) bool {
+	for _, name := range []string{FileName, CompressedFileName} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Discard removes the history of gameID, and its directory if that leaves
+// it empty.
+func (r *Recorder) Discard(gameID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := os.Remove(r.Path(gameID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// Other game data, such as profiles, keeps the directory.
+	os.Remove(filepath.Dir(r.Path(gameID)))
+	return nil
+}
+
+// CompressOlder compresses the history files last written more than age
+// ago, returning how many it compressed.
+func (r *Recorder) CompressOlder(age time.Duration) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(r.Dir, "*", FileName))
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || time.Since(info.ModTime()) < age {
+			continue
+		}
+		r.mu.Lock()
+		err = compress(path)
+		r.mu.Unlock()
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Collected is what enforcing a retention policy did, or would do.
+type Collected struct {
+	Games      int   `json:"games"`
+	Discarded  int   `json:"discarded"`
+	Compressed int   `json:"compressed"`
+	FreedBytes int64 `json:"freedBytes"`
+}
+
+// Collect enforces ret on every history recorded under r.Dir: the histories
+// of won games that aren't sampled are discarded, and those kept that were
+// last written more than ret.CompressAfter ago are compressed, if it is
+// set. won tells by game ID which games were won; games missing from it
+// are kept, as their outcome isn't known. With dryRun, nothing is changed
+// but what would be is reported, freed bytes of compression excepted.
+func (r *Recorder) Collect(ret Retention, won map[string]bool, dryRun bool) (Collected, error) {
+	var c Collected
+	dirs, err := filepath.Glob(filepath.Join(r.Dir, "*"))
+	if err != nil {
+		return c, err
+	}
+	for _, dir := range dirs {
+		if !Exists(dir) {
+			continue
+		}
+		c.Games++
+		gameID := filepath.Base(dir)
+		plain := filepath.Join(dir, FileName)
+		wonGame, known := won[gameID]
+		if known && !ret.Keep(gameID, wonGame) {
+			c.Discarded++
+			for _, path := range []string{plain, plain + ".gz"} {
+				if info, err := os.Stat(path); err == nil {
+					c.FreedBytes += info.Size()
+				}
+			}
+			if dryRun {
+				continue
+			}
+			if err := r.Discard(gameID); err != nil {
+				return c, err
+			}
+			if err := os.Remove(plain + ".gz"); err != nil && !os.IsNotExist(err) {
+				return c, err
+			}
+			os.Remove(dir)
+			continue
+		}
+
+		info, err := os.Stat(plain)
+		if err != nil || ret.CompressAfter <= 0 || time.Since(info.ModTime()) < ret.CompressAfter {
+			continue
+		}
+		c.Compressed++
+		if dryRun {
+			continue
+		}
+		r.mu.Lock()
+		err = compress(plain)
+		r.mu.Unlock()
+		if err != nil {
+			return c, err
+		}
+		if gz, err := os.Stat(plain + ".gz"); err == nil {
+			c.FreedBytes += info.Size() - gz.Size()
+		}
+	}
+	return c, nil
+}
+
+// compress replaces the file at path with a gzipped copy at path.gz.
+func compress(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	_, err = io.Copy(gz, in)
+	if err == nil {
+		err = gz.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
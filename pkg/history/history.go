@@ -0,0 +1,108 @@
+// Package history records the turns of each game to disk so they can be
+// analysed after the game ends.
+package history
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/gamedir"
+)
+
+// FileName is the name of the history file within a game's directory.
+const FileName = "history.jsonl"
+
+// Turn is one recorded turn of a game.
+type Turn struct {
+	Turn    int             `json:"turn"`
+	Request api.GameRequest `json:"request"`
+	// Move is our response, or nil for the final state sent to /end.
+	Move *api.MoveResponse `json:"move,omitempty"`
+	// Shouts are the new shouts opponents made this turn.
+	Shouts []Shout `json:"shouts,omitempty"`
+}
+
+// Shout is a message shouted by a snake.
+type Shout struct {
+	SnakeID string `json:"snakeId"`
+	Name    string `json:"name"`
+	Text    string `json:"text"`
+}
+
+// Recorder appends turns to a history file per game under Dir.
+type Recorder struct {
+	Dir string
+
+	mu sync.Mutex
+}
+
+// Path returns the history file of gameID, in its directory of Dir.
+func (r *Recorder) Path(gameID string) string {
+	return filepath.Join(r.Dir, gamedir.Name(gameID), FileName)
+}
+
+// Record appends turn to the history of gameID.
+func (r *Recorder) Record(gameID string, turn Turn) error {
+	line, err := json.Marshal(turn)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(r.Path(gameID)), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.Path(gameID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads every turn recorded in the history file at path, or in its
+// compressed archive if it has been compressed.
+func Load(path string) ([]Turn, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) && !strings.HasSuffix(path, ".gz") {
+		path += ".gz"
+		f, err = os.Open(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var turns []Turn
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		var turn Turn
+		if err := json.Unmarshal(scanner.Bytes(), &turn); err != nil {
+			return nil, err
+		}
+		turns = append(turns, turn)
+	}
+	return turns, scanner.Err()
+}
@@ -0,0 +1,42 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+func TestRecordStaysInDir(t *testing.T) {
+	tests := []struct {
+		name   string
+		gameID string
+	}{
+		{"plain", "g1"},
+		{"parent", "../../escaped"},
+		{"separator", "a/b"},
+		{"dot", "."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "games")
+			r := &Recorder{Dir: dir}
+			turn := Turn{Turn: 3, Request: api.GameRequest{Turn: 3}}
+			if err := r.Record(tt.gameID, turn); err != nil {
+				t.Fatal(err)
+			}
+			path := r.Path(tt.gameID)
+			if filepath.Dir(filepath.Dir(path)) != dir {
+				t.Errorf("Path(%q) = %q, outside %q", tt.gameID, path, dir)
+			}
+			turns, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(turns) != 1 || turns[0].Turn != 3 {
+				t.Errorf("Load(%q) = %+v, want the recorded turn", path, turns)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// gameLevels holds the level logged at for games singled out with
+// SetGameLevel, by game ID.
+var gameLevels sync.Map
+
+type gameKey struct{}
+
+// WithGame returns a context for logging about the game gameID, which is
+// logged in more detail if it has been singled out with SetGameLevel.
+func WithGame(ctx context.Context, gameID string) context.Context {
+	return context.WithValue(ctx, gameKey{}, gameID)
+}
+
+// SetGameLevel logs everything at level or above about the game gameID,
+// whatever the components' levels, when logged with a context from
+// WithGame.
+func SetGameLevel(gameID string, level slog.Level) {
+	gameLevels.Store(gameID, level)
+}
+
+// ClearGameLevel stops singling out the game gameID.
+func ClearGameLevel(gameID string) {
+	gameLevels.Delete(gameID)
+}
+
+// GameLevels returns the levels of the games singled out with
+// SetGameLevel, by game ID.
+func GameLevels() map[string]string {
+	levels := map[string]string{}
+	gameLevels.Range(func(id, level any) bool {
+		levels[id.(string)] = level.(slog.Level).String()
+		return true
+	})
+	return levels
+}
+
+// gameLevelFor returns the level of the game ctx is about, if it has been
+// singled out.
+func gameLevelFor(ctx context.Context) (slog.Level, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	id, ok := ctx.Value(gameKey{}).(string)
+	if !ok {
+		return 0, false
+	}
+	level, ok := gameLevels.Load(id)
+	if !ok {
+		return 0, false
+	}
+	return level.(slog.Level), true
+}
@@ -0,0 +1,203 @@
+// Package logging routes the snake's logs to a configurable sink, with a
+// level per component so that, say, strategy debugging can be turned up
+// without drowning in server noise.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Components that log.
+const (
+	Server   = "server"
+	Strategy = "strategy"
+	Search   = "search"
+)
+
+// LevelTrace is below debug, for logging whole requests.
+const LevelTrace = slog.LevelDebug - 4
+
+// Config configures logging.
+type Config struct {
+	// Sink is "stdout", "stderr" or the path of a file to log to, rotated
+	// once it grows past MaxBytes with Backups old files kept.
+	Sink     string
+	MaxBytes int64
+	Backups  int
+	// Format is "text" or "json".
+	Format string
+	// Level is the minimum level logged by components not in Levels.
+	Level slog.Level
+	// Levels holds the minimum level logged by individual components.
+	Levels map[string]slog.Level
+}
+
+var (
+	// root is the handler every component logs through.
+	root atomic.Pointer[slog.Handler]
+
+	levelsMu sync.Mutex
+	// defaultLevel applies to components without a level of their own.
+	defaultLevel slog.LevelVar
+	levels       = map[string]*slog.LevelVar{}
+)
+
+// handlerOptions configures the root handler. Components filter by level
+// themselves, so it lets everything through.
+var handlerOptions = &slog.HandlerOptions{
+	Level: LevelTrace,
+	ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.LevelKey && len(groups) == 0 && a.Value.Any() == LevelTrace {
+			a.Value = slog.StringValue("TRACE")
+		}
+		return a
+	},
+}
+
+func init() {
+	var h slog.Handler = slog.NewTextHandler(os.Stdout, handlerOptions)
+	root.Store(&h)
+}
+
+// Setup applies cfg, returning a function that closes the sink.
+func Setup(cfg Config) (close func() error, err error) {
+	var w io.Writer
+	close = func() error { return nil }
+	switch cfg.Sink {
+	case "", "stdout":
+		w = os.Stdout
+	case "stderr":
+		w = os.Stderr
+	default:
+		f, err := OpenRotating(cfg.Sink, cfg.MaxBytes, cfg.Backups)
+		if err != nil {
+			return nil, err
+		}
+		w, close = f, f.Close
+	}
+
+	var h slog.Handler
+	switch cfg.Format {
+	case "", "text":
+		h = slog.NewTextHandler(w, handlerOptions)
+	case "json":
+		h = slog.NewJSONHandler(w, handlerOptions)
+	default:
+		return nil, fmt.Errorf("logging: unknown format %q", cfg.Format)
+	}
+	root.Store(&h)
+
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	defaultLevel.Set(cfg.Level)
+	for _, l := range levels {
+		l.Set(cfg.Level)
+	}
+	for component, level := range cfg.Levels {
+		levelVar(component).Set(level)
+	}
+	return close, nil
+}
+
+// SetLevel changes the minimum level logged by component.
+func SetLevel(component string, level slog.Level) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	levelVar(component).Set(level)
+}
+
+// levelVar returns the level of component, creating it if needed. levelsMu
+// must be held.
+func levelVar(component string) *slog.LevelVar {
+	l, ok := levels[component]
+	if !ok {
+		l = &slog.LevelVar{}
+		l.Set(defaultLevel.Level())
+		levels[component] = l
+	}
+	return l
+}
+
+// For returns the logger for component. It may be called before Setup;
+// records go to whatever sink is configured when they are logged.
+func For(component string) *slog.Logger {
+	levelsMu.Lock()
+	level := levelVar(component)
+	levelsMu.Unlock()
+	return slog.New(&handler{level: level, with: func(h slog.Handler) slog.Handler {
+		return h.WithAttrs([]slog.Attr{slog.String("component", component)})
+	}})
+}
+
+// ParseLevels parses per-component levels written as
+// "strategy=debug,server=warn".
+func ParseLevels(s string) (map[string]slog.Level, error) {
+	levels := map[string]slog.Level{}
+	if s == "" {
+		return levels, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		component, name, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("logging: %q is not component=level", pair)
+		}
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		levels[component] = level
+	}
+	return levels, nil
+}
+
+// handler filters records by its component's level and passes them to the
+// root handler, applying the attributes and groups added with With.
+type handler struct {
+	level *slog.LevelVar
+	with  func(slog.Handler) slog.Handler
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	if level >= h.level.Level() {
+		return true
+	}
+	gameLevel, ok := gameLevelFor(ctx)
+	return ok && level >= gameLevel
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	return h.with(*root.Load()).Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	with := h.with
+	return &handler{level: h.level, with: func(next slog.Handler) slog.Handler {
+		return with(next).WithAttrs(attrs)
+	}}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	with := h.with
+	return &handler{level: h.level, with: func(next slog.Handler) slog.Handler {
+		return with(next).WithGroup(name)
+	}}
+}
+
+// ParseLevel parses a level name: trace, debug, info, warn or error.
+func ParseLevel(name string) (slog.Level, error) {
+	if strings.EqualFold(name, "trace") {
+		return LevelTrace, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("logging: %v", err)
+	}
+	return level, nil
+}
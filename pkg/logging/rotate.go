@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile is a log file that is rotated once it grows past a size
+// limit: path is renamed to path.1, path.1 to path.2 and so on, keeping a
+// fixed number of old files.
+type RotatingFile struct {
+	path     string
+	maxBytes int64
+	backups  int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// OpenRotating opens path for appending, rotating it past maxBytes and
+// keeping backups old files. A maxBytes of 0 never rotates.
+func OpenRotating(path string, maxBytes int64, backups int) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, maxBytes: maxBytes, backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+// Write appends p to the file, first rotating it if p would take it past
+// the size limit. If the old files can't be moved along, p is appended to
+// the current file regardless and rotating is tried again on the next
+// write.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil || r.maxBytes > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil && r.f == nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the old files along, dropping the oldest, and starts a new
+// file. Whether or not that works, path is open again afterwards unless
+// opening it fails, in which case r.f is nil. r.mu must be held.
+func (r *RotatingFile) rotate() error {
+	if r.f != nil {
+		r.f.Close()
+		r.f = nil
+	}
+	err := r.shift()
+	if openErr := r.open(); openErr != nil {
+		return openErr
+	}
+	return err
+}
+
+// shift renames the old files along, path becoming path.1, or removes path
+// if no old files are kept.
+func (r *RotatingFile) shift() error {
+	if r.backups == 0 {
+		return os.Remove(r.path)
+	}
+	os.Remove(fmt.Sprintf("%s.%d", r.path, r.backups))
+	for i := r.backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	return os.Rename(r.path, r.path+".1")
+}
+
+// Close closes the file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	return r.f.Close()
+}
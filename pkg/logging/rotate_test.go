@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readFile returns the contents of path, or "" if it doesn't exist.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ""
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snake.log")
+	r, err := OpenRotating(path, 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	// Each write fills the file, so every later one rotates it first.
+	for _, line := range []string{"aaa\n", "bbb\n", "ccc\n", "ddd\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := map[string]string{
+		path:        "ddd\n",
+		path + ".1": "ccc\n",
+		path + ".2": "bbb\n",
+		// The oldest is dropped once backups are kept.
+		path + ".3": "",
+	}
+	for p, w := range want {
+		if got := readFile(t, p); got != w {
+			t.Errorf("%s = %q, want %q", filepath.Base(p), got, w)
+		}
+	}
+}
+
+func TestRotatingFileNoBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snake.log")
+	r, err := OpenRotating(path, 4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.Write([]byte("aaa\n"))
+	r.Write([]byte("bbb\n"))
+	if got := readFile(t, path); got != "bbb\n" {
+		t.Errorf("file = %q, want %q", got, "bbb\n")
+	}
+	if got := readFile(t, path+".1"); got != "" {
+		t.Errorf("backup kept: %q", got)
+	}
+}
+
+func TestRotatingFileKeepsSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snake.log")
+	if err := os.WriteFile(path, []byte("aaa\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A reopened file counts what it already holds.
+	r, err := OpenRotating(path, 6, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.Write([]byte("bbb\n"))
+	if got := readFile(t, path+".1"); got != "aaa\n" {
+		t.Errorf("backup = %q, want %q", got, "aaa\n")
+	}
+}
+
+func TestRotatingFileRenameFails(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snake.log")
+	// A directory in the backup's place can't be renamed over or removed.
+	if err := os.MkdirAll(filepath.Join(path+".1", "full"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenRotating(path, 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.Write([]byte("aaa\n"))
+	// Rotating fails, but the writes still reach the log.
+	for _, line := range []string{"bbb\n", "ccc\n"} {
+		if n, err := r.Write([]byte(line)); n != len(line) {
+			t.Fatalf("Write(%q) = %d, %v", line, n, err)
+		}
+	}
+	if got := readFile(t, path); got != "aaa\nbbb\nccc\n" {
+		t.Errorf("file = %q, want every line", got)
+	}
+	// Once the way is clear the next write rotates.
+	if err := os.RemoveAll(path + ".1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Write([]byte("ddd\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); got != "ddd\n" {
+		t.Errorf("file after rotating = %q, want %q", got, "ddd\n")
+	}
+	if got := readFile(t, path+".1"); got != "aaa\nbbb\nccc\n" {
+		t.Errorf("backup = %q", got)
+	}
+}
@@ -0,0 +1,11 @@
+package maps
+
+// ArcadeMaze lays hazards out as the walls of a maze.
+const ArcadeMaze = "arcade_maze"
+
+func init() {
+	Register(Map{
+		Name:       ArcadeMaze,
+		PassHazard: func(int) bool { return false },
+	})
+}
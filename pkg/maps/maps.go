@@ -0,0 +1,73 @@
+// Package maps registers how each game map changes the rules of movement,
+// so that supporting a new map is a matter of adding a file that registers
+// it rather than editing the board, the simulator and the heuristics.
+package maps
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/jayuuza/battlesnake/pkg/hazard"
+)
+
+// Map holds the hooks of a map. Nil hooks keep the standard behaviour.
+type Map struct {
+	Name string
+	// PassHazard reports whether a hazard cell may be entered by a snake it
+	// would leave with left health. Hazards are passable by default, unless
+	// they kill or, as on every map, their damage leaves nothing.
+	PassHazard func(left int) bool
+	// Trails is set on maps where snakes leave a trail of stacked hazards
+	// behind their tails that decays by one stack a turn, which the
+	// simulator models.
+	Trails bool
+	// Predict forecasts where hazards spawn in the turns ahead. Hazards
+	// are forecast to stay as they are by default.
+	Predict hazard.Predictor
+}
+
+// CanPass reports whether m lets a snake enter a hazard cell that would
+// leave it with left health.
+func (m Map) CanPass(left int) bool {
+	return m.PassHazard == nil || m.PassHazard(left)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Map{}
+)
+
+// Register makes the hooks of m apply to games on the map m.Name. It panics
+// if the map is already registered.
+func Register(m Map) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[m.Name]; ok {
+		panic(fmt.Sprintf("maps: Register called twice for %s", m.Name))
+	}
+	registry[m.Name] = m
+}
+
+// Lookup returns the map called name, or a map with the standard behaviour
+// if none is registered by that name.
+func Lookup(name string) Map {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	if m, ok := registry[name]; ok {
+		return m
+	}
+	return Map{Name: name}
+}
+
+// Names returns the names of the registered maps in sorted order.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
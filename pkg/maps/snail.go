@@ -0,0 +1,17 @@
+package maps
+
+// SnailMode has snakes leave a trail of stacked hazards behind their tails
+// that decays by one stack a turn.
+const SnailMode = "snail_mode"
+
+// trailMargin is the health we insist on keeping when crossing a snail
+// trail; fresher trails are treated as walls.
+const trailMargin = 15
+
+func init() {
+	Register(Map{
+		Name:       SnailMode,
+		PassHazard: func(left int) bool { return left >= trailMargin },
+		Trails:     true,
+	})
+}
@@ -0,0 +1,15 @@
+package maps
+
+import "github.com/jayuuza/battlesnake/pkg/hazard"
+
+// Maps whose hazards close in on the board over the game, on the ruleset's
+// shrinkEveryNTurns schedule.
+func init() {
+	// Royale turns an edge row or column into hazard at every change.
+	Register(Map{Name: "royale", Predict: hazard.Shrink})
+	// Spirals and boxes spread outwards from the hazards already on the
+	// board.
+	for _, name := range []string{"hz_spiral", "hz_grow_box", "hz_expand_box", "hz_rings"} {
+		Register(Map{Name: name, Predict: hazard.Grow})
+	}
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Latency buckets grow by a factor of 2^(1/4) from bucketBase, so a
+// percentile is estimated to within about 19%.
+const (
+	bucketBase         = 250 * time.Microsecond
+	bucketsPerDoubling = 4
+	numBuckets         = 80
+)
+
+// DecisionLatency records how long moves take to decide, by board size and
+// snake count.
+var DecisionLatency = NewLatencies("decisionLatency")
+
+// Latencies records latency distributions under separate keys.
+type Latencies struct {
+	mu    sync.Mutex
+	hists map[string]*histogram
+}
+
+type histogram struct {
+	counts [numBuckets]int64
+	total  int64
+}
+
+// LatencySummary is a latency distribution's size and percentiles.
+type LatencySummary struct {
+	Count int64   `json:"count"`
+	P50Ms float64 `json:"p50Ms"`
+	P95Ms float64 `json:"p95Ms"`
+	P99Ms float64 `json:"p99Ms"`
+}
+
+// NewLatencies returns an empty set of latencies, published with expvar
+// under name.
+func NewLatencies(name string) *Latencies {
+	l := &Latencies{hists: map[string]*histogram{}}
+	expvar.Publish(name, expvar.Func(func() any { return l.Summaries() }))
+	return l
+}
+
+// LatencyKey returns the key for latencies on a width by height board with
+// the given number of snakes.
+func LatencyKey(width, height, snakes int) string {
+	return fmt.Sprintf("%dx%d/%d", width, height, snakes)
+}
+
+// Observe records a latency of d under key.
+func (l *Latencies) Observe(key string, d time.Duration) {
+	b := 0
+	if d > bucketBase {
+		b = int(math.Ceil(bucketsPerDoubling * math.Log2(float64(d)/float64(bucketBase))))
+	}
+	b = min(b, numBuckets-1)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h, ok := l.hists[key]
+	if !ok {
+		h = &histogram{}
+		l.hists[key] = h
+	}
+	h.counts[b]++
+	h.total++
+}
+
+// Summaries returns the summary of the latencies under every key.
+func (l *Latencies) Summaries() map[string]LatencySummary {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	summaries := make(map[string]LatencySummary, len(l.hists))
+	for key, h := range l.hists {
+		summaries[key] = LatencySummary{
+			Count: h.total,
+			P50Ms: h.percentile(0.50),
+			P95Ms: h.percentile(0.95),
+			P99Ms: h.percentile(0.99),
+		}
+	}
+	return summaries
+}
+
+// percentile returns the upper bound of the bucket holding the p-th
+// fraction of latencies, in milliseconds.
+func (h *histogram) percentile(p float64) float64 {
+	rank := int64(math.Ceil(p * float64(h.total)))
+	var seen int64
+	for b, n := range h.counts {
+		seen += n
+		if seen >= rank {
+			bound := float64(bucketBase) * math.Exp2(float64(b)/bucketsPerDoubling)
+			return math.Round(bound/float64(time.Millisecond)*100) / 100
+		}
+	}
+	return 0
+}
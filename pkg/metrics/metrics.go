@@ -0,0 +1,31 @@
+// Package metrics counts the events worth watching in production. The
+// counters are published with expvar, served at /debug/vars.
+package metrics
+
+import "expvar"
+
+var (
+	// WatchdogTimeouts counts moves the watchdog answered because the
+	// strategy missed its deadline.
+	WatchdogTimeouts = expvar.NewInt("watchdogTimeouts")
+	// WatchdogBusy counts moves the watchdog answered because the
+	// strategy was still deciding an earlier turn.
+	WatchdogBusy = expvar.NewInt("watchdogBusy")
+	// StrategyPanics counts moves the watchdog answered because the
+	// strategy panicked.
+	StrategyPanics = expvar.NewInt("strategyPanics")
+	// BreakerTrips counts games switched to their fallback strategy after
+	// repeated budget overruns.
+	BreakerTrips = expvar.NewInt("breakerTrips")
+	// FallbackMoves counts moves played by a breaker's fallback strategy.
+	FallbackMoves = expvar.NewInt("fallbackMoves")
+	// DegradedGames counts games started on the fallback strategy in
+	// degraded mode.
+	DegradedGames = expvar.NewInt("degradedGames")
+	// SlowPositions counts positions saved to the slow positions
+	// directory.
+	SlowPositions = expvar.NewInt("slowPositions")
+	// DuplicateMoves counts move requests for a turn already answered,
+	// answered again with the same move.
+	DuplicateMoves = expvar.NewInt("duplicateMoves")
+)
@@ -0,0 +1,96 @@
+package opponent
+
+import (
+	"sort"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/results"
+)
+
+// Counter is how we adjust our play against an archetype.
+type Counter struct {
+	// Weights are factors on the weights of evaluation terms, by name.
+	Weights map[string]float64
+	// Risk is a factor on our risk tolerance.
+	Risk float64
+}
+
+// Counters are the counter-profiles of the archetypes.
+var Counters = map[Archetype]Counter{
+	// Deny a greedy snake its food: contest it, and play for it starving.
+	Greedy: {Weights: map[string]float64{"food": 1.5, "outlast": 1.5}, Risk: 1},
+	// Avoid contact with an aggressive snake, keeping our exits open and
+	// off the walls it could pin us to.
+	Aggressive: {Weights: map[string]float64{"escape": 2, "pin": 1.5}, Risk: 0.75},
+	// Bait a cautious snake into traps: it gives way, so take the middle
+	// and press it against the walls.
+	Cautious: {Weights: map[string]float64{"center": 1.5, "edge": 1.5}, Risk: 1.25},
+}
+
+// Counter returns the archetype of the nearest opponent that has one and
+// the counter-profile to play against it, or false if no opponent is
+// classified.
+func (m *Model) Counter(game api.GameRequest) (Archetype, Counter, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	geo := board.GeometryFor(game.Board.Width, game.Board.Height, board.IsWrapped(game.Game.Ruleset.Name))
+	found, near := Unknown, -1
+	for _, snake := range game.Board.Snakes {
+		if snake.ID == game.You.ID {
+			continue
+		}
+		a := m.archetype(snake.ID)
+		if a == Unknown {
+			continue
+		}
+		if d := geo.Distance(game.You.Head, snake.Head); near < 0 || d < near {
+			found, near = a, d
+		}
+	}
+	counter, ok := Counters[found]
+	return found, counter, ok
+}
+
+// Record tallies the archetypes opponents were classified as over many
+// games, by name.
+type Record map[string]map[Archetype]int
+
+// NewRecord returns the record of the archetypes in rs.
+func NewRecord(rs []results.Result) Record {
+	r := Record{}
+	for _, result := range rs {
+		r.Add(result.Archetypes)
+	}
+	return r
+}
+
+// Add tallies the archetypes of one game, by opponent name.
+func (r Record) Add(archetypes map[string]string) {
+	for name, a := range archetypes {
+		if r[name] == nil {
+			r[name] = map[Archetype]int{}
+		}
+		r[name][Archetype(a)]++
+	}
+}
+
+// Priors returns the archetype each opponent was classified as most often,
+// by name.
+func (r Record) Priors() map[string]Archetype {
+	priors := make(map[string]Archetype, len(r))
+	for name, counts := range r {
+		archetypes := make([]Archetype, 0, len(counts))
+		for a := range counts {
+			archetypes = append(archetypes, a)
+		}
+		sort.Slice(archetypes, func(i, j int) bool {
+			a, b := archetypes[i], archetypes[j]
+			return counts[a] > counts[b] || counts[a] == counts[b] && a < b
+		})
+		if len(archetypes) > 0 {
+			priors[name] = archetypes[0]
+		}
+	}
+	return priors
+}
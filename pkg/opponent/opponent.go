@@ -0,0 +1,213 @@
+// Package opponent models how each opponent plays from its moves over the
+// turns of a game, classifying it into an archetype whose play strategies
+// can counter.
+package opponent
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+)
+
+// Archetype is a style of play.
+type Archetype string
+
+const (
+	// Unknown is a snake we haven't seen enough of to classify.
+	Unknown Archetype = ""
+	// Greedy snakes head for food whenever they can.
+	Greedy Archetype = "greedy"
+	// Cautious snakes back away from other snakes' heads.
+	Cautious Archetype = "cautious"
+	// Aggressive snakes close in on other snakes' heads.
+	Aggressive Archetype = "aggressive"
+)
+
+const (
+	// minMoves and minEncounters are the moves, and the moves near another
+	// head, a snake must have made before it is classified.
+	minMoves      = 10
+	minEncounters = 4
+	// encounterRange is how near another snake's head a move must start to
+	// count as an encounter.
+	encounterRange = 4
+	// The shares of moves that make a snake greedy, aggressive or cautious.
+	greedyShare     = 0.6
+	aggressiveShare = 0.5
+	cautiousShare   = 0.6
+)
+
+// Profile counts what a snake did with its moves.
+type Profile struct {
+	Name  string
+	Moves int
+	// Feeding counts the moves that brought it nearer the nearest food.
+	Feeding int
+	// Encounters counts the moves started with another snake's head within
+	// encounterRange, Closing those that moved towards the nearest such
+	// head and Backing those that moved away.
+	Encounters int
+	Closing    int
+	Backing    int
+}
+
+// Archetype classifies the snake, or returns Unknown if it hasn't made
+// enough moves or none of its habits stands out.
+func (p *Profile) Archetype() Archetype {
+	encountered := p.Encounters >= minEncounters
+	switch {
+	case encountered && share(p.Closing, p.Encounters) >= aggressiveShare:
+		return Aggressive
+	case p.Moves >= minMoves && share(p.Feeding, p.Moves) >= greedyShare:
+		return Greedy
+	case encountered && share(p.Backing, p.Encounters) >= cautiousShare:
+		return Cautious
+	}
+	return Unknown
+}
+
+func share(n, of int) float64 {
+	return float64(n) / float64(of)
+}
+
+// Model profiles the opponents of one game from the boards of its turns.
+// It is safe for concurrent use.
+type Model struct {
+	// Priors are the archetypes opponents were classified as in earlier
+	// games, by name, used until they have been seen enough in this one.
+	Priors map[string]Archetype
+
+	mu       sync.Mutex
+	last     api.GameRequest
+	seen     bool
+	profiles map[string]*Profile
+}
+
+// NewModel returns a model of a game's opponents starting from priors.
+func NewModel(priors map[string]Archetype) *Model {
+	return &Model{Priors: priors, profiles: map[string]*Profile{}}
+}
+
+// Observe updates the profiles from the board of a new turn, comparing each
+// snake's head with where it was on the turn observed before.
+func (m *Model) Observe(game api.GameRequest) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.seen && game.Turn <= m.last.Turn {
+		return
+	}
+	if m.seen {
+		m.observe(m.last, game)
+	}
+	m.last, m.seen = game, true
+}
+
+// observe profiles the moves each opponent made from prev to cur.
+func (m *Model) observe(prev, cur api.GameRequest) {
+	geo := board.GeometryFor(prev.Board.Width, prev.Board.Height, board.IsWrapped(prev.Game.Ruleset.Name))
+	before := make(map[string]api.Battlesnake, len(prev.Board.Snakes))
+	for _, snake := range prev.Board.Snakes {
+		before[snake.ID] = snake
+	}
+	for _, snake := range cur.Board.Snakes {
+		was, ok := before[snake.ID]
+		if !ok || snake.ID == cur.You.ID {
+			continue
+		}
+		p := m.profiles[snake.ID]
+		if p == nil {
+			p = &Profile{Name: snake.Name}
+			m.profiles[snake.ID] = p
+		}
+		p.Moves++
+		if food := nearest(geo, was.Head, prev.Board.Food); food >= 0 &&
+			nearest(geo, snake.Head, prev.Board.Food) < food {
+			p.Feeding++
+		}
+
+		head, near := api.Coord{}, -1
+		for _, other := range prev.Board.Snakes {
+			if other.ID == snake.ID {
+				continue
+			}
+			if d := geo.Distance(was.Head, other.Head); near < 0 || d < near {
+				head, near = other.Head, d
+			}
+		}
+		if near < 0 || near > encounterRange {
+			continue
+		}
+		p.Encounters++
+		switch d := geo.Distance(snake.Head, head); {
+		case d < near:
+			p.Closing++
+		case d > near:
+			p.Backing++
+		}
+	}
+}
+
+// nearest returns the distance from pos to the nearest food, ignoring
+// obstacles, or -1 if there is none.
+func nearest(geo *board.Geometry, pos api.Coord, food []api.Coord) int {
+	best := -1
+	for _, f := range food {
+		if d := geo.Distance(pos, f); best < 0 || d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// Archetype returns the archetype of the opponent with the given ID, as
+// profiled in this game or, until it can be, by its prior.
+func (m *Model) Archetype(id string) Archetype {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.archetype(id)
+}
+
+func (m *Model) archetype(id string) Archetype {
+	p, ok := m.profiles[id]
+	if !ok {
+		for _, snake := range m.last.Board.Snakes {
+			if snake.ID == id {
+				return m.Priors[snake.Name]
+			}
+		}
+		return Unknown
+	}
+	if a := p.Archetype(); a != Unknown {
+		return a
+	}
+	return m.Priors[p.Name]
+}
+
+// Archetypes returns the archetypes the opponents were classified as in
+// this game, by name, leaving out those that couldn't be.
+func (m *Model) Archetypes() map[string]Archetype {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	archetypes := map[string]Archetype{}
+	for _, p := range m.profiles {
+		if a := p.Archetype(); a != Unknown {
+			archetypes[p.Name] = a
+		}
+	}
+	return archetypes
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying m.
+func NewContext(ctx context.Context, m *Model) context.Context {
+	return context.WithValue(ctx, contextKey{}, m)
+}
+
+// FromContext returns the model carried by ctx, or nil.
+func FromContext(ctx context.Context) *Model {
+	m, _ := ctx.Value(contextKey{}).(*Model)
+	return m
+}
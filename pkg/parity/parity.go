@@ -0,0 +1,196 @@
+// Package parity checks the simulator against fixture turns encoding the
+// behavior of the official rules engine (BattlesnakeOfficial/rules), so a
+// search never plans around rules the engine doesn't play by. The fixtures
+// are run by the tests and the "parity" subcommand.
+package parity
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/sim"
+)
+
+//go:embed fixtures.json
+var fixtures []byte
+
+// Case is one turn of the standard rules: a board, everyone's moves and the
+// board the engine produces from them.
+type Case struct {
+	Name string `json:"name"`
+	// Rule is the behavior of the engine the case checks.
+	Rule string `json:"rule"`
+	// Width and Height default to 7.
+	Width        int               `json:"width"`
+	Height       int               `json:"height"`
+	HazardDamage int32             `json:"hazardDamage"`
+	Food         []Point           `json:"food"`
+	Hazards      []Point           `json:"hazards"`
+	Snakes       []Snake           `json:"snakes"`
+	Moves        map[string]string `json:"moves"`
+	Expect       Expect            `json:"expect"`
+}
+
+// Expect is the outcome of a Case. Food is only checked if given.
+type Expect struct {
+	Food       []Point  `json:"food"`
+	Snakes     []Snake  `json:"snakes"`
+	Eliminated []string `json:"eliminated"`
+}
+
+// Snake is a snake in a Case, with its body from the head.
+type Snake struct {
+	ID     string  `json:"id"`
+	Health int     `json:"health"`
+	Body   []Point `json:"body"`
+}
+
+// Point is a cell as [x, y], to keep the fixtures compact.
+type Point [2]int
+
+func (p Point) coord() api.Coord { return api.Coord{X: p[0], Y: p[1]} }
+
+// Cases returns the embedded fixtures.
+func Cases() ([]Case, error) {
+	var cases []Case
+	if err := json.Unmarshal(fixtures, &cases); err != nil {
+		return nil, fmt.Errorf("parity: fixtures: %v", err)
+	}
+	return cases, nil
+}
+
+// Request returns the position c starts from, played by its first snake.
+func (c Case) Request() api.GameRequest {
+	game := api.GameRequest{
+		Game: api.Game{ID: c.Name, Ruleset: api.Ruleset{
+			Name:     "standard",
+			Settings: api.RulesetSettings{HazardDamagePerTurn: c.HazardDamage},
+		}},
+		Board: api.Board{Width: c.Width, Height: c.Height},
+	}
+	if game.Board.Width == 0 {
+		game.Board.Width = 7
+	}
+	if game.Board.Height == 0 {
+		game.Board.Height = 7
+	}
+	for _, p := range c.Food {
+		game.Board.Food = append(game.Board.Food, p.coord())
+	}
+	for _, p := range c.Hazards {
+		game.Board.Hazards = append(game.Board.Hazards, p.coord())
+	}
+	for _, s := range c.Snakes {
+		snake := api.Battlesnake{ID: s.ID, Name: s.ID, Health: int32(s.Health), Length: int32(len(s.Body))}
+		for _, p := range s.Body {
+			snake.Body = append(snake.Body, p.coord())
+		}
+		snake.Head = snake.Body[0]
+		game.Board.Snakes = append(game.Board.Snakes, snake)
+	}
+	game.You = game.Board.Snakes[0]
+	return game
+}
+
+// Check plays c's moves in the simulator, returning how the result differs
+// from the engine's.
+func (c Case) Check() ([]string, error) {
+	state := sim.New(c.Request())
+	moves := make([]api.Direction, len(state.Snakes))
+	for i, snake := range state.Snakes {
+		if err := moves[i].UnmarshalText([]byte(c.Moves[snake.ID])); err != nil {
+			return nil, fmt.Errorf("parity: %s: move of %s: %v", c.Name, snake.ID, err)
+		}
+	}
+	state.Apply(moves)
+
+	var diffs []string
+	var eliminated []string
+	for i := range state.Snakes {
+		if state.Snakes[i].Eliminated {
+			eliminated = append(eliminated, state.Snakes[i].ID)
+		}
+	}
+	want := slices.Clone(c.Expect.Eliminated)
+	sort.Strings(eliminated)
+	sort.Strings(want)
+	if !slices.Equal(eliminated, want) {
+		diffs = append(diffs, fmt.Sprintf("eliminated %v, want %v", eliminated, want))
+	}
+	for _, w := range c.Expect.Snakes {
+		i := slices.IndexFunc(state.Snakes, func(s sim.Snake) bool { return s.ID == w.ID })
+		if i < 0 || state.Snakes[i].Eliminated {
+			continue
+		}
+		got := &state.Snakes[i]
+		if got.Health != w.Health {
+			diffs = append(diffs, fmt.Sprintf("%s health %d, want %d", w.ID, got.Health, w.Health))
+		}
+		body := make([]Point, got.Len())
+		for j := range body {
+			seg := got.Segment(j)
+			body[j] = Point{seg.X, seg.Y}
+		}
+		if !slices.Equal(body, w.Body) {
+			diffs = append(diffs, fmt.Sprintf("%s body %v, want %v", w.ID, body, w.Body))
+		}
+	}
+	if c.Expect.Food != nil {
+		var food []Point
+		for i := 0; i < state.Width*state.Height; i++ {
+			if state.Food.Has(i) {
+				p := state.Coord(i)
+				food = append(food, Point{p.X, p.Y})
+			}
+		}
+		if !sameCells(food, c.Expect.Food) {
+			diffs = append(diffs, fmt.Sprintf("food %v, want %v", food, c.Expect.Food))
+		}
+	}
+	return diffs, nil
+}
+
+func sameCells(a, b []Point) bool {
+	less := func(p, q Point) int {
+		if p[1] != q[1] {
+			return p[1] - q[1]
+		}
+		return p[0] - q[0]
+	}
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.SortFunc(a, less)
+	slices.SortFunc(b, less)
+	return slices.Equal(a, b)
+}
+
+// Run checks every fixture, writing a line for each to w, and returns the
+// number that failed.
+func Run(w io.Writer) (int, error) {
+	cases, err := Cases()
+	if err != nil {
+		return 0, err
+	}
+	failed := 0
+	for _, c := range cases {
+		diffs, err := c.Check()
+		if err != nil {
+			return failed, err
+		}
+		if len(diffs) == 0 {
+			fmt.Fprintf(w, "ok   %-34s %s\n", c.Name, c.Rule)
+			continue
+		}
+		failed++
+		fmt.Fprintf(w, "FAIL %-34s %s\n", c.Name, c.Rule)
+		for _, d := range diffs {
+			fmt.Fprintf(w, "     %s\n", d)
+		}
+	}
+	fmt.Fprintf(w, "%d of %d cases match the rules engine\n", len(cases)-failed, len(cases))
+	return failed, nil
+}
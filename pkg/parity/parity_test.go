@@ -0,0 +1,26 @@
+package parity
+
+import "testing"
+
+// TestCases runs every fixture, so that the simulator can't drift from the
+// rules engine without failing the tests.
+func TestCases(t *testing.T) {
+	cases, err := Cases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) == 0 {
+		t.Fatal("no fixtures")
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			diffs, err := c.Check()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range diffs {
+				t.Errorf("%s: %s", c.Rule, d)
+			}
+		})
+	}
+}
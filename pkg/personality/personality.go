@@ -0,0 +1,140 @@
+// Package personality defines named packs of appearance, taunts and risk
+// tolerance that make snake instances running the same strategy feel
+// different.
+package personality
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/appearance"
+)
+
+// Default is the name of the personality used when none is selected.
+const Default = "default"
+
+// Personality is a named pack of cosmetic and behavioural modifiers.
+type Personality struct {
+	Name string `json:"name"`
+	appearance.Appearance
+	// Taunts are shout templates, one of which is shouted on a turn with
+	// probability TauntChance. "{turn}", "{length}" and "{health}" are
+	// replaced with our current values.
+	Taunts      []string `json:"taunts"`
+	TauntChance float64  `json:"tauntChance"`
+	// Replies maps opponent names to the shout we answer their shouts
+	// with, as in the server's shout replies.
+	Replies map[string]string `json:"replies"`
+	// Risk scales how much danger strategies will accept: 1 is neutral,
+	// above 1 takes more risks and below 1 fewer.
+	Risk float64 `json:"risk"`
+}
+
+var (
+	mu    sync.RWMutex
+	packs = map[string]Personality{
+		Default: {
+			Name:       Default,
+			Appearance: appearance.Appearance{Color: "#ff6600", Head: "pixel", Tail: "pixel"},
+			Risk:       1,
+		},
+		"aggro": {
+			Name:        "aggro",
+			Appearance:  appearance.Appearance{Color: "#e0115f", Head: "evil", Tail: "sharp"},
+			Taunts:      []string{"{length} long and hungry", "run.", "turn {turn}: still hunting"},
+			TauntChance: 0.1,
+			Replies:     map[string]string{"*": "big words, {name}"},
+			Risk:        1.5,
+		},
+		"coward": {
+			Name:        "coward",
+			Appearance:  appearance.Appearance{Color: "#f5e663", Head: "shy", Tail: "round-bum"},
+			Taunts:      []string{"please don't eat me", "just passing through", "{health} hp and counting"},
+			TauntChance: 0.05,
+			Risk:        0.5,
+		},
+	}
+)
+
+// Register adds p, replacing any personality with the same name.
+func Register(p Personality) {
+	mu.Lock()
+	defer mu.Unlock()
+	packs[p.Name] = p
+}
+
+// Get returns the personality called name.
+func Get(name string) (Personality, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := packs[name]
+	return p, ok
+}
+
+// Names returns the names of all personalities in sorted order.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(packs))
+	for name := range packs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load registers every personality in the JSON array in the file at path.
+func Load(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var ps []Personality
+	if err := json.Unmarshal(b, &ps); err != nil {
+		return err
+	}
+	for _, p := range ps {
+		if p.Risk == 0 {
+			p.Risk = 1
+		}
+		Register(p)
+	}
+	return nil
+}
+
+// Taunt returns a randomly chosen taunt for this turn, or "" if p stays
+// quiet.
+func (p Personality) Taunt(game api.GameRequest) string {
+	if len(p.Taunts) == 0 || rand.Float64() >= p.TauntChance {
+		return ""
+	}
+	return strings.NewReplacer(
+		"{turn}", strconv.Itoa(game.Turn),
+		"{length}", strconv.Itoa(int(game.You.Length)),
+		"{health}", strconv.Itoa(int(game.You.Health)),
+	).Replace(p.Taunts[rand.Intn(len(p.Taunts))])
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying p, for strategies to read with
+// FromContext.
+func NewContext(ctx context.Context, p Personality) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the personality carried by ctx, or the default one.
+func FromContext(ctx context.Context) Personality {
+	if p, ok := ctx.Value(contextKey{}).(Personality); ok {
+		return p
+	}
+	p, _ := Get(Default)
+	return p
+}
@@ -0,0 +1,512 @@
+// Package repl is an interactive session for studying a position: asking
+// the engine for its move and why, flood filling and searching from it, and
+// stepping the simulator forward to see what happens next.
+package repl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/eval"
+	"github.com/jayuuza/battlesnake/pkg/history"
+	"github.com/jayuuza/battlesnake/pkg/search"
+	"github.com/jayuuza/battlesnake/pkg/sim"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// Timeout is how long the engine may think about a query.
+const Timeout = 5 * time.Second
+
+const help = `commands:
+  load <file> [turn]   load a JSON request, an ASCII board, or a turn of a
+                       recorded game (its directory or history file)
+  paste                read an ASCII board, ended by an empty line
+  show                 print the position
+  best [strategy]      the strategy's move and plan (default: heuristic)
+  eval                 the heuristic's breakdown of every move
+  flood <x> <y>        cells reachable from x,y, by distance
+  search <depth> [out] which moves survive depth turns against every reply
+  kill [out]           look for a forced kill
+                       out saves the search tree, as Graphviz if it ends
+                       in .dot and as JSON otherwise
+  guess <move>         puzzle mode: check your move against the engine's
+  step <move> [moves]  play our move, other snakes playing the given moves
+                       in board order or the opponent strategy's
+  opp <strategy>       the strategy other snakes play when stepping
+  back                 undo the last step
+  save <file>          write the position as a JSON request
+  quit
+`
+
+// Session is the state of a REPL: the position studied and the ones
+// stepped through to reach it.
+type Session struct {
+	w        io.Writer
+	game     api.GameRequest
+	loaded   bool
+	previous []api.GameRequest
+	opponent string
+}
+
+// New returns a session writing to w, studying game if it is not nil.
+func New(w io.Writer, game *api.GameRequest) *Session {
+	s := &Session{w: w, opponent: "heuristic"}
+	if game != nil {
+		s.game, s.loaded = *game, true
+	}
+	return s
+}
+
+// Run reads commands from r until it ends or the quit command.
+func (s *Session) Run(r io.Reader) error {
+	in := bufio.NewScanner(r)
+	fmt.Fprint(s.w, "type help for commands\n> ")
+	for in.Scan() {
+		fields := strings.Fields(in.Text())
+		if len(fields) > 0 {
+			if fields[0] == "quit" || fields[0] == "exit" {
+				return nil
+			}
+			if err := s.Exec(fields, in); err != nil {
+				fmt.Fprintln(s.w, "error:", err)
+			}
+		}
+		fmt.Fprint(s.w, "> ")
+	}
+	return in.Err()
+}
+
+// Exec runs one command, reading any further input it needs from in.
+func (s *Session) Exec(fields []string, in *bufio.Scanner) error {
+	cmd, args := fields[0], fields[1:]
+	switch cmd {
+	case "help", "?":
+		fmt.Fprint(s.w, help)
+		return nil
+	case "load":
+		return s.load(args)
+	case "paste":
+		return s.paste(in)
+	case "opp":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: opp <strategy>")
+		}
+		if _, err := strategy.New(args[0]); err != nil {
+			return err
+		}
+		s.opponent = args[0]
+		return nil
+	}
+	if !s.loaded {
+		return fmt.Errorf("no position: load or paste one first")
+	}
+	switch cmd {
+	case "show":
+		s.show()
+	case "best":
+		return s.best(args)
+	case "eval":
+		s.eval()
+	case "flood":
+		return s.flood(args)
+	case "search":
+		return s.search(args)
+	case "kill":
+		return s.kill(args)
+	case "guess":
+		return s.guess(args)
+	case "step":
+		return s.step(args)
+	case "back":
+		if len(s.previous) == 0 {
+			return fmt.Errorf("no step to undo")
+		}
+		s.game = s.previous[len(s.previous)-1]
+		s.previous = s.previous[:len(s.previous)-1]
+		s.show()
+	case "save":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: save <file>")
+		}
+		b, err := json.MarshalIndent(s.game, "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(args[0], b, 0644)
+	default:
+		return fmt.Errorf("unknown command %q, try help", cmd)
+	}
+	return nil
+}
+
+// Load reads a position from path: a JSON request, an ASCII board or,
+// given a game directory or history file, the recorded turn.
+func Load(path string, turn int) (api.GameRequest, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, history.FileName)
+	}
+	if base := filepath.Base(path); base == history.FileName || base == history.CompressedFileName {
+		turns, err := history.Load(path)
+		if err != nil {
+			return api.GameRequest{}, err
+		}
+		for _, t := range turns {
+			if t.Turn == turn {
+				return t.Request, nil
+			}
+		}
+		return api.GameRequest{}, fmt.Errorf("%s: no turn %d", path, turn)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return api.GameRequest{}, err
+	}
+	if text := strings.TrimSpace(string(b)); strings.HasPrefix(text, "{") {
+		var game api.GameRequest
+		err := json.Unmarshal(b, &game)
+		return game, err
+	}
+	return board.ParseASCII(string(b))
+}
+
+func (s *Session) load(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: load <file> [turn]")
+	}
+	turn := 0
+	if len(args) == 2 {
+		var err error
+		if turn, err = strconv.Atoi(args[1]); err != nil {
+			return fmt.Errorf("turn: %v", err)
+		}
+	}
+	game, err := Load(args[0], turn)
+	if err != nil {
+		return err
+	}
+	s.set(game)
+	return nil
+}
+
+func (s *Session) paste(in *bufio.Scanner) error {
+	var lines []string
+	for in.Scan() && strings.TrimSpace(in.Text()) != "" {
+		lines = append(lines, in.Text())
+	}
+	game, err := board.ParseASCII(strings.Join(lines, "\n"))
+	if err != nil {
+		return err
+	}
+	s.set(game)
+	return nil
+}
+
+// set studies game from scratch.
+func (s *Session) set(game api.GameRequest) {
+	s.game, s.loaded, s.previous = game, true, nil
+	s.show()
+}
+
+func (s *Session) show() {
+	fmt.Fprint(s.w, board.FormatASCII(s.game))
+	fmt.Fprintf(s.w, "we are %s (%s): health %d, length %d, head %v\n",
+		s.game.You.Name, s.game.You.ID, s.game.You.Health, s.game.You.Length, s.game.You.Head)
+}
+
+// context returns a context bounding the engine's thinking.
+func (s *Session) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), Timeout)
+}
+
+// decide returns the named strategy's move in game and its plan.
+func (s *Session) decide(name string, game api.GameRequest) (api.Direction, string, error) {
+	strat, err := strategy.New(name)
+	if err != nil {
+		return 0, "", err
+	}
+	ctx, cancel := s.context()
+	defer cancel()
+	ctx, plan := strategy.WithPlan(ctx)
+	strat.Start(ctx, game)
+	move := strat.Move(ctx, game)
+	return move.Move, plan.String(), nil
+}
+
+func (s *Session) best(args []string) error {
+	name := "heuristic"
+	if len(args) > 0 {
+		name = args[0]
+	}
+	start := time.Now()
+	move, plan, err := s.decide(name, s.game)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.w, "%s plays %s in %v", name, move, time.Since(start).Round(time.Microsecond))
+	if plan != "" {
+		fmt.Fprintf(s.w, ", %s", plan)
+	}
+	fmt.Fprintln(s.w)
+	return nil
+}
+
+// evaluations returns the heuristic's breakdown of each move that doesn't
+// immediately kill us, best first, with its total.
+func (s *Session) evaluations() ([]api.Direction, map[api.Direction]map[string]float64, map[api.Direction]float64) {
+	grid := board.GridFor(s.game)
+	cache := eval.NewCache(s.game, grid)
+	moves := grid.ValidMoves(s.game.You.Head)
+	breakdowns := map[api.Direction]map[string]float64{}
+	totals := map[api.Direction]float64{}
+	for _, move := range moves {
+		p := eval.NewPosition(cache, move)
+		breakdowns[move] = eval.Breakdown(p)
+		totals[move] = eval.Evaluate(p)
+	}
+	sort.SliceStable(moves, func(i, j int) bool { return totals[moves[i]] > totals[moves[j]] })
+	return moves, breakdowns, totals
+}
+
+func (s *Session) eval() {
+	moves, breakdowns, totals := s.evaluations()
+	if len(moves) == 0 {
+		fmt.Fprintln(s.w, "every move is lethal")
+		return
+	}
+	terms := make([]string, 0, len(eval.Terms))
+	for _, t := range eval.Terms {
+		terms = append(terms, t.Name)
+	}
+	fmt.Fprintf(s.w, "%-6s %8s", "move", "total")
+	for _, name := range terms {
+		fmt.Fprintf(s.w, " %8s", name)
+	}
+	fmt.Fprintln(s.w)
+	for _, move := range moves {
+		fmt.Fprintf(s.w, "%-6s %8.3f", move, totals[move])
+		for _, name := range terms {
+			fmt.Fprintf(s.w, " %8.3f", breakdowns[move][name])
+		}
+		fmt.Fprintln(s.w)
+	}
+}
+
+func (s *Session) flood(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: flood <x> <y>")
+	}
+	x, errX := strconv.Atoi(args[0])
+	y, errY := strconv.Atoi(args[1])
+	if errX != nil || errY != nil {
+		return fmt.Errorf("usage: flood <x> <y>")
+	}
+	pos := api.Coord{X: x, Y: y}
+	grid := board.GridFor(s.game)
+	if !grid.InBounds(pos) {
+		return fmt.Errorf("%v is off the board", pos)
+	}
+	dist := grid.Distances(pos)
+	reached := 0
+	for row := grid.Height - 1; row >= 0; row-- {
+		for col := 0; col < grid.Width; col++ {
+			d := dist[row*grid.Width+col]
+			switch {
+			case d < 0:
+				fmt.Fprint(s.w, "·")
+			case d < 10:
+				fmt.Fprint(s.w, d)
+			default:
+				fmt.Fprint(s.w, "+")
+			}
+			if d > 0 {
+				reached++
+			}
+		}
+		fmt.Fprintln(s.w)
+	}
+	fmt.Fprintf(s.w, "%d cells reachable from %v, by distance (+ is 10 or more)\n", reached, pos)
+	return nil
+}
+
+// traceContext returns ctx recording the search tree if args name a file
+// to save it to.
+func traceContext(ctx context.Context, args []string) (context.Context, *search.Trace) {
+	if len(args) == 0 {
+		return ctx, nil
+	}
+	return search.WithTrace(ctx)
+}
+
+// saveTrace writes trace to path, as DOT if it ends in .dot and as JSON
+// otherwise, if trace isn't nil.
+func (s *Session) saveTrace(trace *search.Trace, path string) error {
+	if trace == nil {
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(path, ".dot") {
+		err = trace.WriteDOT(f)
+	} else {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(trace)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.w, "saved %d nodes of the search tree to %s", trace.Nodes, path)
+	if trace.Truncated {
+		fmt.Fprintf(s.w, " (truncated)")
+	}
+	fmt.Fprintln(s.w)
+	return nil
+}
+
+func (s *Session) search(args []string) error {
+	depth := 0
+	if len(args) == 1 || len(args) == 2 {
+		depth, _ = strconv.Atoi(args[0])
+	}
+	if depth < 1 {
+		return fmt.Errorf("usage: search <depth> [out], depth at least 1")
+	}
+	ctx, cancel := s.context()
+	defer cancel()
+	ctx, stats := search.WithStats(ctx)
+	ctx, trace := traceContext(ctx, args[1:])
+	start := time.Now()
+	survives, finished := search.Survival(ctx, s.game, depth)
+	for _, move := range api.Directions {
+		ok, searched := survives[move]
+		switch {
+		case !searched:
+			fmt.Fprintf(s.w, "%-6s not searched\n", move)
+		case ok:
+			fmt.Fprintf(s.w, "%-6s survives %d turns against every reply\n", move, depth)
+		default:
+			fmt.Fprintf(s.w, "%-6s can be killed within %d turns\n", move, depth)
+		}
+	}
+	summary := stats.Summary()
+	fmt.Fprintf(s.w, "%d nodes in %v", summary.Nodes, time.Since(start).Round(time.Microsecond))
+	if !finished {
+		fmt.Fprintf(s.w, ", ran out of time")
+	}
+	fmt.Fprintln(s.w)
+	if trace != nil {
+		return s.saveTrace(trace, args[1])
+	}
+	return nil
+}
+
+func (s *Session) kill(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: kill [out]")
+	}
+	ctx, cancel := s.context()
+	defer cancel()
+	ctx, stats := search.WithStats(ctx)
+	ctx, trace := traceContext(ctx, args)
+	move, ok := search.ForcedKill(ctx, s.game, &search.Throughput{})
+	summary := stats.Summary()
+	if ok {
+		fmt.Fprintf(s.w, "%s forces a kill (searched %d turns deep, %d nodes)\n", move, summary.Depth, summary.Nodes)
+	} else {
+		fmt.Fprintf(s.w, "no forced kill found (%d nodes)\n", summary.Nodes)
+	}
+	if trace != nil {
+		return s.saveTrace(trace, args[0])
+	}
+	return nil
+}
+
+func (s *Session) guess(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: guess <move>")
+	}
+	var guess api.Direction
+	if err := guess.UnmarshalText([]byte(args[0])); err != nil {
+		return err
+	}
+	best, plan, err := s.decide("heuristic", s.game)
+	if err != nil {
+		return err
+	}
+	if guess == best {
+		fmt.Fprintf(s.w, "correct: %s (%s)\n", best, plan)
+		return nil
+	}
+	moves, _, totals := s.evaluations()
+	if _, ok := totals[guess]; !ok {
+		fmt.Fprintf(s.w, "%s is lethal; the engine plays %s (%s)\n", guess, best, plan)
+		return nil
+	}
+	rank := 1
+	for _, move := range moves {
+		if move == guess {
+			break
+		}
+		rank++
+	}
+	fmt.Fprintf(s.w, "the engine plays %s (%s); %s ranks %d of %d, scoring %.3f to %.3f\n",
+		best, plan, guess, rank, len(moves), totals[guess], totals[best])
+	return nil
+}
+
+func (s *Session) step(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: step <move> [moves of the other snakes]")
+	}
+	snakes := s.game.Board.Snakes
+	moves := make([]api.Direction, len(snakes))
+	given := args[1:]
+	for i, snake := range snakes {
+		var text string
+		switch {
+		case snake.ID == s.game.You.ID:
+			text = args[0]
+		case len(given) > 0:
+			text, given = given[0], given[1:]
+		default:
+			them := s.game
+			them.You = snake
+			move, _, err := s.decide(s.opponent, them)
+			if err != nil {
+				return err
+			}
+			moves[i] = move
+			fmt.Fprintf(s.w, "%s plays %s\n", snake.Name, move)
+			continue
+		}
+		if err := moves[i].UnmarshalText([]byte(text)); err != nil {
+			return err
+		}
+	}
+
+	state := sim.New(s.game)
+	state.Apply(moves)
+	s.previous = append(s.previous, s.game)
+	s.game = state.Request(s.game)
+	s.show()
+	if state.Snakes[state.You].Eliminated {
+		fmt.Fprintln(s.w, "we were eliminated; back to undo")
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+// Package results records the outcome of every game we play and summarises
+// them into win rates.
+package results
+
+import (
+	"bufio"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+// Outcomes of a game.
+const (
+	Win  = "win"
+	Loss = "loss"
+	Draw = "draw"
+)
+
+// Result is the outcome of one game.
+type Result struct {
+	GameID   string    `json:"gameId"`
+	Time     time.Time `json:"time"`
+	Strategy string    `json:"strategy"`
+	// Experiment and Arm identify the A/B experiment arm the game was
+	// assigned to, if any.
+	Experiment string `json:"experiment,omitempty"`
+	Arm        string `json:"arm,omitempty"`
+	Outcome    string `json:"outcome"`
+	// Death is what eliminated us, if we were eliminated.
+	Death     string   `json:"death,omitempty"`
+	Turns     int      `json:"turns"`
+	Ruleset   string   `json:"ruleset"`
+	Map       string   `json:"map"`
+	Width     int      `json:"width"`
+	Height    int      `json:"height"`
+	Opponents []string `json:"opponents"`
+	// Archetypes are the styles of play the opponents were classified as,
+	// by name (see package opponent).
+	Archetypes map[string]string `json:"archetypes,omitempty"`
+}
+
+// New returns the result of the game whose final state is end, the request
+// sent to /end. Eliminated snakes are no longer on the board, so we won if
+// we are the only snake left and drew if no snake is.
+func New(end api.GameRequest) Result {
+	r := Result{
+		GameID:  end.Game.ID,
+		Time:    time.Now().UTC(),
+		Outcome: Loss,
+		Turns:   end.Turn,
+		Ruleset: end.Game.Ruleset.Name,
+		Map:     end.Game.Map,
+		Width:   end.Board.Width,
+		Height:  end.Board.Height,
+	}
+	alive := false
+	for _, snake := range end.Board.Snakes {
+		if snake.ID == end.You.ID {
+			alive = true
+		}
+	}
+	switch {
+	case len(end.Board.Snakes) == 0:
+		r.Outcome = Draw
+	case alive && len(end.Board.Snakes) == 1:
+		r.Outcome = Win
+	}
+	return r
+}
+
+// Store appends results to a JSON lines file.
+type Store struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// FileName is the name of the results file within the data directory.
+const FileName = "results.jsonl"
+
+// NewStore returns a Store keeping results in dir.
+func NewStore(dir string) *Store {
+	return &Store{Path: filepath.Join(dir, FileName)}
+}
+
+// Add appends r to the store.
+func (s *Store) Add(r Result) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// All returns every stored result, oldest first.
+func (s *Store) All() ([]Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.Open(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rs []Result
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Result
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+	return rs, scanner.Err()
+}
+
+// Summary counts the outcomes of a set of games.
+type Summary struct {
+	Games   int     `json:"games"`
+	Wins    int     `json:"wins"`
+	Losses  int     `json:"losses"`
+	Draws   int     `json:"draws"`
+	WinRate float64 `json:"winRate"`
+	// Deaths counts the games we were eliminated from by cause.
+	Deaths map[string]int `json:"deaths,omitempty"`
+}
+
+func (s *Summary) add(r Result) {
+	s.Games++
+	switch r.Outcome {
+	case Win:
+		s.Wins++
+	case Loss:
+		s.Losses++
+	case Draw:
+		s.Draws++
+	}
+	s.WinRate = float64(s.Wins) / float64(s.Games)
+	if r.Death != "" {
+		if s.Deaths == nil {
+			s.Deaths = map[string]int{}
+		}
+		s.Deaths[r.Death]++
+	}
+}
+
+// Summarize totals rs.
+func Summarize(rs []Result) Summary {
+	var s Summary
+	for _, r := range rs {
+		s.add(r)
+	}
+	return s
+}
+
+// GroupBy totals rs separately for each value of key, skipping results for
+// which key is empty.
+func GroupBy(rs []Result, key func(Result) string) map[string]Summary {
+	groups := map[string]Summary{}
+	for _, r := range rs {
+		k := key(r)
+		if k == "" {
+			continue
+		}
+		s := groups[k]
+		s.add(r)
+		groups[k] = s
+	}
+	return groups
+}
+
+// GroupByEach is GroupBy for keys with several values per result, such as
+// the opponents: a result is totalled once under each of its keys.
+func GroupByEach(rs []Result, keys func(Result) []string) map[string]Summary {
+	groups := map[string]Summary{}
+	for _, r := range rs {
+		for _, k := range keys(r) {
+			if k == "" {
+				continue
+			}
+			s := groups[k]
+			s.add(r)
+			groups[k] = s
+		}
+	}
+	return groups
+}
+
+// Period totals the games played in a span of time starting at Start.
+type Period struct {
+	Start time.Time `json:"start"`
+	Summary
+}
+
+// Trend totals rs separately for each span of every, in UTC and oldest
+// first, omitting spans without games.
+func Trend(rs []Result, every time.Duration) []Period {
+	byStart := map[time.Time]*Period{}
+	for _, r := range rs {
+		start := r.Time.UTC().Truncate(every)
+		p := byStart[start]
+		if p == nil {
+			p = &Period{Start: start}
+			byStart[start] = p
+		}
+		p.add(r)
+	}
+	periods := make([]Period, 0, len(byStart))
+	for _, p := range byStart {
+		periods = append(periods, *p)
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Start.Before(periods[j].Start) })
+	return periods
+}
+
+// ExperimentReport compares the arms of an A/B experiment.
+type ExperimentReport struct {
+	Name string  `json:"name"`
+	A    Summary `json:"a"`
+	B    Summary `json:"b"`
+	// PValue is the two-sided p-value of a two-proportion z-test of the
+	// arms' win rates; small values mean the difference is unlikely to be
+	// chance. It is 1 until both arms have played.
+	PValue float64 `json:"pValue"`
+}
+
+// Experiments reports every A/B experiment found in rs, by name.
+func Experiments(rs []Result) []ExperimentReport {
+	byName := map[string]*ExperimentReport{}
+	for _, r := range rs {
+		if r.Experiment == "" {
+			continue
+		}
+		e := byName[r.Experiment]
+		if e == nil {
+			e = &ExperimentReport{Name: r.Experiment}
+			byName[r.Experiment] = e
+		}
+		switch r.Arm {
+		case "A":
+			e.A.add(r)
+		case "B":
+			e.B.add(r)
+		}
+	}
+
+	reports := make([]ExperimentReport, 0, len(byName))
+	for _, e := range byName {
+		e.PValue = pValue(e.A, e.B)
+		reports = append(reports, *e)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Name < reports[j].Name })
+	return reports
+}
+
+// pValue returns the two-sided p-value of a two-proportion z-test that a
+// and b have the same win rate.
+func pValue(a, b Summary) float64 {
+	if a.Games == 0 || b.Games == 0 {
+		return 1
+	}
+	pooled := float64(a.Wins+b.Wins) / float64(a.Games+b.Games)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(a.Games) + 1/float64(b.Games)))
+	if se == 0 {
+		return 1
+	}
+	z := (a.WinRate - b.WinRate) / se
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
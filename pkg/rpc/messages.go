@@ -0,0 +1,369 @@
+package rpc
+
+import (
+	"fmt"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+// Encoding and decoding between the api wire types and the messages in
+// proto/battlesnake.proto. Field numbers must match the proto file.
+
+func encodeCoord(e *encoder, c api.Coord) {
+	e.int32(1, int32(c.X))
+	e.int32(2, int32(c.Y))
+}
+
+func decodeCoord(d *decoder, c *api.Coord) error {
+	return d.nested(func(d *decoder, field, wireType int) error {
+		switch field {
+		case 1:
+			v, err := d.int32()
+			c.X = int(v)
+			return err
+		case 2:
+			v, err := d.int32()
+			c.Y = int(v)
+			return err
+		}
+		return d.skip(wireType)
+	})
+}
+
+func decodeCoords(d *decoder, coords *[]api.Coord) error {
+	var c api.Coord
+	if err := decodeCoord(d, &c); err != nil {
+		return err
+	}
+	*coords = append(*coords, c)
+	return nil
+}
+
+func encodeGame(e *encoder, g api.Game) {
+	e.string(1, g.ID)
+	e.message(2, func(e *encoder) {
+		e.string(1, g.Ruleset.Name)
+		e.string(2, g.Ruleset.Version)
+		e.message(3, func(e *encoder) {
+			settings := g.Ruleset.Settings
+			e.int32(1, settings.FoodSpawnChance)
+			e.int32(2, settings.MinimumFood)
+			e.int32(3, settings.HazardDamagePerTurn)
+			e.message(4, func(e *encoder) {
+				e.int32(1, settings.Royale.ShrinkEveryNTurns)
+			})
+			e.message(5, func(e *encoder) {
+				e.bool(1, settings.Squad.AllowBodyCollisions)
+				e.bool(2, settings.Squad.SharedElimination)
+				e.bool(3, settings.Squad.SharedHealth)
+				e.bool(4, settings.Squad.SharedLength)
+			})
+		})
+	})
+	e.string(3, g.Map)
+	e.string(4, g.Source)
+	e.int32(5, g.Timeout)
+}
+
+func decodeGame(d *decoder, g *api.Game) error {
+	return d.nested(func(d *decoder, field, wireType int) error {
+		var err error
+		switch field {
+		case 1:
+			g.ID, err = d.string()
+		case 2:
+			err = decodeRuleset(d, &g.Ruleset)
+		case 3:
+			g.Map, err = d.string()
+		case 4:
+			g.Source, err = d.string()
+		case 5:
+			g.Timeout, err = d.int32()
+		default:
+			err = d.skip(wireType)
+		}
+		return err
+	})
+}
+
+func decodeRuleset(d *decoder, r *api.Ruleset) error {
+	return d.nested(func(d *decoder, field, wireType int) error {
+		var err error
+		switch field {
+		case 1:
+			r.Name, err = d.string()
+		case 2:
+			r.Version, err = d.string()
+		case 3:
+			err = decodeSettings(d, &r.Settings)
+		default:
+			err = d.skip(wireType)
+		}
+		return err
+	})
+}
+
+func decodeSettings(d *decoder, s *api.RulesetSettings) error {
+	return d.nested(func(d *decoder, field, wireType int) error {
+		var err error
+		switch field {
+		case 1:
+			s.FoodSpawnChance, err = d.int32()
+		case 2:
+			s.MinimumFood, err = d.int32()
+		case 3:
+			s.HazardDamagePerTurn, err = d.int32()
+		case 4:
+			err = d.nested(func(d *decoder, field, wireType int) error {
+				if field == 1 {
+					var err error
+					s.Royale.ShrinkEveryNTurns, err = d.int32()
+					return err
+				}
+				return d.skip(wireType)
+			})
+		case 5:
+			err = d.nested(func(d *decoder, field, wireType int) error {
+				var err error
+				switch field {
+				case 1:
+					s.Squad.AllowBodyCollisions, err = d.bool()
+				case 2:
+					s.Squad.SharedElimination, err = d.bool()
+				case 3:
+					s.Squad.SharedHealth, err = d.bool()
+				case 4:
+					s.Squad.SharedLength, err = d.bool()
+				default:
+					err = d.skip(wireType)
+				}
+				return err
+			})
+		default:
+			err = d.skip(wireType)
+		}
+		return err
+	})
+}
+
+func encodeSnake(e *encoder, s api.Battlesnake) {
+	e.string(1, s.ID)
+	e.string(2, s.Name)
+	e.int32(3, s.Health)
+	for _, c := range s.Body {
+		e.message(4, func(e *encoder) { encodeCoord(e, c) })
+	}
+	e.message(5, func(e *encoder) { encodeCoord(e, s.Head) })
+	e.int32(6, s.Length)
+	e.string(7, s.Latency)
+	e.string(8, s.Shout)
+}
+
+func decodeSnake(d *decoder, s *api.Battlesnake) error {
+	return d.nested(func(d *decoder, field, wireType int) error {
+		var err error
+		switch field {
+		case 1:
+			s.ID, err = d.string()
+		case 2:
+			s.Name, err = d.string()
+		case 3:
+			s.Health, err = d.int32()
+		case 4:
+			err = decodeCoords(d, &s.Body)
+		case 5:
+			err = decodeCoord(d, &s.Head)
+		case 6:
+			s.Length, err = d.int32()
+		case 7:
+			s.Latency, err = d.string()
+		case 8:
+			s.Shout, err = d.string()
+		default:
+			err = d.skip(wireType)
+		}
+		return err
+	})
+}
+
+func encodeBoard(e *encoder, b api.Board) {
+	e.int32(1, int32(b.Height))
+	e.int32(2, int32(b.Width))
+	for _, c := range b.Food {
+		e.message(3, func(e *encoder) { encodeCoord(e, c) })
+	}
+	for _, c := range b.Hazards {
+		e.message(4, func(e *encoder) { encodeCoord(e, c) })
+	}
+	for _, s := range b.Snakes {
+		e.message(5, func(e *encoder) { encodeSnake(e, s) })
+	}
+}
+
+func decodeBoard(d *decoder, b *api.Board) error {
+	return d.nested(func(d *decoder, field, wireType int) error {
+		var err error
+		switch field {
+		case 1:
+			var v int32
+			v, err = d.int32()
+			b.Height = int(v)
+		case 2:
+			var v int32
+			v, err = d.int32()
+			b.Width = int(v)
+		case 3:
+			err = decodeCoords(d, &b.Food)
+		case 4:
+			err = decodeCoords(d, &b.Hazards)
+		case 5:
+			var s api.Battlesnake
+			err = decodeSnake(d, &s)
+			b.Snakes = append(b.Snakes, s)
+		default:
+			err = d.skip(wireType)
+		}
+		return err
+	})
+}
+
+// gameRequest is a GameRequest message: the api request plus the strategy
+// and personality selected for Start.
+type gameRequest struct {
+	api.GameRequest
+	Strategy    string
+	Personality string
+}
+
+func marshalGameRequest(r gameRequest) []byte {
+	var e encoder
+	e.message(1, func(e *encoder) { encodeGame(e, r.Game) })
+	e.int32(2, int32(r.Turn))
+	e.message(3, func(e *encoder) { encodeBoard(e, r.Board) })
+	e.message(4, func(e *encoder) { encodeSnake(e, r.You) })
+	e.string(5, r.Strategy)
+	e.string(6, r.Personality)
+	return e.b
+}
+
+func unmarshalGameRequest(b []byte) (gameRequest, error) {
+	var r gameRequest
+	err := fields(b, func(d *decoder, field, wireType int) error {
+		var err error
+		switch field {
+		case 1:
+			err = decodeGame(d, &r.Game)
+		case 2:
+			var v int32
+			v, err = d.int32()
+			r.Turn = int(v)
+		case 3:
+			err = decodeBoard(d, &r.Board)
+		case 4:
+			err = decodeSnake(d, &r.You)
+		case 5:
+			r.Strategy, err = d.string()
+		case 6:
+			r.Personality, err = d.string()
+		default:
+			err = d.skip(wireType)
+		}
+		return err
+	})
+	return r, err
+}
+
+func marshalMoveResponse(m api.MoveResponse) []byte {
+	var e encoder
+	e.string(1, m.Move.String())
+	e.string(2, m.Shout)
+	return e.b
+}
+
+func unmarshalMoveResponse(b []byte) (api.MoveResponse, error) {
+	var m api.MoveResponse
+	err := fields(b, func(d *decoder, field, wireType int) error {
+		switch field {
+		case 1:
+			s, err := d.string()
+			if err != nil {
+				return err
+			}
+			dir, ok := api.ParseDirection(s)
+			if !ok {
+				return fmt.Errorf("rpc: invalid move %q", s)
+			}
+			m.Move = dir
+			return nil
+		case 2:
+			var err error
+			m.Shout, err = d.string()
+			return err
+		}
+		return d.skip(wireType)
+	})
+	return m, err
+}
+
+// infoRequest is an InfoRequest message.
+type infoRequest struct {
+	Strategy    string
+	Personality string
+}
+
+func marshalInfoRequest(r infoRequest) []byte {
+	var e encoder
+	e.string(1, r.Strategy)
+	e.string(2, r.Personality)
+	return e.b
+}
+
+func unmarshalInfoRequest(b []byte) (infoRequest, error) {
+	var r infoRequest
+	err := fields(b, func(d *decoder, field, wireType int) error {
+		var err error
+		switch field {
+		case 1:
+			r.Strategy, err = d.string()
+		case 2:
+			r.Personality, err = d.string()
+		default:
+			err = d.skip(wireType)
+		}
+		return err
+	})
+	return r, err
+}
+
+func marshalInfoResponse(r api.BattlesnakeInfoResponse) []byte {
+	var e encoder
+	e.string(1, r.APIVersion)
+	e.string(2, r.Author)
+	e.string(3, r.Color)
+	e.string(4, r.Head)
+	e.string(5, r.Tail)
+	return e.b
+}
+
+func unmarshalInfoResponse(b []byte) (api.BattlesnakeInfoResponse, error) {
+	var r api.BattlesnakeInfoResponse
+	err := fields(b, func(d *decoder, field, wireType int) error {
+		var err error
+		switch field {
+		case 1:
+			r.APIVersion, err = d.string()
+		case 2:
+			r.Author, err = d.string()
+		case 3:
+			r.Color, err = d.string()
+		case 4:
+			r.Head, err = d.string()
+		case 5:
+			r.Tail, err = d.string()
+		default:
+			err = d.skip(wireType)
+		}
+		return err
+	})
+	return r, err
+}
@@ -0,0 +1,229 @@
+// Package rpc serves the Battlesnake API over gRPC, as defined in
+// proto/battlesnake.proto, so local tooling can drive the engine without
+// HTTP/JSON overhead.
+//
+// It speaks the gRPC wire protocol directly over cleartext HTTP/2 using the
+// standard library: messages are length-prefixed protobuf, uncompressed,
+// and the status is reported in the grpc-status trailer.
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/server"
+)
+
+// ServicePath is the path prefix of the service's methods.
+const ServicePath = "/battlesnake.v1.Battlesnake/"
+
+// gRPC status codes used by the service.
+const (
+	codeOK              = 0
+	codeInvalidArgument = 3
+	codeNotFound        = 5
+	codeUnimplemented   = 12
+	codeInternal        = 13
+)
+
+// maxMessageSize bounds the size of a request message.
+const maxMessageSize = 4 << 20
+
+// Handler returns an http.Handler serving srv's methods as gRPC calls.
+func Handler(srv *server.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			http.Error(w, "gRPC requests only", http.StatusUnsupportedMediaType)
+			return
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+
+		msg, err := readMessage(r.Body)
+		if err != nil {
+			writeStatus(w, codeInvalidArgument, err.Error())
+			return
+		}
+		resp, code, err := call(r.Context(), srv, strings.TrimPrefix(r.URL.Path, ServicePath), msg)
+		if err != nil {
+			writeStatus(w, code, err.Error())
+			return
+		}
+		if err := writeMessage(w, resp); err != nil {
+			return
+		}
+		writeStatus(w, codeOK, "")
+	})
+}
+
+// call invokes the named method with a request message, returning the
+// response message or a gRPC status code and error.
+func call(ctx context.Context, srv *server.Server, method string, msg []byte) ([]byte, int, error) {
+	switch method {
+	case "Info":
+		req, err := unmarshalInfoRequest(msg)
+		if err != nil {
+			return nil, codeInvalidArgument, err
+		}
+		info, err := srv.Info(req.Strategy, req.Personality)
+		if err != nil {
+			return nil, codeNotFound, err
+		}
+		return marshalInfoResponse(info), codeOK, nil
+	case "Start", "Move", "End":
+		req, err := unmarshalGameRequest(msg)
+		if err != nil {
+			return nil, codeInvalidArgument, err
+		}
+		switch method {
+		case "Start":
+			srv.Start(ctx, req.GameRequest, req.Strategy, req.Personality)
+		case "Move":
+			return marshalMoveResponse(srv.Move(ctx, req.GameRequest)), codeOK, nil
+		case "End":
+			srv.End(ctx, req.GameRequest)
+		}
+		return nil, codeOK, nil
+	}
+	return nil, codeUnimplemented, fmt.Errorf("unknown method %q", method)
+}
+
+// readMessage reads a single length-prefixed message from r.
+func readMessage(r io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	if header[0] != 0 {
+		return nil, errors.New("compressed messages are not supported")
+	}
+	n := binary.BigEndian.Uint32(header[1:])
+	if n > maxMessageSize {
+		return nil, fmt.Errorf("message of %d bytes exceeds limit", n)
+	}
+	msg := make([]byte, n)
+	_, err := io.ReadFull(r, msg)
+	return msg, err
+}
+
+// writeMessage writes msg to w with its length prefix.
+func writeMessage(w io.Writer, msg []byte) error {
+	var header [5]byte
+	binary.BigEndian.PutUint32(header[1:], uint32(len(msg)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(msg)
+	return err
+}
+
+// writeStatus sends the call's status in the response trailers.
+func writeStatus(w http.ResponseWriter, code int, message string) {
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(code))
+	if message != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", message)
+	}
+}
+
+// Serve accepts cleartext HTTP/2 gRPC connections on l, serving srv's
+// methods, until l is closed.
+func Serve(l net.Listener, srv *server.Server) error {
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	hs := &http.Server{
+		Handler:   Handler(srv),
+		Protocols: &protocols,
+	}
+	return hs.Serve(l)
+}
+
+// Client calls a Battlesnake gRPC service over cleartext HTTP/2.
+type Client struct {
+	// Addr is the service's host:port.
+	Addr string
+
+	http *http.Client
+}
+
+// NewClient returns a Client for the service at addr.
+func NewClient(addr string) *Client {
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	return &Client{
+		Addr: addr,
+		http: &http.Client{Transport: &http.Transport{Protocols: &protocols}},
+	}
+}
+
+// Info fetches the info response for the named strategy and personality.
+func (c *Client) Info(ctx context.Context, strategy, personality string) (api.BattlesnakeInfoResponse, error) {
+	resp, err := c.invoke(ctx, "Info", marshalInfoRequest(infoRequest{Strategy: strategy, Personality: personality}))
+	if err != nil {
+		return api.BattlesnakeInfoResponse{}, err
+	}
+	return unmarshalInfoResponse(resp)
+}
+
+// Start starts a game played with the named strategy and personality.
+func (c *Client) Start(ctx context.Context, game api.GameRequest, strategy, personality string) error {
+	_, err := c.invoke(ctx, "Start", marshalGameRequest(gameRequest{
+		GameRequest: game,
+		Strategy:    strategy,
+		Personality: personality,
+	}))
+	return err
+}
+
+// Move asks for our move in a turn.
+func (c *Client) Move(ctx context.Context, game api.GameRequest) (api.MoveResponse, error) {
+	resp, err := c.invoke(ctx, "Move", marshalGameRequest(gameRequest{GameRequest: game}))
+	if err != nil {
+		return api.MoveResponse{}, err
+	}
+	return unmarshalMoveResponse(resp)
+}
+
+// End ends a game.
+func (c *Client) End(ctx context.Context, game api.GameRequest) error {
+	_, err := c.invoke(ctx, "End", marshalGameRequest(gameRequest{GameRequest: game}))
+	return err
+}
+
+func (c *Client) invoke(ctx context.Context, method string, msg []byte) ([]byte, error) {
+	var body bytes.Buffer
+	if err := writeMessage(&body, msg); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+c.Addr+ServicePath+method, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if code := resp.Trailer.Get("Grpc-Status"); code != strconv.Itoa(codeOK) {
+		return nil, fmt.Errorf("rpc: %s failed with status %s: %s", method, code, resp.Trailer.Get("Grpc-Message"))
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return readMessage(bytes.NewReader(data))
+}
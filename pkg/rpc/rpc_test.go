@@ -0,0 +1,78 @@
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/bench"
+	"github.com/jayuuza/battlesnake/pkg/rpc"
+	"github.com/jayuuza/battlesnake/pkg/server"
+	"github.com/jayuuza/battlesnake/pkg/store"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+	"github.com/jayuuza/battlesnake/pkg/timing"
+)
+
+// lefty always moves left, shouting the turn's snake count.
+type lefty struct{}
+
+func (lefty) Start(context.Context, api.GameRequest) {}
+func (lefty) End(context.Context, api.GameRequest)   {}
+
+func (lefty) Move(_ context.Context, game api.GameRequest) api.MoveResponse {
+	return api.MoveResponse{Move: api.Left, Shout: strings.Repeat("s", len(game.Board.Snakes))}
+}
+
+func init() {
+	strategy.Register("test-rpc-left", func() strategy.Strategy { return lefty{} })
+}
+
+func TestMove(t *testing.T) {
+	srv := &server.Server{
+		DefaultStrategy: "test-rpc-left",
+		Store:           store.NewMemory(),
+		DataDir:         t.TempDir(),
+		Timing:          timing.DefaultManager,
+	}
+	handler := rpc.Handler(srv)
+	var protos []string
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		protos = append(protos, r.Proto)
+		handler.ServeHTTP(w, r)
+	}))
+	ts.Config.Protocols = new(http.Protocols)
+	ts.Config.Protocols.SetUnencryptedHTTP2(true)
+	ts.Start()
+	defer ts.Close()
+
+	c := rpc.NewClient(ts.Listener.Addr().String())
+	ctx := context.Background()
+	game := bench.Position()
+	if err := c.Start(ctx, game, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	move, err := c.Move(ctx, game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (api.MoveResponse{Move: api.Left, Shout: "ssss"}); move != want {
+		t.Errorf("Move = %+v, want %+v", move, want)
+	}
+	if err := c.End(ctx, game); err != nil {
+		t.Fatal(err)
+	}
+	if len(protos) != 3 {
+		t.Errorf("%d calls served, want 3", len(protos))
+	}
+	for _, proto := range protos {
+		if proto != "HTTP/2.0" {
+			t.Errorf("call made over %s", proto)
+		}
+	}
+	if _, err := c.Info(ctx, "no-such-strategy", ""); err == nil {
+		t.Error("Info of an unknown strategy succeeded")
+	}
+}
@@ -0,0 +1,176 @@
+package rpc
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// This file implements just enough of the protobuf wire format to encode
+// and decode the messages in proto/battlesnake.proto.
+
+const (
+	wireVarint = 0
+	wire64     = 1
+	wireBytes  = 2
+	wire32     = 5
+)
+
+var errTruncated = errors.New("rpc: truncated message")
+
+type encoder struct {
+	b []byte
+}
+
+func (e *encoder) tag(field, wireType int) {
+	e.b = binary.AppendUvarint(e.b, uint64(field)<<3|uint64(wireType))
+}
+
+func (e *encoder) int32(field int, v int32) {
+	if v == 0 {
+		return
+	}
+	e.tag(field, wireVarint)
+	e.b = binary.AppendUvarint(e.b, uint64(int64(v)))
+}
+
+func (e *encoder) bool(field int, v bool) {
+	if !v {
+		return
+	}
+	e.tag(field, wireVarint)
+	e.b = append(e.b, 1)
+}
+
+func (e *encoder) string(field int, s string) {
+	if s == "" {
+		return
+	}
+	e.tag(field, wireBytes)
+	e.b = binary.AppendUvarint(e.b, uint64(len(s)))
+	e.b = append(e.b, s...)
+}
+
+// message encodes a nested message written by f.
+func (e *encoder) message(field int, f func(*encoder)) {
+	var sub encoder
+	f(&sub)
+	e.tag(field, wireBytes)
+	e.b = binary.AppendUvarint(e.b, uint64(len(sub.b)))
+	e.b = append(e.b, sub.b...)
+}
+
+type decoder struct {
+	b []byte
+	// wireType is the wire type of the field being read.
+	wireType int
+}
+
+var errWireType = errors.New("rpc: unexpected wire type")
+
+// next reads the next field's number and wire type, returning false at the
+// end of the message.
+func (d *decoder) next() (field, wireType int, ok bool, err error) {
+	if len(d.b) == 0 {
+		return 0, 0, false, nil
+	}
+	key, err := d.uvarint()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	d.wireType = int(key & 7)
+	return int(key >> 3), d.wireType, true, nil
+}
+
+func (d *decoder) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		return 0, errTruncated
+	}
+	d.b = d.b[n:]
+	return v, nil
+}
+
+func (d *decoder) int32() (int32, error) {
+	if d.wireType != wireVarint {
+		return 0, errWireType
+	}
+	v, err := d.uvarint()
+	return int32(v), err
+}
+
+func (d *decoder) bool() (bool, error) {
+	if d.wireType != wireVarint {
+		return false, errWireType
+	}
+	v, err := d.uvarint()
+	return v != 0, err
+}
+
+func (d *decoder) bytes() ([]byte, error) {
+	if d.wireType != wireBytes {
+		return nil, errWireType
+	}
+	n, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(d.b)) < n {
+		return nil, errTruncated
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b, nil
+}
+
+func (d *decoder) string() (string, error) {
+	b, err := d.bytes()
+	return string(b), err
+}
+
+// skip discards a field of an unknown number.
+func (d *decoder) skip(wireType int) error {
+	var n int
+	switch wireType {
+	case wireVarint:
+		_, err := d.uvarint()
+		return err
+	case wireBytes:
+		_, err := d.bytes()
+		return err
+	case wire64:
+		n = 8
+	case wire32:
+		n = 4
+	default:
+		return errors.New("rpc: unsupported wire type")
+	}
+	if len(d.b) < n {
+		return errTruncated
+	}
+	d.b = d.b[n:]
+	return nil
+}
+
+// fields calls f for every field in b until f or decoding fails.
+func fields(b []byte, f func(d *decoder, field, wireType int) error) error {
+	d := &decoder{b: b}
+	for {
+		field, wireType, ok, err := d.next()
+		if err != nil || !ok {
+			return err
+		}
+		if err := f(d, field, wireType); err != nil {
+			return err
+		}
+	}
+}
+
+// nested decodes the length-delimited message at the decoder's position
+// with f.
+func (d *decoder) nested(f func(d *decoder, field, wireType int) error) error {
+	b, err := d.bytes()
+	if err != nil {
+		return err
+	}
+	return fields(b, f)
+}
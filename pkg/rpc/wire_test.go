@@ -0,0 +1,191 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/bench"
+)
+
+// unhex decodes hex with spaces between bytes for readability.
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// The wanted encodings are written out from the field numbers in
+// proto/battlesnake.proto, each field as its tag (number<<3 | wire type)
+// followed by a varint or a length and bytes.
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{"coord", encoded(func(e *encoder) { encodeCoord(e, api.Coord{X: 3, Y: 300}) }),
+			"08 03 10 ac 02"},
+		// Negative int32s are sign extended to ten bytes.
+		{"negative", encoded(func(e *encoder) { encodeCoord(e, api.Coord{X: -1}) }),
+			"08 ff ff ff ff ff ff ff ff ff 01"},
+		{"zero coord", encoded(func(e *encoder) { encodeCoord(e, api.Coord{}) }),
+			""},
+		// Repeated messages aren't packed: each element is a field of its
+		// own, in order.
+		{"snake", encoded(func(e *encoder) {
+			encodeSnake(e, api.Battlesnake{
+				ID:     "a",
+				Health: 90,
+				Body:   []api.Coord{{X: 1, Y: 2}, {X: 1, Y: 1}},
+				Head:   api.Coord{X: 1, Y: 2},
+				Length: 2,
+				Shout:  "hi",
+			})
+		}), "0a 01 61  18 5a  22 04 08 01 10 02  22 04 08 01 10 01  2a 04 08 01 10 02  30 02  42 02 68 69"},
+		{"squad", encoded(func(e *encoder) {
+			encodeGame(e, api.Game{Ruleset: api.Ruleset{Settings: api.RulesetSettings{
+				Squad: api.SquadSettings{SharedHealth: true},
+			}}})
+		}), "12 08  1a 06  22 00  2a 02 18 01"},
+		{"game request", marshalGameRequest(gameRequest{
+			GameRequest: api.GameRequest{
+				Game:  api.Game{ID: "g", Timeout: 500},
+				Turn:  2,
+				Board: api.Board{Width: 7, Height: 5},
+			},
+			Strategy: "s",
+		}), "0a 0e  0a 01 67  12 06 1a 04 22 00 2a 00  28 f4 03" +
+			"  10 02  1a 04 08 05 10 07  22 02 2a 00  2a 01 73"},
+		{"move response", marshalMoveResponse(api.MoveResponse{Move: api.Left, Shout: "ok"}),
+			"0a 04 6c 65 66 74  12 02 6f 6b"},
+		{"info request", marshalInfoRequest(infoRequest{Personality: "p"}),
+			"12 01 70"},
+		{"info response", marshalInfoResponse(api.BattlesnakeInfoResponse{APIVersion: "1", Tail: "t"}),
+			"0a 01 31  2a 01 74"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if want := unhex(t, tt.want); !bytes.Equal(tt.got, want) {
+				t.Errorf("encoded % x\nwant    % x", tt.got, want)
+			}
+		})
+	}
+}
+
+func encoded(f func(*encoder)) []byte {
+	var e encoder
+	f(&e)
+	return e.b
+}
+
+func TestGameRequestRoundTrip(t *testing.T) {
+	want := gameRequest{GameRequest: bench.Position(), Strategy: "search", Personality: "bold"}
+	want.Game.Map = "standard"
+	want.Game.Source = "league"
+	want.Game.Ruleset.Version = "v1.2.3"
+	want.Game.Ruleset.Settings = api.RulesetSettings{
+		FoodSpawnChance:     15,
+		MinimumFood:         1,
+		HazardDamagePerTurn: 14,
+		Royale:              api.RoyaleSettings{ShrinkEveryNTurns: 25},
+		Squad:               api.SquadSettings{AllowBodyCollisions: true, SharedLength: true},
+	}
+	want.Board.Snakes[1].Latency = "123"
+	want.You.Head = api.Coord{X: -1, Y: 11}
+	got, err := unmarshalGameRequest(marshalGameRequest(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	move := api.MoveResponse{Move: api.Right, Shout: "ssss"}
+	if got, err := unmarshalMoveResponse(marshalMoveResponse(move)); err != nil || got != move {
+		t.Errorf("move response decoded as %+v, %v", got, err)
+	}
+	req := infoRequest{Strategy: "search", Personality: "bold"}
+	if got, err := unmarshalInfoRequest(marshalInfoRequest(req)); err != nil || got != req {
+		t.Errorf("info request decoded as %+v, %v", got, err)
+	}
+	info := api.BattlesnakeInfoResponse{APIVersion: "1", Author: "a", Color: "#123456", Head: "h", Tail: "t"}
+	if got, err := unmarshalInfoResponse(marshalInfoResponse(info)); err != nil || got != info {
+		t.Errorf("info response decoded as %+v, %v", got, err)
+	}
+}
+
+func TestDecodeSkipsUnknownFields(t *testing.T) {
+	// A move response from a newer peer, with fields 9 to 13 of every wire
+	// type around the move: a varint, a fixed64, a fixed32, a string and a
+	// packed repeated int32 of 1, 2 and 300.
+	b := unhex(t, "48 96 01  51 01 02 03 04 05 06 07 08  5d 01 02 03 04"+
+		"  0a 02 75 70  62 02 68 69  6a 04 01 02 ac 02  12 01 21")
+	got, err := unmarshalMoveResponse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (api.MoveResponse{Move: api.Up, Shout: "!"}); got != want {
+		t.Errorf("decoded %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{"truncated tag", "ff", errTruncated},
+		{"truncated varint", "48 ff", errTruncated},
+		{"truncated string", "0a 05 75 70", errTruncated},
+		{"truncated fixed64", "51 01 02", errTruncated},
+		{"wrong wire type", "08 01", errWireType},
+		{"group", "0b", nil},
+		{"invalid move", "0a 04 6a 75 6d 70", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := unmarshalMoveResponse(unhex(t, tt.data))
+			if err == nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("error %v, want %v", err, tt.want)
+			}
+		})
+	}
+	// Errors in nested messages reach the top.
+	if _, err := unmarshalGameRequest(unhex(t, "1a 03 2a 01 30")); !errors.Is(err, errTruncated) {
+		t.Errorf("truncated snake decoded with error %v", err)
+	}
+}
+
+func TestFraming(t *testing.T) {
+	var buf bytes.Buffer
+	msg := []byte("message")
+	if err := writeMessage(&buf, msg); err != nil {
+		t.Fatal(err)
+	}
+	if want := unhex(t, "00 00 00 00 07"); !bytes.HasPrefix(buf.Bytes(), want) {
+		t.Errorf("prefix % x, want % x", buf.Bytes()[:5], want)
+	}
+	if got, err := readMessage(&buf); err != nil || !bytes.Equal(got, msg) {
+		t.Errorf("read %q, %v", got, err)
+	}
+	for name, data := range map[string]string{
+		"compressed": "01 00 00 00 00",
+		"too large":  "00 7f ff ff ff",
+		"truncated":  "00 00 00 00 07 6d",
+	} {
+		if _, err := readMessage(bytes.NewReader(unhex(t, data))); err == nil {
+			t.Errorf("%s message read", name)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package search
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultNodesPerSecond is the throughput assumed before any has been
+// measured; it is deliberately conservative.
+const defaultNodesPerSecond = 500000
+
+// searchShare is the fraction of the time left before the deadline that a
+// search plans to use, leaving the rest for evaluation and the response.
+const searchShare = 0.5
+
+// Throughput measures how many nodes per second search manages, so that
+// later searches can choose a depth that fits their time budget on this
+// machine rather than relying on fixed constants. The zero value is ready
+// to use and safe for concurrent use.
+type Throughput struct {
+	mu             sync.Mutex
+	nodesPerSecond float64
+}
+
+// Observe records that a search visited nodes in elapsed time, averaging it
+// with earlier measurements.
+func (t *Throughput) Observe(nodes int, elapsed time.Duration) {
+	if nodes < 100 || elapsed <= 0 {
+		// Too small a search to measure.
+		return
+	}
+	rate := float64(nodes) / elapsed.Seconds()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.nodesPerSecond == 0 {
+		t.nodesPerSecond = rate
+	} else {
+		t.nodesPerSecond = 0.7*t.nodesPerSecond + 0.3*rate
+	}
+}
+
+// NodesPerSecond returns the measured throughput, or a conservative default
+// if there is no measurement yet.
+func (t *Throughput) NodesPerSecond() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.nodesPerSecond == 0 {
+		return defaultNodesPerSecond
+	}
+	return t.nodesPerSecond
+}
+
+type maxDepthKey struct{}
+
+// WithMaxDepth returns a context in which searches go at most depth turns
+// deep instead of their own maximum, though never less than their minimum,
+// so that games in one process can be played with different depths.
+func WithMaxDepth(ctx context.Context, depth int) context.Context {
+	return context.WithValue(ctx, maxDepthKey{}, depth)
+}
+
+// Depth returns the deepest search, between minDepth and maxDepth or the
+// maximum ctx sets with WithMaxDepth, expected to fit in searchShare of the
+// time left before ctx's deadline. A search of depth d visits about root *
+// branching^d nodes. Without a deadline it returns minDepth.
+func (t *Throughput) Depth(ctx context.Context, root, branching float64, minDepth, maxDepth int) int {
+	if limit, ok := ctx.Value(maxDepthKey{}).(int); ok {
+		maxDepth = max(limit, minDepth)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return minDepth
+	}
+	budget := t.NodesPerSecond() * time.Until(deadline).Seconds() * searchShare
+	depth := minDepth
+	nodes := root
+	for d := 1; d <= depth; d++ {
+		nodes *= branching
+	}
+	for depth < maxDepth && nodes*branching <= budget {
+		nodes *= branching
+		depth++
+	}
+	return depth
+}
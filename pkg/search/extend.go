@@ -0,0 +1,31 @@
+package search
+
+import "github.com/jayuuza/battlesnake/pkg/board"
+
+// maxExtensions bounds the turns standoffs add to any one line of a
+// search, so that two snakes shadowing each other can't keep it going.
+const maxExtensions = 2
+
+// standoff reports whether a live opponent at least as long as us has its
+// head two moves from ours, one square apart. Both can then move onto a
+// square between them next turn, and we lose the collision, so whether we
+// survive the position depends on the turn after it: a search ending on
+// it looks one turn further rather than take our being alive as safe.
+func (x *searcher) standoff() bool {
+	you := &x.s.Snakes[x.s.You]
+	head := you.Head()
+	for i := range x.s.Snakes {
+		snake := &x.s.Snakes[i]
+		if i == x.s.You || snake.Eliminated || snake.Len() < you.Len() {
+			continue
+		}
+		distance := board.Manhattan(head, snake.Head())
+		if x.s.Wrapped {
+			distance = board.WrappedManhattan(head, snake.Head(), x.s.Width, x.s.Height)
+		}
+		if distance == 2 {
+			return true
+		}
+	}
+	return false
+}
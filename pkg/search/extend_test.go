@@ -0,0 +1,87 @@
+package search_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/search"
+)
+
+// Our only move, up, leaves the longer b two squares above us. In cornered
+// our only move after that is onto the square between us, which b can
+// take head-on; in sidestep we can step aside.
+const (
+	cornered = `
+B b . . .
+. b . . .
+. b . . .
+. b b b b
+A a a . .
+`
+	sidestep = `
+B b b b b
+. . . . b
+. . . . b
+. . . . b
+A a a . .
+`
+)
+
+func TestSurvivalStandoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		board    string
+		survives bool
+	}{
+		{"cornered", cornered, false},
+		{"sidestep", sidestep, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game, err := board.ParseASCII(tt.board)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, stats := search.WithStats(context.Background())
+			// A turn deep the standoff is all that's seen, and extending
+			// it decides whether we survive.
+			survives, ok := search.Survival(ctx, game, 1)
+			if !ok {
+				t.Fatal("search didn't finish")
+			}
+			if survives[api.Up] != tt.survives {
+				t.Errorf("up survives = %v, want %v", survives[api.Up], tt.survives)
+			}
+			if stats.Summary().Extensions == 0 {
+				t.Error("no standoff extended")
+			}
+		})
+	}
+}
+
+func TestSurvivalStandoffTable(t *testing.T) {
+	t.Cleanup(func() { search.UseTable(nil) })
+	for _, position := range []string{cornered, sidestep} {
+		game, err := board.ParseASCII(position)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for depth := 1; depth <= 4; depth++ {
+			search.UseTable(nil)
+			want, _ := search.Survival(context.Background(), game, depth)
+			// The same search with a table, empty and then holding the
+			// first search's positions, must agree with it.
+			search.UseTable(search.NewTable(1))
+			for range 2 {
+				got, _ := search.Survival(context.Background(), game, depth)
+				for _, d := range api.Directions {
+					if got[d] != want[d] {
+						t.Errorf("%s at depth %d with a table = %v, want %v", d, depth, got[d], want[d])
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package search
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/arena"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/sim"
+)
+
+// ForcedKill looks for a move that eliminates an opponent next turn
+// whatever the other snakes do, after which we can survive more turns
+// against every reply: as many as t expects to search before ctx expires.
+// Only moves that leave some opponent without a safe square are searched.
+// It reports false if there is no such move or ctx expires before one is
+// verified.
+func ForcedKill(ctx context.Context, game api.GameRequest, t *Throughput) (api.Direction, bool) {
+	if len(game.Board.Snakes) > 64 {
+		// killed below is a bitmask of snakes.
+		return 0, false
+	}
+	grid := board.GridFor(game)
+	grid.Scratch = arena.FromContext(ctx)
+	var candidates []api.Direction
+	for _, move := range grid.ValidMoves(game.You.Head) {
+		if traps(game, grid, grid.Step(game.You.Head, move)) {
+			candidates = append(candidates, move)
+		}
+	}
+	if len(candidates) == 0 {
+		return 0, false
+	}
+
+	// Each turn searched multiplies the nodes by our four moves times the
+	// combinations of opponent replies, about three each once suicides are
+	// pruned.
+	branching := 4 * math.Pow(3, float64(len(game.Board.Snakes)-1))
+	depth := t.Depth(ctx, float64(len(candidates))*branching/4, branching, minKillDepth, maxKillDepth)
+
+	s := sim.Acquire(game)
+	defer sim.Release(s)
+	x := newSearcher(ctx, s)
+	start := time.Now()
+	defer func() {
+		elapsed := time.Since(start)
+		t.Observe(x.nodes, elapsed)
+		x.finish(depth)
+		logger.DebugContext(ctx, "forced kill search", "game", game.Game.ID, "turn", game.Turn, "candidates", len(candidates),
+			"depth", depth, "extensions", x.extensions, "nodes", x.nodes, "elapsed", elapsed, "expired", x.expired)
+	}()
+	for _, move := range candidates {
+		// killed holds the opponents eliminated by every reply so far.
+		var killed uint64 = 1<<len(s.Snakes) - 1
+		killed &^= 1 << s.You
+		ok := x.forAll(move, func() bool {
+			for i := range s.Snakes {
+				if !s.Snakes[i].Eliminated {
+					killed &^= 1 << i
+				}
+			}
+			return killed != 0 && x.survives(depth)
+		})
+		if ok {
+			return move, true
+		}
+		if x.done() {
+			break
+		}
+	}
+	return 0, false
+}
+
+// The survival search after a kill goes at least minKillDepth turns deep,
+// and at most maxKillDepth.
+const (
+	minKillDepth = 1
+	maxKillDepth = 8
+)
+
+// traps reports whether our head moving to head leaves some opponent with
+// no safe square to move to: every neighbor of its head is off the board,
+// occupied, or our new head when we're at least as long.
+func traps(game api.GameRequest, grid *board.Grid, head api.Coord) bool {
+	for _, snake := range game.Board.Snakes {
+		if snake.ID == game.You.ID {
+			continue
+		}
+		safe := 0
+		for _, d := range api.Directions {
+			pos := grid.Step(snake.Head, d)
+			if !grid.IsValid(pos) || pos == head && snake.Length <= game.You.Length {
+				continue
+			}
+			safe++
+		}
+		if safe == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+// Package search looks ahead through simulated turns to find moves that
+// the one-ply evaluation can't see.
+package search
+
+import (
+	"context"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/arena"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/logging"
+	"github.com/jayuuza/battlesnake/pkg/sim"
+)
+
+var logger = logging.For(logging.Search)
+
+// searcher walks the game tree below a sim.State, giving up once ctx
+// expires.
+type searcher struct {
+	ctx     context.Context
+	s       *sim.State
+	expired bool
+	// nodes counts the turns simulated.
+	nodes int
+	// trace records the tree explored, if ctx asks for it.
+	trace *Trace
+	// table remembers positions already searched, if one is in use. It
+	// isn't while tracing, so that traces show the whole tree.
+	table *Table
+	// lookups and hits count the positions looked up in the table and
+	// found there.
+	lookups, hits int
+	// extended is the number of turns standoffs have added to the line
+	// being searched, and extensions counts the standoffs extended.
+	extended, extensions int
+
+	// moves holds the move list of each turn below the root, allocated
+	// from the arena on first use so that nodes don't allocate, and ply is
+	// the turn being searched.
+	arena *arena.Arena
+	moves [][]api.Direction
+	ply   int
+}
+
+func newSearcher(ctx context.Context, s *sim.State) *searcher {
+	x := &searcher{ctx: ctx, s: s, trace: traceFrom(ctx), arena: arena.FromContext(ctx)}
+	if x.trace == nil {
+		x.table = shared.Load()
+	}
+	return x
+}
+
+// done reports whether the search has run out of time.
+func (x *searcher) done() bool {
+	if !x.expired && x.ctx.Err() != nil {
+		x.expired = true
+	}
+	return x.expired
+}
+
+// forAll plays our move against every combination of the other live
+// snakes' moves, reporting whether f holds after each. The state is
+// restored before it returns.
+func (x *searcher) forAll(our api.Direction, f func() bool) bool {
+	if x.ply == len(x.moves) {
+		x.moves = append(x.moves, x.arena.Directions(len(x.s.Snakes)))
+	}
+	moves := x.moves[x.ply]
+	moves[x.s.You] = our
+	x.ply++
+	ok := x.combos(moves, 0, f)
+	x.ply--
+	return ok
+}
+
+func (x *searcher) combos(moves []api.Direction, i int, f func() bool) bool {
+	if i == len(moves) {
+		x.nodes++
+		if x.trace != nil {
+			return x.traced(moves, f)
+		}
+		x.s.Apply(moves)
+		ok := f()
+		x.s.Undo()
+		return ok
+	}
+	if i == x.s.You || x.s.Snakes[i].Eliminated {
+		return x.combos(moves, i+1, f)
+	}
+	tried := false
+	for _, d := range api.Directions {
+		if x.suicidal(i, d) {
+			if x.trace != nil {
+				x.trace.prune(x, i, d)
+			}
+			continue
+		}
+		tried = true
+		moves[i] = d
+		if !x.combos(moves, i+1, f) {
+			return false
+		}
+	}
+	if !tried {
+		// Every move kills the snake; any one stands for them all.
+		moves[i] = api.Up
+		return x.combos(moves, i+1, f)
+	}
+	return true
+}
+
+// traced applies moves as combos does, recording the turn in the trace.
+func (x *searcher) traced(moves []api.Direction, f func() bool) bool {
+	out := make([]bool, len(x.s.Snakes))
+	for i := range x.s.Snakes {
+		out[i] = x.s.Snakes[i].Eliminated
+	}
+	x.s.Apply(moves)
+	n := x.trace.enter(x, moves, out)
+	ok := f()
+	x.trace.leave(n, ok)
+	x.s.Undo()
+	return ok
+}
+
+// finish records the search's statistics, it having gone depth turns deep.
+func (x *searcher) finish(depth int) {
+	recordStats(x.ctx, x.nodes, depth, x.extensions, x.expired)
+	if x.table != nil {
+		x.table.count(x.lookups, x.hits)
+	}
+}
+
+// suicidal reports whether moving snake i in direction d certainly
+// eliminates it without affecting anyone else: off the edge of an unwrapped
+// board, or back into its own neck. Such moves are pruned from the search.
+func (x *searcher) suicidal(i int, d api.Direction) bool {
+	snake := &x.s.Snakes[i]
+	next := snake.Head().Move(d)
+	if snake.Len() > 1 && next == snake.Segment(1) {
+		return true
+	}
+	return !x.s.Wrapped && !board.InBounds(next, x.s.Width, x.s.Height)
+}
+
+// survives reports whether we are alive and have a move that keeps us alive
+// against every reply for depth more turns, and one more if the last of
+// them ends in a standoff, up to maxExtensions in any line. It reports
+// false once the search has run out of time.
+func (x *searcher) survives(depth int) bool {
+	if x.s.Snakes[x.s.You].Eliminated || x.done() {
+		return false
+	}
+	if depth == 0 {
+		if x.extended == maxExtensions || !x.standoff() {
+			return true
+		}
+		x.extended++
+		x.extensions++
+		defer func() { x.extended-- }()
+		depth = 1
+	}
+	// The table's entries are searched with the whole extension budget,
+	// so a line standoffs have already extended neither uses nor keeps
+	// them, even once deep enough.
+	var key uint64
+	cached := x.table != nil && depth >= minTableDepth && x.extended == 0
+	if cached {
+		key = x.s.Hash()
+		x.lookups++
+		if holds, ok := x.table.Lookup(key, depth); ok {
+			x.hits++
+			return holds
+		}
+	}
+	holds := false
+	for _, d := range api.Directions {
+		if x.forAll(d, func() bool { return x.survives(depth - 1) }) {
+			holds = true
+			break
+		}
+	}
+	// A search cut short by the deadline proves nothing either way.
+	if cached && !x.done() {
+		x.table.Store(key, depth, holds)
+	}
+	return holds
+}
@@ -0,0 +1,58 @@
+package search
+
+import (
+	"context"
+	"sync"
+)
+
+// Stats accumulates the searches made while deciding a move, when carried
+// by the context given to them. It is safe for concurrent use.
+type Stats struct {
+	mu sync.Mutex
+	s  Summary
+}
+
+// Summary totals the searches made while deciding a move.
+type Summary struct {
+	Searches int `json:"searches"`
+	Nodes    int `json:"nodes"`
+	// Depth is the deepest any search was set to go, in turns.
+	Depth int `json:"depth"`
+	// Extensions counts the standoffs searched a turn deeper than Depth.
+	Extensions int `json:"extensions"`
+	// Expired reports whether any search ran out of time.
+	Expired bool `json:"expired"`
+}
+
+// Summary returns the totals so far.
+func (st *Stats) Summary() Summary {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.s
+}
+
+func (st *Stats) record(nodes, depth, extensions int, expired bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.s.Searches++
+	st.s.Nodes += nodes
+	st.s.Depth = max(st.s.Depth, depth)
+	st.s.Extensions += extensions
+	st.s.Expired = st.s.Expired || expired
+}
+
+type statsKey struct{}
+
+// WithStats returns a context that collects the statistics of searches
+// made with it into the returned Stats.
+func WithStats(ctx context.Context) (context.Context, *Stats) {
+	st := &Stats{}
+	return context.WithValue(ctx, statsKey{}, st), st
+}
+
+// recordStats adds a search to the statistics collected by ctx, if any.
+func recordStats(ctx context.Context, nodes, depth, extensions int, expired bool) {
+	if st, ok := ctx.Value(statsKey{}).(*Stats); ok {
+		st.record(nodes, depth, extensions, expired)
+	}
+}
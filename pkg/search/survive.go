@@ -0,0 +1,29 @@
+package search
+
+import (
+	"context"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/sim"
+)
+
+// Survival reports, for each of our moves, whether we survive depth turns
+// after it whatever the other snakes do, standoffs the search ends on
+// being searched a turn further, and whether the search finished before
+// ctx expired. Moves it didn't get to are left out.
+func Survival(ctx context.Context, game api.GameRequest, depth int) (map[api.Direction]bool, bool) {
+	s := sim.Acquire(game)
+	defer sim.Release(s)
+	x := newSearcher(ctx, s)
+	defer x.finish(depth)
+
+	survives := map[api.Direction]bool{}
+	for _, move := range api.Directions {
+		ok := x.forAll(move, func() bool { return x.survives(depth - 1) })
+		if x.done() {
+			return survives, false
+		}
+		survives[move] = ok
+	}
+	return survives, true
+}
@@ -0,0 +1,149 @@
+package search
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultTableMB is the size of the transposition table the server gives
+// searches unless configured otherwise.
+const DefaultTableMB = 32
+
+// tableShards is the number of independently locked parts of a Table. Each
+// search locks one shard per lookup, so concurrent searches rarely wait on
+// each other.
+const tableShards = 64
+
+// minTableDepth is the shallowest search result worth keeping: below it,
+// searching again is about as cheap as looking the position up.
+const minTableDepth = 2
+
+// Table is a transposition table: it remembers whether we survive from
+// positions already searched, keyed by sim.State.Hash, so that a position
+// reached again by other moves, in the same search or a later one, isn't
+// searched twice. It holds a fixed number of entries, keeping the deeper
+// result when two positions share a slot. It is safe for concurrent use.
+type Table struct {
+	shards [tableShards]tableShard
+
+	lookups atomic.Int64
+	hits    atomic.Int64
+}
+
+type tableShard struct {
+	mu      sync.Mutex
+	entries []tableEntry
+	// Pad shards to a cache line each, so that locking one doesn't slow
+	// down the cores using its neighbors.
+	_ [32]byte
+}
+
+// tableEntry records that the search holds, or fails, from a position for
+// depth turns, with up to maxExtensions standoffs extended beyond them. An
+// entry with depth 0 is empty.
+type tableEntry struct {
+	key   uint64
+	depth int32
+	holds bool
+}
+
+// NewTable returns a table using about mb MiB of memory. The memory is
+// written once so that it is mapped now, rather than page by page during
+// the first searches.
+func NewTable(mb int) *Table {
+	t := &Table{}
+	perShard := max(mb<<20/tableShards/16, 1)
+	for i := range t.shards {
+		t.shards[i].entries = make([]tableEntry, perShard)
+		for j := range t.shards[i].entries {
+			t.shards[i].entries[j].key = 0
+		}
+	}
+	return t
+}
+
+// slot returns the shard and entry index of key. The shard is picked by
+// the key's top bits and the entry by its bottom ones, so the two are
+// independent.
+func (t *Table) slot(key uint64) (*tableShard, int) {
+	shard := &t.shards[key>>(64-6)]
+	return shard, int(key % uint64(len(shard.entries)))
+}
+
+// Lookup returns whether the search held from the position with key for
+// depth turns, if known. A position survived for more turns is survived
+// for fewer, and one failed in fewer turns fails in more.
+func (t *Table) Lookup(key uint64, depth int) (holds, ok bool) {
+	shard, i := t.slot(key)
+	shard.mu.Lock()
+	e := shard.entries[i]
+	shard.mu.Unlock()
+	if e.depth == 0 || e.key != key {
+		return false, false
+	}
+	if e.holds && int(e.depth) >= depth || !e.holds && int(e.depth) <= depth {
+		return e.holds, true
+	}
+	return false, false
+}
+
+// Store records whether the search held from the position with key for
+// depth turns. It replaces a shallower result for another position, but
+// not a deeper one.
+func (t *Table) Store(key uint64, depth int, holds bool) {
+	shard, i := t.slot(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	e := &shard.entries[i]
+	if e.key != key && int(e.depth) > depth {
+		return
+	}
+	*e = tableEntry{key: key, depth: int32(depth), holds: holds}
+}
+
+// count adds a search's lookups and hits to the statistics. Searches count
+// their own and add them up when they finish, rather than sharing counters
+// at every lookup.
+func (t *Table) count(lookups, hits int) {
+	t.lookups.Add(int64(lookups))
+	t.hits.Add(int64(hits))
+}
+
+// TableStats summarizes how useful a table has been.
+type TableStats struct {
+	Entries int     `json:"entries"`
+	Lookups int64   `json:"lookups"`
+	Hits    int64   `json:"hits"`
+	HitRate float64 `json:"hitRate"`
+}
+
+// Stats returns the table's statistics so far.
+func (t *Table) Stats() TableStats {
+	st := TableStats{
+		Entries: tableShards * len(t.shards[0].entries),
+		Lookups: t.lookups.Load(),
+		Hits:    t.hits.Load(),
+	}
+	if st.Lookups > 0 {
+		st.HitRate = float64(st.Hits) / float64(st.Lookups)
+	}
+	return st
+}
+
+var shared atomic.Pointer[Table]
+
+// UseTable makes every search share t, or no table if t is nil, which is
+// the default.
+func UseTable(t *Table) {
+	shared.Store(t)
+}
+
+func init() {
+	expvar.Publish("searchTable", expvar.Func(func() any {
+		if t := shared.Load(); t != nil {
+			return t.Stats()
+		}
+		return nil
+	}))
+}
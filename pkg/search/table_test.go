@@ -0,0 +1,40 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/sim"
+)
+
+func TestTableSkipsExtendedLines(t *testing.T) {
+	game, err := board.ParseASCII(`
+. . . . .
+. . B b b
+. . . . b
+A a a . .
+. . . . .
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := sim.New(game)
+	x := newSearcher(context.Background(), s)
+	x.table = NewTable(1)
+	// Entries are searched with the whole extension budget, so a line
+	// with less left must neither use nor keep them.
+	x.extended = 1
+	x.survives(3)
+	if x.lookups != 0 {
+		t.Errorf("extended line looked up %d positions", x.lookups)
+	}
+	if _, ok := x.table.Lookup(s.Hash(), 3); ok {
+		t.Error("extended line stored its result")
+	}
+	x.extended = 0
+	x.survives(3)
+	if _, ok := x.table.Lookup(s.Hash(), 3); !ok {
+		t.Error("result not stored")
+	}
+}
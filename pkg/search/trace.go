@@ -0,0 +1,182 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+// MaxTraceNodes bounds the nodes a Trace records, as a deep search visits
+// far more than can be drawn.
+const MaxTraceNodes = 20000
+
+// Results of a traced node.
+const (
+	// Holds means the search's condition held after the node's moves: we
+	// survived, or made the kill, against every reply below it.
+	Holds = "holds"
+	// Fails means it didn't.
+	Fails = "fails"
+	// Pruned marks a move skipped as certainly suicidal.
+	Pruned = "pruned"
+)
+
+// TraceNode is one simulated turn of a traced search.
+type TraceNode struct {
+	ID   int `json:"id"`
+	Turn int `json:"turn"`
+	// Moves holds the move of each snake that moved, by snake ID.
+	Moves map[string]string `json:"moves"`
+	// Eliminated lists the snakes eliminated by this turn's moves.
+	Eliminated []string     `json:"eliminated,omitempty"`
+	Result     string       `json:"result"`
+	Children   []*TraceNode `json:"children,omitempty"`
+}
+
+// Trace records the tree a search explores, when carried by the context
+// given to it. Branches the search cut off once the outcome was known are
+// absent. It is not safe for concurrent use, so only one search should be
+// made with it at a time.
+type Trace struct {
+	// Root stands for the position searched; its children are the first
+	// turns simulated.
+	Root TraceNode `json:"root"`
+	// Nodes counts the nodes recorded, and Truncated reports whether more
+	// were visited than MaxTraceNodes.
+	Nodes     int  `json:"nodes"`
+	Truncated bool `json:"truncated"`
+
+	stack []*TraceNode
+}
+
+type traceKey struct{}
+
+// WithTrace returns a context that records the trees of searches made
+// with it into the returned Trace.
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	t := &Trace{}
+	return context.WithValue(ctx, traceKey{}, t), t
+}
+
+// traceFrom returns the trace ctx records into, or nil.
+func traceFrom(ctx context.Context) *Trace {
+	t, _ := ctx.Value(traceKey{}).(*Trace)
+	return t
+}
+
+func (t *Trace) parent() *TraceNode {
+	if len(t.stack) == 0 {
+		return &t.Root
+	}
+	return t.stack[len(t.stack)-1]
+}
+
+// add records a child of the current node, or returns nil once the trace
+// is full.
+func (t *Trace) add(n *TraceNode) *TraceNode {
+	if t.Nodes >= MaxTraceNodes {
+		t.Truncated = true
+		return nil
+	}
+	t.Nodes++
+	n.ID = t.Nodes
+	p := t.parent()
+	p.Children = append(p.Children, n)
+	return n
+}
+
+// enter records the turn just applied to x's state with moves, by the
+// snakes not out before it, and descends into it.
+func (t *Trace) enter(x *searcher, moves []api.Direction, out []bool) *TraceNode {
+	n := &TraceNode{Turn: x.s.Turn, Moves: map[string]string{}}
+	for i, d := range moves {
+		if out[i] {
+			continue
+		}
+		snake := &x.s.Snakes[i]
+		n.Moves[snake.ID] = d.String()
+		if snake.Eliminated {
+			n.Eliminated = append(n.Eliminated, snake.ID)
+		}
+	}
+	if t.add(n) == nil {
+		return nil
+	}
+	t.stack = append(t.stack, n)
+	return n
+}
+
+// leave records the result of n, entered last, and returns to its parent.
+func (t *Trace) leave(n *TraceNode, ok bool) {
+	if n == nil {
+		return
+	}
+	n.Result = Fails
+	if ok {
+		n.Result = Holds
+	}
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// prune records that snake i moving d was skipped.
+func (t *Trace) prune(x *searcher, i int, d api.Direction) {
+	t.add(&TraceNode{
+		Turn:   x.s.Turn + 1,
+		Moves:  map[string]string{x.s.Snakes[i].ID: d.String()},
+		Result: Pruned,
+	})
+}
+
+// WriteDOT draws the trace as a Graphviz graph: turns where the search's
+// condition held in green, failed in red, and pruned moves dashed.
+func (t *Trace) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph search {\n\tnode [shape=box, fontname=\"monospace\", fontsize=10];\n")
+	b.WriteString("\tn0 [label=\"position\", shape=ellipse];\n")
+	var walk func(parent int, n *TraceNode)
+	walk = func(parent int, n *TraceNode) {
+		var label strings.Builder
+		fmt.Fprintf(&label, "turn %d", n.Turn)
+		for _, id := range sortedKeys(n.Moves) {
+			fmt.Fprintf(&label, "\\n%s %s", id, n.Moves[id])
+		}
+		if len(n.Eliminated) > 0 {
+			fmt.Fprintf(&label, "\\nout: %s", strings.Join(n.Eliminated, ", "))
+		}
+		style := ""
+		switch n.Result {
+		case Holds:
+			style = `, color="#2e7d32", fontcolor="#2e7d32"`
+		case Fails:
+			style = `, color="#c62828", fontcolor="#c62828"`
+		case Pruned:
+			style = `, style=dashed, color="#9e9e9e", fontcolor="#9e9e9e"`
+		}
+		fmt.Fprintf(&b, "\tn%d [label=\"%s\"%s];\n\tn%d -> n%d;\n", n.ID, label.String(), style, parent, n.ID)
+		for _, c := range n.Children {
+			walk(n.ID, c)
+		}
+	}
+	for _, c := range t.Root.Children {
+		walk(0, c)
+	}
+	if t.Truncated {
+		fmt.Fprintf(&b, "\ttruncated [label=\"truncated after %d nodes\", shape=plaintext];\n", t.Nodes)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
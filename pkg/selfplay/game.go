@@ -0,0 +1,251 @@
+// Package selfplay plays local games between strategies on the simulator,
+// without an engine or HTTP in between, and keeps an Elo ladder of how they
+// fare against each other.
+package selfplay
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/arena"
+	"github.com/jayuuza/battlesnake/pkg/eval"
+	"github.com/jayuuza/battlesnake/pkg/personality"
+	"github.com/jayuuza/battlesnake/pkg/search"
+	"github.com/jayuuza/battlesnake/pkg/sim"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// Player is a strategy playing with a set of term weights.
+type Player struct {
+	// Name identifies the player on the ladder.
+	Name     string
+	Strategy string
+	// Weights replace the configured weights of the terms they name.
+	Weights map[string]float64
+	// Risk replaces the default personality's risk tolerance, unless 0.
+	Risk float64
+	// MaxDepth limits how deep the player's searches go, unless 0.
+	MaxDepth int
+	// PanicHealth replaces the configured health below which the player
+	// panics, unless nil.
+	PanicHealth *int
+}
+
+// Context returns ctx carrying the player's parameters for its strategy.
+func (p Player) Context(ctx context.Context) context.Context {
+	ctx = eval.NewContext(ctx, p.Weights)
+	if p.Risk != 0 {
+		pers := personality.FromContext(ctx)
+		pers.Risk = p.Risk
+		ctx = personality.NewContext(ctx, pers)
+	}
+	if p.MaxDepth != 0 {
+		ctx = search.WithMaxDepth(ctx, p.MaxDepth)
+	}
+	if p.PanicHealth != nil {
+		ctx = strategy.WithPanicHealth(ctx, *p.PanicHealth)
+	}
+	return ctx
+}
+
+// Settings are the rules games are played under.
+type Settings struct {
+	Width, Height int
+	// Timeout is the time each snake has to decide each move.
+	Timeout time.Duration
+	// MaxTurns ends a game as a draw between the snakes still alive, or 0
+	// for no limit.
+	MaxTurns int
+}
+
+// DefaultSettings are a standard 11x11 game with a short timeout, so that
+// many games can be played quickly.
+var DefaultSettings = Settings{Width: 11, Height: 11, Timeout: 100 * time.Millisecond, MaxTurns: 1000}
+
+// Standard rules for spawning food.
+const (
+	minFood        = 1
+	foodSpawnRate  = 0.15
+	startingLength = 3
+)
+
+// Outcome is how a game ended.
+type Outcome struct {
+	Turns int
+	// Survived is the number of turns each player, in the order given to
+	// Play, stayed in the game; the players still alive at the end survived
+	// every turn.
+	Survived []int
+	// Winner is the index of the last player left, or -1 for a draw.
+	Winner int
+}
+
+// Play plays a game between players, each one snake, under settings.
+func Play(ctx context.Context, settings Settings, players []Player) (Outcome, error) {
+	strats := make([]strategy.Strategy, len(players))
+	for i, p := range players {
+		strat, err := strategy.New(p.Strategy)
+		if err != nil {
+			return Outcome{}, err
+		}
+		strats[i] = strat
+	}
+	game := setup(settings, len(players))
+	state := sim.New(game)
+	// you is each snake as last seen alive, for the end requests of those
+	// eliminated.
+	you := make([]api.Battlesnake, len(players))
+	request := func(i int) api.GameRequest {
+		state.You = i
+		r := state.Request(game)
+		if state.Snakes[i].Eliminated {
+			r.You = you[i]
+		}
+		you[i] = r.You
+		return r
+	}
+	playerCtx := func(i int) context.Context {
+		return players[i].Context(ctx)
+	}
+
+	for i, strat := range strats {
+		strat.Start(playerCtx(i), request(i))
+	}
+	outcome := Outcome{Survived: make([]int, len(players)), Winner: -1}
+	moves := make([]api.Direction, len(players))
+	for state.Alive() > 1 && (settings.MaxTurns == 0 || state.Turn < settings.MaxTurns) {
+		if err := ctx.Err(); err != nil {
+			return Outcome{}, err
+		}
+		for i, strat := range strats {
+			if !state.Snakes[i].Eliminated {
+				moves[i] = move(playerCtx(i), strat, request(i), settings.Timeout)
+			}
+		}
+		state.Apply(moves)
+		spawnFood(state)
+		for i := range state.Snakes {
+			if !state.Snakes[i].Eliminated {
+				outcome.Survived[i] = state.Turn
+			}
+		}
+	}
+	for i, strat := range strats {
+		strat.End(playerCtx(i), request(i))
+		if !state.Snakes[i].Eliminated && state.Alive() == 1 {
+			outcome.Winner = i
+		}
+	}
+	outcome.Turns = state.Turn
+	return outcome, nil
+}
+
+// move asks strat for its move within timeout, recovering from panics as
+// the server's watchdog would. A snake that panics moves up, as the engine
+// moves snakes that don't answer.
+func move(ctx context.Context, strat strategy.Strategy, game api.GameRequest, timeout time.Duration) (d api.Direction) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	scratch := arena.Get()
+	defer arena.Put(scratch)
+	defer func() {
+		if recover() != nil {
+			d = api.Up
+		}
+	}()
+	return strat.Move(arena.NewContext(ctx, scratch), game).Move
+}
+
+// setup returns the starting position of a game between n snakes: the
+// snakes stacked on shuffled start points one cell in from the corners and
+// the middle of the edges, food diagonally in front of each, and food in
+// the centre, as the engine lays out standard boards.
+func setup(settings Settings, n int) api.GameRequest {
+	w, h := settings.Width, settings.Height
+	starts := []api.Coord{
+		{X: 1, Y: 1}, {X: w - 2, Y: h - 2}, {X: 1, Y: h - 2}, {X: w - 2, Y: 1},
+		{X: w / 2, Y: 1}, {X: w / 2, Y: h - 2}, {X: 1, Y: h / 2}, {X: w - 2, Y: h / 2},
+	}
+	if n > len(starts) {
+		panic(fmt.Sprintf("selfplay: at most %d snakes, not %d", len(starts), n))
+	}
+	rand.Shuffle(len(starts), func(i, j int) { starts[i], starts[j] = starts[j], starts[i] })
+	center := api.Coord{X: w / 2, Y: h / 2}
+
+	game := api.GameRequest{
+		Game: api.Game{
+			ID:      "selfplay-" + strconv.FormatInt(rand.Int63(), 36),
+			Ruleset: api.Ruleset{Name: "standard", Version: "v1.2.3"},
+			Map:     "standard",
+			Timeout: int32(settings.Timeout / time.Millisecond),
+			Source:  "custom",
+		},
+		Board: api.Board{Width: w, Height: h, Food: []api.Coord{center}},
+	}
+	for i, start := range starts[:n] {
+		snake := api.Battlesnake{
+			ID:     "snake-" + strconv.Itoa(i),
+			Name:   "snake " + strconv.Itoa(i),
+			Health: sim.MaxHealth,
+			Head:   start,
+			Length: startingLength,
+		}
+		for range startingLength {
+			snake.Body = append(snake.Body, start)
+		}
+		game.Board.Snakes = append(game.Board.Snakes, snake)
+		food := api.Coord{X: start.X + sign(center.X-start.X), Y: start.Y + sign(center.Y-start.Y)}
+		if food.X == start.X {
+			food.X++
+		}
+		if food.Y == start.Y {
+			food.Y++
+		}
+		if food != center {
+			game.Board.Food = append(game.Board.Food, food)
+		}
+	}
+	game.You = game.Board.Snakes[0]
+	return game
+}
+
+// spawnFood places food on a random empty cell if there is less than the
+// minimum, or by chance.
+func spawnFood(s *sim.State) {
+	if s.Food.Count() >= minFood && rand.Float64() >= foodSpawnRate {
+		return
+	}
+	occupied := s.Food
+	for i := range s.Snakes {
+		snake := &s.Snakes[i]
+		if snake.Eliminated {
+			continue
+		}
+		for j := 0; j < snake.Len(); j++ {
+			occupied.Set(s.Index(snake.Segment(j)))
+		}
+	}
+	var empty []int
+	for i := 0; i < s.Width*s.Height; i++ {
+		if !occupied.Has(i) {
+			empty = append(empty, i)
+		}
+	}
+	if len(empty) > 0 {
+		s.Food.Set(empty[rand.Intn(len(empty))])
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,147 @@
+package selfplay
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Elo ratings.
+const (
+	// InitialRating is the rating of a player before its first game.
+	InitialRating = 1500
+	// kFactor is the most a rating moves in a game against one opponent;
+	// in games against several it is shared between them.
+	kFactor = 32
+)
+
+// Rating is a player's standing on the ladder.
+type Rating struct {
+	Name   string  `json:"name"`
+	Rating float64 `json:"rating"`
+	Games  int     `json:"games"`
+	Wins   int     `json:"wins"`
+	Losses int     `json:"losses"`
+	Draws  int     `json:"draws"`
+	// First is when the player first played, which orders a lineage of
+	// weight files by age.
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+}
+
+// Ladder rates players by their results against each other, persisted to a
+// JSON file so that ratings carry over from one run to the next.
+type Ladder struct {
+	Path string
+
+	mu      sync.Mutex
+	ratings map[string]*Rating
+}
+
+// LoadLadder reads the ladder kept in path, which is empty if the file
+// doesn't exist yet.
+func LoadLadder(path string) (*Ladder, error) {
+	l := &Ladder{Path: path, ratings: map[string]*Rating{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ratings []*Rating
+	if err := json.Unmarshal(data, &ratings); err != nil {
+		return nil, err
+	}
+	for _, r := range ratings {
+		l.ratings[r.Name] = r
+	}
+	return l, nil
+}
+
+// Record updates the ratings of players with the outcome of a game between
+// them. Each pair is rated as a game of its own, won by whichever survived
+// longer.
+func (l *Ladder) Record(players []Player, outcome Outcome) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now().UTC()
+	ratings := make([]*Rating, len(players))
+	for i, p := range players {
+		r := l.ratings[p.Name]
+		if r == nil {
+			r = &Rating{Name: p.Name, Rating: InitialRating, First: now}
+			l.ratings[p.Name] = r
+		}
+		ratings[i] = r
+	}
+
+	k := kFactor / float64(max(len(players)-1, 1))
+	deltas := make([]float64, len(players))
+	for i := range players {
+		for j := range players {
+			if i == j {
+				continue
+			}
+			score := 0.5
+			switch {
+			case outcome.Survived[i] > outcome.Survived[j]:
+				score = 1
+			case outcome.Survived[i] < outcome.Survived[j]:
+				score = 0
+			}
+			expected := 1 / (1 + math.Pow(10, (ratings[j].Rating-ratings[i].Rating)/400))
+			deltas[i] += k * (score - expected)
+		}
+	}
+	for i, r := range ratings {
+		r.Rating += deltas[i]
+		r.Games++
+		r.Last = now
+		switch outcome.Winner {
+		case i:
+			r.Wins++
+		case -1:
+			r.Draws++
+		default:
+			r.Losses++
+		}
+	}
+}
+
+// Ratings returns every player's rating, best first.
+func (l *Ladder) Ratings() []Rating {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ratings := make([]Rating, 0, len(l.ratings))
+	for _, r := range l.ratings {
+		ratings = append(ratings, *r)
+	}
+	sort.Slice(ratings, func(i, j int) bool {
+		if ratings[i].Rating != ratings[j].Rating {
+			return ratings[i].Rating > ratings[j].Rating
+		}
+		return ratings[i].Name < ratings[j].Name
+	})
+	return ratings
+}
+
+// Save writes the ladder to its file, replacing it atomically.
+func (l *Ladder) Save() error {
+	data, err := json.MarshalIndent(l.Ratings(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0755); err != nil {
+		return err
+	}
+	tmp := l.Path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.Path)
+}
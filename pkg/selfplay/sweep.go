@@ -0,0 +1,208 @@
+package selfplay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Parameters a sweep can vary, besides the weight of each term, named
+// "weight." followed by the term's name.
+const (
+	ParamDepth       = "depth"
+	ParamRisk        = "risk"
+	ParamPanicHealth = "panic-health"
+	weightPrefix     = "weight."
+)
+
+// Param is a parameter of a sweep and the values it takes.
+type Param struct {
+	Name   string
+	Values []float64
+}
+
+// ParseParam parses a parameter given as name=value,value,..., e.g.
+// depth=2,4,8 or weight.space=5,10.
+func ParseParam(s string) (Param, error) {
+	name, values, ok := strings.Cut(s, "=")
+	if !ok || values == "" {
+		return Param{}, fmt.Errorf("selfplay: parameter %q is not name=value,...", s)
+	}
+	switch {
+	case name == ParamDepth, name == ParamRisk, name == ParamPanicHealth:
+	case strings.HasPrefix(name, weightPrefix) && knownTerm(strings.TrimPrefix(name, weightPrefix)):
+	default:
+		return Param{}, fmt.Errorf("selfplay: unknown parameter %q", name)
+	}
+	p := Param{Name: name}
+	for _, v := range strings.Split(values, ",") {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return Param{}, fmt.Errorf("selfplay: %s: %v", name, err)
+		}
+		p.Values = append(p.Values, f)
+	}
+	return p, nil
+}
+
+// Configurations returns a player of strategyName for every combination of
+// the params' values, named after them, e.g. "depth=4 risk=1.2".
+func Configurations(strategyName string, params []Param) []Player {
+	players := []Player{{Strategy: strategyName}}
+	for _, param := range params {
+		var next []Player
+		for _, p := range players {
+			for _, v := range param.Values {
+				next = append(next, p.with(param.Name, v))
+			}
+		}
+		players = next
+	}
+	return players
+}
+
+// with returns a copy of p with the parameter name set to v, appended to
+// its name.
+func (p Player) with(name string, v float64) Player {
+	switch {
+	case name == ParamDepth:
+		p.MaxDepth = int(v)
+	case name == ParamRisk:
+		p.Risk = v
+	case name == ParamPanicHealth:
+		health := int(v)
+		p.PanicHealth = &health
+	default:
+		p.Weights = maps.Clone(p.Weights)
+		if p.Weights == nil {
+			p.Weights = map[string]float64{}
+		}
+		p.Weights[strings.TrimPrefix(name, weightPrefix)] = v
+	}
+	if p.Name != "" {
+		p.Name += " "
+	}
+	p.Name += name + "=" + strconv.FormatFloat(v, 'g', -1, 64)
+	return p
+}
+
+// Sweep plays each of a set of configurations against fixed baselines.
+type Sweep struct {
+	Configurations []Player
+	// Baselines are the opponents, taken in turn: each game pits a
+	// configuration against the next Snakes-1 of them.
+	Baselines []Player
+	// Games is the number of games each configuration plays.
+	Games    int
+	Snakes   int
+	Settings Settings
+	// Parallel is the number of games played at once.
+	Parallel int
+}
+
+// SweepResult is how a configuration fared.
+type SweepResult struct {
+	Name   string `json:"name"`
+	Games  int    `json:"games"`
+	Wins   int    `json:"wins"`
+	Losses int    `json:"losses"`
+	Draws  int    `json:"draws"`
+	// Score is the share of the points the configuration took, a win
+	// counting one and a draw half, and Error the half-width of its 95%
+	// confidence interval.
+	Score float64 `json:"score"`
+	Error float64 `json:"error"`
+	// Turns is the mean number of turns the configuration survived.
+	Turns float64 `json:"turns"`
+}
+
+// Run plays the sweep's games and returns the results of its
+// configurations, best first. A cancelled sweep returns the games played so
+// far.
+func (sw *Sweep) Run(ctx context.Context) ([]SweepResult, error) {
+	snakes := max(sw.Snakes, 2)
+	type job struct{ config, game int }
+	jobs := make(chan job)
+	var (
+		mu      sync.Mutex
+		results = make([]SweepResult, len(sw.Configurations))
+		turns   = make([]int, len(sw.Configurations))
+		errs    []error
+		wg      sync.WaitGroup
+	)
+	for i, c := range sw.Configurations {
+		results[i].Name = c.Name
+	}
+	for range max(sw.Parallel, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				table := []Player{sw.Configurations[j.config]}
+				for k := range snakes - 1 {
+					table = append(table, sw.Baselines[(j.game*(snakes-1)+k)%len(sw.Baselines)])
+				}
+				outcome, err := Play(ctx, sw.Settings, table)
+				mu.Lock()
+				switch {
+				case err != nil:
+					if ctx.Err() == nil {
+						errs = append(errs, err)
+					}
+				default:
+					r := &results[j.config]
+					r.Games++
+					switch outcome.Winner {
+					case 0:
+						r.Wins++
+					case -1:
+						r.Draws++
+					default:
+						r.Losses++
+					}
+					turns[j.config] += outcome.Survived[0]
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for game := range sw.Games {
+		for config := range sw.Configurations {
+			select {
+			case jobs <- job{config, game}:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		r := &results[i]
+		if r.Games == 0 {
+			continue
+		}
+		n := float64(r.Games)
+		r.Score = (float64(r.Wins) + float64(r.Draws)/2) / n
+		r.Error = 1.96 * math.Sqrt(r.Score*(1-r.Score)/n)
+		r.Turns = float64(turns[i]) / n
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Turns > results[j].Turns
+	})
+	return results, nil
+}
@@ -0,0 +1,74 @@
+package selfplay
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jayuuza/battlesnake/pkg/config"
+	"github.com/jayuuza/battlesnake/pkg/eval"
+)
+
+// LoadWeights reads a weight file: a JSON object of term weights by name,
+// as POST /admin/weights takes, or a configuration file whose [weights]
+// table sets them, if its name ends in .toml.
+func LoadWeights(path string) (map[string]float64, error) {
+	weights := map[string]float64{}
+	if strings.HasSuffix(path, ".toml") {
+		cfg, err := config.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, term := range eval.Terms {
+			value, ok := cfg.Get("weights", term.Name)
+			if !ok {
+				continue
+			}
+			if weights[term.Name], err = strconv.ParseFloat(value, 64); err != nil {
+				return nil, fmt.Errorf("%s: weights.%s: %v", path, term.Name, err)
+			}
+		}
+		return weights, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &weights); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for name := range weights {
+		if !knownTerm(name) {
+			return nil, fmt.Errorf("%s: unknown term %q", path, name)
+		}
+	}
+	return weights, nil
+}
+
+func knownTerm(name string) bool {
+	for _, term := range eval.Terms {
+		if term.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// WeightedPlayer returns the player playing strategyName with the weights
+// read from the file at path. Its name joins the strategy, the file's name
+// and a hash of the weights, e.g. heuristic@v3.json#1f2e3d4c, so that each
+// version of a file is rated apart.
+func WeightedPlayer(strategyName, path string, weights map[string]float64) Player {
+	data, _ := json.Marshal(weights)
+	sum := sha256.Sum256(data)
+	return Player{
+		Name:     strategyName + "@" + filepath.Base(path) + "#" + hex.EncodeToString(sum[:4]),
+		Strategy: strategyName,
+		Weights:  weights,
+	}
+}
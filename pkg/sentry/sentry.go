@@ -0,0 +1,151 @@
+// Package sentry reports errors to Sentry, or any service accepting its
+// store API, so incidents surface without tailing logs.
+package sentry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/logging"
+)
+
+// Event levels.
+const (
+	Error   = "error"
+	Warning = "warning"
+	Fatal   = "fatal"
+)
+
+// Event is an error report.
+type Event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger,omitempty"`
+	Message     string            `json:"message"`
+	Environment string            `json:"environment,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+}
+
+var logger = logging.For(logging.Server)
+
+// queueSize is the number of events that may wait to be sent; more are
+// dropped rather than slowing down moves.
+const queueSize = 64
+
+// Client sends events in the background.
+type Client struct {
+	// Environment tags every event, e.g. "production".
+	Environment string
+
+	endpoint string
+	auth     string
+	http     *http.Client
+	queue    chan Event
+}
+
+// New returns a client for the project identified by dsn, of the form
+// https://key@host[/path]/project. The store API is under path, as with
+// Sentry served behind a prefix.
+func New(dsn string) (*Client, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sentry: %v", err)
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	prefix, project := path[:i+1], path[i+1:]
+	if u.User == nil || u.User.Username() == "" || project == "" {
+		return nil, fmt.Errorf("sentry: DSN %q is not scheme://key@host[/path]/project", dsn)
+	}
+	auth := "Sentry sentry_version=7, sentry_client=battlesnake/1.0, sentry_key=" + u.User.Username()
+	if secret, ok := u.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+	c := &Client{
+		endpoint: fmt.Sprintf("%s://%s%sapi/%s/store/", u.Scheme, u.Host, prefix, project),
+		auth:     auth,
+		http:     &http.Client{Timeout: 5 * time.Second},
+		queue:    make(chan Event, queueSize),
+	}
+	go c.send()
+	return c, nil
+}
+
+// Capture queues e to be sent, filling in its ID, time and platform. It
+// never blocks: the event is dropped if the queue is full.
+func (c *Client) Capture(e Event) {
+	if e.EventID == "" {
+		var id [16]byte
+		rand.Read(id[:])
+		e.EventID = hex.EncodeToString(id[:])
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now().UTC()
+	}
+	if e.Level == "" {
+		e.Level = Error
+	}
+	e.Platform = "go"
+	if e.Environment == "" {
+		e.Environment = c.Environment
+	}
+	if e.ServerName == "" {
+		e.ServerName, _ = os.Hostname()
+	}
+	select {
+	case c.queue <- e:
+	default:
+		logger.Warn("dropping error report, queue full", "message", e.Message)
+	}
+}
+
+func (c *Client) send() {
+	for e := range c.queue {
+		if err := c.post(e); err != nil {
+			logger.Warn("sending error report", "event", e.EventID, "err", err)
+		}
+	}
+}
+
+func (c *Client) post(e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", c.auth)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("sentry: %s", resp.Status)
+	}
+	return nil
+}
+
+// Truncate returns b as a string of at most n bytes, marking where it was
+// cut.
+func Truncate(b []byte, n int) string {
+	if len(b) <= n {
+		return string(b)
+	}
+	return fmt.Sprintf("%s... (%d bytes truncated)", b[:n], len(b)-n)
+}
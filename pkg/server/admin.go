@@ -0,0 +1,225 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/jayuuza/battlesnake/pkg/eval"
+	"github.com/jayuuza/battlesnake/pkg/logging"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// The admin API, below /admin/, controls a running server. Every request
+// must carry the server's AdminToken as a bearer token:
+//
+//	GET  /admin/state     dumps the server's state as AdminState
+//	POST /admin/strategy  {"strategy": "greedy"} switches the strategy new
+//	                      games play, or with "game" one game's strategy
+//	POST /admin/weights   {"space": 12} sets heuristic term weights
+//	POST /admin/debug     {"game": "id", "level": "debug"} logs everything
+//	                      at level or above for one game; no level stops
+
+// AdminState is a snapshot of the server's internal state.
+type AdminState struct {
+	DefaultStrategy string             `json:"defaultStrategy"`
+	Degraded        bool               `json:"degraded"`
+	Weights         map[string]float64 `json:"weights"`
+	// DebugGames holds the log levels of the games singled out for
+	// debugging, by game ID.
+	DebugGames map[string]string `json:"debugGames"`
+	Games      []AdminGame       `json:"games"`
+	Goroutines int               `json:"goroutines"`
+	HeapBytes  uint64            `json:"heapBytes"`
+}
+
+// AdminGame is a game being played.
+type AdminGame struct {
+	ID          string `json:"id"`
+	Strategy    string `json:"strategy"`
+	Personality string `json:"personality"`
+	Experiment  string `json:"experiment,omitempty"`
+	Arm         string `json:"arm,omitempty"`
+	Shadowed    bool   `json:"shadowed,omitempty"`
+}
+
+// defaultStrategy returns the name of the strategy played by games that
+// don't select one, as switched by the admin API.
+func (s *Server) defaultStrategy() string {
+	if name := s.strategyOverride.Load(); name != nil {
+		return *name
+	}
+	return s.DefaultStrategy
+}
+
+// State returns a snapshot of the server's internal state.
+func (s *Server) State() AdminState {
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+	state := AdminState{
+		DefaultStrategy: s.defaultStrategy(),
+		Degraded:        s.Degraded(),
+		Weights:         eval.Weights(),
+		DebugGames:      logging.GameLevels(),
+		Goroutines:      runtime.NumGoroutine(),
+		HeapBytes:       memory.HeapAlloc,
+	}
+
+	s.mu.Lock()
+	ids := make([]string, 0, len(s.workers))
+	for id := range s.workers {
+		ids = append(ids, id)
+	}
+	shadowed := map[string]bool{}
+	for id := range s.shadows {
+		shadowed[id] = true
+	}
+	s.mu.Unlock()
+
+	sort.Strings(ids)
+	for _, id := range ids {
+		game := AdminGame{ID: id, Shadowed: shadowed[id]}
+		stored, ok, err := s.Store.Get(id)
+		if err != nil {
+			logger.Error("loading game", "game", id, "err", err)
+		} else if ok {
+			game.Strategy = stored.Strategy
+			game.Personality = stored.Personality
+			game.Experiment = stored.Experiment
+			game.Arm = stored.Arm
+		}
+		state.Games = append(state.Games, game)
+	}
+	return state
+}
+
+// SwitchStrategy makes games that don't select a strategy play name from
+// now on or, if gameID isn't empty, switches that game to name from its
+// next move.
+func (s *Server) SwitchStrategy(name, gameID string) error {
+	if _, err := strategy.New(name); err != nil {
+		return err
+	}
+	if gameID == "" {
+		s.strategyOverride.Store(&name)
+		logger.Info("switched default strategy", "strategy", name)
+		return nil
+	}
+
+	game, ok, err := s.Store.Get(gameID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("server: unknown game %q", gameID)
+	}
+	game.Strategy = name
+	if err := s.Store.Put(game); err != nil {
+		return err
+	}
+	// The game's strategy is recreated from the store, and started, on its
+	// next move.
+	s.onWorker(gameID, func(w *worker) { w.strategy = nil })
+	logger.Info("switched strategy", "game", gameID, "strategy", name)
+	return nil
+}
+
+// HandleAdmin serves the admin API.
+func (s *Server) HandleAdmin(w http.ResponseWriter, r *http.Request) {
+	if s.AdminToken == "" {
+		http.NotFound(w, r)
+		return
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.AdminToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	endpoint := strings.TrimPrefix(r.URL.Path, "/admin/")
+	if endpoint == "state" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s.State()); err != nil {
+			logger.Error("encoding admin state", "err", err)
+		}
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var err error
+	switch endpoint {
+	case "strategy":
+		var body struct{ Strategy, Game string }
+		if err = json.NewDecoder(r.Body).Decode(&body); err == nil {
+			err = s.SwitchStrategy(body.Strategy, body.Game)
+		}
+	case "weights":
+		var weights map[string]float64
+		if err = json.NewDecoder(r.Body).Decode(&weights); err == nil {
+			err = setWeights(weights)
+		}
+	case "debug":
+		var body struct{ Game, Level string }
+		if err = json.NewDecoder(r.Body).Decode(&body); err == nil {
+			err = debugGame(body.Game, body.Level)
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setWeights sets the weights of the heuristic terms named in weights,
+// checking every name before changing any.
+func setWeights(weights map[string]float64) error {
+	current := eval.Weights()
+	for name := range weights {
+		if _, ok := current[name]; !ok {
+			return fmt.Errorf("server: unknown term %q", name)
+		}
+	}
+	for name, weight := range weights {
+		if err := eval.SetWeight(name, weight); err != nil {
+			return err
+		}
+	}
+	logger.Info("set weights", "weights", weights)
+	return nil
+}
+
+// debugGame logs everything at level or above for the game gameID, or
+// stops singling it out if level is empty.
+func debugGame(gameID, level string) error {
+	if gameID == "" {
+		return fmt.Errorf("server: no game given")
+	}
+	if level == "" {
+		logging.ClearGameLevel(gameID)
+		logger.Info("stopped debugging game", "game", gameID)
+		return nil
+	}
+	l, err := logging.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	logging.SetGameLevel(gameID, l)
+	logger.Info("debugging game", "game", gameID, "level", l)
+	return nil
+}
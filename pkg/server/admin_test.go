@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/store"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// starter is a strategy that counts the moves it is asked for without
+// having been started.
+type starter struct{ started bool }
+
+var unstartedMoves atomic.Int32
+
+func (s *starter) Start(context.Context, api.GameRequest) { s.started = true }
+
+func (s *starter) Move(context.Context, api.GameRequest) api.MoveResponse {
+	if !s.started {
+		unstartedMoves.Add(1)
+	}
+	return api.MoveResponse{Move: api.Up}
+}
+
+func (s *starter) End(context.Context, api.GameRequest) {}
+
+func init() {
+	strategy.Register("test-starter", func() strategy.Strategy { return &starter{} })
+}
+
+func TestSwitchStrategyStarts(t *testing.T) {
+	unstartedMoves.Store(0)
+	s := &Server{DefaultStrategy: "test-starter", Store: store.NewMemory(), DataDir: t.TempDir()}
+	ctx := context.Background()
+	request := api.GameRequest{
+		Game:  api.Game{ID: "g1", Timeout: 500},
+		Board: api.Board{Width: 11, Height: 11},
+		You:   api.Battlesnake{ID: "us", Head: api.Coord{X: 5, Y: 5}, Body: []api.Coord{{X: 5, Y: 5}}, Length: 1, Health: 100},
+	}
+	request.Board.Snakes = []api.Battlesnake{request.You}
+	s.Start(ctx, request, "", "")
+	request.Turn = 1
+	s.Move(ctx, request)
+	if err := s.SwitchStrategy("test-starter", "g1"); err != nil {
+		t.Fatal(err)
+	}
+	request.Turn = 2
+	s.Move(ctx, request)
+	s.End(ctx, request)
+	if n := unstartedMoves.Load(); n != 0 {
+		t.Fatalf("%d moves asked of a strategy that wasn't started", n)
+	}
+}
+
+// lingerer is a strategy whose moves ignore their deadline, running until
+// the test lets them finish, and that records whether it was ended while
+// still deciding one.
+type lingerer struct{ strategy.NopHooks }
+
+var (
+	lingering      atomic.Bool
+	finishLinger   chan struct{}
+	endedLingering atomic.Bool
+)
+
+func (lingerer) Move(context.Context, api.GameRequest) api.MoveResponse {
+	lingering.Store(true)
+	<-finishLinger
+	lingering.Store(false)
+	return api.MoveResponse{Move: api.Up}
+}
+
+func (lingerer) End(context.Context, api.GameRequest) {
+	endedLingering.Store(lingering.Load())
+}
+
+func init() {
+	strategy.Register("test-lingerer", func() strategy.Strategy { return lingerer{} })
+}
+
+func TestEndWaitsForMove(t *testing.T) {
+	finishLinger = make(chan struct{})
+	s := &Server{DefaultStrategy: "test-lingerer", Store: store.NewMemory(), DataDir: t.TempDir()}
+	ctx := context.Background()
+	request := api.GameRequest{
+		Game:  api.Game{ID: "g1", Timeout: 100},
+		Board: api.Board{Width: 11, Height: 11},
+		You:   api.Battlesnake{ID: "us", Head: api.Coord{X: 5, Y: 5}, Body: []api.Coord{{X: 5, Y: 5}}, Length: 1, Health: 100},
+	}
+	request.Board.Snakes = []api.Battlesnake{request.You}
+	s.Start(ctx, request, "", "")
+	request.Turn = 1
+	// The watchdog answers for the move, which goes on being decided.
+	s.Move(ctx, request)
+	if !lingering.Load() {
+		t.Fatal("move not still being decided after the watchdog answered")
+	}
+	ended := make(chan struct{})
+	go func() {
+		s.End(ctx, request)
+		close(ended)
+	}()
+	select {
+	case <-ended:
+		t.Fatal("game ended while its move was still being decided")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(finishLinger)
+	<-ended
+	if endedLingering.Load() {
+		t.Fatal("strategy ended while deciding a move")
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"time"
+)
+
+// CompressHistory compresses the recorded histories last written more than
+// Retention.CompressAfter ago, every interval until ctx is done. It skips
+// rounds while the server is degraded.
+func (s *Server) CompressHistory(ctx context.Context, interval time.Duration) {
+	if s.History == nil || s.Retention == nil || s.Retention.CompressAfter <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if !s.Degraded() {
+			n, err := s.History.CompressOlder(s.Retention.CompressAfter)
+			if err != nil {
+				logger.Error("compressing history", "err", err)
+			} else if n > 0 {
+				logger.Info("compressed history", "games", n)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// uploadInterval is how often finished games are looked for to upload.
+const uploadInterval = time.Minute
+
+// UploadGames uploads finished games from the data directory with Uploads
+// every minute until ctx is done, skipping rounds while the server is
+// degraded.
+func (s *Server) UploadGames(ctx context.Context) {
+	if s.Uploads == nil || s.DataDir == "" {
+		return
+	}
+	ticker := time.NewTicker(uploadInterval)
+	defer ticker.Stop()
+	for {
+		if !s.Degraded() {
+			uploaded, removed, err := s.Uploads.Sweep(ctx, s.DataDir)
+			if err != nil {
+				logger.Error("uploading games", "err", err)
+			}
+			if uploaded > 0 || removed > 0 {
+				logger.Info("uploaded games", "games", uploaded, "removed", removed)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
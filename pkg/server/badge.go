@@ -0,0 +1,94 @@
+package server
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+
+	"github.com/jayuuza/battlesnake/pkg/results"
+)
+
+// badgeTemplate is a flat shields.io-style badge. Its arguments are the
+// total width, label width, value width, value color, label x, value x,
+// label and value, with x positions in tenths of a pixel as the text is
+// scaled down for crisper rendering.
+const badgeTemplate = `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[7]s: %[8]s">
+<title>%[7]s: %[8]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%[2]d" height="20" fill="#555"/><rect x="%[2]d" width="%[3]d" height="20" fill="%[4]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="110">
+<text x="%[5]d" y="150" fill="#010101" fill-opacity=".3" transform="scale(.1)">%[7]s</text><text x="%[5]d" y="140" transform="scale(.1)">%[7]s</text>
+<text x="%[6]d" y="150" fill="#010101" fill-opacity=".3" transform="scale(.1)">%[8]s</text><text x="%[6]d" y="140" transform="scale(.1)">%[8]s</text>
+</g>
+</svg>
+`
+
+// badge renders a badge reading label: value on a background of color.
+func badge(label, value, color string) []byte {
+	// Verdana at 11px averages about 7px a character; pad each side by 5.
+	lw, vw := 7*len(label)+10, 7*len(value)+10
+	label, value = html.EscapeString(label), html.EscapeString(value)
+	return []byte(fmt.Sprintf(badgeTemplate, lw+vw, lw, vw, color, lw*5, lw*10+vw*5, label, value))
+}
+
+// winRateColor returns the badge color for a win rate.
+func winRateColor(rate float64) string {
+	switch {
+	case rate >= 0.6:
+		return "#4c1"
+	case rate >= 0.45:
+		return "#a4a61d"
+	case rate >= 0.3:
+		return "#fe7d37"
+	default:
+		return "#e05d44"
+	}
+}
+
+// HandleWinRateBadge serves an SVG badge of the win rate from the results
+// store. The "strategy" query parameter limits it to one strategy's games
+// and "last" to the most recent games.
+func (s *Server) HandleWinRateBadge(w http.ResponseWriter, r *http.Request) {
+	if s.Results == nil {
+		http.Error(w, "server: results are not being recorded", http.StatusServiceUnavailable)
+		return
+	}
+	rs, err := s.Results.All()
+	if err != nil {
+		logger.Error("badge", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	label := "win rate"
+	if name := r.URL.Query().Get("strategy"); name != "" {
+		var filtered []results.Result
+		for _, result := range rs {
+			if result.Strategy == name {
+				filtered = append(filtered, result)
+			}
+		}
+		rs = filtered
+		label = name + " win rate"
+	}
+	if last := r.URL.Query().Get("last"); last != "" {
+		n, err := strconv.Atoi(last)
+		if err != nil || n <= 0 {
+			http.Error(w, "last: want a positive number of games", http.StatusBadRequest)
+			return
+		}
+		rs = rs[max(len(rs)-n, 0):]
+	}
+
+	summary := results.Summarize(rs)
+	value, color := "no games", "#9f9f9f"
+	if summary.Games > 0 {
+		value = fmt.Sprintf("%.0f%% of %d", 100*summary.WinRate, summary.Games)
+		color = winRateColor(summary.WinRate)
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	// Image proxies such as GitHub's cache badges unless told otherwise.
+	w.Header().Set("Cache-Control", "max-age=300")
+	w.Write(badge(label, value, color))
+}
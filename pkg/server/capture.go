@@ -0,0 +1,107 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/gamedir"
+)
+
+// Raw captures are the bodies of start, move and end requests exactly as
+// they arrived, before any decoding, so that a request that was decoded
+// wrongly or not at all can be replayed byte for byte:
+//
+//	<CaptureDir>/<game>/<turn>-<endpoint>.json
+//	<CaptureDir>/undecodable/<time>-<endpoint>.json
+//
+// Bodies are written in the background; if the disk falls behind, they are
+// dropped rather than delaying moves.
+
+// captureQueue is the number of captured bodies that may wait to be
+// written.
+const captureQueue = 256
+
+// undecodableDir is the directory, within CaptureDir, of bodies whose game
+// and turn couldn't be read.
+const undecodableDir = "undecodable"
+
+// capturedBody is a request body waiting to be written to path.
+type capturedBody struct {
+	path string
+	data []byte
+}
+
+// captureBodies tees the bodies of game requests to CaptureDir before
+// passing them on to next.
+func (s *Server) captureBodies(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, endpoint := splitPath(r.URL.Path)
+		if s.CaptureDir == "" || endpoint == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), errReader{err}))
+		s.queueCapture(capturedBody{path: s.capturePath(endpoint, data), data: data})
+		next.ServeHTTP(w, r)
+	})
+}
+
+// errReader is a reader failing with err, or at EOF if err is nil, so that
+// a body that failed to read while being captured fails the same way when
+// decoded.
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return 0, io.EOF
+}
+
+// capturePath returns the file the body of a request to endpoint is
+// captured to, by the game and turn it names if they can be read.
+func (s *Server) capturePath(endpoint string, data []byte) string {
+	var key struct {
+		Game struct {
+			ID string `json:"id"`
+		} `json:"game"`
+		Turn int `json:"turn"`
+	}
+	if json.Unmarshal(data, &key) != nil || !gamedir.Safe(key.Game.ID) || key.Game.ID == undecodableDir {
+		name := fmt.Sprintf("%d-%s.json", time.Now().UnixNano(), endpoint)
+		return filepath.Join(s.CaptureDir, undecodableDir, name)
+	}
+	return filepath.Join(s.CaptureDir, key.Game.ID, fmt.Sprintf("%04d-%s.json", key.Turn, endpoint))
+}
+
+// queueCapture queues body to be written, dropping it if the queue is full.
+func (s *Server) queueCapture(body capturedBody) {
+	s.captureOnce.Do(func() {
+		s.captures = make(chan capturedBody, captureQueue)
+		go s.writeCaptures()
+	})
+	select {
+	case s.captures <- body:
+	default:
+		logger.Warn("dropping raw capture, writes are falling behind", "path", body.path)
+	}
+}
+
+func (s *Server) writeCaptures() {
+	for body := range s.captures {
+		if err := os.MkdirAll(filepath.Dir(body.path), 0755); err != nil {
+			logger.Error("writing raw capture", "err", err)
+			continue
+		}
+		if err := os.WriteFile(body.path, body.data, 0644); err != nil {
+			logger.Error("writing raw capture", "err", err)
+		}
+	}
+}
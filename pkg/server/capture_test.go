@@ -0,0 +1,31 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCapturePath(t *testing.T) {
+	s := &Server{CaptureDir: "raw"}
+	tests := []struct {
+		body string
+		// dir is the directory of CaptureDir the body is written to.
+		dir string
+	}{
+		{`{"game":{"id":"g1"},"turn":5}`, "g1"},
+		{`{"game":{"id":"../../escaped"},"turn":5}`, undecodableDir},
+		{`{"game":{"id":"a/b"},"turn":5}`, undecodableDir},
+		{`{"game":{"id":".."},"turn":5}`, undecodableDir},
+		{`{"game":{"id":"undecodable"},"turn":5}`, undecodableDir},
+		{`{"game":`, undecodableDir},
+	}
+	for _, tt := range tests {
+		path := s.capturePath("move", []byte(tt.body))
+		if got := filepath.Dir(path); got != filepath.Join(s.CaptureDir, tt.dir) {
+			t.Errorf("capturePath(%s) = %q, want it in %q", tt.body, path, tt.dir)
+		}
+	}
+	if got, want := s.capturePath("move", []byte(`{"game":{"id":"g1"},"turn":5}`)), filepath.Join("raw", "g1", "0005-move.json"); got != want {
+		t.Errorf("capturePath = %q, want %q", got, want)
+	}
+}
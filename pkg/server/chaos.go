@@ -0,0 +1,86 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultChaosDelay is the longest delay injected when none is given.
+const defaultChaosDelay = time.Second
+
+// Chaos injects failures into the decision pipeline, to check that the
+// watchdog, the fallback and panic recovery keep the snake alive. It is
+// for testing only: never enable it for games that count.
+type Chaos struct {
+	// Delay is the probability (0-1) that the strategy starts deciding a
+	// move only after a random delay of up to MaxDelay.
+	Delay    float64
+	MaxDelay time.Duration
+	// Drop is the probability that the strategy's computation is lost, the
+	// move never being answered.
+	Drop float64
+	// Panic is the probability that the strategy panics.
+	Panic float64
+}
+
+// ParseChaos parses a comma-separated list of key=value settings of Chaos:
+// delay, max-delay, drop and panic, e.g. "delay=0.2,max-delay=800ms,panic=0.05".
+func ParseChaos(spec string) (*Chaos, error) {
+	c := &Chaos{MaxDelay: defaultChaosDelay}
+	for _, setting := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(setting), "=")
+		if !ok {
+			return nil, fmt.Errorf("chaos: %q is not key=value", setting)
+		}
+		var err error
+		switch key {
+		case "delay":
+			c.Delay, err = parseChance(value)
+		case "max-delay":
+			c.MaxDelay, err = time.ParseDuration(value)
+		case "drop":
+			c.Drop, err = parseChance(value)
+		case "panic":
+			c.Panic, err = parseChance(value)
+		default:
+			err = fmt.Errorf("unknown setting, want delay, max-delay, drop or panic")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("chaos: %s: %v", key, err)
+		}
+	}
+	return c, nil
+}
+
+func parseChance(value string) (float64, error) {
+	p, err := strconv.ParseFloat(value, 64)
+	if err == nil && (p < 0 || p > 1) {
+		err = fmt.Errorf("%v is not a probability", p)
+	}
+	return p, err
+}
+
+// inject is called by the goroutine deciding a move before it asks the
+// strategy, and fails it as c says. It does nothing if c is nil.
+func (c *Chaos) inject(gameID string, turn int) {
+	if c == nil {
+		return
+	}
+	if rand.Float64() < c.Delay && c.MaxDelay > 0 {
+		delay := time.Duration(rand.Int63n(int64(c.MaxDelay)))
+		logger.Warn("chaos: delaying move", "game", gameID, "turn", turn, "delay", delay)
+		time.Sleep(delay)
+	}
+	if rand.Float64() < c.Drop {
+		logger.Warn("chaos: dropping move", "game", gameID, "turn", turn)
+		runtime.Goexit()
+	}
+	if rand.Float64() < c.Panic {
+		logger.Warn("chaos: panicking", "game", gameID, "turn", turn)
+		panic("chaos: injected panic")
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/decision"
+)
+
+// Decision traces are built and written in the background, as evaluating
+// every candidate again costs more than a move can spare; if the disk or
+// the evaluations fall behind, traces are dropped rather than delaying
+// moves.
+
+// decisionQueue is the number of decisions that may wait to be traced.
+const decisionQueue = 256
+
+// decided is a move waiting to be traced.
+type decided struct {
+	request  api.GameRequest
+	strategy string
+	move     api.Direction
+	plan     string
+	timing   decision.Timing
+}
+
+// traceDecision queues the trace of a move decided in request, if traces
+// are recorded and the server isn't degraded.
+func (s *Server) traceDecision(d decided) {
+	if s.Decisions == nil || s.Degraded() {
+		return
+	}
+	s.decisionOnce.Do(func() {
+		s.decisions = make(chan decided, decisionQueue)
+		go s.writeDecisions()
+	})
+	select {
+	case s.decisions <- d:
+	default:
+		logger.Warn("dropping decision trace, traces are falling behind", "game", d.request.Game.ID, "turn", d.request.Turn)
+	}
+}
+
+func (s *Server) writeDecisions() {
+	for d := range s.decisions {
+		t := decision.New(d.request, d.strategy, d.move, d.plan, d.timing)
+		if err := s.Decisions.Record(t); err != nil {
+			logger.Error("recording decision trace", "game", d.request.Game.ID, "err", err)
+		}
+	}
+}
+
+// millis returns d in milliseconds.
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
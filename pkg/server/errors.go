@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/sentry"
+)
+
+// maxReportedRequest is the most of a request attached to an error report.
+const maxReportedRequest = 8 << 10
+
+// reportError sends an error report with request, which may be a
+// GameRequest or the raw bytes of one, attached, if reporting is enabled.
+func (s *Server) reportError(level, message string, request any, tags map[string]string) {
+	if s.Errors == nil {
+		return
+	}
+	raw, ok := request.([]byte)
+	if !ok {
+		raw, _ = json.Marshal(request)
+	}
+	s.Errors.Capture(sentry.Event{
+		Level:   level,
+		Logger:  "server",
+		Message: message,
+		Tags:    tags,
+		Extra:   map[string]any{"request": sentry.Truncate(raw, maxReportedRequest)},
+	})
+}
+
+// gameTags returns the tags identifying the game and turn of request.
+func gameTags(request api.GameRequest, endpoint string) map[string]string {
+	return map[string]string{
+		"endpoint": endpoint,
+		"game":     request.Game.ID,
+		"turn":     fmt.Sprint(request.Turn),
+		"ruleset":  request.Game.Ruleset.Name,
+		"map":      request.Game.Map,
+	}
+}
+
+// reportPanic reports a panic while handling request, then resumes
+// panicking. It must be deferred.
+func (s *Server) reportPanic(endpoint string, request api.GameRequest) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	tags := gameTags(request, endpoint)
+	s.reportError(sentry.Fatal, fmt.Sprintf("panic: %v\n\n%s", r, debug.Stack()), request, tags)
+	panic(r)
+}
+
+// reportOverrun reports a move that took longer than its budget.
+func (s *Server) reportOverrun(request api.GameRequest, took, budget time.Duration) {
+	tags := gameTags(request, "move")
+	message := fmt.Sprintf("move took %v, over its %v budget", took.Round(time.Millisecond), budget)
+	s.reportError(sentry.Warning, message, request, tags)
+}
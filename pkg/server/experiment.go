@@ -0,0 +1,30 @@
+package server
+
+import "hash/fnv"
+
+// buckets is the granularity of experiment ratios.
+const buckets = 10000
+
+// Experiment splits games that don't select a strategy between two
+// strategies, so their win rates can be compared.
+type Experiment struct {
+	Name string
+	A    string
+	B    string
+	// RatioB is the fraction (0-1) of games assigned to B.
+	RatioB float64
+}
+
+// Assign returns the arm, "A" or "B", and strategy for a game. The arm is
+// chosen by hashing the game ID, so every replica assigns a game alike.
+func (e *Experiment) Assign(gameID string) (arm, strategy string) {
+	h := fnv.New64a()
+	h.Write([]byte(e.Name))
+	h.Write([]byte{0})
+	h.Write([]byte(gameID))
+	// FNV mixes the final bytes into the low bits best.
+	if float64(h.Sum64()%buckets)/buckets < e.RatioB {
+		return "B", e.B
+	}
+	return "A", e.A
+}
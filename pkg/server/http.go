@@ -0,0 +1,252 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/sentry"
+	"github.com/jayuuza/battlesnake/pkg/timing"
+)
+
+// Over HTTP, the strategy for a game is selected by the first of: the URL
+// path prefix (a snake registered as https://host/aggro plays "aggro") or
+// the "strategy" query parameter. The personality is selected by the
+// "personality" query parameter, and the API version spoken by the "api"
+// query parameter, the current one by default.
+
+// Handler returns an http.Handler routing the Battlesnake endpoints, both at
+// the root and below a strategy name prefix, /stats, the win rate badge at
+// /badge/winrate, the streaming overlay below /overlay/, the expvar metrics
+// at /debug/vars and the admin API. Game requests are captured raw to
+// CaptureDir if it is set.
+func (s *Server) Handler() http.Handler {
+	return stampArrival(s.captureBodies(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/admin/") {
+			s.HandleAdmin(w, r)
+			return
+		}
+		if r.URL.Path == "/debug/vars" {
+			expvar.Handler().ServeHTTP(w, r)
+			return
+		}
+		if r.URL.Path == "/stats" {
+			s.HandleStats(w, r)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/overlay/") {
+			s.HandleOverlay(w, r)
+			return
+		}
+		if r.URL.Path == "/badge/winrate" {
+			s.HandleWinRateBadge(w, r)
+			return
+		}
+		_, endpoint := splitPath(r.URL.Path)
+		switch endpoint {
+		case "start":
+			s.HandleStart(w, r)
+		case "move":
+			s.HandleMove(w, r)
+		case "end":
+			s.HandleEnd(w, r)
+		default:
+			s.HandleIndex(w, r)
+		}
+	})))
+}
+
+// stampArrival records the time each request arrived in its context, before
+// anything else is done with it.
+func stampArrival(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(timing.WithArrival(r.Context(), time.Now())))
+	})
+}
+
+// splitPath splits a request path into the strategy prefix and the endpoint
+// name, which is empty for the index.
+func splitPath(path string) (prefix, endpoint string) {
+	path = strings.Trim(path, "/")
+	i := strings.LastIndex(path, "/")
+	last := path[i+1:]
+	switch last {
+	case "start", "move", "end":
+		if i < 0 {
+			return "", last
+		}
+		return path[:i], last
+	}
+	return path, ""
+}
+
+// requestedStrategy returns the name of the strategy selected by r, or ""
+// if it doesn't select one.
+func requestedStrategy(r *http.Request) string {
+	if prefix, _ := splitPath(r.URL.Path); prefix != "" {
+		return prefix
+	}
+	return r.URL.Query().Get("strategy")
+}
+
+// requestedPersonality returns the name of the personality selected by r,
+// or "" if it doesn't select one.
+func requestedPersonality(r *http.Request) string {
+	return r.URL.Query().Get("personality")
+}
+
+// requestedVersion returns the API version selected by r, answering with
+// 400 Bad Request if it is unknown.
+func requestedVersion(w http.ResponseWriter, r *http.Request) (api.Version, bool) {
+	name := r.URL.Query().Get("api")
+	if name == "" {
+		return api.V1, true
+	}
+	version, ok := api.LookupVersion(name)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown API version %q, known: %s", name, strings.Join(api.VersionNames(), ", ")),
+			http.StatusBadRequest)
+	}
+	return version, ok
+}
+
+// HandleIndex is called when your Battlesnake is created and refreshed
+// by play.battlesnake.com. BattlesnakeInfoResponse contains information about
+// your Battlesnake, including what it should look like on the game board.
+func (s *Server) HandleIndex(w http.ResponseWriter, r *http.Request) {
+	version, ok := requestedVersion(w, r)
+	if !ok {
+		return
+	}
+	info, err := s.infoFor(requestedStrategy(r), requestedPersonality(r), version)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	serveInfo(w, r, info)
+}
+
+// maxPooledBody is the largest request buffer kept for reuse, so one huge
+// request doesn't pin its memory for good.
+const maxPooledBody = 1 << 20
+
+// bodies holds buffers request bodies are read into, reused across
+// requests.
+var bodies = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// requestBody is a request body read into a pooled buffer.
+type requestBody struct {
+	buf *bytes.Buffer
+}
+
+// Bytes returns the body, valid until release.
+func (b requestBody) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// release returns the buffer for reuse. The body must not be used
+// afterwards; nothing decoded from it refers to it.
+func (b requestBody) release() {
+	if b.buf.Cap() <= maxPooledBody {
+		b.buf.Reset()
+		bodies.Put(b.buf)
+	}
+}
+
+// decodeRequest decodes the GameRequest in r's body, also returned raw,
+// in the given API version, answering with 400 Bad Request and
+// reporting the error if it can't. The body is read into a pooled buffer,
+// which the caller must release once done with it.
+func (s *Server) decodeRequest(w http.ResponseWriter, r *http.Request, version api.Version) (api.GameRequest, requestBody, bool) {
+	request := api.GameRequest{}
+	body := requestBody{buf: bodies.Get().(*bytes.Buffer)}
+	if r.ContentLength > 0 && r.ContentLength <= maxPooledBody {
+		body.buf.Grow(int(r.ContentLength) + bytes.MinRead)
+	}
+	_, err := body.buf.ReadFrom(r.Body)
+	if err == nil {
+		err = version.DecodeRequest(body.Bytes(), &request)
+	}
+	if err != nil {
+		logger.Warn("decoding request", "path", r.URL.Path, "err", err)
+		s.reportError(sentry.Error, fmt.Sprintf("decoding %s: %v", r.URL.Path, err), body.Bytes(),
+			map[string]string{"endpoint": r.URL.Path})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return request, body, false
+	}
+	return request, body, true
+}
+
+// HandleStart is called at the start of each game your Battlesnake is playing.
+// The GameRequest object contains information about the game that's about to start.
+// Versions that take the snake's looks from the start response get them.
+func (s *Server) HandleStart(w http.ResponseWriter, r *http.Request) {
+	version, ok := requestedVersion(w, r)
+	if !ok {
+		return
+	}
+	request, body, ok := s.decodeRequest(w, r, version)
+	defer body.release()
+	if !ok {
+		return
+	}
+
+	s.Start(r.Context(), request, requestedStrategy(r), requestedPersonality(r))
+
+	info, err := s.Info(requestedStrategy(r), requestedPersonality(r))
+	if err != nil {
+		return
+	}
+	if response := version.Start(info); response != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logger.Error("encoding start", "game", request.Game.ID, "err", err)
+		}
+	}
+}
+
+// HandleMove is called for each turn of each game.
+// Valid responses are "up", "down", "left", or "right".
+func (s *Server) HandleMove(w http.ResponseWriter, r *http.Request) {
+	version, ok := requestedVersion(w, r)
+	if !ok {
+		return
+	}
+	request, body, ok := s.decodeRequest(w, r, version)
+	defer body.release()
+	if !ok {
+		return
+	}
+
+	move := s.Move(withRawRequest(r.Context(), body.Bytes()), request)
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(version.Move(move))
+	if err != nil {
+		logger.Error("encoding move", "game", request.Game.ID, "err", err)
+	}
+}
+
+// HandleEnd is called when a game your Battlesnake was playing has ended.
+// It's purely for informational purposes, no response required.
+func (s *Server) HandleEnd(w http.ResponseWriter, r *http.Request) {
+	version, ok := requestedVersion(w, r)
+	if !ok {
+		return
+	}
+	request, body, ok := s.decodeRequest(w, r, version)
+	defer body.release()
+	if !ok {
+		return
+	}
+
+	s.End(r.Context(), request)
+}
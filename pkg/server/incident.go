@@ -0,0 +1,151 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/gamedir"
+	"github.com/jayuuza/battlesnake/pkg/search"
+)
+
+// An incident bundle is a directory holding everything needed to reproduce
+// a slow turn offline:
+//
+//	request.json     the GameRequest as received
+//	incident.json    an Incident: timings and search statistics
+//	goroutines.txt   every goroutine's stack when the threshold passed
+//	cpu.pprof        a CPU profile from then until the move was decided,
+//	                 unless another CPU profile was running
+
+// Incident describes a turn that took longer than the incident threshold.
+type Incident struct {
+	GameID      string         `json:"gameId"`
+	Turn        int            `json:"turn"`
+	Strategy    string         `json:"strategy"`
+	Time        time.Time      `json:"time"`
+	TookMs      float64        `json:"tookMs"`
+	BudgetMs    float64        `json:"budgetMs"`
+	ThresholdMs float64        `json:"thresholdMs"`
+	Search      search.Summary `json:"search"`
+}
+
+type rawRequestKey struct{}
+
+// withRawRequest returns a context carrying the request body as received,
+// for incident bundles.
+func withRawRequest(ctx context.Context, body []byte) context.Context {
+	return context.WithValue(ctx, rawRequestKey{}, body)
+}
+
+// incidentWatch watches one move for passing the incident threshold, when
+// it starts sampling what the server is doing.
+type incidentWatch struct {
+	timer *time.Timer
+
+	mu         sync.Mutex
+	done       bool
+	fired      bool
+	goroutines []byte
+	cpu        *bytes.Buffer
+}
+
+// watchIncident starts watching a move for passing the incident threshold,
+// returning nil if incidents aren't being captured.
+func (s *Server) watchIncident() *incidentWatch {
+	if s.IncidentDir == "" || s.IncidentThreshold <= 0 || s.Degraded() {
+		return nil
+	}
+	w := &incidentWatch{}
+	w.timer = time.AfterFunc(s.IncidentThreshold, w.sample)
+	return w
+}
+
+// sample dumps every goroutine and starts a CPU profile, if none is
+// running.
+func (w *incidentWatch) sample() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return
+	}
+	w.fired = true
+	var buf bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&buf, 2)
+	w.goroutines = buf.Bytes()
+	cpu := &bytes.Buffer{}
+	if pprof.StartCPUProfile(cpu) == nil {
+		w.cpu = cpu
+	}
+}
+
+// finishIncident stops watching the move decided for request and, if it passed
+// the threshold, writes its incident bundle.
+func (s *Server) finishIncident(ctx context.Context, w *incidentWatch, request api.GameRequest, stats *search.Stats, took, budget time.Duration) {
+	if w == nil {
+		return
+	}
+	w.timer.Stop()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.done = true
+	if !w.fired {
+		return
+	}
+	if w.cpu != nil {
+		pprof.StopCPUProfile()
+	}
+
+	raw, ok := ctx.Value(rawRequestKey{}).([]byte)
+	if !ok {
+		raw, _ = json.Marshal(request)
+	}
+	incident := Incident{
+		GameID:      request.Game.ID,
+		Turn:        request.Turn,
+		Strategy:    s.loadGame(request).Strategy,
+		Time:        time.Now().UTC(),
+		TookMs:      float64(took) / float64(time.Millisecond),
+		BudgetMs:    float64(budget) / float64(time.Millisecond),
+		ThresholdMs: float64(s.IncidentThreshold) / float64(time.Millisecond),
+		Search:      stats.Summary(),
+	}
+	if err := s.writeIncident(incident, raw, w); err != nil {
+		logger.Error("writing incident", "game", request.Game.ID, "err", err)
+		return
+	}
+	logger.WarnContext(ctx, "slow turn", "game", request.Game.ID, "turn", request.Turn,
+		"took", took, "nodes", incident.Search.Nodes, "depth", incident.Search.Depth)
+}
+
+func (s *Server) writeIncident(incident Incident, raw []byte, w *incidentWatch) error {
+	dir := filepath.Join(s.IncidentDir, fmt.Sprintf("%s-%d", gamedir.Name(incident.GameID), incident.Turn))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(incident, "", "  ")
+	if err != nil {
+		return err
+	}
+	files := map[string][]byte{
+		"request.json":   raw,
+		"incident.json":  b,
+		"goroutines.txt": w.goroutines,
+	}
+	if w.cpu != nil {
+		files["cpu.pprof"] = w.cpu.Bytes()
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
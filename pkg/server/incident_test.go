@@ -0,0 +1,27 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteIncidentStaysInDir(t *testing.T) {
+	for _, gameID := range []string{"g1", "../../escaped", "a/b", ".."} {
+		s := &Server{IncidentDir: filepath.Join(t.TempDir(), "incidents")}
+		incident := Incident{GameID: gameID, Turn: 7}
+		if err := s.writeIncident(incident, []byte("{}"), &incidentWatch{}); err != nil {
+			t.Fatalf("%q: %v", gameID, err)
+		}
+		entries, err := os.ReadDir(s.IncidentDir)
+		if err != nil {
+			t.Fatalf("%q: %v", gameID, err)
+		}
+		if len(entries) != 1 || !entries[0].IsDir() {
+			t.Fatalf("%q: incident directory holds %v, want one bundle", gameID, entries)
+		}
+		if _, err := os.Stat(filepath.Join(s.IncidentDir, entries[0].Name(), "incident.json")); err != nil {
+			t.Errorf("%q: %v", gameID, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+// Info responses are encoded once and served from memory with an ETag, so
+// that the engine's refreshes and health checks cost nothing under load. A
+// response is encoded again the first time it is asked for on a new day,
+// when scheduled skins may change.
+
+// infoCacheControl lets clients reuse an info response for five minutes
+// before revalidating it.
+const infoCacheControl = "public, max-age=300"
+
+// infoKey identifies an info response by the names requested, as given.
+type infoKey struct {
+	strategy, personality, version string
+}
+
+// encodedInfo is an info response ready to be served.
+type encodedInfo struct {
+	body []byte
+	etag string
+	// day is the date it was encoded on.
+	day string
+}
+
+// PrecomputeInfo encodes the info responses of the default snake and every
+// configured snake in the current API version, so that the first requests
+// for them are served from memory too.
+func (s *Server) PrecomputeInfo() {
+	s.infoFor("", "", api.V1)
+	for name := range s.Snakes {
+		s.infoFor(name, "", api.V1)
+	}
+}
+
+// infoFor returns the encoded info response for the named strategy, or
+// snake, and personality in version, from the cache unless it was encoded
+// on an earlier day.
+func (s *Server) infoFor(strategyName, personalityName string, version api.Version) (encodedInfo, error) {
+	key := infoKey{strategyName, personalityName, version.Name()}
+	day := time.Now().Format(time.DateOnly)
+	if cached, ok := s.infos.Load(key); ok && cached.(encodedInfo).day == day {
+		return cached.(encodedInfo), nil
+	}
+
+	response, err := s.Info(strategyName, personalityName)
+	if err != nil {
+		return encodedInfo{}, err
+	}
+	response.APIVersion = version.Name()
+	body, err := json.Marshal(version.Info(response))
+	if err != nil {
+		return encodedInfo{}, err
+	}
+	sum := sha256.Sum256(body)
+	info := encodedInfo{
+		body: append(body, '\n'),
+		etag: `"` + hex.EncodeToString(sum[:8]) + `"`,
+		day:  day,
+	}
+	s.infos.Store(key, info)
+	return info, nil
+}
+
+// serveInfo writes info, or 304 Not Modified if the client already has it.
+func serveInfo(w http.ResponseWriter, r *http.Request, info encodedInfo) {
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set("ETag", info.etag)
+	h.Set("Cache-Control", infoCacheControl)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(info.body))
+}
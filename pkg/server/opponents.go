@@ -0,0 +1,59 @@
+package server
+
+import (
+	"github.com/jayuuza/battlesnake/pkg/opponent"
+	"github.com/jayuuza/battlesnake/pkg/results"
+)
+
+// opponentModel returns the model of the opponents of the game of worker w,
+// starting one from the archetypes they were classified as in earlier games
+// if this process hasn't seen the game yet.
+func (s *Server) opponentModel(w *worker) *opponent.Model {
+	if w.model != nil {
+		return w.model
+	}
+	s.loadArchetypes()
+	s.mu.Lock()
+	priors := s.archetypes.Priors()
+	s.mu.Unlock()
+	w.model = opponent.NewModel(priors)
+	return w.model
+}
+
+// loadArchetypes tallies the archetypes recorded in the results of earlier
+// games, once.
+func (s *Server) loadArchetypes() {
+	s.archetypesOnce.Do(func() {
+		var rs []results.Result
+		if s.Results != nil {
+			var err error
+			if rs, err = s.Results.All(); err != nil {
+				logger.Error("loading results", "err", err)
+			}
+		}
+		record := opponent.NewRecord(rs)
+		s.mu.Lock()
+		s.archetypes = record
+		s.mu.Unlock()
+	})
+}
+
+// classifyOpponents sets the archetypes the opponents were classified as by
+// m in the game ending with result, and tallies them for games to come.
+func (s *Server) classifyOpponents(m *opponent.Model, result *results.Result) {
+	if m == nil {
+		return
+	}
+	archetypes := m.Archetypes()
+	if len(archetypes) == 0 {
+		return
+	}
+	result.Archetypes = make(map[string]string, len(archetypes))
+	for name, a := range archetypes {
+		result.Archetypes[name] = string(a)
+	}
+	s.loadArchetypes()
+	s.mu.Lock()
+	s.archetypes.Add(result.Archetypes)
+	s.mu.Unlock()
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/jayuuza/battlesnake/pkg/store"
+)
+
+// Overlay is what the streaming overlay shows about a game in progress.
+type Overlay struct {
+	GameID    string   `json:"gameId"`
+	Strategy  string   `json:"strategy"`
+	Opponents []string `json:"opponents,omitempty"`
+	store.Live
+}
+
+// Overlay returns the overlay state of the game with the given ID, or of
+// the game this process last moved in if id is "latest", and false if the
+// game isn't being played.
+func (s *Server) Overlay(id string) (Overlay, bool) {
+	if id == "latest" {
+		last := s.lastGame.Load()
+		if last == nil {
+			return Overlay{}, false
+		}
+		id = *last
+	}
+	game, ok, err := s.Store.Get(id)
+	if err != nil {
+		logger.Error("loading game", "game", id, "err", err)
+	}
+	if !ok {
+		return Overlay{}, false
+	}
+	return Overlay{
+		GameID:    game.ID,
+		Strategy:  game.Strategy,
+		Opponents: game.Opponents,
+		Live:      game.Live,
+	}, true
+}
+
+// HandleOverlay serves /overlay/<game ID> as an HTML page for adding to
+// OBS as a browser source, which polls /overlay/<game ID>.json for the
+// game's Overlay. The game ID may be "latest".
+func (s *Server) HandleOverlay(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/overlay/")
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if id, ok := strings.CutSuffix(id, ".json"); ok {
+		overlay, ok := s.Overlay(id)
+		if !ok {
+			http.Error(w, "no game in progress", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := json.NewEncoder(w).Encode(overlay); err != nil {
+			logger.Error("encoding overlay", "game", id, "err", err)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(overlayPage))
+}
+
+// overlayPage has a transparent background so only the panel shows over
+// the stream. It fetches the JSON beside its own URL.
+const overlayPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Battlesnake overlay</title>
+<style>
+body { background: transparent; margin: 0; font: 600 22px/1.3 "Segoe UI", Helvetica, Arial, sans-serif; color: #fff; }
+#panel { display: inline-block; margin: 12px; padding: 12px 18px; border-radius: 10px; background: rgba(20, 20, 30, .8); min-width: 280px; }
+#panel.idle { opacity: .5; }
+.row { display: flex; justify-content: space-between; gap: 24px; }
+.label { color: #aab; font-weight: 400; }
+.bar { height: 8px; border-radius: 4px; background: #333; margin: 2px 0 8px; }
+.bar div { height: 100%; border-radius: 4px; background: #4c1; transition: width .3s; }
+#shout { font-style: italic; color: #ffd866; }
+</style>
+</head>
+<body>
+<div id="panel" class="idle">
+<div class="row"><span id="strategy">waiting for a game</span><span class="label" id="turn"></span></div>
+<div class="row"><span class="label">health</span><span id="health"></span></div>
+<div class="bar"><div id="healthbar" style="width: 0"></div></div>
+<div class="row"><span class="label">length</span><span id="length"></span></div>
+<div class="row"><span class="label">move</span><span id="move"></span></div>
+<div class="row"><span class="label">plan</span><span id="plan"></span></div>
+<div id="shout"></div>
+</div>
+<script>
+const url = location.pathname.replace(/\/$/, "") + ".json";
+const set = (id, text) => document.getElementById(id).textContent = text;
+async function poll() {
+  try {
+    const res = await fetch(url, {cache: "no-store"});
+    const panel = document.getElementById("panel");
+    if (!res.ok) {
+      panel.className = "idle";
+    } else {
+      const o = await res.json();
+      panel.className = "";
+      set("strategy", o.strategy + (o.opponents ? " vs " + o.opponents.join(", ") : ""));
+      set("turn", "turn " + o.turn);
+      set("health", o.health);
+      set("length", o.length);
+      set("move", o.move);
+      set("plan", o.plan || "");
+      set("shout", o.shout ? "“" + o.shout + "”" : "");
+      const bar = document.getElementById("healthbar");
+      bar.style.width = o.health + "%";
+      bar.style.background = o.health > 50 ? "#4c1" : o.health > 20 ? "#fe7d37" : "#e05d44";
+    }
+  } catch (e) {}
+  setTimeout(poll, 500);
+}
+poll();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// Limits are the resource levels above which the server degrades. A zero
+// limit is not checked.
+type Limits struct {
+	HeapBytes  uint64
+	Goroutines int
+	// Interval is how often usage is checked.
+	Interval time.Duration
+}
+
+// recovery is the fraction of its limits usage must fall below before the
+// server leaves degraded mode, so it doesn't flap around a limit.
+const recovery = 0.8
+
+// exceeded reports whether heap or goroutines exceed l scaled by scale.
+func (l Limits) exceeded(heap uint64, goroutines int, scale float64) bool {
+	return l.HeapBytes > 0 && float64(heap) > float64(l.HeapBytes)*scale ||
+		l.Goroutines > 0 && float64(goroutines) > float64(l.Goroutines)*scale
+}
+
+// WatchPressure checks memory and goroutine usage against limits until ctx
+// is done, putting the server in degraded mode while they are exceeded.
+// Degraded, new games play the Fallback strategy and the shadow strategy,
+// profiling and request tracing are shed; games in progress carry on.
+func (s *Server) WatchPressure(ctx context.Context, limits Limits) {
+	if limits.Interval <= 0 {
+		limits.Interval = time.Second
+	}
+	ticker := time.NewTicker(limits.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var memory runtime.MemStats
+		runtime.ReadMemStats(&memory)
+		goroutines := runtime.NumGoroutine()
+		switch degraded := s.degraded.Load(); {
+		case !degraded && limits.exceeded(memory.HeapAlloc, goroutines, 1):
+			s.degraded.Store(true)
+			logger.Warn("entering degraded mode", "heapBytes", memory.HeapAlloc, "goroutines", goroutines)
+			debug.FreeOSMemory()
+		case degraded && !limits.exceeded(memory.HeapAlloc, goroutines, recovery):
+			s.degraded.Store(false)
+			logger.Info("leaving degraded mode", "heapBytes", memory.HeapAlloc, "goroutines", goroutines)
+		}
+	}
+}
+
+// Degraded reports whether the server is in degraded mode.
+func (s *Server) Degraded() bool {
+	return s.degraded.Load()
+}
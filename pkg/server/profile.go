@@ -0,0 +1,84 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/gamedir"
+)
+
+// maxProfile is the longest a game is profiled for. A game that never gets
+// an /end request would otherwise keep the profiler busy, and every later
+// game from being sampled.
+const maxProfile = 15 * time.Minute
+
+// profiler captures a CPU profile over the duration of a single game and a
+// heap profile when it ends. The runtime only supports one CPU profile at a
+// time, so games that start while a capture is running are not sampled.
+type profiler struct {
+	mu     sync.Mutex
+	dir    string
+	gameID string
+	cpu    *os.File
+	// timeout stops the capture once it has run for maxProfile.
+	timeout *time.Timer
+}
+
+// start begins capturing a CPU profile for gameID into its directory of
+// dir, unless another game is already being profiled.
+func (p *profiler) start(dir, gameID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cpu != nil {
+		return
+	}
+
+	gameDir := filepath.Join(dir, gamedir.Name(gameID))
+	if err := os.MkdirAll(gameDir, 0755); err != nil {
+		logger.Error("profiling", "game", gameID, "err", err)
+		return
+	}
+	f, err := os.Create(filepath.Join(gameDir, "cpu.pprof"))
+	if err != nil {
+		logger.Error("profiling", "game", gameID, "err", err)
+		return
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		logger.Error("profiling", "game", gameID, "err", err)
+		f.Close()
+		return
+	}
+	p.dir = gameDir
+	p.gameID = gameID
+	p.cpu = f
+	p.timeout = time.AfterFunc(maxProfile, func() { p.stop(gameID) })
+}
+
+// stop finishes the capture for gameID and writes its heap profile, when the
+// game ends or has been profiled for maxProfile. It is a no-op if gameID
+// isn't the game being profiled.
+func (p *profiler) stop(gameID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cpu == nil || p.gameID != gameID {
+		return
+	}
+
+	pprof.StopCPUProfile()
+	p.cpu.Close()
+	p.cpu = nil
+	p.timeout.Stop()
+
+	f, err := os.Create(filepath.Join(p.dir, "heap.pprof"))
+	if err != nil {
+		logger.Error("profiling", "game", gameID, "err", err)
+		return
+	}
+	defer f.Close()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		logger.Error("profiling", "game", gameID, "err", err)
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/metrics"
+	"github.com/jayuuza/battlesnake/pkg/store"
+)
+
+// selfTestTurns is the number of moves asked for in each self-test game.
+const selfTestTurns = 3
+
+// SelfTest plays a short synthetic game against the HTTP handlers with
+// every strategy the server may play (the default, each snake, the
+// fallback, the shadow and the experiment's arms), as the engine would. It
+// returns an error for each strategy whose info isn't served, or that
+// answers a move that isn't legal, panics or overruns the game's timeout.
+//
+// The games are played on a copy of the server's strategy configuration
+// that keeps no history or results and notifies no one, so they leave no
+// trace but the logs.
+func (s *Server) SelfTest(ctx context.Context) error {
+	probe := &Server{
+		DefaultStrategy:    s.defaultStrategy(),
+		DefaultPersonality: s.DefaultPersonality,
+		Snakes:             s.Snakes,
+		Store:              store.NewMemory(),
+		Appearance:         s.Appearance,
+		Fallback:           s.Fallback,
+		BreakerTrips:       s.BreakerTrips,
+		Timing:             s.Timing,
+		Warmup:             s.Warmup,
+	}
+	handler := probe.Handler()
+
+	var errs []error
+	for _, name := range s.selfTestStrategies() {
+		if err := selfTestGame(ctx, handler, name); err != nil {
+			errs = append(errs, fmt.Errorf("self-test of %q: %v", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// selfTestStrategies returns the names of the strategies and snakes the
+// server may play, the default first.
+func (s *Server) selfTestStrategies() []string {
+	names := []string{s.defaultStrategy()}
+	add := func(name string) {
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	for name := range s.Snakes {
+		add(name)
+	}
+	add(s.Fallback)
+	add(s.Shadow)
+	if s.Experiment != nil {
+		add(s.Experiment.A)
+		add(s.Experiment.B)
+	}
+	slices.Sort(names[1:])
+	return names
+}
+
+// selfTestGame plays a game with the strategy or snake name through
+// handler.
+func selfTestGame(ctx context.Context, handler http.Handler, name string) error {
+	info := serve(ctx, handler, http.MethodGet, "/"+name+"/", nil)
+	if info.Code != http.StatusOK {
+		return fmt.Errorf("info: %d %s", info.Code, bytes.TrimSpace(info.Body.Bytes()))
+	}
+	var response api.BattlesnakeInfoResponse
+	if err := json.Unmarshal(info.Body.Bytes(), &response); err != nil {
+		return fmt.Errorf("info: %v", err)
+	}
+
+	request := selfTestRequest(name)
+	if rec := serve(ctx, handler, http.MethodPost, "/"+name+"/start", request); rec.Code != http.StatusOK {
+		return fmt.Errorf("start: %d %s", rec.Code, bytes.TrimSpace(rec.Body.Bytes()))
+	}
+	legal := board.GridFor(request).ValidMoves(request.You.Head)
+	timeout := time.Duration(request.Game.Timeout) * time.Millisecond
+	for turn := 0; turn < selfTestTurns; turn++ {
+		request.Turn = turn
+		panics, timeouts := metrics.StrategyPanics.Value(), metrics.WatchdogTimeouts.Value()
+		start := time.Now()
+		rec := serve(ctx, handler, http.MethodPost, "/"+name+"/move", request)
+		took := time.Since(start)
+		if rec.Code != http.StatusOK {
+			return fmt.Errorf("move: %d %s", rec.Code, bytes.TrimSpace(rec.Body.Bytes()))
+		}
+		var move api.MoveResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &move); err != nil {
+			return fmt.Errorf("move: %v", err)
+		}
+		switch {
+		case metrics.StrategyPanics.Value() > panics:
+			return fmt.Errorf("move: strategy panicked")
+		case metrics.WatchdogTimeouts.Value() > timeouts:
+			return fmt.Errorf("move: strategy missed its deadline")
+		case took > timeout:
+			return fmt.Errorf("move: took %v, over the %v timeout", took, timeout)
+		case !slices.Contains(legal, move.Move):
+			return fmt.Errorf("move: %s is not legal, want one of %v", move.Move, legal)
+		}
+	}
+	if rec := serve(ctx, handler, http.MethodPost, "/"+name+"/end", request); rec.Code != http.StatusOK {
+		return fmt.Errorf("end: %d %s", rec.Code, bytes.TrimSpace(rec.Body.Bytes()))
+	}
+	return nil
+}
+
+// serve makes a request to handler, with body encoded as JSON if given.
+func serve(ctx context.Context, handler http.Handler, method, path string, body any) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	r := httptest.NewRequestWithContext(ctx, method, path, &buf)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	return rec
+}
+
+// selfTestRequest returns the board self-test games are played on: a duel
+// on a standard board, our snake along the left wall with its neck below
+// it, so that only right and up are legal.
+func selfTestRequest(name string) api.GameRequest {
+	you := api.Battlesnake{
+		ID:     "self-test-you",
+		Name:   "you",
+		Health: 90,
+		Body:   []api.Coord{{X: 0, Y: 5}, {X: 0, Y: 4}, {X: 0, Y: 3}},
+		Head:   api.Coord{X: 0, Y: 5},
+		Length: 3,
+	}
+	opponent := api.Battlesnake{
+		ID:     "self-test-opponent",
+		Name:   "opponent",
+		Health: 90,
+		Body:   []api.Coord{{X: 8, Y: 5}, {X: 9, Y: 5}, {X: 10, Y: 5}},
+		Head:   api.Coord{X: 8, Y: 5},
+		Length: 3,
+	}
+	return api.GameRequest{
+		Game: api.Game{
+			ID:      "self-test-" + name,
+			Ruleset: api.Ruleset{Name: "standard", Version: "v1.2.3"},
+			Map:     "standard",
+			Timeout: 500,
+		},
+		Board: api.Board{
+			Width:  11,
+			Height: 11,
+			Food:   []api.Coord{{X: 5, Y: 5}, {X: 2, Y: 9}},
+			Snakes: []api.Battlesnake{you, opponent},
+		},
+		You: you,
+	}
+}
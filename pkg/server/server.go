@@ -0,0 +1,571 @@
+// Package server exposes the Battlesnake API.
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/analysis"
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/appearance"
+	"github.com/jayuuza/battlesnake/pkg/decision"
+	"github.com/jayuuza/battlesnake/pkg/history"
+	"github.com/jayuuza/battlesnake/pkg/logging"
+	"github.com/jayuuza/battlesnake/pkg/metrics"
+	"github.com/jayuuza/battlesnake/pkg/opponent"
+	"github.com/jayuuza/battlesnake/pkg/personality"
+	"github.com/jayuuza/battlesnake/pkg/results"
+	"github.com/jayuuza/battlesnake/pkg/search"
+	"github.com/jayuuza/battlesnake/pkg/sentry"
+	"github.com/jayuuza/battlesnake/pkg/spectate"
+	"github.com/jayuuza/battlesnake/pkg/store"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+	"github.com/jayuuza/battlesnake/pkg/timing"
+	"github.com/jayuuza/battlesnake/pkg/upload"
+	"github.com/jayuuza/battlesnake/pkg/webhook"
+)
+
+// logger logs for the server component.
+var logger = logging.For(logging.Server)
+
+// Server plays Battlesnake games. Its Info, Start, Move and End methods
+// implement the Battlesnake API independently of transport; Handler serves
+// them over HTTP.
+//
+// The strategy and personality for a game are chosen when it starts and
+// default to DefaultStrategy and DefaultPersonality, unless an Experiment
+// assigns the strategy.
+type Server struct {
+	// DefaultStrategy is the name of the strategy used when a request
+	// doesn't select one.
+	DefaultStrategy string
+	// DefaultPersonality is the name of the personality used when a
+	// request doesn't select one.
+	DefaultPersonality string
+	// Snakes maps snake names to the strategy and personality they play. A
+	// request selecting a snake's name as its strategy plays as that snake.
+	Snakes map[string]Snake
+	// Store remembers per-game state between requests.
+	Store store.Store
+	// DataDir is the directory game data is written to, one subdirectory
+	// per game ID.
+	DataDir string
+	// ProfileRate is the fraction of games (0-1) to capture CPU and heap
+	// profiles for.
+	ProfileRate float64
+	// History records every turn when set.
+	History *history.Recorder
+	// Decisions records a trace of every move decided when set.
+	Decisions *decision.Recorder
+	// Retention, when set with History, decides which games' histories
+	// are kept once they end; all are kept if it is nil.
+	Retention *history.Retention
+	// Uploads, when set, uploads finished games from DataDir to object
+	// storage, see UploadGames.
+	Uploads *upload.Uploader
+	// Spectator, when set, records the engine's frames of every game played
+	// on the public engine next to its history.
+	Spectator *spectate.Client
+	// Reports, when set with History, writes a post-game analysis report
+	// to each game's directory when it ends.
+	Reports bool
+	// Appearance, when set, overrides the personality's appearance with
+	// scheduled or rotating skins.
+	Appearance appearance.Provider
+	// Fallback names the cheap strategy a game switches to once its own
+	// strategy overruns its soft budget BreakerTrips turns in a row. No
+	// breaker is used if Fallback is empty.
+	Fallback     string
+	BreakerTrips int
+	// Results records the outcome of every game when set.
+	Results *results.Store
+	// Webhooks, when set, is notified of the outcome of every game.
+	Webhooks *webhook.Notifier
+	// Experiment, when set, assigns games that don't select a strategy to
+	// one of two strategies.
+	Experiment *Experiment
+	// AdminToken is the bearer token the admin API requires. The admin API is
+	// disabled if it is empty.
+	AdminToken string
+	// Errors, when set, reports panics, undecodable requests and moves that
+	// overrun their budget.
+	Errors *sentry.Client
+	// SlowDir is the directory positions on which the strategy blew its
+	// budget are saved to, as SlowPositions. None are saved if it is empty.
+	SlowDir string
+	// IncidentDir is the directory incident bundles are written to for
+	// moves taking longer than IncidentThreshold. None are written if
+	// either is unset.
+	IncidentDir       string
+	IncidentThreshold time.Duration
+	// CaptureDir is the directory the raw bodies of start, move and end
+	// requests are captured to, by game and turn. None are captured if it
+	// is empty.
+	CaptureDir string
+	// Shadow names a strategy evaluated in the background on every move
+	// without affecting play, its choices compared with the live
+	// strategy's. No shadow runs if it is empty.
+	Shadow string
+	// Timing computes the time strategies may spend on each move.
+	Timing timing.Manager
+	// Warmup, when set, pays each game's cold-start costs when it starts
+	// rather than on its first move.
+	Warmup bool
+	// Chaos, when set, injects delays, dropped moves and panics into the
+	// strategies' decisions, for resilience testing.
+	Chaos *Chaos
+	// ShoutReplies maps opponent names to the shout we answer them with
+	// when they shout something new. The key "*" answers anyone else.
+	ShoutReplies map[string]string
+
+	profiler profiler
+	// degraded is set while WatchPressure finds resources under pressure.
+	degraded atomic.Bool
+	// strategyOverride replaces DefaultStrategy once the admin API switches
+	// it.
+	strategyOverride atomic.Pointer[string]
+	// lastGame is the ID of the game this process last moved in.
+	lastGame atomic.Pointer[string]
+
+	mu sync.Mutex
+	// workers holds the worker of each game in progress.
+	workers map[string]*worker
+	shadows map[string]*shadowGame
+	// thinking holds the games whose strategy is deciding a move, with a
+	// channel closed once it is done.
+	thinking map[string]chan struct{}
+	// archetypes tallies the archetypes of opponents in recorded results,
+	// loaded once.
+	archetypes     opponent.Record
+	archetypesOnce sync.Once
+	// captures queues raw request bodies to be written to CaptureDir,
+	// started with the first.
+	captures    chan capturedBody
+	captureOnce sync.Once
+	// decisions queues moves to be traced to Decisions, started with the
+	// first.
+	decisions    chan decided
+	decisionOnce sync.Once
+	// infos caches encoded info responses by infoKey.
+	infos sync.Map
+}
+
+// Snake is a named snake instance: a strategy played with a personality.
+type Snake struct {
+	Strategy    string
+	Personality string
+}
+
+// resolveSnake returns the strategy and personality selected by names that
+// may name a snake rather than a strategy.
+func (s *Server) resolveSnake(strategyName, personalityName string) (string, string) {
+	snake, ok := s.Snakes[strategyName]
+	if !ok {
+		return strategyName, personalityName
+	}
+	if personalityName == "" {
+		personalityName = snake.Personality
+	}
+	return snake.Strategy, personalityName
+}
+
+// Info returns the info response for a snake instance playing the named
+// strategy, or snake, and personality; empty names select the defaults.
+func (s *Server) Info(strategyName, personalityName string) (api.BattlesnakeInfoResponse, error) {
+	strategyName, personalityName = s.resolveSnake(strategyName, personalityName)
+	if strategyName == "" {
+		strategyName = s.defaultStrategy()
+	}
+	if personalityName == "" {
+		personalityName = s.DefaultPersonality
+	}
+	if _, err := strategy.New(strategyName); err != nil {
+		return api.BattlesnakeInfoResponse{}, err
+	}
+	p, ok := personality.Get(personalityName)
+	if !ok {
+		return api.BattlesnakeInfoResponse{}, fmt.Errorf("server: unknown personality %q", personalityName)
+	}
+
+	look := p.Appearance
+	if s.Appearance != nil {
+		look = s.Appearance.At(time.Now()).Over(look)
+	}
+	return api.BattlesnakeInfoResponse{
+		APIVersion: "1",
+		Author:     "jayuuza",
+		Color:      look.Color,
+		Head:       look.Head,
+		Tail:       look.Tail,
+	}, nil
+}
+
+// Start begins a game played with the named strategy, or snake, and
+// personality; empty names select the defaults.
+func (s *Server) Start(ctx context.Context, request api.GameRequest, strategyName, personalityName string) {
+	s.onWorker(request.Game.ID, func(w *worker) {
+		s.start(ctx, w, request, strategyName, personalityName)
+	})
+}
+
+// Move decides our move for a turn.
+func (s *Server) Move(ctx context.Context, request api.GameRequest) (move api.MoveResponse) {
+	if _, ok := timing.Arrival(ctx); !ok {
+		ctx = timing.WithArrival(ctx, time.Now())
+	}
+	s.onWorker(request.Game.ID, func(w *worker) {
+		move = s.move(ctx, w, request)
+	})
+	return move
+}
+
+// End finishes a game, dropping the state kept for it.
+func (s *Server) End(ctx context.Context, request api.GameRequest) {
+	s.onWorker(request.Game.ID, func(w *worker) {
+		s.end(ctx, w, request)
+	})
+}
+
+func (s *Server) start(ctx context.Context, w *worker, request api.GameRequest, strategyName, personalityName string) {
+	ctx = logging.WithGame(ctx, request.Game.ID)
+	defer s.reportPanic("start", request)
+	strategyName, personalityName = s.resolveSnake(strategyName, personalityName)
+	game := store.Game{
+		ID:          request.Game.ID,
+		Strategy:    strategyName,
+		Personality: personalityName,
+	}
+	for _, snake := range request.Board.Snakes {
+		if snake.ID != request.You.ID {
+			game.Opponents = append(game.Opponents, snake.Name)
+		}
+	}
+	if game.Strategy == "" && s.Experiment != nil {
+		game.Experiment = s.Experiment.Name
+		game.Arm, game.Strategy = s.Experiment.Assign(request.Game.ID)
+	}
+	if game.Strategy == "" {
+		game.Strategy = s.defaultStrategy()
+	}
+	if game.Personality == "" {
+		game.Personality = s.DefaultPersonality
+	}
+	if s.Degraded() && s.Fallback != "" && game.Strategy != s.Fallback {
+		logger.WarnContext(ctx, "degraded, playing fallback strategy", "game", game.ID, "strategy", game.Strategy, "fallback", s.Fallback)
+		game.Strategy = s.Fallback
+		// Keep the fallback's games out of the experiment's results.
+		game.Experiment, game.Arm = "", ""
+		metrics.DegradedGames.Add(1)
+	}
+	if !s.Degraded() && rand.Float64() < s.ProfileRate {
+		s.profiler.start(s.DataDir, request.Game.ID)
+	}
+
+	if err := s.Store.Put(game); err != nil {
+		logger.Error("saving game", "game", request.Game.ID, "err", err)
+	}
+	logger.InfoContext(ctx, "start", "game", request.Game.ID, "strategy", game.Strategy, "personality", game.Personality,
+		"ruleset", request.Game.Ruleset.Name, "map", request.Game.Map, "snakes", len(request.Board.Snakes))
+	if !s.Degraded() {
+		logger.Log(ctx, logging.LevelTrace, "start request", "game", request.Game.ID, "request", request)
+	}
+	s.opponentModel(w).Observe(request)
+	ctx = personality.NewContext(ctx, s.personalityFor(request))
+	s.strategyFor(w, request).Start(ctx, request)
+	s.warmUp(ctx, request, game.Strategy)
+	s.startShadow(ctx, request, game.Strategy)
+	s.spectate(request)
+}
+
+func (s *Server) move(ctx context.Context, w *worker, request api.GameRequest) api.MoveResponse {
+	ctx = logging.WithGame(ctx, request.Game.ID)
+	defer s.reportPanic("move", request)
+	start := time.Now()
+	game := s.loadGame(request)
+	if move, ok := answered(game, request); ok {
+		logger.InfoContext(ctx, "duplicate move request, answering as before", "game", request.Game.ID, "turn", request.Turn, "move", move.Move)
+		metrics.DuplicateMoves.Add(1)
+		return move
+	}
+	arrival, _ := timing.Arrival(ctx)
+	game.Latency.Observe(request.You.Latency)
+	game.Latency.ObserveArrival(request.Turn, arrival)
+	budget := s.Timing.Budget(request.Game.Timeout, game.Latency, start.Sub(arrival))
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+
+	p := s.personalityFor(request)
+	watch := s.watchIncident()
+	strategyCtx, stats := search.WithStats(personality.NewContext(ctx, p))
+	strategyCtx, plan := strategy.WithPlan(strategyCtx)
+	opponents := s.opponentModel(w)
+	opponents.Observe(request)
+	strategyCtx = opponent.NewContext(strategyCtx, opponents)
+	if w.strategy == nil {
+		// The game's strategy was switched or its worker recreated since
+		// it started: start the strategy anew from the current position.
+		s.strategyFor(w, request).Start(personality.NewContext(ctx, p), request)
+	}
+	decideStart := time.Now()
+	move, slow := s.decide(strategyCtx, s.strategyFor(w, request), request, budget+s.Timing.Grace)
+	decideMs := millis(time.Since(decideStart))
+	if slow != "" {
+		s.saveSlowPosition(request, slow, budget)
+	}
+	s.shadowMove(request, move.Move, budget)
+
+	shouts := trackShouts(request, &game)
+	if move.Shout == "" {
+		move.Shout = reply(shouts, p.Replies, s.ShoutReplies)
+	}
+	if move.Shout == "" {
+		move.Shout = p.Taunt(request)
+	}
+	s.record(request, &move, shouts)
+	w.lastTurn = &history.Turn{Turn: request.Turn, Request: request, Move: &move}
+
+	took := time.Since(start)
+	s.finishIncident(ctx, watch, request, stats, took, budget)
+	metrics.DecisionLatency.Observe(metrics.LatencyKey(request.Board.Width, request.Board.Height, len(request.Board.Snakes)), took)
+	if took > budget {
+		s.reportOverrun(request, took, budget)
+	}
+	game.Latency.LastComputeMs = float64(time.Since(arrival)) / float64(time.Millisecond)
+	game.Live = store.Live{
+		Turn:   request.Turn,
+		Health: int(request.You.Health),
+		Length: int(request.You.Length),
+		Move:   move.Move.String(),
+		Shout:  move.Shout,
+		Plan:   plan.String(),
+	}
+	if slow != "" {
+		game.Live.Plan = "safe move (" + slow + ")"
+	}
+	s.traceDecision(decided{
+		request:  request,
+		strategy: game.Strategy,
+		move:     move.Move,
+		plan:     game.Live.Plan,
+		timing: decision.Timing{
+			TimeoutMs: int(request.Game.Timeout),
+			BudgetMs:  millis(budget),
+			DecideMs:  decideMs,
+			TotalMs:   game.Latency.LastComputeMs,
+		},
+	})
+	s.lastGame.Store(&request.Game.ID)
+	logger.DebugContext(ctx, "move", "game", request.Game.ID, "turn", request.Turn, "move", move.Move, "ms", game.Latency.LastComputeMs)
+	if !s.Degraded() {
+		logger.Log(ctx, logging.LevelTrace, "move request", "game", request.Game.ID, "turn", request.Turn, "request", request)
+	}
+	if err := s.Store.Put(game); err != nil {
+		logger.Error("saving game", "game", request.Game.ID, "err", err)
+	}
+	return move
+}
+
+func (s *Server) end(ctx context.Context, w *worker, request api.GameRequest) {
+	ctx = logging.WithGame(ctx, request.Game.ID)
+	defer s.reportPanic("end", request)
+	logger.InfoContext(ctx, "end", "game", request.Game.ID, "turns", request.Turn)
+	ctx = personality.NewContext(ctx, s.personalityFor(request))
+	strat := s.strategyFor(w, request)
+	// A move the watchdog answered for may still be being decided: the
+	// strategy and the game's profile are only released once it is done.
+	releaseCtx := context.WithoutCancel(ctx)
+	s.afterThinking(ctx, request.Game.ID, func() {
+		strat.End(releaseCtx, request)
+		s.profiler.stop(request.Game.ID)
+	})
+	s.endShadow(ctx, request)
+	s.record(request, nil, nil)
+	result := s.gameResult(w, request)
+	s.recordResult(result)
+	if s.keepHistory(result) {
+		s.writeReport(request.Game.ID)
+	}
+	s.forget(w, request.Game.ID)
+}
+
+// personalityFor returns the personality chosen for game.
+func (s *Server) personalityFor(game api.GameRequest) personality.Personality {
+	name := s.DefaultPersonality
+	stored, ok, err := s.Store.Get(game.Game.ID)
+	if err != nil {
+		logger.Error("loading game", "game", game.Game.ID, "err", err)
+	} else if ok && stored.Personality != "" {
+		name = stored.Personality
+	}
+	if p, ok := personality.Get(name); ok {
+		return p
+	}
+	p, _ := personality.Get(personality.Default)
+	return p
+}
+
+// softBudget is the fraction of a move's budget a strategy may routinely
+// use before it counts towards tripping the breaker.
+const softBudget = 0.8
+
+// strategyFor returns the strategy playing game on its worker w, recreating
+// it from the stored choice if this process hasn't seen the game yet.
+func (s *Server) strategyFor(w *worker, game api.GameRequest) strategy.Strategy {
+	if w.strategy != nil {
+		return w.strategy
+	}
+
+	name := s.defaultStrategy()
+	stored, ok, err := s.Store.Get(game.Game.ID)
+	if err != nil {
+		logger.Error("loading game", "game", game.Game.ID, "err", err)
+	} else if ok {
+		name = stored.Strategy
+	}
+	strat, err := strategy.New(name)
+	if err != nil {
+		logger.Warn("unknown strategy, using default", "game", game.Game.ID, "err", err, "strategy", s.defaultStrategy())
+		strat, _ = strategy.New(s.defaultStrategy())
+	}
+	if s.Fallback != "" {
+		breaker, err := strategy.NewBreaker(strat, s.Fallback, s.BreakerTrips, softBudget)
+		if err != nil {
+			logger.Error("creating breaker", "game", game.Game.ID, "err", err)
+		} else {
+			breaker.OnTrip = func(game api.GameRequest) {
+				s.saveSlowPosition(game, SlowBreaker, 0)
+			}
+			strat = breaker
+		}
+	}
+	w.strategy = strat
+	return strat
+}
+
+// loadGame returns the stored state of the game in request, or fresh state
+// if none is stored.
+func (s *Server) loadGame(request api.GameRequest) store.Game {
+	game, ok, err := s.Store.Get(request.Game.ID)
+	if err != nil {
+		logger.Error("loading game", "game", request.Game.ID, "err", err)
+	}
+	if !ok {
+		game = store.Game{ID: request.Game.ID, Strategy: s.defaultStrategy()}
+	}
+	return game
+}
+
+// answered returns the move already answered for the turn of request, if
+// it is a retry or duplicate of a request handled before: the engine may
+// send a turn again, and must get the same answer rather than one decided
+// afresh.
+func answered(game store.Game, request api.GameRequest) (api.MoveResponse, bool) {
+	if game.Live.Move == "" || game.Live.Turn != request.Turn {
+		return api.MoveResponse{}, false
+	}
+	move, ok := api.ParseDirection(game.Live.Move)
+	if !ok {
+		return api.MoveResponse{}, false
+	}
+	return api.MoveResponse{Move: move, Shout: game.Live.Shout}, true
+}
+
+// trackShouts returns the opponents' new shouts this turn, logging them and
+// remembering them in game.
+func trackShouts(request api.GameRequest, game *store.Game) []history.Shout {
+	if game.Shouts == nil {
+		game.Shouts = map[string]string{}
+	}
+	shouts := newShouts(request, game.Shouts)
+	for _, shout := range shouts {
+		logger.Info("shout", "game", request.Game.ID, "turn", request.Turn, "snake", shout.Name, "text", shout.Text)
+	}
+	return shouts
+}
+
+// record appends a turn to the game's history if recording is enabled.
+func (s *Server) record(request api.GameRequest, move *api.MoveResponse, shouts []history.Shout) {
+	if s.History == nil {
+		return
+	}
+	err := s.History.Record(request.Game.ID, history.Turn{
+		Turn:    request.Turn,
+		Request: request,
+		Move:    move,
+		Shouts:  shouts,
+	})
+	if err != nil {
+		logger.Error("recording history", "game", request.Game.ID, "err", err)
+	}
+}
+
+// keepHistory applies the Retention policy to the recorded history of the
+// game ending with result, discarding it unless it is to be kept.
+func (s *Server) keepHistory(result results.Result) bool {
+	if s.History == nil || s.Retention == nil || s.Retention.Keep(result.GameID, result.Outcome == results.Win) {
+		return true
+	}
+	if err := s.History.Discard(result.GameID); err != nil {
+		logger.Error("discarding history", "game", result.GameID, "err", err)
+	}
+	return false
+}
+
+// writeReport analyses a finished game from its history in the background,
+// if reports are enabled and the server isn't degraded.
+func (s *Server) writeReport(gameID string) {
+	if !s.Reports || s.History == nil || s.Degraded() {
+		return
+	}
+	go func() {
+		if _, err := analysis.Write(filepath.Dir(s.History.Path(gameID))); err != nil {
+			logger.Error("writing report", "game", gameID, "err", err)
+		}
+	}()
+}
+
+// gameResult returns the outcome of the game ending with request, on its
+// worker w.
+func (s *Server) gameResult(w *worker, request api.GameRequest) results.Result {
+	game := s.loadGame(request)
+	result := results.New(request)
+	result.Strategy = game.Strategy
+	result.Experiment = game.Experiment
+	result.Arm = game.Arm
+	result.Opponents = game.Opponents
+	s.classifyOpponents(w.model, &result)
+	if w.lastTurn != nil {
+		result.Death = analysis.Death([]history.Turn{*w.lastTurn, {Turn: request.Turn, Request: request}})
+	}
+	return result
+}
+
+// recordResult adds result to the results store, if results are being
+// recorded, and notifies the webhooks.
+func (s *Server) recordResult(result results.Result) {
+	if s.Webhooks != nil {
+		s.Webhooks.Notify(result)
+	}
+	if s.Results == nil {
+		return
+	}
+	if err := s.Results.Add(result); err != nil {
+		logger.Error("recording result", "game", result.GameID, "err", err)
+	}
+}
+
+// forget drops all state kept for a finished game, its worker w exiting
+// once done.
+func (s *Server) forget(w *worker, gameID string) {
+	logging.ClearGameLevel(gameID)
+	w.ended = true
+
+	if err := s.Store.Delete(gameID); err != nil {
+		logger.Error("deleting game", "game", gameID, "err", err)
+	}
+}
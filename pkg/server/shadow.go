@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/gamedir"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// ShadowReport compares the moves of a game's live strategy with those of
+// the shadow strategy evaluated alongside it.
+type ShadowReport struct {
+	GameID string `json:"gameId"`
+	Live   string `json:"live"`
+	Shadow string `json:"shadow"`
+	// Turns is the number of turns both strategies chose a move for.
+	Turns int `json:"turns"`
+	// Skipped counts turns the shadow missed because it was still busy
+	// with an earlier one.
+	Skipped       int                  `json:"skipped"`
+	Disagreements []ShadowDisagreement `json:"disagreements"`
+}
+
+// ShadowDisagreement is a turn on which the strategies chose differently.
+type ShadowDisagreement struct {
+	Turn   int           `json:"turn"`
+	Live   api.Direction `json:"live"`
+	Shadow api.Direction `json:"shadow"`
+}
+
+// shadowGame is the shadow strategy of one game. mu is held while the
+// shadow computes a move, so turns are evaluated in order and a turn
+// arriving while the last is still running is skipped. The report is
+// guarded by the Server's mutex.
+type shadowGame struct {
+	mu       sync.Mutex
+	strategy strategy.Strategy
+	report   ShadowReport
+}
+
+// startShadow creates and starts the shadow strategy for a game.
+func (s *Server) startShadow(ctx context.Context, request api.GameRequest, live string) {
+	if s.Shadow == "" || s.Degraded() {
+		return
+	}
+	strat, err := strategy.New(s.Shadow)
+	if err != nil {
+		logger.Error("creating shadow", "game", request.Game.ID, "err", err)
+		return
+	}
+	if !shadowed(request, func() { strat.Start(ctx, request) }) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shadows == nil {
+		s.shadows = map[string]*shadowGame{}
+	}
+	s.shadows[request.Game.ID] = &shadowGame{
+		strategy: strat,
+		report: ShadowReport{
+			GameID: request.Game.ID,
+			Live:   live,
+			Shadow: s.Shadow,
+		},
+	}
+}
+
+// shadowMove evaluates the shadow strategy on request in the background,
+// giving it the same budget the live strategy had, and compares its choice
+// with live.
+func (s *Server) shadowMove(request api.GameRequest, live api.Direction, budget time.Duration) {
+	s.mu.Lock()
+	shadow := s.shadows[request.Game.ID]
+	s.mu.Unlock()
+	if shadow == nil {
+		return
+	}
+	if !shadow.mu.TryLock() {
+		s.mu.Lock()
+		shadow.report.Skipped++
+		s.mu.Unlock()
+		return
+	}
+
+	go func() {
+		defer shadow.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), budget)
+		defer cancel()
+		var move api.MoveResponse
+		ok := shadowed(request, func() { move = shadow.strategy.Move(ctx, request) })
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !ok {
+			if s.shadows[request.Game.ID] == shadow {
+				delete(s.shadows, request.Game.ID)
+			}
+			return
+		}
+		shadow.report.Turns++
+		if move.Move != live {
+			shadow.report.Disagreements = append(shadow.report.Disagreements, ShadowDisagreement{
+				Turn:   request.Turn,
+				Live:   live,
+				Shadow: move.Move,
+			})
+			logger.Debug("shadow disagreed", "game", request.Game.ID, "turn", request.Turn,
+				"shadow", s.Shadow, "shadowMove", move.Move, "liveMove", live)
+		}
+	}()
+}
+
+// endShadow ends a game's shadow strategy once its last move is evaluated,
+// then logs and saves the disagreement report.
+func (s *Server) endShadow(ctx context.Context, request api.GameRequest) {
+	s.mu.Lock()
+	shadow := s.shadows[request.Game.ID]
+	delete(s.shadows, request.Game.ID)
+	s.mu.Unlock()
+	if shadow == nil {
+		return
+	}
+
+	go func() {
+		shadow.mu.Lock()
+		defer shadow.mu.Unlock()
+		shadowed(request, func() { shadow.strategy.End(context.Background(), request) })
+
+		s.mu.Lock()
+		report := shadow.report
+		s.mu.Unlock()
+		logger.Info("shadow report", "game", report.GameID, "shadow", report.Shadow, "live", report.Live,
+			"disagreements", len(report.Disagreements), "turns", report.Turns, "skipped", report.Skipped)
+		if err := s.saveShadowReport(report); err != nil {
+			logger.Error("saving shadow report", "game", report.GameID, "err", err)
+		}
+	}()
+}
+
+// shadowed calls f, which runs the shadow strategy on request, reporting
+// whether it returned rather than panicked. A panic is logged rather than
+// taking the server down with it, and the caller drops the shadow for the
+// rest of the game: it must never affect the live strategy.
+func shadowed(request api.GameRequest, f func()) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("shadow strategy panicked, dropping it for the game", "game", request.Game.ID,
+				"turn", request.Turn, "panic", r, "stack", string(debug.Stack()))
+		}
+	}()
+	f()
+	return true
+}
+
+// saveShadowReport writes report to shadow.json in the game's data
+// directory.
+func (s *Server) saveShadowReport(report ShadowReport) error {
+	if s.DataDir == "" {
+		return nil
+	}
+	dir := filepath.Join(s.DataDir, gamedir.Name(report.GameID))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "shadow.json"), b, 0644)
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// panicky is a strategy that panics in the methods it is told to.
+type panicky struct{ start, move, end bool }
+
+func (p panicky) Start(context.Context, api.GameRequest) {
+	if p.start {
+		panic("start")
+	}
+}
+
+func (p panicky) Move(context.Context, api.GameRequest) api.MoveResponse {
+	if p.move {
+		panic("move")
+	}
+	return api.MoveResponse{Move: api.Down}
+}
+
+func (p panicky) End(context.Context, api.GameRequest) {
+	if p.end {
+		panic("end")
+	}
+}
+
+func init() {
+	strategy.Register("test-panic-start", func() strategy.Strategy { return panicky{start: true} })
+	strategy.Register("test-panic-move", func() strategy.Strategy { return panicky{move: true} })
+	strategy.Register("test-panic-end", func() strategy.Strategy { return panicky{end: true} })
+}
+
+func TestShadowPanics(t *testing.T) {
+	tests := []struct {
+		shadow string
+		// kept reports whether the shadow is still evaluated after a move.
+		kept bool
+	}{
+		{"test-panic-start", false},
+		{"test-panic-move", false},
+		{"test-panic-end", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shadow, func(t *testing.T) {
+			s := &Server{Shadow: tt.shadow, DataDir: t.TempDir()}
+			request := api.GameRequest{Game: api.Game{ID: "g1"}, Turn: 1}
+			s.startShadow(context.Background(), request, "live")
+			s.shadowMove(request, api.Up, 10*time.Millisecond)
+			// Wait for the move, which holds the shadow's lock.
+			s.mu.Lock()
+			shadow := s.shadows["g1"]
+			s.mu.Unlock()
+			if shadow != nil {
+				shadow.mu.Lock()
+				shadow.mu.Unlock()
+			}
+			s.mu.Lock()
+			_, kept := s.shadows["g1"]
+			s.mu.Unlock()
+			if kept != tt.kept {
+				t.Fatalf("shadow kept = %v, want %v", kept, tt.kept)
+			}
+			if !kept {
+				return
+			}
+			// The report is still saved when End panics.
+			s.endShadow(context.Background(), request)
+			path := filepath.Join(s.DataDir, "g1", "shadow.json")
+			for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+				var report ShadowReport
+				if b, err := os.ReadFile(path); err == nil && json.Unmarshal(b, &report) == nil {
+					if report.Turns != 1 || len(report.Disagreements) != 1 {
+						t.Errorf("report = %+v, want one turn disagreeing", report)
+					}
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatal("no shadow report saved")
+				}
+			}
+		})
+	}
+}
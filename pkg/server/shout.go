@@ -0,0 +1,52 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/history"
+)
+
+// newShouts returns the opponents' shouts in game that differ from the last
+// shout seen from them in seen, and records them in seen.
+func newShouts(game api.GameRequest, seen map[string]string) []history.Shout {
+	var shouts []history.Shout
+	for _, snake := range game.Board.Snakes {
+		if snake.ID == game.You.ID || snake.Shout == "" || seen[snake.ID] == snake.Shout {
+			continue
+		}
+		seen[snake.ID] = snake.Shout
+		shouts = append(shouts, history.Shout{
+			SnakeID: snake.ID,
+			Name:    snake.Name,
+			Text:    snake.Shout,
+		})
+	}
+	return shouts
+}
+
+// reply returns our answer to the first of shouts made by a snake we have a
+// reply for, or "" if there is none. Each set of replies is consulted in
+// turn. In replies "{name}" is replaced by the opponent's name and "{shout}"
+// by what they said.
+func reply(shouts []history.Shout, replySets ...map[string]string) string {
+	for _, replies := range replySets {
+		if text := replyFrom(shouts, replies); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+func replyFrom(shouts []history.Shout, replies map[string]string) string {
+	for _, shout := range shouts {
+		text, ok := replies[shout.Name]
+		if !ok {
+			text, ok = replies["*"]
+		}
+		if ok {
+			return strings.NewReplacer("{name}", shout.Name, "{shout}", shout.Text).Replace(text)
+		}
+	}
+	return ""
+}
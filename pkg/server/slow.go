@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/gamedir"
+	"github.com/jayuuza/battlesnake/pkg/metrics"
+)
+
+// Reasons a position is saved as slow.
+const (
+	SlowTimeout = "timeout"
+	SlowBusy    = "busy"
+	SlowPanic   = "panic"
+	SlowBreaker = "breaker"
+)
+
+// SlowPosition is a position on which a strategy blew its budget, saved so
+// it can be reproduced and profiled.
+type SlowPosition struct {
+	Reason   string          `json:"reason"`
+	Strategy string          `json:"strategy"`
+	BudgetMs float64         `json:"budgetMs,omitempty"`
+	Time     time.Time       `json:"time"`
+	Request  api.GameRequest `json:"request"`
+}
+
+// saveSlowPosition counts a move the strategy couldn't answer in time and
+// writes the position to the slow positions directory, if one is set.
+func (s *Server) saveSlowPosition(request api.GameRequest, reason string, budget time.Duration) {
+	switch reason {
+	case SlowTimeout:
+		metrics.WatchdogTimeouts.Add(1)
+	case SlowBusy:
+		metrics.WatchdogBusy.Add(1)
+	case SlowPanic:
+		metrics.StrategyPanics.Add(1)
+	}
+	if s.SlowDir == "" {
+		return
+	}
+
+	position := SlowPosition{
+		Reason:   reason,
+		Strategy: s.loadGame(request).Strategy,
+		BudgetMs: float64(budget) / float64(time.Millisecond),
+		Time:     time.Now().UTC(),
+		Request:  request,
+	}
+	b, err := json.MarshalIndent(position, "", "  ")
+	if err != nil {
+		logger.Error("encoding slow position", "game", request.Game.ID, "err", err)
+		return
+	}
+	if err := os.MkdirAll(s.SlowDir, 0755); err != nil {
+		logger.Error("saving slow position", "game", request.Game.ID, "err", err)
+		return
+	}
+	name := fmt.Sprintf("%s-%d-%s.json", gamedir.Name(request.Game.ID), request.Turn, reason)
+	if err := os.WriteFile(filepath.Join(s.SlowDir, name), b, 0644); err != nil {
+		logger.Error("saving slow position", "game", request.Game.ID, "err", err)
+		return
+	}
+	metrics.SlowPositions.Add(1)
+}
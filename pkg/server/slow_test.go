@@ -0,0 +1,25 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/store"
+)
+
+func TestSaveSlowPositionStaysInDir(t *testing.T) {
+	for _, gameID := range []string{"g1", "../../escaped", "a/b"} {
+		s := &Server{SlowDir: filepath.Join(t.TempDir(), "slow"), Store: store.NewMemory()}
+		request := api.GameRequest{Game: api.Game{ID: gameID}, Turn: 4}
+		s.saveSlowPosition(request, SlowPanic, 0)
+		entries, err := os.ReadDir(s.SlowDir)
+		if err != nil {
+			t.Fatalf("%q: %v", gameID, err)
+		}
+		if len(entries) != 1 || entries[0].IsDir() {
+			t.Errorf("%q: slow directory holds %v, want one position", gameID, entries)
+		}
+	}
+}
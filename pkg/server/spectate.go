@@ -0,0 +1,31 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+// maxSpectate bounds how long the events of a game are recorded, in case
+// the engine never ends the stream.
+const maxSpectate = 2 * time.Hour
+
+// spectate records the engine's frames of the game starting in request in
+// the background, if the Spectator is set and the game is played on the
+// public engine, which sets its source; local games leave it empty.
+func (s *Server) spectate(request api.GameRequest) {
+	if s.Spectator == nil || request.Game.Source == "" || s.Degraded() {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), maxSpectate)
+		defer cancel()
+		frames, err := s.Spectator.Record(ctx, request.Game.ID)
+		if err != nil {
+			logger.Warn("spectating", "game", request.Game.ID, "frames", frames, "err", err)
+			return
+		}
+		logger.Debug("spectated", "game", request.Game.ID, "frames", frames)
+	}()
+}
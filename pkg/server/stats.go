@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/jayuuza/battlesnake/pkg/metrics"
+	"github.com/jayuuza/battlesnake/pkg/results"
+)
+
+// Stats summarises the results of the games we've played.
+type Stats struct {
+	Overall     results.Summary            `json:"overall"`
+	Strategies  map[string]results.Summary `json:"strategies"`
+	Experiments []results.ExperimentReport `json:"experiments"`
+	// Latency summarises decision latency since the server started, by
+	// board size and snake count as metrics.LatencyKey.
+	Latency map[string]metrics.LatencySummary `json:"latency"`
+}
+
+// Stats returns win rates overall, per strategy and per experiment arm from
+// the results store, and decision latency percentiles.
+func (s *Server) Stats() (Stats, error) {
+	if s.Results == nil {
+		return Stats{}, errors.New("server: results are not being recorded")
+	}
+	rs, err := s.Results.All()
+	if err != nil {
+		return Stats{}, err
+	}
+	return Stats{
+		Overall:     results.Summarize(rs),
+		Strategies:  results.GroupBy(rs, func(r results.Result) string { return r.Strategy }),
+		Experiments: results.Experiments(rs),
+		Latency:     metrics.DecisionLatency.Summaries(),
+	}, nil
+}
+
+// HandleStats serves Stats as JSON.
+func (s *Server) HandleStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.Stats()
+	if err != nil {
+		logger.Error("stats", "err", err)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		logger.Error("stats", "err", err)
+	}
+}
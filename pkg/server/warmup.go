@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/arena"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// warmupShare is the share of the game's timeout a warmup may take, as a
+// divisor: the start request must still be answered in time.
+const warmupShare = 4
+
+// warmUp pays a game's cold-start costs when it starts rather than on its
+// first move: it builds the board's geometry and grid and decides a
+// throwaway move on the starting position with a fresh instance of the
+// game's strategy, which fills the arena, state and evaluation pools and
+// the hazard forecasts for the board. The game's own strategy is left
+// untouched, so nothing it remembers comes from the warmup.
+func (s *Server) warmUp(ctx context.Context, request api.GameRequest, strategyName string) {
+	if !s.Warmup || s.Degraded() {
+		return
+	}
+	start := time.Now()
+	board.GridFor(request)
+	strat, err := strategy.New(strategyName)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(request.Game.Timeout)*time.Millisecond/warmupShare)
+	defer cancel()
+	scratch := arena.Get()
+	defer arena.Put(scratch)
+	func() {
+		// A strategy failing here will fail the first move too, where the
+		// watchdog handles it; the warmup only mustn't fail the start.
+		defer func() { recover() }()
+		strat.Move(arena.NewContext(ctx, scratch), request)
+	}()
+	logger.DebugContext(ctx, "warmed up", "game", request.Game.ID, "took", time.Since(start))
+}
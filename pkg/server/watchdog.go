@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/arena"
+	"github.com/jayuuza/battlesnake/pkg/sentry"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// decide asks strat for a move, guaranteeing an answer within deadline: if
+// the strategy hasn't decided by then, panics, or is still busy with an
+// earlier turn of the game, it answers with strategy.SafeMove instead and
+// returns the reason, SlowTimeout, SlowPanic or SlowBusy. The strategy is
+// left to notice ctx being cancelled.
+func (s *Server) decide(ctx context.Context, strat strategy.Strategy, request api.GameRequest, deadline time.Duration) (api.MoveResponse, string) {
+	timer := time.NewTimer(deadline)
+	defer timer.Stop()
+
+	gameID := request.Game.ID
+	if !s.startThinking(gameID) {
+		logger.WarnContext(ctx, "watchdog: strategy still busy with an earlier turn",
+			"game", gameID, "turn", request.Turn)
+		return strategy.SafeMove(request), SlowBusy
+	}
+	done := make(chan api.MoveResponse, 1)
+	go func() {
+		defer s.stopThinking(gameID)
+		// The arena outlives the watchdog, being released only once the
+		// strategy is done with it.
+		scratch := arena.Get()
+		defer arena.Put(scratch)
+		defer func() {
+			if r := recover(); r != nil {
+				s.reportError(sentry.Fatal, fmt.Sprintf("panic: %v\n\n%s", r, debug.Stack()), request, gameTags(request, "move"))
+				logger.ErrorContext(ctx, "strategy panicked", "game", gameID, "turn", request.Turn, "panic", r)
+				close(done)
+			}
+		}()
+		s.Chaos.inject(gameID, request.Turn)
+		done <- strat.Move(arena.NewContext(ctx, scratch), request)
+	}()
+
+	select {
+	case move, ok := <-done:
+		if !ok {
+			return strategy.SafeMove(request), SlowPanic
+		}
+		return move, ""
+	case <-timer.C:
+		logger.WarnContext(ctx, "watchdog: no move by the deadline, answering with a safe move",
+			"game", gameID, "turn", request.Turn, "deadline", deadline)
+		return strategy.SafeMove(request), SlowTimeout
+	}
+}
+
+// startThinking marks a strategy as deciding a move for gameID, reporting
+// false if it is already busy with one.
+func (s *Server) startThinking(gameID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.thinking[gameID]; ok {
+		return false
+	}
+	if s.thinking == nil {
+		s.thinking = map[string]chan struct{}{}
+	}
+	s.thinking[gameID] = make(chan struct{})
+	return true
+}
+
+func (s *Server) stopThinking(gameID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	close(s.thinking[gameID])
+	delete(s.thinking, gameID)
+}
+
+// stillThinking returns a channel closed once the strategy of gameID is
+// done deciding the move it is busy with, or nil if it isn't busy.
+func (s *Server) stillThinking(gameID string) <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if done, ok := s.thinking[gameID]; ok {
+		return done
+	}
+	return nil
+}
+
+// afterThinking runs f once the strategy of gameID isn't deciding a move.
+// A move the watchdog answered for had its context cancelled with its
+// request, so the strategy should be done shortly: it is waited for up to
+// endWait, after which f is left to run in the background once it is.
+func (s *Server) afterThinking(ctx context.Context, gameID string, f func()) {
+	thinking := s.stillThinking(gameID)
+	if thinking == nil {
+		f()
+		return
+	}
+	timer := time.NewTimer(endWait)
+	defer timer.Stop()
+	select {
+	case <-thinking:
+		f()
+	case <-timer.C:
+		logger.WarnContext(ctx, "strategy still deciding a move, releasing it once done", "game", gameID)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("releasing a strategy panicked", "game", gameID, "panic", r, "stack", string(debug.Stack()))
+				}
+			}()
+			<-thinking
+			f()
+		}()
+	}
+}
+
+// endWait is how long afterThinking waits for a strategy deciding a move.
+const endWait = time.Second
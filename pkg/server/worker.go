@@ -0,0 +1,94 @@
+package server
+
+import (
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/history"
+	"github.com/jayuuza/battlesnake/pkg/opponent"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// The requests of a game are handled one at a time, in the order they
+// arrive, on a goroutine of the game's own: its worker. The worker owns the
+// state this process keeps for the game, which is only touched from its
+// goroutine and so needs no locking; the workers of different games run in
+// parallel.
+
+// workerIdle is how long a worker waits for its game's next request before
+// exiting, so that games that never end don't keep theirs for good. A game
+// heard from again gets a new worker, its strategy recreated from the
+// store as if on another replica.
+const workerIdle = 5 * time.Minute
+
+// worker runs the requests of one game.
+type worker struct {
+	jobs chan func(*worker)
+	// pending counts the jobs sent or about to be; the worker only exits
+	// once it is 0. It is guarded by Server.mu.
+	pending int
+
+	// The game's state, owned by the worker's goroutine.
+	strategy strategy.Strategy
+	model    *opponent.Model
+	lastTurn *history.Turn
+	// ended is set once the game ended, for the worker to exit.
+	ended bool
+}
+
+// onWorker runs job on the worker of gameID, starting one if the game has
+// none, and returns once it is done. A panic in job is raised again in the
+// caller's goroutine, as if job had run there.
+func (s *Server) onWorker(gameID string, job func(*worker)) {
+	s.mu.Lock()
+	w, ok := s.workers[gameID]
+	if !ok {
+		w = &worker{jobs: make(chan func(*worker))}
+		if s.workers == nil {
+			s.workers = map[string]*worker{}
+		}
+		s.workers[gameID] = w
+		go s.runWorker(gameID, w)
+	}
+	w.pending++
+	s.mu.Unlock()
+
+	done := make(chan any, 1)
+	w.jobs <- func(w *worker) {
+		defer func() { done <- recover() }()
+		job(w)
+	}
+	if r := <-done; r != nil {
+		panic(r)
+	}
+}
+
+// runWorker runs the jobs of gameID until the game has ended, or no job has
+// come for workerIdle, and none are pending.
+func (s *Server) runWorker(gameID string, w *worker) {
+	idle := time.NewTimer(workerIdle)
+	defer idle.Stop()
+	for {
+		select {
+		case job := <-w.jobs:
+			job(w)
+			s.mu.Lock()
+			w.pending--
+			if w.ended && w.pending == 0 {
+				delete(s.workers, gameID)
+				s.mu.Unlock()
+				return
+			}
+			s.mu.Unlock()
+			idle.Reset(workerIdle)
+		case <-idle.C:
+			s.mu.Lock()
+			if w.pending == 0 {
+				delete(s.workers, gameID)
+				s.mu.Unlock()
+				return
+			}
+			s.mu.Unlock()
+			idle.Reset(workerIdle)
+		}
+	}
+}
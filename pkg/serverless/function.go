@@ -0,0 +1,60 @@
+package serverless
+
+import (
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/jayuuza/battlesnake/pkg/logging"
+	"github.com/jayuuza/battlesnake/pkg/personality"
+	"github.com/jayuuza/battlesnake/pkg/sentry"
+	"github.com/jayuuza/battlesnake/pkg/server"
+	"github.com/jayuuza/battlesnake/pkg/store"
+	"github.com/jayuuza/battlesnake/pkg/timing"
+)
+
+var (
+	functionOnce    sync.Once
+	functionHandler http.Handler
+)
+
+// Function serves the Battlesnake API as a Google Cloud Function (or any
+// platform taking a plain HTTP handler function). Since such platforms
+// don't pass flags, the server is configured from the environment:
+// STRATEGY and PERSONALITY select the defaults, and SENTRY_DSN enables error
+// reporting.
+func Function(w http.ResponseWriter, r *http.Request) {
+	functionOnce.Do(func() {
+		functionHandler = FromEnv().Handler()
+	})
+	functionHandler.ServeHTTP(w, r)
+}
+
+// FromEnv returns a Server configured from the STRATEGY, PERSONALITY and
+// SENTRY_DSN environment variables, falling back to the random strategy and
+// the default personality.
+func FromEnv() *server.Server {
+	srv := &server.Server{
+		DefaultStrategy:    envOr("STRATEGY", "random"),
+		DefaultPersonality: envOr("PERSONALITY", personality.Default),
+		Store:              store.NewMemory(),
+		Fallback:           "greedy",
+		BreakerTrips:       3,
+		Timing:             timing.DefaultManager,
+	}
+	if dsn := os.Getenv("SENTRY_DSN"); dsn != "" {
+		if errs, err := sentry.New(dsn); err != nil {
+			logging.For(logging.Server).Error("configuring error reporting", "err", err)
+		} else {
+			srv.Errors = errs
+		}
+	}
+	return srv
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
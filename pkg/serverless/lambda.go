@@ -0,0 +1,140 @@
+// Package serverless runs the Battlesnake handlers on serverless platforms
+// instead of behind ListenAndServe.
+package serverless
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// runtimeAPIVersion is the version of the Lambda runtime API spoken.
+const runtimeAPIVersion = "2018-06-01"
+
+// proxyEvent is an API Gateway proxy integration event. Both the REST API
+// (payload version 1.0) and HTTP API (2.0) shapes are accepted.
+type proxyEvent struct {
+	HTTPMethod            string            `json:"httpMethod"`
+	Path                  string            `json:"path"`
+	QueryStringParameters map[string]string `json:"queryStringParameters"`
+	RawPath               string            `json:"rawPath"`
+	RawQueryString        string            `json:"rawQueryString"`
+	RequestContext        struct {
+		HTTP struct {
+			Method string `json:"method"`
+		} `json:"http"`
+	} `json:"requestContext"`
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+}
+
+type proxyResponse struct {
+	StatusCode      int               `json:"statusCode"`
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+}
+
+// ServeLambda runs h as an AWS Lambda function behind API Gateway, using
+// the custom runtime API at the address in AWS_LAMBDA_RUNTIME_API. It only
+// returns if the runtime API fails.
+func ServeLambda(h http.Handler) error {
+	base := "http://" + os.Getenv("AWS_LAMBDA_RUNTIME_API") + "/" + runtimeAPIVersion + "/runtime/invocation/"
+	for {
+		resp, err := http.Get(base + "next")
+		if err != nil {
+			return err
+		}
+		payload, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		id := resp.Header.Get("Lambda-Runtime-Aws-Request-Id")
+		ctx, cancel := context.WithCancel(context.Background())
+		if ms, err := strconv.ParseInt(resp.Header.Get("Lambda-Runtime-Deadline-Ms"), 10, 64); err == nil {
+			ctx, cancel = context.WithDeadline(ctx, time.Unix(0, ms*int64(time.Millisecond)))
+		}
+		out, err := invoke(ctx, h, payload)
+		cancel()
+		if err != nil {
+			out, _ = json.Marshal(map[string]string{
+				"errorMessage": err.Error(),
+				"errorType":    "InvalidEvent",
+			})
+			_, err = http.Post(base+id+"/error", "application/json", bytes.NewReader(out))
+		} else {
+			_, err = http.Post(base+id+"/response", "application/json", bytes.NewReader(out))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// invoke serves a single API Gateway event with h and returns the encoded
+// proxy response.
+func invoke(ctx context.Context, h http.Handler, payload []byte) ([]byte, error) {
+	var event proxyEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+
+	method, path, query := event.HTTPMethod, event.Path, event.RawQueryString
+	if method == "" {
+		method = event.RequestContext.HTTP.Method
+	}
+	if path == "" {
+		path = event.RawPath
+	}
+	if query == "" && len(event.QueryStringParameters) > 0 {
+		values := url.Values{}
+		for k, v := range event.QueryStringParameters {
+			values.Set(k, v)
+		}
+		query = values.Encode()
+	}
+	body := []byte(event.Body)
+	if event.IsBase64Encoded {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(event.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	target := path
+	if query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("serverless: bad request %s %s: %v", method, target, err)
+	}
+	for k, v := range event.Headers {
+		req.Header.Set(k, v)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	resp := proxyResponse{
+		StatusCode: rec.Code,
+		Headers:    map[string]string{},
+		Body:       rec.Body.String(),
+	}
+	for k := range rec.Header() {
+		resp.Headers[k] = rec.Header().Get(k)
+	}
+	return json.Marshal(resp)
+}
@@ -0,0 +1,128 @@
+package serverless
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/bench"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// upward always moves up.
+type upward struct{}
+
+func (upward) Start(context.Context, api.GameRequest) {}
+func (upward) End(context.Context, api.GameRequest)   {}
+
+func (upward) Move(context.Context, api.GameRequest) api.MoveResponse {
+	return api.MoveResponse{Move: api.Up, Shout: "up we go"}
+}
+
+func init() {
+	strategy.Register("test-serverless-up", func() strategy.Strategy { return upward{} })
+}
+
+// functionURLEvent is what a Lambda Function URL sends for a request: the
+// HTTP API's payload version 2.0, with the body base64 encoded.
+const functionURLEvent = `{
+	"version": "2.0",
+	"routeKey": "$default",
+	"rawPath": "/move",
+	"rawQueryString": "personality=default",
+	"headers": {"content-type": "application/json", "host": "abc.lambda-url.us-east-1.on.aws"},
+	"requestContext": {"http": {"method": "POST", "path": "/move", "protocol": "HTTP/1.1"}},
+	"body": %q,
+	"isBase64Encoded": true
+}`
+
+func TestInvokeFunctionURL(t *testing.T) {
+	t.Setenv("STRATEGY", "test-serverless-up")
+	t.Setenv("SENTRY_DSN", "")
+	h := FromEnv().Handler()
+
+	request, err := json.Marshal(bench.Position())
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := fmt.Sprintf(functionURLEvent, base64.StdEncoding.EncodeToString(request))
+	out, err := invoke(context.Background(), h, []byte(event))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp proxyResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("response %s: %v", out, err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("status %d: %s", resp.StatusCode, resp.Body)
+	}
+	if ct := resp.Headers["Content-Type"]; !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	// JSON goes back as it is.
+	if resp.IsBase64Encoded {
+		t.Error("response body base64 encoded")
+	}
+	var move api.MoveResponse
+	if err := json.Unmarshal([]byte(resp.Body), &move); err != nil {
+		t.Fatalf("body %q: %v", resp.Body, err)
+	}
+	if move.Move != api.Up || move.Shout != "up we go" {
+		t.Errorf("moved %+v", move)
+	}
+}
+
+func TestInvokeRESTEvent(t *testing.T) {
+	t.Setenv("STRATEGY", "test-serverless-up")
+	t.Setenv("SENTRY_DSN", "")
+	h := FromEnv().Handler()
+
+	// A REST API event, payload version 1.0, with a plain body and the
+	// query as parameters.
+	event := `{
+		"httpMethod": "GET",
+		"path": "/",
+		"queryStringParameters": {"api": "1"},
+		"headers": {"Accept": "application/json"},
+		"body": "",
+		"isBase64Encoded": false
+	}`
+	out, err := invoke(context.Background(), h, []byte(event))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp proxyResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatal(err)
+	}
+	var info api.BattlesnakeInfoResponse
+	if resp.StatusCode != 200 || json.Unmarshal([]byte(resp.Body), &info) != nil || info.APIVersion != "1" {
+		t.Errorf("index answered %d: %s", resp.StatusCode, resp.Body)
+	}
+
+	event = `{"httpMethod": "GET", "path": "/", "queryStringParameters": {"api": "7"}}`
+	if out, err = invoke(context.Background(), h, []byte(event)); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(out, &resp); err != nil || resp.StatusCode != 400 {
+		t.Errorf("unknown API version answered %d: %s", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestInvokeBadEvent(t *testing.T) {
+	h := FromEnv().Handler()
+	for name, event := range map[string]string{
+		"not JSON":   `{"rawPath": `,
+		"bad base64": `{"rawPath": "/move", "requestContext": {"http": {"method": "POST"}}, "body": "!!", "isBase64Encoded": true}`,
+		"bad method": `{"rawPath": "/", "requestContext": {"http": {"method": "GE T"}}}`,
+	} {
+		if _, err := invoke(context.Background(), h, []byte(event)); err == nil {
+			t.Errorf("%s event served", name)
+		}
+	}
+}
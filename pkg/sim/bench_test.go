@@ -0,0 +1,65 @@
+package sim_test
+
+import (
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/bench"
+	"github.com/jayuuza/battlesnake/pkg/sim"
+)
+
+// BenchmarkNew and BenchmarkAcquire compare building a state afresh with
+// reusing a pooled one, which shouldn't allocate.
+func BenchmarkNew(b *testing.B) {
+	game := bench.Position()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sim.New(game)
+	}
+}
+
+func BenchmarkAcquire(b *testing.B) {
+	game := bench.Position()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sim.Release(sim.Acquire(game))
+	}
+}
+
+func BenchmarkApplyUndo(b *testing.B) {
+	s := sim.New(bench.Position())
+	moves := []api.Direction{api.Up, api.Down, api.Right, api.Left}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Apply(moves)
+		s.Undo()
+	}
+}
+
+func BenchmarkPerft2(b *testing.B) {
+	s := sim.New(bench.Position())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sim.Perft(s, 2)
+	}
+}
+
+// TestAcquireAllocs guards the pooling: once warm, acquiring and releasing
+// a state for the reference position allocates nothing. Under the race
+// detector only applying and undoing moves is checked.
+func TestAcquireAllocs(t *testing.T) {
+	game := bench.Position()
+	sim.Release(sim.Acquire(game))
+	if allocs := testing.AllocsPerRun(100, func() { sim.Release(sim.Acquire(game)) }); allocs > 0 && !raceEnabled {
+		t.Errorf("Acquire and Release allocate %.1f times, want 0", allocs)
+	}
+	s := sim.New(game)
+	moves := []api.Direction{api.Up, api.Down, api.Right, api.Left}
+	s.Apply(moves)
+	s.Undo()
+	if allocs := testing.AllocsPerRun(100, func() { s.Apply(moves); s.Undo() }); allocs > 0 {
+		t.Errorf("Apply and Undo allocate %.1f times, want 0", allocs)
+	}
+}
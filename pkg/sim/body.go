@@ -0,0 +1,81 @@
+package sim
+
+import "github.com/jayuuza/battlesnake/pkg/api"
+
+// minBodyCap is the smallest ring allocated for a body, so short snakes
+// can eat a few times before it has to grow.
+const minBodyCap = 16
+
+// body is a snake's segments in a ring buffer, so moving, growing and
+// undoing either cost the same however long the snake is and however deep
+// a search has gone. The head is at cells[head] and the segments behind it
+// at the cells before, wrapping around; len(cells) is a power of two.
+type body struct {
+	cells  []api.Coord
+	head   int
+	length int
+}
+
+// reset replaces the segments with segs, given from the head, reusing the
+// ring if it is large enough.
+func (b *body) reset(segs []api.Coord) {
+	size := minBodyCap
+	for size <= len(segs) {
+		size *= 2
+	}
+	if len(b.cells) < size {
+		b.cells = make([]api.Coord, size)
+	}
+	b.length = len(segs)
+	b.head = b.length - 1
+	for i, c := range segs {
+		b.cells[b.head-i] = c
+	}
+}
+
+func (b *body) mask() int { return len(b.cells) - 1 }
+
+// segment returns the i-th segment counting from the head.
+func (b *body) segment(i int) api.Coord {
+	return b.cells[(b.head-i)&b.mask()]
+}
+
+// tailIndex returns the cell holding the last segment.
+func (b *body) tailIndex() int {
+	return (b.head - b.length + 1) & b.mask()
+}
+
+// advance moves the head to c, dropping the tail. The ring always keeps a
+// free cell behind the tail so that grow can reuse it.
+func (b *body) advance(c api.Coord) {
+	if b.length+1 >= len(b.cells) {
+		b.resize()
+	}
+	b.head = (b.head + 1) & b.mask()
+	b.cells[b.head] = c
+}
+
+// grow adds a segment on top of the tail, as eating does.
+func (b *body) grow() {
+	tail := b.cells[b.tailIndex()]
+	b.length++
+	b.cells[b.tailIndex()] = tail
+}
+
+// retreat reverts the last advance and any grow after it, restoring the
+// dropped tail; length is the length before the advance.
+func (b *body) retreat(tail api.Coord, length int) {
+	b.head = (b.head - 1) & b.mask()
+	b.length = length
+	b.cells[b.tailIndex()] = tail
+}
+
+// resize doubles the ring, laying the segments out again from cell 0.
+func (b *body) resize() {
+	cells := make([]api.Coord, 2*len(b.cells))
+	for i := 0; i < b.length; i++ {
+		cells[b.length-1-i] = b.segment(i)
+	}
+	b.cells = cells
+	b.head = b.length - 1
+}
@@ -0,0 +1,63 @@
+package sim
+
+// Hash returns a 64-bit hash of everything that decides how the game goes
+// on from the state: the board and its rules, food, hazards, and every
+// live snake's body and health. Transposition tables key positions by it,
+// so two states hash alike whichever moves led to them.
+func (s *State) Hash() uint64 {
+	h := s.hashBase
+	for _, w := range s.Food {
+		h = mix(h ^ w)
+	}
+	for i := range s.Snakes {
+		snake := &s.Snakes[i]
+		if snake.Eliminated {
+			h = mix(h ^ uint64(i) ^ 1<<63)
+			continue
+		}
+		h = mix(h ^ uint64(i) ^ uint64(snake.Health)<<8 ^ uint64(snake.Len())<<32)
+		for j := 0; j < snake.Len(); j++ {
+			seg := snake.Segment(j)
+			h = mix(h ^ uint64(uint32(seg.X)) ^ uint64(uint32(seg.Y))<<32)
+		}
+	}
+	if s.Trails {
+		for idx := range s.trails {
+			if n := s.HazardStack(idx); n > 0 {
+				h = mix(h ^ uint64(idx) ^ uint64(n)<<32)
+			}
+		}
+	}
+	return h
+}
+
+// hashRules hashes what stays the same for the whole game, so Hash needn't
+// go over it at every node.
+func (s *State) hashRules() uint64 {
+	h := mix(uint64(s.Width) ^ uint64(s.Height)<<16 ^ uint64(s.You)<<32 ^ uint64(len(s.Snakes))<<48)
+	flags := uint64(0)
+	if s.Wrapped {
+		flags |= 1
+	}
+	if s.Trails {
+		flags |= 2
+	}
+	h = mix(h ^ uint64(int64(s.HazardDamage)) ^ flags<<60)
+	for idx, n := range s.stacks {
+		if n > 0 {
+			h = mix(h ^ uint64(idx) ^ uint64(n)<<32)
+		}
+	}
+	return h
+}
+
+// mix is the finalizer of SplitMix64, spreading every input bit over the
+// whole hash.
+func mix(h uint64) uint64 {
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
@@ -0,0 +1,96 @@
+package sim
+
+import "github.com/jayuuza/battlesnake/pkg/api"
+
+// Perft counts the positions reached after depth turns from s, every live
+// snake playing each of its legal moves: any direction but back into its
+// own neck. Lines on which the game ends sooner, with one snake left (none
+// in a solo game), reach no positions. Comparing counts with a reference
+// implementation validates the simulator, and timing them measures its
+// throughput. The state is restored before Perft returns.
+func Perft(s *State, depth int) uint64 {
+	return newPerfter(s, depth).perft(depth, -1)
+}
+
+// Divide returns Perft's count below each of our legal moves, to narrow
+// down where two implementations disagree.
+func Divide(s *State, depth int) map[api.Direction]uint64 {
+	counts := map[api.Direction]uint64{}
+	p := newPerfter(s, depth)
+	if depth < 1 || p.over() {
+		return counts
+	}
+	for _, d := range api.Directions {
+		if s.legal(s.You, d) {
+			p.moves[depth][s.You] = d
+			counts[d] = p.combos(depth, 0, s.You)
+		}
+	}
+	return counts
+}
+
+// perfter holds the moves being tried at each remaining depth, so counting
+// doesn't allocate.
+type perfter struct {
+	s     *State
+	solo  bool
+	moves [][]api.Direction
+}
+
+func newPerfter(s *State, depth int) *perfter {
+	p := &perfter{s: s, solo: len(s.Snakes) == 1, moves: make([][]api.Direction, depth+1)}
+	for i := range p.moves {
+		p.moves[i] = make([]api.Direction, len(s.Snakes))
+	}
+	return p
+}
+
+// perft counts the positions depth turns on, with the move of snake fixed
+// already chosen if it isn't -1.
+func (p *perfter) perft(depth, fixed int) uint64 {
+	if depth == 0 {
+		return 1
+	}
+	if p.over() {
+		return 0
+	}
+	return p.combos(depth, 0, fixed)
+}
+
+// combos sums perft below every combination of moves for the snakes from i
+// on, skipping fixed.
+func (p *perfter) combos(depth, i, fixed int) uint64 {
+	s, moves := p.s, p.moves[depth]
+	if i == len(s.Snakes) {
+		s.Apply(moves)
+		n := p.perft(depth-1, -1)
+		s.Undo()
+		return n
+	}
+	if i == fixed || s.Snakes[i].Eliminated {
+		return p.combos(depth, i+1, fixed)
+	}
+	var n uint64
+	for _, d := range api.Directions {
+		if s.legal(i, d) {
+			moves[i] = d
+			n += p.combos(depth, i+1, fixed)
+		}
+	}
+	return n
+}
+
+// over reports whether the game has ended.
+func (p *perfter) over() bool {
+	if p.solo {
+		return p.s.Alive() == 0
+	}
+	return p.s.Alive() <= 1
+}
+
+// legal reports whether snake i may move in direction d: anywhere but back
+// into its neck.
+func (s *State) legal(i int, d api.Direction) bool {
+	snake := &s.Snakes[i]
+	return snake.Len() < 2 || s.step(snake.Head(), d) != snake.Segment(1)
+}
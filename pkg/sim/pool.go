@@ -0,0 +1,25 @@
+package sim
+
+import (
+	"sync"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+)
+
+var statePool = sync.Pool{
+	New: func() interface{} { return &State{} },
+}
+
+// Acquire returns a State for the position in game, reusing one released
+// earlier when possible. Search should prefer it to New so that building a
+// state for every simulated branch doesn't churn the garbage collector.
+func Acquire(game api.GameRequest) *State {
+	s := statePool.Get().(*State)
+	s.Reset(game)
+	return s
+}
+
+// Release returns s to the pool. s must not be used afterwards.
+func Release(s *State) {
+	statePool.Put(s)
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package sim_test
+
+// raceEnabled reports whether the race detector is on. sync.Pool then drops
+// items at random, so pooling can't be held to zero allocations.
+const raceEnabled = true
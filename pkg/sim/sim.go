@@ -0,0 +1,384 @@
+// Package sim simulates Battlesnake turns under the standard rules,
+// including the decaying hazard trails of snail_mode.
+//
+// A State is advanced in place with Apply and restored with Undo, so search
+// can walk a game tree depth first without copying the board at every node.
+package sim
+
+import (
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+)
+
+// MaxHealth is the health a snake starts with and is restored to by eating.
+const MaxHealth = 100
+
+// Snake is a snake's state within a simulation.
+type Snake struct {
+	ID         string
+	Health     int
+	Eliminated bool
+
+	body body
+}
+
+// Head returns the snake's head.
+func (s *Snake) Head() api.Coord {
+	return s.body.segment(0)
+}
+
+// Tail returns the snake's last segment.
+func (s *Snake) Tail() api.Coord {
+	return s.body.cells[s.body.tailIndex()]
+}
+
+// Len returns the number of segments in the snake.
+func (s *Snake) Len() int {
+	return s.body.length
+}
+
+// Segment returns the i-th segment counting from the head, which is 0.
+func (s *Snake) Segment(i int) api.Coord {
+	return s.body.segment(i)
+}
+
+// State is a position that can be advanced and rewound.
+type State struct {
+	*board.Layout
+	Turn    int
+	Snakes  []Snake
+	Food    board.Bits
+	Hazards board.Bits
+	// You is the index of our snake in Snakes.
+	You int
+
+	// HazardDamage is dealt per stacked hazard; it is negative on maps whose
+	// hazards heal.
+	HazardDamage int
+	Wrapped      bool
+	// Trails is set on maps where snakes leave decaying hazard trails, which
+	// are tracked separately from Hazards.
+	Trails bool
+
+	// stacks counts the hazards on each cell. trails holds, for each cell,
+	// the turn its trail disappears; a trail stacks one hazard per
+	// remaining turn.
+	stacks []uint8
+	trails []int
+	// hashBase is the part of Hash fixed for the game.
+	hashBase uint64
+
+	undo      []turnUndo
+	snakeUndo []snakeUndo
+	dead      []bool
+}
+
+type turnUndo struct {
+	food board.Bits
+}
+
+type snakeUndo struct {
+	health     int
+	eliminated bool
+	tail       api.Coord
+	grew       bool
+	// trail is the cell the snake left a trail on, or -1, and trailEnd the
+	// turn any previous trail there ended.
+	trail    int
+	trailEnd int
+}
+
+// New builds the simulation state for the position in game.
+func New(game api.GameRequest) *State {
+	s := &State{}
+	s.Reset(game)
+	return s
+}
+
+// Reset replaces the state with the position in game, reusing the memory
+// already allocated for snake bodies and undo stacks.
+func (s *State) Reset(game api.GameRequest) {
+	var bb board.Bitboard
+	bb.Reset(game.Board)
+	s.Layout = bb.Layout
+	s.Turn = game.Turn
+	s.Food = bb.Food
+	s.Hazards = bb.Hazards
+	s.You = 0
+	s.HazardDamage = int(game.Game.Ruleset.Settings.HazardDamagePerTurn)
+	s.Wrapped = board.IsWrapped(game.Game.Ruleset.Name)
+	s.Trails = board.HazardTrails(game.Game.Map)
+	s.stacks = s.stacks[:0]
+	s.trails = s.trails[:0]
+	if !s.Trails && len(game.Board.Hazards) > 0 {
+		for i := 0; i < s.Width*s.Height; i++ {
+			s.stacks = append(s.stacks, 0)
+		}
+		for _, coord := range game.Board.Hazards {
+			if s.onBoard(coord) && s.stacks[s.Index(coord)] < 255 {
+				s.stacks[s.Index(coord)]++
+			}
+		}
+	}
+	if s.Trails {
+		// Each duplicate hazard entry is one more turn of trail.
+		for i := 0; i < s.Width*s.Height; i++ {
+			s.trails = append(s.trails, s.Turn)
+		}
+		for _, coord := range game.Board.Hazards {
+			if s.onBoard(coord) {
+				s.trails[s.Index(coord)]++
+			}
+		}
+		s.Hazards = board.Bits{}
+	}
+	s.undo = s.undo[:0]
+	s.snakeUndo = s.snakeUndo[:0]
+
+	if cap(s.Snakes) < len(game.Board.Snakes) {
+		s.Snakes = make([]Snake, len(game.Board.Snakes))
+	}
+	s.Snakes = s.Snakes[:len(game.Board.Snakes)]
+	for i, snake := range game.Board.Snakes {
+		s.Snakes[i] = Snake{
+			ID:     snake.ID,
+			Health: int(snake.Health),
+			body:   s.Snakes[i].body,
+		}
+		s.Snakes[i].body.reset(snake.Body)
+		if snake.ID == game.You.ID {
+			s.You = i
+		}
+	}
+	s.hashBase = s.hashRules()
+}
+
+// HazardStack returns the number of hazards stacked on cell idx, by the
+// map or by snail trails.
+func (s *State) HazardStack(idx int) int {
+	switch {
+	case s.Trails:
+		return max(s.trails[idx]-s.Turn, 0)
+	case len(s.stacks) > 0:
+		return int(s.stacks[idx])
+	}
+	return 0
+}
+
+// Alive returns the number of snakes not yet eliminated.
+func (s *State) Alive() int {
+	n := 0
+	for i := range s.Snakes {
+		if !s.Snakes[i].Eliminated {
+			n++
+		}
+	}
+	return n
+}
+
+// Apply advances the state by one turn, moving each snake in the direction
+// at the same index in moves. Moves for eliminated snakes are ignored.
+func (s *State) Apply(moves []api.Direction) {
+	s.undo = append(s.undo, turnUndo{food: s.Food})
+
+	for i := range s.Snakes {
+		snake := &s.Snakes[i]
+		s.snakeUndo = append(s.snakeUndo, snakeUndo{
+			health:     snake.Health,
+			eliminated: snake.Eliminated,
+			tail:       snake.Tail(),
+			trail:      -1,
+		})
+		if snake.Eliminated {
+			continue
+		}
+		snake.body.advance(s.step(snake.Head(), moves[i]))
+		snake.Health--
+	}
+
+	base := len(s.snakeUndo) - len(s.Snakes)
+	var eaten board.Bits
+	for i := range s.Snakes {
+		snake := &s.Snakes[i]
+		if snake.Eliminated {
+			continue
+		}
+		head := snake.Head()
+		if !s.onBoard(head) {
+			continue
+		}
+		idx := s.Index(head)
+		if !s.Food.Has(idx) {
+			snake.Health = min(snake.Health-s.HazardStack(idx)*s.HazardDamage, MaxHealth)
+		}
+		if s.Food.Has(idx) {
+			snake.Health = MaxHealth
+			snake.body.grow()
+			s.snakeUndo[base+i].grew = true
+			eaten.Set(idx)
+		}
+	}
+	s.Food = s.Food.AndNot(eaten)
+
+	s.eliminate()
+	if s.Trails {
+		s.layTrails(base)
+	}
+	s.Turn++
+}
+
+// layTrails leaves a trail as long as the snake on the cell each surviving
+// snake's tail just vacated. Trails decay without further work, since
+// HazardStack counts down to the turn they end.
+func (s *State) layTrails(base int) {
+	for i := range s.Snakes {
+		snake := &s.Snakes[i]
+		u := &s.snakeUndo[base+i]
+		if snake.Eliminated || u.eliminated || u.grew || !s.onBoard(u.tail) || u.tail == snake.Tail() {
+			continue
+		}
+		idx := s.Index(u.tail)
+		u.trail, u.trailEnd = idx, s.trails[idx]
+		s.trails[idx] = s.Turn + 1 + snake.Len()
+	}
+}
+
+// eliminate applies the standard elimination rules to the snakes that just
+// moved. As in the rules engine, snakes out of health or off the board are
+// removed first, so their bodies and heads take no part in collisions.
+func (s *State) eliminate() {
+	for i := range s.Snakes {
+		snake := &s.Snakes[i]
+		if !snake.Eliminated && (snake.Health <= 0 || !s.onBoard(snake.Head())) {
+			snake.Eliminated = true
+		}
+	}
+
+	var bodies board.Bits
+	for i := range s.Snakes {
+		snake := &s.Snakes[i]
+		if snake.Eliminated {
+			continue
+		}
+		for j := 1; j < snake.Len(); j++ {
+			if seg := snake.Segment(j); s.onBoard(seg) {
+				bodies.Set(s.Index(seg))
+			}
+		}
+	}
+
+	if cap(s.dead) < len(s.Snakes) {
+		s.dead = make([]bool, len(s.Snakes))
+	}
+	dead := s.dead[:len(s.Snakes)]
+	for i := range dead {
+		dead[i] = false
+	}
+	for i := range s.Snakes {
+		snake := &s.Snakes[i]
+		if snake.Eliminated {
+			continue
+		}
+		head := snake.Head()
+		if bodies.Has(s.Index(head)) {
+			dead[i] = true
+			continue
+		}
+		for j := range s.Snakes {
+			other := &s.Snakes[j]
+			if j == i || other.Eliminated || other.Head() != head {
+				continue
+			}
+			if snake.Len() <= other.Len() {
+				dead[i] = true
+			}
+		}
+	}
+	for i, d := range dead {
+		if d {
+			s.Snakes[i].Eliminated = true
+		}
+	}
+}
+
+// Undo reverts the most recent Apply.
+func (s *State) Undo() {
+	last := s.undo[len(s.undo)-1]
+	s.undo = s.undo[:len(s.undo)-1]
+	s.Food = last.food
+	s.Turn--
+
+	base := len(s.snakeUndo) - len(s.Snakes)
+	for i := range s.Snakes {
+		snake := &s.Snakes[i]
+		u := s.snakeUndo[base+i]
+		if u.trail >= 0 {
+			s.trails[u.trail] = u.trailEnd
+		}
+		if !u.eliminated {
+			length := snake.Len()
+			if u.grew {
+				length--
+			}
+			snake.body.retreat(u.tail, length)
+		}
+		snake.Health = u.health
+		snake.Eliminated = u.eliminated
+	}
+	s.snakeUndo = s.snakeUndo[:base]
+}
+
+// step returns the cell one move from pos, wrapping around the edges in
+// wrapped games.
+func (s *State) step(pos api.Coord, d api.Direction) api.Coord {
+	pos = pos.Move(d)
+	if s.Wrapped {
+		pos.X = (pos.X + s.Width) % s.Width
+		pos.Y = (pos.Y + s.Height) % s.Height
+	}
+	return pos
+}
+
+func (s *State) onBoard(pos api.Coord) bool {
+	return board.InBounds(pos, s.Width, s.Height)
+}
+
+// Request returns game with its turn and board replaced by the state's,
+// for handing a simulated position back to strategies. Eliminated snakes
+// are left off the board, as the engine does, and You is updated while we
+// are alive.
+func (s *State) Request(game api.GameRequest) api.GameRequest {
+	names := map[string]api.Battlesnake{}
+	for _, snake := range game.Board.Snakes {
+		names[snake.ID] = snake
+	}
+	game.Turn = s.Turn
+	game.Board.Food, game.Board.Hazards, game.Board.Snakes = nil, nil, nil
+	for i := 0; i < s.Width*s.Height; i++ {
+		if s.Food.Has(i) {
+			game.Board.Food = append(game.Board.Food, s.Coord(i))
+		}
+		for n := s.HazardStack(i); n > 0; n-- {
+			game.Board.Hazards = append(game.Board.Hazards, s.Coord(i))
+		}
+	}
+	for i := range s.Snakes {
+		snake := &s.Snakes[i]
+		if snake.Eliminated {
+			continue
+		}
+		out := names[snake.ID]
+		out.Health = int32(snake.Health)
+		out.Body = make([]api.Coord, snake.Len())
+		for j := range out.Body {
+			out.Body[j] = snake.Segment(j)
+		}
+		out.Head = snake.Head()
+		out.Length = int32(snake.Len())
+		game.Board.Snakes = append(game.Board.Snakes, out)
+		if i == s.You {
+			game.You = out
+		}
+	}
+	return game
+}
@@ -0,0 +1,253 @@
+// Package spectate records the frames the official engine broadcasts for
+// games we play, next to the history we recorded ourselves, so the two can
+// be compared: a difference means we saw a board the engine didn't play,
+// or the engine played a move we didn't send.
+package spectate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/gamedir"
+	"github.com/jayuuza/battlesnake/pkg/history"
+)
+
+// FileName is the name of the recorded frames within a game's directory.
+const FileName = "frames.jsonl"
+
+// DefaultURL is the websocket the public engine streams a game's events
+// on, with {game} replaced by the game ID.
+const DefaultURL = "wss://engine.battlesnake.com/games/{game}/events"
+
+// Client records the events of games to a file per game under Dir.
+type Client struct {
+	Dir string
+	// URL is the event stream of a game, with {game} replaced by its ID.
+	URL string
+}
+
+// Path returns the frames file of gameID, in its directory of Dir.
+func (c *Client) Path(gameID string) string {
+	return filepath.Join(c.Dir, gamedir.Name(gameID), FileName)
+}
+
+// Record appends the events of gameID to its frames file as they are
+// streamed, one JSON object per line, until the game ends, the engine
+// closes the stream or ctx is done. It returns the number of frames
+// recorded.
+func (c *Client) Record(ctx context.Context, gameID string) (int, error) {
+	ws, err := dial(ctx, strings.ReplaceAll(c.URL, "{game}", url.PathEscape(gameID)))
+	if err != nil {
+		return 0, err
+	}
+	defer ws.close()
+
+	if err := os.MkdirAll(filepath.Dir(c.Path(gameID)), 0755); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(c.Path(gameID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	frames := 0
+	var line bytes.Buffer
+	for {
+		msg, err := ws.read()
+		if errors.Is(err, io.EOF) {
+			return frames, nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return frames, ctx.Err()
+			}
+			return frames, err
+		}
+		var event Event
+		if err := json.Unmarshal(msg, &event); err != nil {
+			return frames, fmt.Errorf("spectate: %s: %v", gameID, err)
+		}
+		line.Reset()
+		json.Compact(&line, msg)
+		line.WriteByte('\n')
+		if _, err := f.Write(line.Bytes()); err != nil {
+			return frames, err
+		}
+		switch event.Type {
+		case "frame":
+			frames++
+		case "game_end":
+			return frames, nil
+		}
+	}
+}
+
+// Event is a message of the engine's event stream. Data is a Frame for
+// events of type "frame".
+type Event struct {
+	Type string          `json:"Type"`
+	Data json.RawMessage `json:"Data"`
+}
+
+// Frame is the board the engine played at a turn.
+type Frame struct {
+	Turn    int         `json:"Turn"`
+	Snakes  []Snake     `json:"Snakes"`
+	Food    []api.Coord `json:"Food"`
+	Hazards []api.Coord `json:"Hazards"`
+}
+
+// Snake is a snake in a Frame, eliminated ones included.
+type Snake struct {
+	ID      string      `json:"ID"`
+	Name    string      `json:"Name"`
+	Body    []api.Coord `json:"Body"`
+	Health  int32       `json:"Health"`
+	Latency string      `json:"Latency"`
+	Death   *Death      `json:"Death"`
+}
+
+// Death is how and when a snake was eliminated.
+type Death struct {
+	Cause        string `json:"Cause"`
+	Turn         int    `json:"Turn"`
+	EliminatedBy string `json:"EliminatedBy"`
+}
+
+// Load reads the frames recorded in the frames file at path, in order.
+func Load(path string) ([]Frame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var frames []Frame
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, maxMessage)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, err
+		}
+		if event.Type != "frame" {
+			continue
+		}
+		var frame Frame
+		if err := json.Unmarshal(event.Data, &frame); err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+	}
+	return frames, scanner.Err()
+}
+
+// Discrepancy is a difference between the board we were sent at a turn and
+// the engine's frame of it.
+type Discrepancy struct {
+	Turn int `json:"turn"`
+	// Snake is the ID of the snake that differs, or "" for the board.
+	Snake  string `json:"snake,omitempty"`
+	Field  string `json:"field"`
+	Ours   string `json:"ours"`
+	Engine string `json:"engine"`
+}
+
+// Compare returns how the boards recorded in turns differ from the engine's
+// frames of the same turns. Turns without a frame are skipped.
+func Compare(frames []Frame, turns []history.Turn) []Discrepancy {
+	byTurn := make(map[int]Frame, len(frames))
+	for _, f := range frames {
+		byTurn[f.Turn] = f
+	}
+	var diffs []Discrepancy
+	for _, t := range turns {
+		frame, ok := byTurn[t.Turn]
+		if !ok {
+			continue
+		}
+		diffs = append(diffs, compareTurn(frame, t.Request)...)
+	}
+	return diffs
+}
+
+// compareTurn compares the board sent to us with the engine's frame.
+func compareTurn(frame Frame, request api.GameRequest) []Discrepancy {
+	var diffs []Discrepancy
+	differ := func(snake, field string, ours, engine any) {
+		diffs = append(diffs, Discrepancy{
+			Turn:   frame.Turn,
+			Snake:  snake,
+			Field:  field,
+			Ours:   fmt.Sprint(ours),
+			Engine: fmt.Sprint(engine),
+		})
+	}
+
+	if !sameCells(request.Board.Food, frame.Food) {
+		differ("", "food", request.Board.Food, frame.Food)
+	}
+	if !sameCells(request.Board.Hazards, frame.Hazards) {
+		differ("", "hazards", request.Board.Hazards, frame.Hazards)
+	}
+
+	ours := make(map[string]api.Battlesnake, len(request.Board.Snakes))
+	for _, s := range request.Board.Snakes {
+		ours[s.ID] = s
+	}
+	known := make(map[string]bool, len(frame.Snakes))
+	for _, engine := range frame.Snakes {
+		known[engine.ID] = true
+		s, onBoard := ours[engine.ID]
+		alive := engine.Death == nil
+		switch {
+		case alive && !onBoard:
+			differ(engine.ID, "eliminated", true, false)
+		case !alive && onBoard:
+			differ(engine.ID, "eliminated", false, true)
+		case alive:
+			if s.Health != engine.Health {
+				differ(engine.ID, "health", s.Health, engine.Health)
+			}
+			if !slices.Equal(s.Body, engine.Body) {
+				differ(engine.ID, "body", s.Body, engine.Body)
+			}
+		}
+	}
+	for _, s := range request.Board.Snakes {
+		if !known[s.ID] {
+			differ(s.ID, "snake", "on board", "unknown")
+		}
+	}
+	return diffs
+}
+
+// sameCells reports whether a and b hold the same cells as many times each,
+// in any order.
+func sameCells(a, b []api.Coord) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[api.Coord]int, len(a))
+	for _, c := range a {
+		count[c]++
+	}
+	for _, c := range b {
+		if count[c]--; count[c] < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,16 @@
+package spectate
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPathStaysInDir(t *testing.T) {
+	c := &Client{Dir: "games"}
+	for _, gameID := range []string{"g1", "../../escaped", "a/b", "..", ""} {
+		path := c.Path(gameID)
+		if filepath.Dir(filepath.Dir(path)) != c.Dir || filepath.Base(path) != FileName {
+			t.Errorf("Path(%q) = %q, want %s in a directory of %q", gameID, path, FileName, c.Dir)
+		}
+	}
+}
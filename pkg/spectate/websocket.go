@@ -0,0 +1,227 @@
+package spectate
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// The engine's event stream is read over a minimal websocket client
+// (RFC 6455): it reads text and binary messages, answers pings and closes,
+// and never sends data of its own.
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// maxMessage bounds the size of a message, so a broken stream can't make
+// us buffer without end.
+const maxMessage = 16 << 20
+
+// acceptGUID is appended to the handshake key to derive the accept header.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// conn is a client websocket connection.
+type conn struct {
+	c    net.Conn
+	r    *bufio.Reader
+	stop func() bool
+}
+
+// dial opens a websocket connection to a ws:// or wss:// URL. The
+// connection is closed when ctx is done.
+func dial(ctx context.Context, rawURL string) (*conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	var nc net.Conn
+	switch u.Scheme {
+	case "ws":
+		var d net.Dialer
+		nc, err = d.DialContext(ctx, "tcp", hostPort(u, "80"))
+	case "wss":
+		d := tls.Dialer{Config: &tls.Config{ServerName: u.Hostname()}}
+		nc, err = d.DialContext(ctx, "tcp", hostPort(u, "443"))
+	default:
+		return nil, fmt.Errorf("spectate: unsupported scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { nc.Close() })
+
+	c := &conn{c: nc, r: bufio.NewReader(nc), stop: stop}
+	if err := c.handshake(u); err != nil {
+		c.close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func hostPort(u *url.URL, port string) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// handshake upgrades the connection to a websocket.
+func (c *conn) handshake(u *url.URL) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			"Upgrade":               {"websocket"},
+			"Connection":            {"Upgrade"},
+			"Sec-WebSocket-Key":     {key},
+			"Sec-WebSocket-Version": {"13"},
+		},
+		Host: u.Host,
+	}
+	if err := req.Write(c.c); err != nil {
+		return err
+	}
+	resp, err := http.ReadResponse(c.r, req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return fmt.Errorf("spectate: %s: %s", u.Redacted(), resp.Status)
+	}
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		return fmt.Errorf("spectate: %s: bad Sec-WebSocket-Accept", u.Redacted())
+	}
+	return nil
+}
+
+// read returns the next data message, answering control frames on the way.
+// It returns io.EOF once the server closes the connection.
+func (c *conn) read() ([]byte, error) {
+	var msg []byte
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			// Echo the status code back, as the protocol asks.
+			if len(payload) > 2 {
+				payload = payload[:2]
+			}
+			c.writeFrame(opClose, payload)
+			return nil, io.EOF
+		case opText, opBinary, opContinuation:
+		default:
+			return nil, fmt.Errorf("spectate: unknown opcode %#x", op)
+		}
+		if len(msg)+len(payload) > maxMessage {
+			return nil, fmt.Errorf("spectate: message over %d bytes", maxMessage)
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+// readFrame reads one frame, unmasking its payload if the server masked it.
+func (c *conn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	op = header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	n := uint64(header[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > maxMessage {
+		return false, 0, nil, fmt.Errorf("spectate: frame of %d bytes", n)
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, op, payload, nil
+}
+
+// writeFrame writes a control frame, masked as client frames must be.
+func (c *conn) writeFrame(op byte, payload []byte) error {
+	if len(payload) > 125 {
+		return errors.New("spectate: control frame payload too long")
+	}
+	frame := make([]byte, 6+len(payload))
+	frame[0] = 0x80 | op
+	frame[1] = 0x80 | byte(len(payload))
+	if _, err := rand.Read(frame[2:6]); err != nil {
+		return err
+	}
+	for i, b := range payload {
+		frame[6+i] = b ^ frame[2+i%4]
+	}
+	_, err := c.c.Write(frame)
+	return err
+}
+
+func (c *conn) close() error {
+	c.stop()
+	return c.c.Close()
+}
@@ -0,0 +1,218 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Redis is a Store kept in Redis, so that replicas behind a load balancer
+// share game state. Games are stored as JSON and expire after TTL in case
+// their /end never arrives.
+type Redis struct {
+	Addr string
+	// Username is the ACL user authenticated as with Password, or empty
+	// for the default user.
+	Username string
+	Password string
+	DB       int
+	// Prefix is prepended to game IDs to form keys.
+	Prefix string
+	TTL    time.Duration
+	// Timeout bounds each round trip to Redis.
+	Timeout time.Duration
+
+	conns chan *redisConn
+}
+
+// NewRedis returns a Redis store for a URL of the form
+// redis://[[username]:password@]host[:port][/db].
+func NewRedis(rawURL string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("store: unsupported scheme %q", u.Scheme)
+	}
+
+	r := &Redis{
+		Addr:    u.Host,
+		Prefix:  "battlesnake:game:",
+		TTL:     time.Hour,
+		Timeout: 50 * time.Millisecond,
+		conns:   make(chan *redisConn, 16),
+	}
+	if u.Port() == "" {
+		r.Addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if password, ok := u.User.Password(); ok {
+		r.Username = u.User.Username()
+		r.Password = password
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if r.DB, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("store: bad database %q", db)
+		}
+	}
+	return r, nil
+}
+
+func (r *Redis) Get(id string) (Game, bool, error) {
+	reply, err := r.do("GET", r.Prefix+id)
+	if err != nil || reply == nil {
+		return Game{}, false, err
+	}
+	b, ok := reply.([]byte)
+	if !ok {
+		return Game{}, false, fmt.Errorf("redis: unexpected reply %v to GET", reply)
+	}
+	var game Game
+	if err := json.Unmarshal(b, &game); err != nil {
+		return Game{}, false, err
+	}
+	return game, true, nil
+}
+
+func (r *Redis) Put(game Game) error {
+	b, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+	_, err = r.do("SET", r.Prefix+game.ID, string(b), "PX", strconv.FormatInt(r.TTL.Milliseconds(), 10))
+	return err
+}
+
+func (r *Redis) Delete(id string) error {
+	_, err := r.do("DEL", r.Prefix+id)
+	return err
+}
+
+// do runs a command on a pooled connection and returns its reply: nil, a
+// string for status replies, an int64, []byte for bulk strings or
+// []interface{} for arrays.
+func (r *Redis) do(args ...string) (interface{}, error) {
+	c, err := r.conn()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := c.do(r.Timeout, args...)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		// The connection may be mid-reply; don't reuse it.
+		c.Close()
+		return nil, err
+	}
+	select {
+	case r.conns <- c:
+	default:
+		c.Close()
+	}
+	return reply, err
+}
+
+// conn returns an idle pooled connection or dials a new one.
+func (r *Redis) conn() (*redisConn, error) {
+	select {
+	case c := <-r.conns:
+		return c, nil
+	default:
+	}
+
+	nc, err := net.DialTimeout("tcp", r.Addr, r.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+	if r.Password != "" {
+		auth := []string{"AUTH", r.Password}
+		if r.Username != "" {
+			auth = []string{"AUTH", r.Username, r.Password}
+		}
+		if _, err := c.do(r.Timeout, auth...); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if r.DB != 0 {
+		if _, err := c.do(r.Timeout, "SELECT", strconv.Itoa(r.DB)); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// redisError is an error reply from the server.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *redisConn) do(timeout time.Duration, args ...string) (interface{}, error) {
+	c.SetDeadline(time.Now().Add(timeout))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c, b.String()); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+func (c *redisConn) read() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
@@ -0,0 +1,83 @@
+// Package store keeps the state we track about each game between requests.
+package store
+
+import (
+	"sync"
+
+	"github.com/jayuuza/battlesnake/pkg/timing"
+)
+
+// Game is the state remembered about a single game.
+type Game struct {
+	ID string `json:"id"`
+	// Strategy is the name of the strategy chosen for the game.
+	Strategy string `json:"strategy"`
+	// Personality is the name of the personality chosen for the game.
+	Personality string `json:"personality"`
+	// Experiment and Arm identify the A/B experiment arm the game was
+	// assigned to, if any.
+	Experiment string `json:"experiment,omitempty"`
+	Arm        string `json:"arm,omitempty"`
+	// Opponents are the names of the other snakes at the start of the game.
+	Opponents []string `json:"opponents,omitempty"`
+	// Shouts holds the last shout seen from each opponent, keyed by snake
+	// ID.
+	Shouts map[string]string `json:"shouts,omitempty"`
+	// Latency tracks the network overhead of the game's requests.
+	Latency timing.Estimate `json:"latency"`
+	// Live is our snake's state as of the last move, for spectators and
+	// to answer a retried move the same.
+	Live Live `json:"live"`
+}
+
+// Live is our snake's state as of the last move played in a game.
+type Live struct {
+	Turn   int    `json:"turn"`
+	Health int    `json:"health"`
+	Length int    `json:"length"`
+	Move   string `json:"move"`
+	Shout  string `json:"shout,omitempty"`
+	// Plan describes what the strategy intended with the move.
+	Plan string `json:"plan,omitempty"`
+}
+
+// Store persists Game state keyed by game ID. Implementations must be safe
+// for concurrent use.
+type Store interface {
+	// Get returns the game with the given ID, or false if it isn't stored.
+	Get(id string) (Game, bool, error)
+	Put(game Game) error
+	Delete(id string) error
+}
+
+// Memory is a Store held in process memory.
+type Memory struct {
+	mu    sync.RWMutex
+	games map[string]Game
+}
+
+// NewMemory returns an empty in-memory Store.
+func NewMemory() *Memory {
+	return &Memory{games: map[string]Game{}}
+}
+
+func (m *Memory) Get(id string) (Game, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	game, ok := m.games[id]
+	return game, ok, nil
+}
+
+func (m *Memory) Put(game Game) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.games[game.ID] = game
+	return nil
+}
+
+func (m *Memory) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.games, id)
+	return nil
+}
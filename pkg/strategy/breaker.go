@@ -0,0 +1,84 @@
+package strategy
+
+import (
+	"context"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/metrics"
+)
+
+// Breaker plays Primary until it overruns its soft budget on Trips
+// consecutive turns, then plays Fallback for the rest of the game, so one
+// pathological position can't cause a run of timeouts.
+type Breaker struct {
+	Primary  Strategy
+	Fallback Strategy
+	// Trips is the number of consecutive overruns that trips the breaker.
+	Trips int
+	// SoftBudget is the fraction (0-1) of the time left before the move
+	// deadline that Primary may use without counting as an overrun.
+	SoftBudget float64
+	// OnTrip, when set, is called with the position on which the breaker
+	// trips.
+	OnTrip func(game api.GameRequest)
+
+	overruns int
+	tripped  bool
+}
+
+// NewBreaker wraps primary in a Breaker falling back to the strategy
+// registered as fallback.
+func NewBreaker(primary Strategy, fallback string, trips int, softBudget float64) (*Breaker, error) {
+	fb, err := New(fallback)
+	if err != nil {
+		return nil, err
+	}
+	return &Breaker{
+		Primary:    primary,
+		Fallback:   fb,
+		Trips:      trips,
+		SoftBudget: softBudget,
+	}, nil
+}
+
+func (b *Breaker) Start(ctx context.Context, game api.GameRequest) {
+	b.Primary.Start(ctx, game)
+	b.Fallback.Start(ctx, game)
+}
+
+func (b *Breaker) Move(ctx context.Context, game api.GameRequest) api.MoveResponse {
+	if b.tripped {
+		metrics.FallbackMoves.Add(1)
+		return b.Fallback.Move(ctx, game)
+	}
+
+	start := time.Now()
+	move := b.Primary.Move(ctx, game)
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return move
+	}
+
+	soft := time.Duration(float64(deadline.Sub(start)) * b.SoftBudget)
+	if time.Since(start) <= soft {
+		b.overruns = 0
+		return move
+	}
+	b.overruns++
+	if b.overruns >= b.Trips {
+		b.tripped = true
+		metrics.BreakerTrips.Add(1)
+		if b.OnTrip != nil {
+			b.OnTrip(game)
+		}
+		logger.WarnContext(ctx, "over soft budget, falling back for the rest of the game",
+			"game", game.Game.ID, "turn", game.Turn, "overruns", b.overruns)
+	}
+	return move
+}
+
+func (b *Breaker) End(ctx context.Context, game api.GameRequest) {
+	b.Primary.End(ctx, game)
+	b.Fallback.End(ctx, game)
+}
@@ -0,0 +1,29 @@
+package strategy
+
+import (
+	"context"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/eval"
+)
+
+func init() {
+	Register("duel", func() Strategy { return &Duel{} })
+}
+
+// Duel plays as Heuristic, but in one-on-one games also weighs the length
+// race: it races for food while it can out-length the opponent before the
+// board fills, and otherwise squeezes the opponent for space.
+type Duel struct {
+	Heuristic
+}
+
+// duelWeight is the weight of the length race against the heuristic terms.
+const duelWeight = 2
+
+func (d *Duel) Move(ctx context.Context, game api.GameRequest) api.MoveResponse {
+	if len(game.Board.Snakes) != 2 {
+		return d.Heuristic.Move(ctx, game)
+	}
+	return d.move(ctx, game, eval.Term{Name: "duel", Weight: duelWeight, Score: eval.Duel})
+}
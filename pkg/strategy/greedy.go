@@ -0,0 +1,53 @@
+package strategy
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/personality"
+)
+
+func init() {
+	Register("greedy", func() Strategy { return Greedy{} })
+}
+
+// Greedy follows the shortest path to the nearest food, or to healing
+// hazards once health runs low, only ever taking moves that don't
+// immediately kill us. It crosses damaging hazards only when it would leave
+// them with at least hazardMargin health, scaled down for personalities
+// that take more risks. It is cheap enough to serve as the fallback when a
+// stronger strategy runs out of time.
+type Greedy struct {
+	NopHooks
+}
+
+// lowHealth is the health below which Greedy will settle for healing
+// instead of food.
+const lowHealth = 40
+
+// hazardMargin is the health a neutral personality insists on keeping when
+// crossing hazards.
+const hazardMargin = 20
+
+func (Greedy) Move(ctx context.Context, game api.GameRequest) api.MoveResponse {
+	minHealth := hazardMargin
+	if risk := personality.FromContext(ctx).Risk; risk > 0 {
+		minHealth = int(hazardMargin / risk)
+	}
+	grid := board.GridFor(game)
+	goal := grid.IsFood
+	if game.You.Health < lowHealth {
+		goal = func(pos api.Coord) bool { return grid.IsFood(pos) || grid.IsHealing(pos) }
+	}
+	if route := grid.HealthPath(game.You.Head, int(game.You.Health), minHealth, goal); route.Found() {
+		return api.MoveResponse{Move: route.Moves[0]}
+	}
+
+	possibleMoves := grid.ValidMoves(game.You.Head)
+	if len(possibleMoves) == 0 {
+		return RandomMove()
+	}
+	return api.MoveResponse{Move: possibleMoves[rand.Intn(len(possibleMoves))]}
+}
@@ -0,0 +1,152 @@
+package strategy
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"math/rand"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/arena"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/eval"
+	"github.com/jayuuza/battlesnake/pkg/opponent"
+	"github.com/jayuuza/battlesnake/pkg/personality"
+	"github.com/jayuuza/battlesnake/pkg/search"
+)
+
+func init() {
+	Register("heuristic", func() Strategy { return &Heuristic{} })
+}
+
+// Heuristic takes any forced kill it finds, and otherwise plays the move
+// that doesn't immediately kill us with the best evaluation, breaking ties
+// at random, never moving where an enemy at least as long as us could meet
+// our head unless it must. How deep it verifies kills adapts to the search
+// throughput measured on earlier turns of the game.
+//
+// Once health drops below the panic threshold (SetPanicHealth) it panics
+// until it has recovered well above it: it heads straight for food,
+// crossing hazards and contesting food it would otherwise leave, and
+// weighs food above everything else when it can't.
+type Heuristic struct {
+	NopHooks
+
+	throughput search.Throughput
+	// panic is set while the game is played in panic.
+	panic bool
+}
+
+func (h *Heuristic) Move(ctx context.Context, game api.GameRequest) api.MoveResponse {
+	return h.move(ctx, game)
+}
+
+// move plays as Move, adding the given terms to the evaluation.
+func (h *Heuristic) move(ctx context.Context, game api.GameRequest, extra ...eval.Term) api.MoveResponse {
+	if move, ok := search.ForcedKill(ctx, game, &h.throughput); ok {
+		SetPlan(ctx, "forced kill")
+		return api.MoveResponse{Move: move}
+	}
+
+	grid := board.GridFor(game)
+	grid.Scratch = arena.FromContext(ctx)
+	possibleMoves := grid.ValidMoves(game.You.Head)
+	if len(possibleMoves) == 0 {
+		return RandomMove()
+	}
+
+	risk := personality.FromContext(ctx).Risk
+	// Counter the style of play of the nearest opponent we have profiled.
+	var (
+		archetype  opponent.Archetype
+		counter    opponent.Counter
+		countering bool
+	)
+	if model := opponent.FromContext(ctx); model != nil {
+		if archetype, counter, countering = model.Counter(game); countering {
+			risk *= counter.Risk
+		}
+	}
+	cache := eval.NewCache(game, grid)
+	possibleMoves = avoidLethal(possibleMoves, game.You.Head, grid, cache.Danger())
+	scale := counter.Weights
+	if h.panic = panicking(ctx, h.panic, game.You.Health); h.panic {
+		if move, ok := panicMove(game, grid, cache, risk); ok {
+			SetPlan(ctx, "panicking for food")
+			return api.MoveResponse{Move: move}
+		}
+		scale = maps.Clone(scale)
+		if scale == nil {
+			scale = map[string]float64{}
+		}
+		scale["food"] = max(scale["food"], 1) * panicFood
+		scale["bait"] = 0
+		risk *= panicRisk
+	}
+	weights := eval.FromContext(ctx)
+	position := func(move api.Direction) *eval.Position {
+		p := eval.NewPosition(cache, move)
+		if risk > 0 {
+			p.Risk = risk
+		}
+		p.Scale = scale
+		p.Weights = weights
+		return p
+	}
+	var best []api.Direction
+	bestScore := 0.0
+	for _, move := range possibleMoves {
+		p := position(move)
+		score := eval.Evaluate(p)
+		for _, t := range extra {
+			score += t.Weight * t.Score(p)
+		}
+		if logger.Enabled(ctx, slog.LevelDebug) {
+			logger.DebugContext(ctx, "evaluated", "game", game.Game.ID, "turn", game.Turn, "move", move,
+				"score", score, "terms", eval.Breakdown(p))
+		}
+		switch {
+		case best == nil || score > bestScore:
+			best, bestScore = []api.Direction{move}, score
+		case score == bestScore:
+			best = append(best, move)
+		}
+	}
+	move := best[rand.Intn(len(best))]
+	if Planning(ctx) {
+		plan := "playing for " + leadingTerm(eval.Breakdown(position(move)))
+		if countering {
+			plan += ", countering " + string(archetype) + " play"
+		}
+		SetPlan(ctx, plan)
+	}
+	return api.MoveResponse{Move: move}
+}
+
+// avoidLethal returns the moves that don't take our head to a cell an enemy
+// at least as long as us could move to and survive, so that no weighing of
+// terms can walk us into a losing head-to-head, or every move if all do.
+func avoidLethal(moves []api.Direction, head api.Coord, grid *board.Grid, danger *board.DangerMap) []api.Direction {
+	safe := make([]api.Direction, 0, len(moves))
+	for _, move := range moves {
+		if !danger.IsLethal(grid.Step(head, move)) {
+			safe = append(safe, move)
+		}
+	}
+	if len(safe) == 0 {
+		return moves
+	}
+	return safe
+}
+
+// leadingTerm returns the name of the term contributing most to a
+// position's evaluation, given its breakdown.
+func leadingTerm(breakdown map[string]float64) string {
+	name, best := "", 0.0
+	for term, score := range breakdown {
+		if name == "" || score > best || score == best && term < name {
+			name, best = term, score
+		}
+	}
+	return name
+}
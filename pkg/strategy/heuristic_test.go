@@ -0,0 +1,61 @@
+package strategy_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// loadPosition reads the hand-drawn position name from testdata.
+func loadPosition(t *testing.T, name string) api.GameRequest {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	game, err := board.ParseASCII(string(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return game
+}
+
+// TestAvoidsLethal checks that strategies never move where an enemy at
+// least as long as us could meet our head when they have another move,
+// however well the rest of the evaluation scores it. Ties are broken at
+// random, so each position is played a number of times.
+func TestAvoidsLethal(t *testing.T) {
+	tests := []struct {
+		strategy string
+		fixture  string
+	}{
+		// Right is the most open move, but the length 5 snake's head
+		// is next to it.
+		{"heuristic", "lethal.txt"},
+		// Right squeezes the opponent most, but its head is two cells
+		// away and it is longer.
+		{"duel", "duel_lethal.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy+"/"+tt.fixture, func(t *testing.T) {
+			game := loadPosition(t, tt.fixture)
+			grid := board.GridFor(game)
+			danger := board.NewDangerMap(game)
+			for range 20 {
+				s, err := strategy.New(tt.strategy)
+				if err != nil {
+					t.Fatal(err)
+				}
+				move := s.Move(context.Background(), game).Move
+				if next := grid.Step(game.You.Head, move); danger.IsLethal(next) {
+					t.Fatalf("moved %s into %v, which a longer enemy can take", move, next)
+				}
+			}
+		})
+	}
+}
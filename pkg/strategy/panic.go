@@ -0,0 +1,87 @@
+package strategy
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+	"github.com/jayuuza/battlesnake/pkg/eval"
+)
+
+// DefaultPanicHealth is the health below which Heuristic panics unless
+// SetPanicHealth changes it.
+const DefaultPanicHealth = 15
+
+const (
+	// panicRelease is how much health above the panic threshold we must
+	// regain to calm down, so that health hovering around the threshold
+	// doesn't flip us in and out of panic every turn.
+	panicRelease = 15
+	// panicFood is the factor on the food term's weight while panicking,
+	// enough for it to outweigh every other term. The bait term, which
+	// keeps us off food a longer enemy contests, is dropped.
+	panicFood = 20
+	// panicRisk is the factor on our risk tolerance while panicking.
+	panicRisk = 2
+)
+
+var panicHealth atomic.Int32
+
+func init() {
+	panicHealth.Store(DefaultPanicHealth)
+}
+
+// SetPanicHealth sets the health below which Heuristic panics, or disables
+// panicking if it is 0.
+func SetPanicHealth(health int) {
+	panicHealth.Store(int32(health))
+}
+
+type panicHealthKey struct{}
+
+// WithPanicHealth returns a context in which Heuristic panics below health
+// instead of the threshold SetPanicHealth set, or never if it is 0, so that
+// games in one process can be played with different thresholds.
+func WithPanicHealth(ctx context.Context, health int) context.Context {
+	return context.WithValue(ctx, panicHealthKey{}, int32(health))
+}
+
+// panicking reports whether we are in panic at health in ctx, given whether
+// we were on the turn before.
+func panicking(ctx context.Context, was bool, health int32) bool {
+	threshold, ok := ctx.Value(panicHealthKey{}).(int32)
+	if !ok {
+		threshold = panicHealth.Load()
+	}
+	if was {
+		return health < threshold+panicRelease
+	}
+	return health < threshold
+}
+
+// panicMove returns the first move towards the nearest food or healing
+// cell, crossing hazards down to the margin our raised risk tolerance
+// allows and whoever else is after the food, unless it leads into a space
+// too small for us or a cell an enemy at least as long as us could take.
+func panicMove(game api.GameRequest, grid *board.Grid, cache *eval.Cache, risk float64) (api.Direction, bool) {
+	if risk <= 0 {
+		risk = 1
+	}
+	minHealth := int(hazardMargin / (risk * panicRisk))
+	goal := func(pos api.Coord) bool { return grid.IsFood(pos) || grid.IsHealing(pos) }
+	route := grid.HealthPath(game.You.Head, int(game.You.Health), max(minHealth, 1), goal)
+	if !route.Found() {
+		return 0, false
+	}
+	move := route.Moves[0]
+	if !slices.Contains(grid.ValidMoves(game.You.Head), move) {
+		return 0, false
+	}
+	next := grid.Step(game.You.Head, move)
+	if cache.Danger().IsLethal(next) || !goal(next) && cache.Reachable(next) < int(game.You.Length) {
+		return 0, false
+	}
+	return move, true
+}
@@ -0,0 +1,47 @@
+package strategy
+
+import (
+	"context"
+	"sync"
+)
+
+// Plan holds a short description of what a strategy intends with the move
+// it is deciding, such as "forced kill", for showing to spectators. It is
+// safe for concurrent use.
+type Plan struct {
+	mu   sync.Mutex
+	text string
+}
+
+// String returns the description set last, or "" if none was.
+func (p *Plan) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.text
+}
+
+type planKey struct{}
+
+// WithPlan returns a context that collects the plan described by the
+// strategy deciding a move with it into the returned Plan.
+func WithPlan(ctx context.Context) (context.Context, *Plan) {
+	p := &Plan{}
+	return context.WithValue(ctx, planKey{}, p), p
+}
+
+// Planning reports whether ctx collects a plan, so strategies can skip
+// working out a description nobody will read.
+func Planning(ctx context.Context) bool {
+	_, ok := ctx.Value(planKey{}).(*Plan)
+	return ok
+}
+
+// SetPlan describes the plan for the move being decided with ctx, if ctx
+// collects one.
+func SetPlan(ctx context.Context, text string) {
+	if p, ok := ctx.Value(planKey{}).(*Plan); ok {
+		p.mu.Lock()
+		p.text = text
+		p.mu.Unlock()
+	}
+}
@@ -0,0 +1,30 @@
+package strategy
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+)
+
+func init() {
+	Register("random", func() Strategy { return Random{} })
+}
+
+// Random picks a random move that doesn't immediately kill us, falling back
+// to any random move when there is none.
+type Random struct {
+	NopHooks
+}
+
+func (Random) Move(ctx context.Context, game api.GameRequest) api.MoveResponse {
+	possibleMoves := board.GridFor(game).ValidMoves(game.You.Head)
+	if len(possibleMoves) == 0 {
+		return RandomMove()
+	}
+
+	return api.MoveResponse{
+		Move: possibleMoves[rand.Intn(len(possibleMoves))],
+	}
+}
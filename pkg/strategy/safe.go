@@ -0,0 +1,31 @@
+package strategy
+
+import (
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+)
+
+// SafeMove returns a move chosen in well under a millisecond for when there
+// is no time to think: among the moves that don't immediately kill us, it
+// avoids cells an equal or longer enemy could take and then prefers the
+// most open space, up to our length.
+func SafeMove(game api.GameRequest) api.MoveResponse {
+	grid := board.GridFor(game)
+	danger := board.NewDangerMap(game)
+	best, bestScore := api.Direction(0), -1
+	found := false
+	for _, move := range grid.ValidMoves(game.You.Head) {
+		next := grid.Step(game.You.Head, move)
+		score := grid.Reachable(next, int(game.You.Length), nil)
+		if !danger.IsLethal(next) {
+			score += int(game.You.Length) + 1
+		}
+		if !found || score > bestScore {
+			best, bestScore, found = move, score, true
+		}
+	}
+	if !found {
+		return RandomMove()
+	}
+	return api.MoveResponse{Move: best}
+}
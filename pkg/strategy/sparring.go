@@ -0,0 +1,57 @@
+package strategy
+
+import (
+	"context"
+	"slices"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/board"
+)
+
+func init() {
+	Register("sparring", func() Strategy { return Sparring{} })
+}
+
+// Sparring is a deliberately simple sparring partner: it copies the last
+// move of the nearest opponent whenever that move is safe for us, and
+// otherwise plays as Greedy. It is a predictable baseline opponent to play
+// stronger strategies against, and the smallest complete example of a
+// strategy: it keeps no state between turns, so NopHooks provides its
+// Start and End, and everything it needs is in the request.
+type Sparring struct {
+	NopHooks
+}
+
+func (Sparring) Move(ctx context.Context, game api.GameRequest) api.MoveResponse {
+	grid := board.GridFor(game)
+	if move, ok := lastMove(nearestOpponent(game, grid)); ok &&
+		slices.Contains(grid.ValidMoves(game.You.Head), move) {
+		return api.MoveResponse{Move: move}
+	}
+	return Greedy{}.Move(ctx, game)
+}
+
+// nearestOpponent returns the opponent whose head is closest to ours, or a
+// zero snake if we are alone.
+func nearestOpponent(game api.GameRequest, grid *board.Grid) api.Battlesnake {
+	var nearest api.Battlesnake
+	best := -1
+	for _, snake := range game.Board.Snakes {
+		if snake.ID == game.You.ID {
+			continue
+		}
+		if d := grid.Distance(snake.Head, game.You.Head); best < 0 || d < best {
+			nearest, best = snake, d
+		}
+	}
+	return nearest
+}
+
+// lastMove returns the move snake made last turn, from its neck to its
+// head, reporting false before its first move or across a wrapped edge.
+func lastMove(snake api.Battlesnake) (api.Direction, bool) {
+	if len(snake.Body) < 2 {
+		return 0, false
+	}
+	return snake.Body[1].DirectionTo(snake.Body[0])
+}
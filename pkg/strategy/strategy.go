@@ -0,0 +1,81 @@
+// Package strategy decides which way our Battlesnake moves.
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/logging"
+)
+
+// Strategy decides our moves for a single game. Start is called once before
+// the first Move and End once after the last, so implementations may keep
+// per-game state between calls. The context passed to Move carries the
+// deadline by which the move must be decided.
+type Strategy interface {
+	Start(ctx context.Context, game api.GameRequest)
+	Move(ctx context.Context, game api.GameRequest) api.MoveResponse
+	End(ctx context.Context, game api.GameRequest)
+}
+
+var logger = logging.For(logging.Strategy)
+
+// Factory creates a fresh Strategy for a game.
+type Factory func() Strategy
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a strategy available under name. It panics if name is
+// already registered.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic("strategy: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// New creates the strategy registered under name.
+func New(name string) (Strategy, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("strategy: unknown strategy %q", name)
+	}
+	return factory(), nil
+}
+
+// Names returns the names of all registered strategies in sorted order.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NopHooks provides no-op Start and End methods for strategies that don't
+// keep per-game state.
+type NopHooks struct{}
+
+func (NopHooks) Start(ctx context.Context, game api.GameRequest) {}
+func (NopHooks) End(ctx context.Context, game api.GameRequest)   {}
+
+// RandomMove Chooses a random direction to move in
+func RandomMove() api.MoveResponse {
+	return api.MoveResponse{
+		Move: api.Directions[rand.Intn(len(api.Directions))],
+	}
+}
@@ -0,0 +1,143 @@
+// Package timing decides how long strategies may think about each move.
+//
+// The engine reports, on every request, the round trip it measured for our
+// previous response. Subtracting the time we spent answering that response,
+// from its arrival, leaves the network overhead, which is tracked per game
+// and taken out of the next move's budget, together with the time the
+// request waited with us before its move began to be decided.
+//
+// Arrival times also bound the network overhead without the engine's help:
+// the time from our answer to one turn until the next turn's request
+// arrives covers the way back to the engine, the way out again and
+// whatever the engine did in between, so its smallest value over the game
+// is an upper bound of the round trip, used until the engine reports one.
+package timing
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Estimate is the measured overhead of a game. It is stored with the game
+// between requests, so its fields are plain milliseconds.
+type Estimate struct {
+	// OverheadMs is a moving average of the engine-reported latency minus
+	// our own compute time.
+	OverheadMs float64 `json:"overheadMs"`
+	// Samples is the number of turns OverheadMs is based on.
+	Samples int `json:"samples"`
+	// LastComputeMs is how long we took to answer the previous move, from
+	// the arrival of its request.
+	LastComputeMs float64 `json:"lastComputeMs"`
+	// LastTurn and LastArrivalMs are the turn of the previous move and the
+	// Unix time in milliseconds its request arrived at.
+	LastTurn      int   `json:"lastTurn"`
+	LastArrivalMs int64 `json:"lastArrivalMs,omitempty"`
+	// GapMs is the smallest time between our answer to a turn and the
+	// arrival of the next, over GapSamples consecutive turns.
+	GapMs      float64 `json:"gapMs,omitempty"`
+	GapSamples int     `json:"gapSamples,omitempty"`
+}
+
+// smoothing is the weight of the newest sample in the moving average.
+const smoothing = 0.3
+
+// Observe folds the latency the engine reported for our previous response
+// into the estimate. latency is the raw "latency" field of our snake, which
+// is empty or "0" when there was no previous response.
+func (e *Estimate) Observe(latency string) {
+	ms, err := strconv.ParseFloat(latency, 64)
+	if err != nil || ms <= 0 {
+		return
+	}
+	overhead := ms - e.LastComputeMs
+	if overhead < 0 {
+		overhead = 0
+	}
+	if e.Samples == 0 {
+		e.OverheadMs = overhead
+	} else {
+		e.OverheadMs += smoothing * (overhead - e.OverheadMs)
+	}
+	e.Samples++
+}
+
+// ObserveArrival records that the request of turn arrived at t, bounding
+// the overhead by the gap since our answer to the turn before.
+func (e *Estimate) ObserveArrival(turn int, t time.Time) {
+	arrival := t.UnixMilli()
+	if e.LastArrivalMs > 0 && turn == e.LastTurn+1 {
+		gap := float64(arrival-e.LastArrivalMs) - e.LastComputeMs
+		if gap >= 0 {
+			if e.GapSamples == 0 {
+				e.GapMs = gap
+			}
+			e.GapMs = math.Min(e.GapMs, gap)
+			e.GapSamples++
+		}
+	}
+	e.LastTurn, e.LastArrivalMs = turn, arrival
+}
+
+// Overhead returns the estimated overhead: the one the engine's reports
+// measure if any, otherwise the bound of the arrival gaps, or def if
+// nothing has been measured yet.
+func (e Estimate) Overhead(def time.Duration) time.Duration {
+	switch {
+	case e.Samples > 0:
+		return time.Duration(e.OverheadMs * float64(time.Millisecond))
+	case e.GapSamples > 0:
+		return min(time.Duration(e.GapMs*float64(time.Millisecond)), def)
+	}
+	return def
+}
+
+// Manager computes move budgets.
+type Manager struct {
+	// Margin is held back on top of the measured overhead.
+	Margin time.Duration
+	// DefaultOverhead is assumed until latency has been measured.
+	DefaultOverhead time.Duration
+	// MinBudget is the least time a strategy is ever given, however large
+	// the overhead.
+	MinBudget time.Duration
+	// Grace is how long past its budget a strategy may run before the
+	// watchdog answers for it. It should be well within Margin.
+	Grace time.Duration
+}
+
+// DefaultManager holds back a conservative overhead until the first
+// latency report arrives.
+var DefaultManager = Manager{
+	Margin:          30 * time.Millisecond,
+	DefaultOverhead: 150 * time.Millisecond,
+	MinBudget:       20 * time.Millisecond,
+	Grace:           10 * time.Millisecond,
+}
+
+// Budget returns how long a strategy may spend on a move in a game with the
+// given timeout in milliseconds, whose request already waited queued
+// before its move began to be decided.
+func (m Manager) Budget(timeoutMs int32, est Estimate, queued time.Duration) time.Duration {
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+	budget := timeout - est.Overhead(m.DefaultOverhead) - m.Margin - queued
+	if budget < m.MinBudget {
+		budget = m.MinBudget
+	}
+	return budget
+}
+
+type arrivalKey struct{}
+
+// WithArrival returns a context carrying the time its request arrived.
+func WithArrival(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, arrivalKey{}, t)
+}
+
+// Arrival returns the time the request of ctx arrived, if known.
+func Arrival(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(arrivalKey{}).(time.Time)
+	return t, ok
+}
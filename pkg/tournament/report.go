@@ -0,0 +1,178 @@
+package tournament
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/selfplay"
+)
+
+// Report is the outcome of a tournament.
+type Report struct {
+	Format   string    `json:"format"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	// Games is the number of games in each match.
+	Games     int        `json:"games"`
+	Standings []Standing `json:"standings"`
+	Matches   []Match    `json:"matches"`
+	// Placings are the players of a double elimination tournament in the
+	// order they went out, the winner last.
+	Placings []string `json:"placings,omitempty"`
+	// Regressions are the players that did worse than in the tournament
+	// before, if compared with one.
+	Regressions []Regression `json:"regressions,omitempty"`
+}
+
+// Standing is how a player did in a tournament.
+type Standing struct {
+	Rank        int    `json:"rank"`
+	Name        string `json:"name"`
+	MatchWins   int    `json:"matchWins"`
+	MatchLosses int    `json:"matchLosses"`
+	Wins        int    `json:"wins"`
+	Losses      int    `json:"losses"`
+	Draws       int    `json:"draws"`
+	// Score is the share of the points the player took in its games, a win
+	// counting one and a draw half.
+	Score float64 `json:"score"`
+}
+
+// Regression is a player whose score fell from one tournament to the next.
+type Regression struct {
+	Name          string  `json:"name"`
+	Score         float64 `json:"score"`
+	PreviousScore float64 `json:"previousScore"`
+	Rank          int     `json:"rank"`
+	PreviousRank  int     `json:"previousRank"`
+}
+
+// Compare sets the report's regressions to the players whose score fell by
+// more than tolerance since prev, a tournament of the same format. It
+// returns them.
+func (r *Report) Compare(prev *Report, tolerance float64) []Regression {
+	r.Regressions = nil
+	if prev == nil || prev.Format != r.Format {
+		return nil
+	}
+	before := map[string]Standing{}
+	for _, s := range prev.Standings {
+		before[s.Name] = s
+	}
+	for _, s := range r.Standings {
+		if p, ok := before[s.Name]; ok && p.Score-s.Score > tolerance {
+			r.Regressions = append(r.Regressions, Regression{
+				Name:          s.Name,
+				Score:         s.Score,
+				PreviousScore: p.Score,
+				Rank:          s.Rank,
+				PreviousRank:  p.Rank,
+			})
+		}
+	}
+	return r.Regressions
+}
+
+// reportPrefix starts the name of every report file, followed by when the
+// tournament started.
+const reportPrefix = "tournament-"
+
+// Save writes the report to dir, named after when the tournament started,
+// and returns the file's path.
+func (r *Report) Save(dir string) (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, reportPrefix+r.Started.Format("20060102T150405.000Z")+".json")
+	return path, os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Latest returns the most recent report saved to dir, or nil if there is
+// none.
+func Latest(dir string) (*Report, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var last string
+	for _, entry := range entries {
+		// The names sort in the order the tournaments started.
+		if name := entry.Name(); strings.HasPrefix(name, reportPrefix) && strings.HasSuffix(name, ".json") && name > last {
+			last = name
+		}
+	}
+	if last == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, last))
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// rank fills in the standings of players from the matches: by placing in
+// double elimination, then by matches won, then by score.
+func (r *Report) rank(players []selfplay.Player) {
+	byName := map[string]*Standing{}
+	for _, p := range players {
+		r.Standings = append(r.Standings, Standing{Name: p.Name})
+	}
+	for i := range r.Standings {
+		byName[r.Standings[i].Name] = &r.Standings[i]
+	}
+	for _, m := range r.Matches {
+		a, b := byName[m.A], byName[m.B]
+		a.Wins += m.WinsA
+		a.Losses += m.WinsB
+		b.Wins += m.WinsB
+		b.Losses += m.WinsA
+		a.Draws += m.Draws
+		b.Draws += m.Draws
+		if m.Winner == m.A {
+			a.MatchWins++
+			b.MatchLosses++
+		} else {
+			b.MatchWins++
+			a.MatchLosses++
+		}
+	}
+	placing := map[string]int{}
+	for i, name := range r.Placings {
+		placing[name] = len(r.Placings) - i
+	}
+	for i := range r.Standings {
+		s := &r.Standings[i]
+		if games := s.Wins + s.Losses + s.Draws; games > 0 {
+			s.Score = (float64(s.Wins) + float64(s.Draws)/2) / float64(games)
+		}
+	}
+	sort.SliceStable(r.Standings, func(i, j int) bool {
+		a, b := r.Standings[i], r.Standings[j]
+		switch {
+		case placing[a.Name] != placing[b.Name]:
+			return placing[a.Name] < placing[b.Name]
+		case a.MatchWins != b.MatchWins:
+			return a.MatchWins > b.MatchWins
+		}
+		return a.Score > b.Score
+	})
+	for i := range r.Standings {
+		r.Standings[i].Rank = i + 1
+	}
+}
@@ -0,0 +1,238 @@
+// Package tournament plays self-play tournaments between strategies on the
+// simulator, round-robin or double elimination, and compares each one with
+// the last to flag strategies that got worse.
+package tournament
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/selfplay"
+)
+
+// Formats of a tournament.
+const (
+	RoundRobin        = "round-robin"
+	DoubleElimination = "double-elimination"
+)
+
+// Tournament is a set of players and how they play each other. Every match
+// is a duel of Games games, won by whoever wins more of them, or survives
+// more turns in total if that is even.
+type Tournament struct {
+	Format   string
+	Players  []selfplay.Player
+	Games    int
+	Settings selfplay.Settings
+	// Parallel is the number of games played at once.
+	Parallel int
+}
+
+// Match is the outcome of a match between two players.
+type Match struct {
+	// Round is the round the match was played in, from 1; in double
+	// elimination Bracket says whether it was between unbeaten players,
+	// once beaten ones, or one of each in the final.
+	Round   int    `json:"round"`
+	Bracket string `json:"bracket,omitempty"`
+	A       string `json:"a"`
+	B       string `json:"b"`
+	WinsA   int    `json:"winsA"`
+	WinsB   int    `json:"winsB"`
+	Draws   int    `json:"draws"`
+	Winner  string `json:"winner"`
+
+	turnsA, turnsB int
+}
+
+// Brackets of a double elimination tournament.
+const (
+	Winners = "winners"
+	Losers  = "losers"
+	Final   = "final"
+)
+
+// Run plays the tournament and returns its report.
+func (t *Tournament) Run(ctx context.Context) (*Report, error) {
+	if len(t.Players) < 2 {
+		return nil, errors.New("tournament: needs at least two players")
+	}
+	report := &Report{Format: t.Format, Started: time.Now().UTC(), Games: t.Games}
+	var err error
+	switch t.Format {
+	case RoundRobin:
+		report.Matches, err = t.roundRobin(ctx)
+	case DoubleElimination:
+		var eliminated []string
+		report.Matches, eliminated, err = t.doubleElimination(ctx)
+		report.Placings = eliminated
+	default:
+		return nil, fmt.Errorf("tournament: unknown format %q", t.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	report.Finished = time.Now().UTC()
+	report.rank(t.Players)
+	return report, nil
+}
+
+// roundRobin plays a match between every pair of players.
+func (t *Tournament) roundRobin(ctx context.Context) ([]Match, error) {
+	var pairs [][2]selfplay.Player
+	for i, a := range t.Players {
+		for _, b := range t.Players[i+1:] {
+			pairs = append(pairs, [2]selfplay.Player{a, b})
+		}
+	}
+	matches, err := t.play(ctx, pairs)
+	for i := range matches {
+		matches[i].Round = 1
+	}
+	return matches, err
+}
+
+// doubleElimination plays rounds of matches until one player is left, each
+// round pairing the unbeaten players with each other and the players
+// beaten once with each other. A player beaten twice is out; the final
+// between the last unbeaten player and the last beaten one is replayed if
+// the unbeaten one loses it. It returns the matches and the players in the
+// order they went out, the winner last.
+func (t *Tournament) doubleElimination(ctx context.Context) ([]Match, []string, error) {
+	byName := map[string]selfplay.Player{}
+	losses := map[string]int{}
+	var unbeaten, beaten []string
+	for _, p := range t.Players {
+		byName[p.Name] = p
+		unbeaten = append(unbeaten, p.Name)
+	}
+	rand.Shuffle(len(unbeaten), func(i, j int) { unbeaten[i], unbeaten[j] = unbeaten[j], unbeaten[i] })
+
+	var matches, round []Match
+	var out []string
+	for n := 1; len(unbeaten)+len(beaten) > 1; n++ {
+		var pairs [][2]selfplay.Player
+		var brackets []string
+		pair := func(names []string, bracket string) []string {
+			for len(names) >= 2 {
+				pairs = append(pairs, [2]selfplay.Player{byName[names[0]], byName[names[1]]})
+				brackets = append(brackets, bracket)
+				names = names[2:]
+			}
+			return names
+		}
+		if len(unbeaten) == 1 && len(beaten) == 1 || len(unbeaten) == 0 && len(beaten) == 2 {
+			final := append(append([]string{}, unbeaten...), beaten...)
+			unbeaten, beaten = nil, nil
+			pair(final, Final)
+		} else {
+			// A player left without an opponent has a bye.
+			unbeaten = pair(unbeaten, Winners)
+			beaten = pair(beaten, Losers)
+		}
+
+		var err error
+		if round, err = t.play(ctx, pairs); err != nil {
+			return nil, nil, err
+		}
+		for i := range round {
+			m := &round[i]
+			m.Round, m.Bracket = n, brackets[i]
+			loser := m.A
+			if m.Winner == m.A {
+				loser = m.B
+			}
+			losses[loser]++
+			for _, name := range []string{m.A, m.B} {
+				switch losses[name] {
+				case 0:
+					unbeaten = append(unbeaten, name)
+				case 1:
+					beaten = append(beaten, name)
+				default:
+					out = append(out, name)
+				}
+			}
+		}
+		matches = append(matches, round...)
+	}
+	return matches, append(out, append(unbeaten, beaten...)...), nil
+}
+
+// play plays a match between each pair, the games of every match at once
+// up to Parallel.
+func (t *Tournament) play(ctx context.Context, pairs [][2]selfplay.Player) ([]Match, error) {
+	matches := make([]Match, len(pairs))
+	for i, pair := range pairs {
+		matches[i].A, matches[i].B = pair[0].Name, pair[1].Name
+	}
+	type job struct{ match int }
+	jobs := make(chan job)
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	for range max(t.Parallel, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				outcome, err := selfplay.Play(ctx, t.Settings, pairs[j.match][:])
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, err)
+				} else {
+					m := &matches[j.match]
+					switch outcome.Winner {
+					case 0:
+						m.WinsA++
+					case 1:
+						m.WinsB++
+					default:
+						m.Draws++
+					}
+					m.turnsA += outcome.Survived[0]
+					m.turnsB += outcome.Survived[1]
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for range max(t.Games, 1) {
+		for i := range pairs {
+			jobs <- job{i}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	for i := range matches {
+		m := &matches[i]
+		switch {
+		case m.WinsA != m.WinsB:
+			m.Winner = m.A
+			if m.WinsB > m.WinsA {
+				m.Winner = m.B
+			}
+		case m.turnsA != m.turnsB:
+			m.Winner = m.A
+			if m.turnsB > m.turnsA {
+				m.Winner = m.B
+			}
+		default:
+			m.Winner = []string{m.A, m.B}[rand.Intn(2)]
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,142 @@
+// Package upload pushes the data of finished games, their histories and the
+// reports and frames recorded next to them, to S3-compatible object
+// storage, so that a long-lived host doesn't fill its disk and analyses can
+// run elsewhere.
+package upload
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/history"
+)
+
+// MarkerName is the file written to a game's directory once its data has
+// been uploaded.
+const MarkerName = ".uploaded"
+
+// DefaultSettle is how long a game's directory must go unwritten before it
+// is taken to be finished.
+const DefaultSettle = 10 * time.Minute
+
+// Uploader uploads the game directories of a data directory.
+type Uploader struct {
+	Bucket *Bucket
+	// Prefix is prepended to the key of every object, with {date} replaced
+	// by the day the game was last written, e.g. "games/{date}/". A game's
+	// files are stored under Prefix + game ID + "/".
+	Prefix string
+	// Settle is how long a game's directory must go unwritten before it is
+	// uploaded, DefaultSettle if 0.
+	Settle time.Duration
+	// KeepLocal is how long a game's directory is kept once uploaded: 0
+	// removes it right away, and a negative duration keeps it for good.
+	KeepLocal time.Duration
+}
+
+// Sweep uploads the finished games recorded in dir that haven't been yet,
+// and removes those uploaded longer than KeepLocal ago. It returns how many
+// games it uploaded and removed; a game that fails to upload is retried on
+// the next sweep.
+func (u *Uploader) Sweep(ctx context.Context, dir string) (uploaded, removed int, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	settle := u.Settle
+	if settle == 0 {
+		settle = DefaultSettle
+	}
+	var errs []error
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			break
+		}
+		gameDir := filepath.Join(dir, entry.Name())
+		if !entry.IsDir() || !history.Exists(gameDir) {
+			continue
+		}
+		if marker, err := os.Stat(filepath.Join(gameDir, MarkerName)); err == nil {
+			if u.KeepLocal >= 0 && time.Since(marker.ModTime()) >= u.KeepLocal {
+				if err := os.RemoveAll(gameDir); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				removed++
+			}
+			continue
+		}
+		last, err := lastWrite(gameDir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if time.Since(last) < settle {
+			continue
+		}
+		if err := u.uploadGame(ctx, gameDir, last); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		uploaded++
+	}
+	return uploaded, removed, errors.Join(errs...)
+}
+
+// uploadGame uploads every file of the game directory gameDir, last written
+// at last, then marks it uploaded.
+func (u *Uploader) uploadGame(ctx context.Context, gameDir string, last time.Time) error {
+	prefix := strings.ReplaceAll(u.Prefix, "{date}", last.UTC().Format(time.DateOnly)) + filepath.Base(gameDir) + "/"
+	entries, err := os.ReadDir(gameDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(gameDir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if err := u.Bucket.Put(ctx, prefix+entry.Name(), data, contentType(entry.Name())); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(filepath.Join(gameDir, MarkerName), nil, 0644)
+}
+
+// lastWrite returns when a file of dir was last written.
+func lastWrite(dir string) (time.Time, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var last time.Time
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+	return last, nil
+}
+
+func contentType(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".gz"):
+		return "application/gzip"
+	case strings.HasSuffix(name, ".json"):
+		return "application/json"
+	case strings.HasSuffix(name, ".jsonl"):
+		return "application/x-ndjson"
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,163 @@
+// Package webhook posts game results to chat webhooks, so a team channel
+// hears about every win and every embarrassing death.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/analysis"
+	"github.com/jayuuza/battlesnake/pkg/logging"
+	"github.com/jayuuza/battlesnake/pkg/results"
+)
+
+var logger = logging.For(logging.Server)
+
+// DefaultLink links a notification to the game's replay on the Battlesnake
+// site.
+const DefaultLink = "https://play.battlesnake.com/game/{game}"
+
+// queueSize is the number of notifications that may wait to be sent; more
+// are dropped rather than holding up /end.
+const queueSize = 64
+
+// Message is the JSON posted to every webhook. Discord reads Content and
+// Slack reads Text, so the same body works for both; other receivers can
+// use Result.
+type Message struct {
+	Content string         `json:"content"`
+	Text    string         `json:"text"`
+	Link    string         `json:"link,omitempty"`
+	Result  results.Result `json:"result"`
+}
+
+// Notifier posts a Message for every game result to its webhooks in the
+// background.
+type Notifier struct {
+	// On holds the outcomes notified of; all are if it is empty.
+	On map[string]bool
+	// Link is the URL included in messages, with {game} replaced by the
+	// game ID, or empty for none.
+	Link string
+
+	urls  []string
+	http  *http.Client
+	queue chan Message
+}
+
+// New returns a notifier posting to urls.
+func New(urls []string) *Notifier {
+	n := &Notifier{
+		Link:  DefaultLink,
+		urls:  urls,
+		http:  &http.Client{Timeout: 5 * time.Second},
+		queue: make(chan Message, queueSize),
+	}
+	go n.send()
+	return n
+}
+
+// Notify queues a message about result unless its outcome isn't notified
+// of. It never blocks: the message is dropped if the queue is full.
+func (n *Notifier) Notify(result results.Result) {
+	if len(n.On) > 0 && !n.On[result.Outcome] {
+		return
+	}
+	m := Message{Result: result}
+	if n.Link != "" {
+		m.Link = strings.ReplaceAll(n.Link, "{game}", result.GameID)
+	}
+	m.Text = Summary(result)
+	if m.Link != "" {
+		m.Text += " " + m.Link
+	}
+	m.Content = m.Text
+	select {
+	case n.queue <- m:
+	default:
+		logger.Warn("dropping webhook notification, queue full", "game", result.GameID)
+	}
+}
+
+// embarrassing are the deaths nobody else can be blamed for.
+var embarrassing = map[string]bool{
+	analysis.Wall:          true,
+	analysis.SelfCollision: true,
+	analysis.Starvation:    true,
+}
+
+// Summary describes result in one line.
+func Summary(result results.Result) string {
+	var b strings.Builder
+	switch result.Outcome {
+	case results.Win:
+		fmt.Fprintf(&b, ":trophy: Won in %d turns", result.Turns)
+	case results.Draw:
+		fmt.Fprintf(&b, ":handshake: Drew in %d turns", result.Turns)
+	default:
+		if embarrassing[result.Death] {
+			fmt.Fprintf(&b, ":facepalm: Died embarrassingly on turn %d", result.Turns)
+		} else {
+			fmt.Fprintf(&b, ":skull: Lost on turn %d", result.Turns)
+		}
+		if result.Death != "" {
+			fmt.Fprintf(&b, " (%s)", result.Death)
+		}
+	}
+	if len(result.Opponents) > 0 {
+		fmt.Fprintf(&b, " against %s", strings.Join(result.Opponents, ", "))
+	}
+	fmt.Fprintf(&b, " playing %s on %dx%d %s", result.Strategy, result.Width, result.Height, result.Ruleset)
+	if result.Map != "" && result.Map != "standard" {
+		fmt.Fprintf(&b, "/%s", result.Map)
+	}
+	return b.String()
+}
+
+func (n *Notifier) send() {
+	for m := range n.queue {
+		body, err := json.Marshal(m)
+		if err != nil {
+			logger.Error("encoding webhook notification", "game", m.Result.GameID, "err", err)
+			continue
+		}
+		for _, target := range n.urls {
+			if err := n.post(target, body); err != nil {
+				logger.Warn("sending webhook notification", "game", m.Result.GameID, "host", host(target), "err", err)
+			}
+		}
+	}
+}
+
+// post sends body to target. Webhook URLs carry their secret in the path,
+// so the error returned never includes target.
+func (n *Notifier) post(target string, body []byte) error {
+	resp, err := n.http.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			return ue.Err
+		}
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+// host returns the host of target, which is safe to log where target isn't.
+func host(target string) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "invalid URL"
+	}
+	return u.Host
+}
@@ -0,0 +1,158 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/analysis"
+	"github.com/jayuuza/battlesnake/pkg/results"
+)
+
+func result(outcome, death string) results.Result {
+	return results.Result{
+		GameID:    "g1",
+		Strategy:  "search",
+		Outcome:   outcome,
+		Death:     death,
+		Turns:     42,
+		Ruleset:   "standard",
+		Map:       "standard",
+		Width:     11,
+		Height:    11,
+		Opponents: []string{"hungry", "coward"},
+	}
+}
+
+func TestSummary(t *testing.T) {
+	mapped := result(results.Win, "")
+	mapped.Map = "arcade_maze"
+	alone := result(results.Draw, "")
+	alone.Opponents = nil
+	tests := []struct {
+		name   string
+		result results.Result
+		want   string
+	}{
+		{"win", result(results.Win, ""),
+			":trophy: Won in 42 turns against hungry, coward playing search on 11x11 standard"},
+		{"draw", alone,
+			":handshake: Drew in 42 turns playing search on 11x11 standard"},
+		{"loss", result(results.Loss, analysis.HeadToHead),
+			":skull: Lost on turn 42 (head-to-head) against hungry, coward playing search on 11x11 standard"},
+		{"embarrassing", result(results.Loss, analysis.Wall),
+			":facepalm: Died embarrassingly on turn 42 (wall) against hungry, coward playing search on 11x11 standard"},
+		{"unknown death", result(results.Loss, ""),
+			":skull: Lost on turn 42 against hungry, coward playing search on 11x11 standard"},
+		{"map", mapped,
+			":trophy: Won in 42 turns against hungry, coward playing search on 11x11 standard/arcade_maze"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summary(tt.result); got != tt.want {
+				t.Errorf("Summary() = %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifyOn(t *testing.T) {
+	// Without a sender the queue holds whatever was notified.
+	n := &Notifier{
+		On:    map[string]bool{results.Loss: true},
+		Link:  DefaultLink,
+		queue: make(chan Message, queueSize),
+	}
+	n.Notify(result(results.Win, ""))
+	n.Notify(result(results.Loss, analysis.Wall))
+	if len(n.queue) != 1 {
+		t.Fatalf("%d messages queued, want 1", len(n.queue))
+	}
+	m := <-n.queue
+	if m.Result.Outcome != results.Loss {
+		t.Errorf("queued a %s", m.Result.Outcome)
+	}
+	if want := "https://play.battlesnake.com/game/g1"; m.Link != want {
+		t.Errorf("Link = %q, want %q", m.Link, want)
+	}
+	if want := Summary(m.Result) + " " + m.Link; m.Text != want || m.Content != want {
+		t.Errorf("Text = %q, Content = %q, want %q", m.Text, m.Content, want)
+	}
+
+	n.On = nil
+	n.Notify(result(results.Win, ""))
+	n.Notify(result(results.Draw, ""))
+	if len(n.queue) != 2 {
+		t.Errorf("%d messages queued without a filter, want 2", len(n.queue))
+	}
+}
+
+func TestNotifyDropsWhenFull(t *testing.T) {
+	n := &Notifier{queue: make(chan Message, 1)}
+	done := make(chan struct{})
+	go func() {
+		n.Notify(result(results.Win, ""))
+		n.Notify(result(results.Loss, ""))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Notify blocked on a full queue")
+	}
+	if m := <-n.queue; m.Result.Outcome != results.Win {
+		t.Errorf("kept the %s, want the first message", m.Result.Outcome)
+	}
+	if len(n.queue) != 0 {
+		t.Error("the second message was queued")
+	}
+}
+
+func TestNotifyPosts(t *testing.T) {
+	got := make(chan Message, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m Message
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			t.Error(err)
+		}
+		got <- m
+	}))
+	defer srv.Close()
+	n := New([]string{srv.URL + "/hooks/secret"})
+	n.Notify(result(results.Win, ""))
+	select {
+	case m := <-got:
+		if m.Result.GameID != "g1" || m.Content == "" || m.Content != m.Text {
+			t.Errorf("posted %+v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing posted")
+	}
+}
+
+func TestPostHidesURL(t *testing.T) {
+	const secret = "s3cr3t-t0k3n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	n := &Notifier{http: srv.Client()}
+	target := srv.URL + "/api/webhooks/1/" + secret
+	if err := n.post(target, []byte("{}")); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("post to a refusing webhook = %v, want its status", err)
+	}
+	// Once the server is gone the request itself fails.
+	srv.Close()
+	err := n.post(target, []byte("{}"))
+	if err == nil {
+		t.Fatal("post to a closed server succeeded")
+	}
+	if strings.Contains(err.Error(), secret) {
+		t.Errorf("error %q reveals the webhook URL", err)
+	}
+	if h := host(target); strings.Contains(h, secret) || h != strings.TrimPrefix(srv.URL, "http://") {
+		t.Errorf("host(%q) = %q", target, h)
+	}
+}
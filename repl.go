@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/jayuuza/battlesnake/pkg/api"
+	"github.com/jayuuza/battlesnake/pkg/repl"
+)
+
+// runREPL studies a position interactively, loading the one named in args
+// if any.
+func runREPL(w io.Writer, args []string) error {
+	var game *api.GameRequest
+	switch len(args) {
+	case 0:
+	case 1, 2:
+		turn := 0
+		if len(args) == 2 {
+			var err error
+			if turn, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("usage: repl [position file or game dir] [turn]")
+			}
+		}
+		g, err := repl.Load(args[0], turn)
+		if err != nil {
+			return err
+		}
+		game = &g
+	default:
+		return fmt.Errorf("usage: repl [position file or game dir] [turn]")
+	}
+	s := repl.New(w, game)
+	if game != nil {
+		s.Exec([]string{"show"}, nil)
+	}
+	return s.Run(os.Stdin)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/jayuuza/battlesnake/pkg/analysis"
+	"github.com/jayuuza/battlesnake/pkg/history"
+)
+
+// runReport prints the post-game report of each game named in args, given
+// as a game directory or its history file.
+func runReport(w io.Writer, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: report <game dir or %s>...", history.FileName)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	for _, path := range args {
+		if base := filepath.Base(path); base == history.FileName || base == history.CompressedFileName {
+			path = filepath.Dir(path)
+		}
+		r, err := analysis.Load(path)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/results"
+)
+
+// statsGroupings are the ways results can be grouped by with stats -by.
+var statsGroupings = map[string]func(results.Result) []string{
+	"strategy": func(r results.Result) []string { return []string{r.Strategy} },
+	"ruleset":  func(r results.Result) []string { return []string{r.Ruleset} },
+	"map":      func(r results.Result) []string { return []string{r.Map} },
+	"size":     func(r results.Result) []string { return []string{fmt.Sprintf("%dx%d", r.Width, r.Height)} },
+	"opponent": func(r results.Result) []string { return r.Opponents },
+	"archetype": func(r results.Result) []string {
+		var names []string
+		for _, archetype := range r.Archetypes {
+			names = append(names, archetype)
+		}
+		return names
+	},
+}
+
+// gameStats is what stats prints.
+type gameStats struct {
+	Overall results.Summary `json:"overall"`
+	// Groups are the win rates by each grouping asked for, by value.
+	Groups map[string]map[string]results.Summary `json:"groups,omitempty"`
+	Trend  []results.Period                      `json:"trend,omitempty"`
+}
+
+// runStats prints win rates from the results store: overall, grouped by
+// ruleset, map, board size, opponent and the like, and over time.
+func runStats(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	dir := fs.String("data-dir", "games", "directory of recorded games, holding "+results.FileName)
+	by := fs.String("by", "ruleset,map,size,opponent", "comma-separated groupings to report win rates by: strategy, ruleset, map, size, opponent, archetype")
+	every := fs.Duration("trend", 7*24*time.Hour, "span of time to report the win rate trend by, or 0 for no trend")
+	since := fs.Duration("since", 0, "only count games played this long ago or later, or 0 for every game")
+	strategyName := fs.String("strategy", "", "only count games played by this strategy")
+	minGames := fs.Int("min-games", 1, "omit groups with fewer games than this")
+	asJSON := fs.Bool("json", false, "print the stats as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var groupings []string
+	if *by != "" {
+		groupings = strings.Split(*by, ",")
+	}
+	for _, name := range groupings {
+		if statsGroupings[name] == nil {
+			return fmt.Errorf("unknown grouping %q", name)
+		}
+	}
+
+	all, err := results.NewStore(*dir).All()
+	if err != nil {
+		return err
+	}
+	var rs []results.Result
+	for _, r := range all {
+		if *since > 0 && time.Since(r.Time) > *since {
+			continue
+		}
+		if *strategyName != "" && r.Strategy != *strategyName {
+			continue
+		}
+		rs = append(rs, r)
+	}
+
+	stats := gameStats{Overall: results.Summarize(rs)}
+	if len(groupings) > 0 {
+		stats.Groups = map[string]map[string]results.Summary{}
+	}
+	for _, name := range groupings {
+		groups := results.GroupByEach(rs, statsGroupings[name])
+		for key, s := range groups {
+			if s.Games < *minGames {
+				delete(groups, key)
+			}
+		}
+		stats.Groups[name] = groups
+	}
+	if *every > 0 {
+		stats.Trend = results.Trend(rs, *every)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+	return printStats(w, stats, groupings, *every)
+}
+
+// printStats prints stats as tables, the groupings in the order given.
+func printStats(w io.Writer, stats gameStats, groupings []string, every time.Duration) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	row := func(label string, s results.Summary) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.1f%%\n", label, s.Games, s.Wins, s.Losses, s.Draws, 100*s.WinRate)
+	}
+	header := func(title string) {
+		fmt.Fprintf(tw, "%s\tgames\twins\tlosses\tdraws\twin rate\n", title)
+	}
+
+	header("")
+	row("overall", stats.Overall)
+	for _, name := range groupings {
+		groups := stats.Groups[name]
+		keys := make([]string, 0, len(groups))
+		for key := range groups {
+			keys = append(keys, key)
+		}
+		// Most played first, as the win rates of those mean the most.
+		sort.Slice(keys, func(i, j int) bool {
+			if gi, gj := groups[keys[i]].Games, groups[keys[j]].Games; gi != gj {
+				return gi > gj
+			}
+			return keys[i] < keys[j]
+		})
+		fmt.Fprintln(tw)
+		header(name)
+		for _, key := range keys {
+			row(key, groups[key])
+		}
+	}
+	if len(stats.Trend) > 0 {
+		layout := time.DateOnly
+		if every%(24*time.Hour) != 0 {
+			layout = "2006-01-02 15:04"
+		}
+		fmt.Fprintln(tw)
+		header("since")
+		for _, p := range stats.Trend {
+			row(p.Start.Format(layout), p.Summary)
+		}
+	}
+	return tw.Flush()
+}
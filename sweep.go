@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jayuuza/battlesnake/pkg/selfplay"
+	"github.com/jayuuza/battlesnake/pkg/strategy"
+)
+
+// runSweep plays every combination of a grid of parameters against fixed
+// baselines on the simulator and ranks the combinations by how they fared.
+func runSweep(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("sweep", flag.ContinueOnError)
+	strategyName := fs.String("strategy", "heuristic", "strategy whose parameters are swept")
+	var params []selfplay.Param
+	fs.Func("param", "parameter and the values it takes, e.g. depth=2,4,8, risk=0.8,1.2, panic-health=10,25 or weight.space=5,10; repeat for a grid", func(s string) error {
+		p, err := selfplay.ParseParam(s)
+		if err == nil {
+			params = append(params, p)
+		}
+		return err
+	})
+	baselines := fs.String("baselines", "heuristic", "comma-separated opponents: strategies, or strategy@weight-file to play one with the weights in the file")
+	games := fs.Int("games", 50, "games each combination plays")
+	snakes := fs.Int("snakes", 2, "snakes in each game, the others baselines taken in turn")
+	size := fs.Int("size", selfplay.DefaultSettings.Width, "width and height of the board")
+	timeout := fs.Duration("timeout", selfplay.DefaultSettings.Timeout, "time each snake has to decide a move")
+	maxTurns := fs.Int("max-turns", selfplay.DefaultSettings.MaxTurns, "turns after which a game is a draw between the snakes left, or 0 for no limit")
+	parallel := fs.Int("parallel", runtime.GOMAXPROCS(0), "games to play at once")
+	asJSON := fs.Bool("json", false, "print the results as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(params) == 0 {
+		return fmt.Errorf("no -param to sweep")
+	}
+	if _, err := strategy.New(*strategyName); err != nil {
+		return err
+	}
+
+	sweep := &selfplay.Sweep{
+		Configurations: selfplay.Configurations(*strategyName, params),
+		Games:          *games,
+		Snakes:         *snakes,
+		Settings:       selfplay.Settings{Width: *size, Height: *size, Timeout: *timeout, MaxTurns: *maxTurns},
+		Parallel:       *parallel,
+	}
+	for _, spec := range strings.Split(*baselines, ",") {
+		p, err := baselinePlayer(spec)
+		if err != nil {
+			return err
+		}
+		sweep.Baselines = append(sweep.Baselines, p)
+	}
+
+	// An interrupted sweep still reports the games played so far.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results, err := sweep.Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tparameters\tgames\twins\tlosses\tdraws\tscore\tturns")
+	for i, r := range results {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%d\t%.1f%% ± %.1f\t%.0f\n", i+1, r.Name, r.Games, r.Wins, r.Losses, r.Draws, 100*r.Score, 100*r.Error, r.Turns)
+	}
+	return tw.Flush()
+}
+
+// baselinePlayer returns the player spec names: a strategy, or
+// strategy@weight-file for a strategy playing with the weights in the file.
+func baselinePlayer(spec string) (selfplay.Player, error) {
+	name, path, weighted := strings.Cut(spec, "@")
+	if _, err := strategy.New(name); err != nil {
+		return selfplay.Player{}, err
+	}
+	if !weighted {
+		return selfplay.Player{Name: name, Strategy: name}, nil
+	}
+	weights, err := selfplay.LoadWeights(path)
+	if err != nil {
+		return selfplay.Player{}, err
+	}
+	return selfplay.WeightedPlayer(name, path, weights), nil
+}
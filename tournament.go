@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jayuuza/battlesnake/pkg/selfplay"
+	"github.com/jayuuza/battlesnake/pkg/tournament"
+)
+
+// runTournament plays self-play tournaments between strategies, once or on
+// a schedule, saving a report of each and flagging the strategies that did
+// worse than in the one before.
+func runTournament(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("tournament", flag.ContinueOnError)
+	format := fs.String("format", tournament.RoundRobin, "round-robin, or double-elimination")
+	names := fs.String("strategies", "", "comma-separated strategies to play with the configured weights, or empty for every registered one")
+	weightFiles := fs.String("weights", "", "comma-separated weight files, each played by the -weighted strategies")
+	weighted := fs.String("weighted", "heuristic,duel", "comma-separated strategies to play with each weight file")
+	games := fs.Int("games", 10, "games in each match")
+	size := fs.Int("size", selfplay.DefaultSettings.Width, "width and height of the board")
+	timeout := fs.Duration("timeout", selfplay.DefaultSettings.Timeout, "time each snake has to decide a move")
+	maxTurns := fs.Int("max-turns", selfplay.DefaultSettings.MaxTurns, "turns after which a game is a draw between the snakes left, or 0 for no limit")
+	parallel := fs.Int("parallel", runtime.GOMAXPROCS(0), "games to play at once")
+	reports := fs.String("reports", "tournaments", "directory the report of each tournament is saved to, and the last one compared with")
+	tolerance := fs.Float64("tolerance", 0.1, "fall in score since the last tournament (0-1) above which a player is flagged as a regression")
+	every := fs.Duration("every", 0, "keep running, playing a tournament this often, e.g. 24h; 0 plays one and exits non-zero on regressions")
+	at := fs.String("at", "", "time of day, HH:MM, to play the first scheduled tournament at, e.g. 03:00 with -every 24h for nightly tournaments")
+	asJSON := fs.Bool("json", false, "print each report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	players, err := arenaPlayers(*names, *weightFiles, *weighted)
+	if err != nil {
+		return err
+	}
+	t := &tournament.Tournament{
+		Format:   *format,
+		Players:  players,
+		Games:    *games,
+		Settings: selfplay.Settings{Width: *size, Height: *size, Timeout: *timeout, MaxTurns: *maxTurns},
+		Parallel: *parallel,
+	}
+	play := func(ctx context.Context) ([]tournament.Regression, error) {
+		prev, err := tournament.Latest(*reports)
+		if err != nil {
+			return nil, err
+		}
+		report, err := t.Run(ctx)
+		if err != nil {
+			return nil, err
+		}
+		regressions := report.Compare(prev, *tolerance)
+		path, err := report.Save(*reports)
+		if err != nil {
+			return nil, err
+		}
+		if *asJSON {
+			return regressions, json.NewEncoder(w).Encode(report)
+		}
+		fmt.Fprintf(w, "%s tournament of %d players, report in %s\n", report.Format, len(report.Standings), path)
+		return regressions, printStandings(w, report)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *every <= 0 {
+		regressions, err := play(ctx)
+		if err == nil && len(regressions) > 0 {
+			err = fmt.Errorf("%d regressions since the last tournament", len(regressions))
+		}
+		return err
+	}
+
+	next := time.Now()
+	if *at != "" {
+		clock, err := time.Parse("15:04", *at)
+		if err != nil {
+			return fmt.Errorf("-at: %v", err)
+		}
+		next = time.Date(next.Year(), next.Month(), next.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+		if next.Before(time.Now()) {
+			next = next.AddDate(0, 0, 1)
+		}
+	}
+	for {
+		select {
+		case <-time.After(time.Until(next)):
+		case <-ctx.Done():
+			return nil
+		}
+		regressions, err := play(ctx)
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil
+		case err != nil:
+			log.Printf("tournament: %v", err)
+		}
+		for _, r := range regressions {
+			log.Printf("tournament: %s regressed: score %.1f%%, was %.1f%%", r.Name, 100*r.Score, 100*r.PreviousScore)
+		}
+		for !next.After(time.Now()) {
+			next = next.Add(*every)
+		}
+	}
+}
+
+// printStandings prints a tournament's standings and regressions.
+func printStandings(w io.Writer, report *tournament.Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tplayer\tmatches won\tlost\tgames won\tlost\tdrawn\tscore")
+	for _, s := range report.Standings {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%d\t%d\t%.1f%%\n", s.Rank, s.Name, s.MatchWins, s.MatchLosses, s.Wins, s.Losses, s.Draws, 100*s.Score)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, r := range report.Regressions {
+		if _, err := fmt.Fprintf(w, "REGRESSION %s: score %.1f%%, was %.1f%%; rank %d, was %d\n",
+			r.Name, 100*r.Score, 100*r.PreviousScore, r.Rank, r.PreviousRank); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jayuuza/battlesnake/pkg/decision"
+)
+
+// runTraces converts recorded decision traces to flat records, one per
+// candidate move, as JSON lines or CSV, or prints the traces' schema.
+func runTraces(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("traces", flag.ContinueOnError)
+	dir := fs.String("data-dir", "games", "directory of recorded games, read when no game directories or trace files are named")
+	asCSV := fs.Bool("csv", false, "write CSV with a header row instead of JSON lines")
+	schema := fs.Bool("schema", false, "print the JSON Schema of the traces written, and nothing else")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *schema {
+		_, err := w.Write(decision.Schema)
+		return err
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		matches, err := filepath.Glob(filepath.Join(*dir, "*", decision.FileName))
+		if err != nil {
+			return err
+		}
+		sort.Strings(matches)
+		paths = matches
+	}
+	var records []decision.Record
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			path = filepath.Join(path, decision.FileName)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		traces, err := decision.Read(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		for _, t := range traces {
+			records = append(records, decision.Flatten(t)...)
+		}
+	}
+
+	if *asCSV {
+		return decision.WriteCSV(w, records)
+	}
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}